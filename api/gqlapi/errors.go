@@ -0,0 +1,56 @@
+package gqlapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/MarcinZ20/bankAPI/internal/repository"
+	"github.com/MarcinZ20/bankAPI/internal/services"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Error codes reported in the extensions of a GraphQL error
+const (
+	CodeBadUserInput    = "BAD_USER_INPUT"
+	CodeNotFound        = "NOT_FOUND"
+	CodeAlreadyExists   = "ALREADY_EXISTS"
+	CodeUnauthenticated = "UNAUTHENTICATED"
+	CodeForbidden       = "FORBIDDEN"
+	CodeInternal        = "INTERNAL"
+)
+
+// Resolver error carrying a machine readable code in its extensions
+type Error struct {
+	Message string
+	Code    string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Extensions() map[string]any {
+	return map[string]any{"code": e.Code}
+}
+
+func newError(code, format string, args ...any) *Error {
+	return &Error{Message: fmt.Sprintf(format, args...), Code: code}
+}
+
+// Maps a BankService error to a GraphQL error with the messages of the REST error responses
+func toError(err error, resource, key string) error {
+	var validationErr *services.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		return newError(CodeBadUserInput, "%s", validationErr.Message)
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return newError(CodeNotFound, "%s not found: %s", resource, key)
+	case errors.Is(err, repository.ErrAlreadyExists):
+		return newError(CodeAlreadyExists, "%s with SWIFT code %s already exists", resource, key)
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return err
+	default:
+		return newError(CodeInternal, "database error: %v", err)
+	}
+}
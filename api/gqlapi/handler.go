@@ -0,0 +1,93 @@
+package gqlapi
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"log/slog"
+
+	"github.com/MarcinZ20/bankAPI/api/middleware"
+	"github.com/MarcinZ20/bankAPI/api/responses"
+	"github.com/MarcinZ20/bankAPI/internal/services"
+	"github.com/MarcinZ20/bankAPI/internal/tracing"
+	"github.com/gofiber/fiber/v2"
+	"github.com/graph-gophers/graphql-go"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Limits that keep a single query from fanning out without bound
+const (
+	maxDepth       = 8
+	maxQueryLength = 8 << 10
+)
+
+//go:embed schema.graphql
+var schemaSDL string
+
+// Holds GraphQL endpoint settings
+type Options struct {
+	// Queries need no credentials, mutations still need the write scope
+	PublicReads bool
+}
+
+// Body of a GraphQL request sent as application/json
+type request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+type principalKey struct{}
+
+// Executes GraphQL queries and mutations against BankService.
+// Expects the request principal set by middleware.Authenticate, scopes are checked per root field.
+func Handler(opts Options) fiber.Handler {
+	schema := graphql.MustParseSchema(schemaSDL, &resolver{publicReads: opts.PublicReads},
+		graphql.MaxDepth(maxDepth),
+		graphql.MaxQueryLength(maxQueryLength),
+	)
+
+	return func(c *fiber.Ctx) error {
+		ctx, ok := middleware.GetRequestContext(c)
+		if !ok {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to get request context")
+		}
+
+		sm := services.GetInstance()
+		if sm == nil || !sm.IsInitialized() {
+			return responses.DatabaseError(fmt.Errorf("service not initialized"))
+		}
+
+		var req request
+		if err := c.BodyParser(&req); err != nil {
+			return responses.ValidationError(fmt.Sprintf("Invalid request body: %v", err))
+		}
+		if req.Query == "" {
+			return responses.ValidationError("Invalid request body: query is required")
+		}
+		middleware.AddLogAttrs(c, slog.String("operationName", req.OperationName))
+
+		ctx, span := tracing.Start(ctx, "gqlapi.Handler", attribute.String("graphql.operation.name", req.OperationName))
+		defer span.End()
+
+		if principal, ok := middleware.GetPrincipal(c); ok {
+			ctx = context.WithValue(ctx, principalKey{}, principal)
+		}
+		ctx = withLoader(ctx, newHeadquarterLoader(sm.BankService))
+
+		// Errors of single fields are part of the response, so it is sent with 200 like any GraphQL server does
+		return c.JSON(schema.Exec(ctx, req.Query, req.OperationName, req.Variables))
+	}
+}
+
+// Rejects the field unless the caller was granted the scope
+func authorize(ctx context.Context, scope string) error {
+	principal, ok := ctx.Value(principalKey{}).(*middleware.Principal)
+	if !ok {
+		return newError(CodeUnauthenticated, "Authentication required")
+	}
+	if !principal.HasScope(scope) {
+		return newError(CodeForbidden, "Missing required scope: %s", scope)
+	}
+	return nil
+}
@@ -0,0 +1,237 @@
+package gqlapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MarcinZ20/bankAPI/api/middleware"
+	"github.com/MarcinZ20/bankAPI/internal/auth/authtest"
+	"github.com/MarcinZ20/bankAPI/internal/repository"
+	"github.com/MarcinZ20/bankAPI/internal/services"
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Counts the batched headquarter lookups reaching the store
+type countingStore struct {
+	*repository.MemoryRepository
+	batches atomic.Int32
+}
+
+func (s *countingStore) FindHeadquarters(ctx context.Context, swiftCodes []string) ([]models.Headquarter, error) {
+	s.batches.Add(1)
+	return s.MemoryRepository.FindHeadquarters(ctx, swiftCodes)
+}
+
+var store = &countingStore{MemoryRepository: repository.NewMemoryRepository()}
+
+func TestMain(m *testing.M) {
+	services.NewServiceManagerWithStore(store)
+	os.Exit(m.Run())
+}
+
+type response struct {
+	Data   map[string]any `json:"data"`
+	Errors []struct {
+		Message    string         `json:"message"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+func setupApp(opts Options) *fiber.App {
+	store.Load(map[string]models.Headquarter{
+		"AAAAPLPWXXX": {
+			SwiftCode: "AAAAPLPWXXX", BankName: "BANK A", Address: "STREET 1", CountryISO2: "PL", CountryName: "POLAND", IsHeadquarter: true,
+			Branches: []models.Branch{
+				{SwiftCode: "AAAAPLPW001", BankName: "BANK A", Address: "STREET 2", CountryISO2: "PL", CountryName: "POLAND"},
+				{SwiftCode: "AAAAPLPW002", BankName: "BANK A", Address: "STREET 3", CountryISO2: "PL", CountryName: "POLAND"},
+			},
+		},
+		"AAAAPLKRXXX": {
+			SwiftCode: "AAAAPLKRXXX", BankName: "BANK A KRAKOW", CountryISO2: "PL", CountryName: "POLAND", IsHeadquarter: true,
+			Branches: []models.Branch{
+				{SwiftCode: "AAAAPLKR001", BankName: "BANK A KRAKOW", CountryISO2: "PL", CountryName: "POLAND"},
+			},
+		},
+		"BBBBDEFFXXX": {
+			SwiftCode: "BBBBDEFFXXX", BankName: "BANK B", CountryISO2: "DE", CountryName: "GERMANY", IsHeadquarter: true,
+		},
+	})
+	store.batches.Store(0)
+
+	app := fiber.New()
+	app.Use(middleware.WithTimeout(time.Second))
+	app.Post("/graphql", middleware.Authenticate(middleware.AuthConfig{
+		APIKeys: authtest.APIKeys{
+			"bk_reader": {ID: "reader", Scopes: []string{models.ScopeRead}},
+			"bk_writer": {ID: "writer", Scopes: []string{models.ScopeRead, models.ScopeWrite}},
+		},
+	}), Handler(opts))
+	return app
+}
+
+func execute(t *testing.T, app *fiber.App, apiKey, query string, variables map[string]any) response {
+	t.Helper()
+	body, err := json.Marshal(request{Query: query, Variables: variables})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(fiber.MethodPost, "/graphql", strings.NewReader(string(body)))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	if apiKey != "" {
+		req.Header.Set(middleware.APIKeyHeader, apiKey)
+	}
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var result response
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	return result
+}
+
+func errorCode(t *testing.T, result response) string {
+	t.Helper()
+	require.NotEmpty(t, result.Errors)
+	code, _ := result.Errors[0].Extensions["code"].(string)
+	return code
+}
+
+func TestHandler_SwiftCode(t *testing.T) {
+	app := setupApp(Options{PublicReads: true})
+
+	result := execute(t, app, "", `{
+		swiftCode(code: "aaaaplpwxxx") {
+			bankName
+			... on Headquarter { branches { swiftCode } }
+		}
+	}`, nil)
+	require.Empty(t, result.Errors)
+	assert.Equal(t, map[string]any{
+		"bankName": "BANK A",
+		"branches": []any{
+			map[string]any{"swiftCode": "AAAAPLPW001"},
+			map[string]any{"swiftCode": "AAAAPLPW002"},
+		},
+	}, result.Data["swiftCode"])
+
+	result = execute(t, app, "", `{
+		swiftCode(code: "AAAAPLPW001") {
+			__typename
+			... on Branch { headquarter { swiftCode } }
+		}
+	}`, nil)
+	require.Empty(t, result.Errors)
+	assert.Equal(t, map[string]any{
+		"__typename":  "Branch",
+		"headquarter": map[string]any{"swiftCode": "AAAAPLPWXXX"},
+	}, result.Data["swiftCode"])
+
+	result = execute(t, app, "", `{ swiftCode(code: "CCCCFRPPXXX") { bankName } }`, nil)
+	require.Empty(t, result.Errors)
+	assert.Nil(t, result.Data["swiftCode"])
+
+	result = execute(t, app, "", `{ swiftCode(code: "INVALID") { bankName } }`, nil)
+	assert.Equal(t, CodeBadUserInput, errorCode(t, result))
+}
+
+func TestHandler_Country(t *testing.T) {
+	app := setupApp(Options{PublicReads: true})
+
+	result := execute(t, app, "", `query($iso2: String!) { country(iso2: $iso2) { name headquarters { bankName } } }`,
+		map[string]any{"iso2": "pl"})
+	require.Empty(t, result.Errors)
+	assert.Equal(t, map[string]any{
+		"name": "POLAND",
+		"headquarters": []any{
+			map[string]any{"bankName": "BANK A KRAKOW"},
+			map[string]any{"bankName": "BANK A"},
+		},
+	}, result.Data["country"])
+
+	result = execute(t, app, "", `{ country(iso2: "FR") { name } }`, nil)
+	require.Empty(t, result.Errors)
+	assert.Nil(t, result.Data["country"])
+}
+
+func TestHandler_BatchesHeadquarterLookups(t *testing.T) {
+	app := setupApp(Options{PublicReads: true})
+
+	result := execute(t, app, "", `{
+		search(q: "AAAAPL") {
+			swiftCode
+			... on Branch { headquarter { swiftCode } }
+		}
+	}`, nil)
+	require.Empty(t, result.Errors)
+	assert.Len(t, result.Data["search"], 5)
+	assert.Equal(t, int32(1), store.batches.Load())
+
+	store.batches.Store(0)
+	result = execute(t, app, "", `{ lookup(codes: ["AAAAPLPW002", "CCCCFRPPXXX", "BBBBDEFFXXX", "AAAAPLPW009", "AAAAPLPWXXX"]) { swiftCode } }`, nil)
+	require.Empty(t, result.Errors)
+	assert.Equal(t, []any{
+		map[string]any{"swiftCode": "AAAAPLPW002"},
+		nil,
+		map[string]any{"swiftCode": "BBBBDEFFXXX"},
+		nil,
+		map[string]any{"swiftCode": "AAAAPLPWXXX"},
+	}, result.Data["lookup"])
+	assert.Equal(t, int32(1), store.batches.Load())
+}
+
+func TestHandler_Mutations(t *testing.T) {
+	app := setupApp(Options{PublicReads: true})
+	create := `mutation($input: SwiftCodeInput!) { createSwiftCode(input: $input) { swiftCode bankName } }`
+	input := map[string]any{"input": map[string]any{
+		"swiftCode": "bbbbdeff001", "bankName": "Bank B", "countryISO2": "de", "countryName": "Germany", "isHeadquarter": false,
+	}}
+
+	result := execute(t, app, "", create, input)
+	assert.Equal(t, CodeUnauthenticated, errorCode(t, result))
+
+	result = execute(t, app, "bk_reader", create, input)
+	assert.Equal(t, CodeForbidden, errorCode(t, result))
+
+	result = execute(t, app, "bk_writer", create, input)
+	require.Empty(t, result.Errors)
+	assert.Equal(t, map[string]any{"swiftCode": "BBBBDEFF001", "bankName": "BANK B"}, result.Data["createSwiftCode"])
+
+	result = execute(t, app, "bk_writer", create, input)
+	assert.Equal(t, CodeAlreadyExists, errorCode(t, result))
+
+	result = execute(t, app, "bk_writer", `mutation { deleteSwiftCode(code: "BBBBDEFF001") }`, nil)
+	require.Empty(t, result.Errors)
+	assert.Equal(t, true, result.Data["deleteSwiftCode"])
+
+	result = execute(t, app, "bk_writer", `mutation { deleteSwiftCode(code: "BBBBDEFF001") }`, nil)
+	assert.Equal(t, CodeNotFound, errorCode(t, result))
+}
+
+func TestHandler_ReadScope(t *testing.T) {
+	app := setupApp(Options{})
+
+	result := execute(t, app, "", `{ swiftCode(code: "AAAAPLPWXXX") { bankName } }`, nil)
+	assert.Equal(t, CodeUnauthenticated, errorCode(t, result))
+
+	result = execute(t, app, "bk_reader", `{ swiftCode(code: "AAAAPLPWXXX") { bankName } }`, nil)
+	require.Empty(t, result.Errors)
+	assert.Equal(t, map[string]any{"bankName": "BANK A"}, result.Data["swiftCode"])
+}
+
+func TestHandler_InvalidRequest(t *testing.T) {
+	app := setupApp(Options{PublicReads: true})
+
+	req := httptest.NewRequest(fiber.MethodPost, "/graphql", strings.NewReader(`{"variables": {}}`))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+}
@@ -0,0 +1,55 @@
+package gqlapi
+
+import (
+	"context"
+	"time"
+
+	"github.com/MarcinZ20/bankAPI/internal/services"
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+	"github.com/graph-gophers/dataloader/v7"
+)
+
+// How long the loader collects keys before it looks them up in one batch
+const loaderWait = 2 * time.Millisecond
+
+type loaderKey struct{}
+
+// Loads headquarters by SWIFT code, batching the lookups of one request into a single query.
+// Unknown codes load as nil without an error.
+type headquarterLoader = dataloader.Loader[string, *models.Headquarter]
+
+// Creates the loader of a single request, its cache lives as long as the request
+func newHeadquarterLoader(service *services.BankService) *headquarterLoader {
+	batch := func(ctx context.Context, swiftCodes []string) []*dataloader.Result[*models.Headquarter] {
+		results := make([]*dataloader.Result[*models.Headquarter], len(swiftCodes))
+
+		hqs, err := service.GetHeadquarters(ctx, swiftCodes)
+		if err != nil {
+			err = toError(err, "headquarters", "")
+			for i := range results {
+				results[i] = &dataloader.Result[*models.Headquarter]{Error: err}
+			}
+			return results
+		}
+
+		found := make(map[string]*models.Headquarter, len(hqs))
+		for i := range hqs {
+			found[hqs[i].SwiftCode] = &hqs[i]
+		}
+		for i, swiftCode := range swiftCodes {
+			results[i] = &dataloader.Result[*models.Headquarter]{Data: found[swiftCode]}
+		}
+		return results
+	}
+
+	return dataloader.NewBatchedLoader(batch, dataloader.WithWait[string, *models.Headquarter](loaderWait))
+}
+
+func withLoader(ctx context.Context, loader *headquarterLoader) context.Context {
+	return context.WithValue(ctx, loaderKey{}, loader)
+}
+
+func loaderFrom(ctx context.Context) *headquarterLoader {
+	loader, _ := ctx.Value(loaderKey{}).(*headquarterLoader)
+	return loader
+}
@@ -0,0 +1,39 @@
+package gqlapi
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/graph-gophers/graphql-go"
+)
+
+// Root of the read-only schema, it only offers __typename so no mutation of the schema validates against it
+const readOnlyMutation = `
+type ReadOnlyMutation {
+  _readOnly: Boolean
+}
+`
+
+// Schemas used to validate requests before they are executed, they have no resolvers
+var (
+	validationSchema = graphql.MustParseSchema(schemaSDL, nil, graphql.MaxDepth(maxDepth))
+	readOnlySchema   = graphql.MustParseSchema(
+		strings.Replace(schemaSDL, "mutation: Mutation", "mutation: ReadOnlyMutation", 1)+readOnlyMutation, nil,
+		graphql.MaxDepth(maxDepth),
+	)
+)
+
+// Reports whether a GraphQL request may write, so the rate limiter takes its token from the write bucket.
+// The document is validated against the schema and against a copy without mutations: only a document valid
+// in both is a read. A body or document that does not parse or validate counts as a write, as does a document
+// holding a mutation next to the query it names.
+func IsMutation(c *fiber.Ctx) bool {
+	var req request
+	if err := c.BodyParser(&req); err != nil || req.Query == "" || len(req.Query) > maxQueryLength {
+		return true
+	}
+	if errs := validationSchema.Validate(req.Query); len(errs) > 0 {
+		return true
+	}
+	return len(readOnlySchema.Validate(req.Query)) > 0
+}
@@ -0,0 +1,75 @@
+package gqlapi
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsMutation(t *testing.T) {
+	app := fiber.New()
+	app.Post("/graphql", func(c *fiber.Ctx) error {
+		if IsMutation(c) {
+			return c.SendString("write")
+		}
+		return c.SendString("read")
+	})
+
+	classify := func(t *testing.T, body string) string {
+		req := httptest.NewRequest(fiber.MethodPost, "/graphql", strings.NewReader(body))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		result, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(result)
+	}
+	query := func(document string) string {
+		body, err := json.Marshal(request{Query: document})
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{name: "shorthand query", body: query(`{ swiftCode(code: "AAAAPLPWXXX") { bankName } }`), want: "read"},
+		{name: "named query", body: query(`query Lookup { country(iso2: "PL") { name } }`), want: "read"},
+		{name: "mutation", body: query(`mutation { deleteSwiftCode(code: "AAAAPLPW001") }`), want: "write"},
+		{
+			name: "mutation hidden behind comments and strings",
+			body: query("# query {\nmutation Remove($code: String = \"query { x }\") { deleteSwiftCode(code: $code) }"),
+			want: "write",
+		},
+		{
+			name: "mutation through a fragment",
+			body: query(`fragment Remove on Mutation { deleteSwiftCode(code: "X") } mutation { ...Remove }`),
+			want: "write",
+		},
+		{
+			name: "query next to a mutation",
+			body: query(`query Read { country(iso2: "PL") { name } } mutation Write { deleteSwiftCode(code: "X") }`),
+			want: "write",
+		},
+		{name: "syntax error", body: query(`{ swiftCode(code: "X"`), want: "write"},
+		{name: "unknown field", body: query(`{ accounts { id } }`), want: "write"},
+		{name: "malformed body", body: `{"query": `, want: "write"},
+		{name: "missing query", body: `{}`, want: "write"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, classify(t, tt.body))
+		})
+	}
+}
@@ -0,0 +1,349 @@
+package gqlapi
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/MarcinZ20/bankAPI/internal/services"
+	"github.com/MarcinZ20/bankAPI/internal/transform"
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+	"github.com/MarcinZ20/bankAPI/pkg/utils"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Most SWIFT codes a single lookup may ask for
+const maxLookup = 100
+
+// Resolves the Query and Mutation root fields with the BankService the REST handlers use
+type resolver struct {
+	publicReads bool
+}
+
+func (r *resolver) SwiftCode(ctx context.Context, args struct{ Code string }) (*bankResolver, error) {
+	if err := r.authorizeRead(ctx); err != nil {
+		return nil, err
+	}
+
+	swiftCode := strings.ToUpper(args.Code)
+	if !utils.IsValidSwiftCodeFormat(swiftCode) {
+		return nil, newError(CodeBadUserInput, "Invalid SWIFT code format: %v", args.Code)
+	}
+
+	if strings.HasSuffix(swiftCode, "XXX") {
+		hq, err := bankService().GetHeadquarter(ctx, swiftCode)
+		if err != nil {
+			return nil, ignoreNotFound(err, "headquarter", swiftCode)
+		}
+		return &bankResolver{bankFields{hq}}, nil
+	}
+
+	branch, err := bankService().GetBranch(ctx, swiftCode)
+	if err != nil {
+		return nil, ignoreNotFound(err, "branch", swiftCode)
+	}
+	return &bankResolver{bankFields{branch}}, nil
+}
+
+func (r *resolver) Country(ctx context.Context, args struct{ Iso2 string }) (*countryResolver, error) {
+	if err := r.authorizeRead(ctx); err != nil {
+		return nil, err
+	}
+
+	countryCode := strings.ToUpper(args.Iso2)
+	if !utils.IsValidCountryCode(countryCode) {
+		return nil, newError(CodeBadUserInput, "Invalid country code format: %v", args.Iso2)
+	}
+
+	hqs, err := bankService().GetBanksByCountryCode(ctx, countryCode)
+	if err != nil {
+		return nil, ignoreNotFound(err, "records", countryCode)
+	}
+	if len(hqs) == 0 {
+		return nil, nil
+	}
+	return &countryResolver{iso2: countryCode, hqs: hqs}, nil
+}
+
+func (r *resolver) Search(ctx context.Context, args struct{ Q string }) ([]*bankResolver, error) {
+	if err := r.authorizeRead(ctx); err != nil {
+		return nil, err
+	}
+
+	prefix := strings.ToUpper(args.Q)
+	if !utils.IsValidSwiftCodePrefix(prefix) {
+		return nil, newError(CodeBadUserInput, "Invalid SWIFT code prefix: %v", args.Q)
+	}
+
+	entities, err := bankService().SearchByPrefix(ctx, prefix)
+	if err != nil {
+		return []*bankResolver{}, ignoreNotFound(err, "records", prefix)
+	}
+
+	banks := make([]*bankResolver, len(entities))
+	for i, entity := range entities {
+		banks[i] = &bankResolver{bankFields{entity}}
+	}
+	return banks, nil
+}
+
+// Loads the headquarters of all codes in one batch, branches are read from their headquarter
+func (r *resolver) Lookup(ctx context.Context, args struct{ Codes []string }) ([]*bankResolver, error) {
+	if err := r.authorizeRead(ctx); err != nil {
+		return nil, err
+	}
+
+	if len(args.Codes) > maxLookup {
+		return nil, newError(CodeBadUserInput, "At most %d SWIFT codes can be looked up at once", maxLookup)
+	}
+	swiftCodes := make([]string, len(args.Codes))
+	var parents []string
+	for i, code := range args.Codes {
+		swiftCodes[i] = strings.ToUpper(code)
+		if !utils.IsValidSwiftCodeFormat(swiftCodes[i]) {
+			return nil, newError(CodeBadUserInput, "Invalid SWIFT code format: %v", code)
+		}
+		if parent := parentSwiftCode(swiftCodes[i]); !slices.Contains(parents, parent) {
+			parents = append(parents, parent)
+		}
+	}
+
+	hqs, errs := loaderFrom(ctx).LoadMany(ctx, parents)()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	banks := make([]*bankResolver, len(swiftCodes))
+	for i, swiftCode := range swiftCodes {
+		hq := hqs[slices.Index(parents, parentSwiftCode(swiftCode))]
+		if hq == nil {
+			continue
+		}
+		if swiftCode == hq.SwiftCode {
+			banks[i] = &bankResolver{bankFields{hq}}
+			continue
+		}
+		if j := slices.IndexFunc(hq.Branches, func(b models.Branch) bool { return b.SwiftCode == swiftCode }); j >= 0 {
+			banks[i] = &bankResolver{bankFields{&hq.Branches[j]}}
+		}
+	}
+	return banks, nil
+}
+
+// Input of createSwiftCode, the fields of the POST /v1/swift-codes body
+type swiftCodeInput struct {
+	SwiftCode     string
+	BankName      string
+	Address       *string
+	CountryISO2   string
+	CountryName   string
+	IsHeadquarter bool
+}
+
+func (r *resolver) CreateSwiftCode(ctx context.Context, args struct{ Input swiftCodeInput }) (*bankResolver, error) {
+	if err := authorize(ctx, models.ScopeWrite); err != nil {
+		return nil, err
+	}
+
+	record := &models.Branch{
+		SwiftCode:     args.Input.SwiftCode,
+		BankName:      args.Input.BankName,
+		CountryISO2:   args.Input.CountryISO2,
+		CountryName:   args.Input.CountryName,
+		IsHeadquarter: args.Input.IsHeadquarter,
+	}
+	if args.Input.Address != nil {
+		record.Address = *args.Input.Address
+	}
+
+	if !utils.IsValidSwiftCodeFormat(strings.ToUpper(record.SwiftCode)) {
+		return nil, newError(CodeBadUserInput, "Invalid SWIFT code format: %v", record.SwiftCode)
+	}
+	if !utils.IsValidCountryCode(strings.ToUpper(record.CountryISO2)) {
+		return nil, newError(CodeBadUserInput, "Invalid country code format: %v", record.CountryISO2)
+	}
+
+	transformer := transform.ModelTransformer{}
+	transformer.CleanRequestModel(record)
+	defer loaderFrom(ctx).Clear(ctx, parentSwiftCode(record.SwiftCode))
+
+	if strings.HasSuffix(record.SwiftCode, "XXX") {
+		if !record.IsHeadquarter {
+			return nil, newError(CodeBadUserInput, "SWIFT code ends with XXX, but <isHeadquarter> is false")
+		}
+
+		hq := &models.Headquarter{
+			SwiftCode:     record.SwiftCode,
+			BankName:      record.BankName,
+			Address:       record.Address,
+			CountryName:   record.CountryName,
+			CountryISO2:   record.CountryISO2,
+			IsHeadquarter: true,
+			Branches:      []models.Branch{},
+		}
+		if err := bankService().AddHeadquarter(ctx, hq); err != nil {
+			return nil, toError(err, "Headquarter", record.SwiftCode)
+		}
+		return &bankResolver{bankFields{hq}}, nil
+	}
+
+	parent := parentSwiftCode(record.SwiftCode)
+	if err := bankService().AddBranch(ctx, parent, record); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, toError(err, "parent headquarter", parent)
+		}
+		return nil, toError(err, "Branch", record.SwiftCode)
+	}
+	return &bankResolver{bankFields{record}}, nil
+}
+
+func (r *resolver) DeleteSwiftCode(ctx context.Context, args struct{ Code string }) (bool, error) {
+	if err := authorize(ctx, models.ScopeWrite); err != nil {
+		return false, err
+	}
+
+	swiftCode := strings.ToUpper(args.Code)
+	if !utils.IsValidSwiftCodeFormat(swiftCode) {
+		return false, newError(CodeBadUserInput, "Invalid SWIFT code format: %v", args.Code)
+	}
+	defer loaderFrom(ctx).Clear(ctx, parentSwiftCode(swiftCode))
+
+	if strings.HasSuffix(swiftCode, "XXX") {
+		if err := bankService().DeleteHeadquarter(ctx, swiftCode, nil); err != nil {
+			return false, toError(err, "headquarter", swiftCode)
+		}
+		return true, nil
+	}
+
+	if err := bankService().DeleteBranch(ctx, swiftCode, parentSwiftCode(swiftCode), nil); err != nil {
+		return false, toError(err, "branch", swiftCode)
+	}
+	return true, nil
+}
+
+func (r *resolver) authorizeRead(ctx context.Context) error {
+	if r.publicReads {
+		return nil
+	}
+	return authorize(ctx, models.ScopeRead)
+}
+
+// Fields shared by every type implementing the Bank interface
+type bankFields struct {
+	entity models.BankEntity
+}
+
+func (f bankFields) SwiftCode() string   { return f.entity.GetSwiftCode() }
+func (f bankFields) BankName() string    { return f.entity.GetBankName() }
+func (f bankFields) Address() string     { return f.entity.GetAddress() }
+func (f bankFields) CountryISO2() string { return f.entity.GetCountryISO2() }
+func (f bankFields) CountryName() string { return f.entity.GetCountryName() }
+func (f bankFields) IsHeadquarter() bool { return f.entity.IsHq() }
+
+// Resolves the Bank interface to a Headquarter or a Branch
+type bankResolver struct {
+	bankFields
+}
+
+func (r *bankResolver) ToHeadquarter() (*headquarterResolver, bool) {
+	hq, ok := r.entity.(*models.Headquarter)
+	if !ok {
+		return nil, false
+	}
+	return &headquarterResolver{bankFields{hq}, hq}, true
+}
+
+func (r *bankResolver) ToBranch() (*branchResolver, bool) {
+	branch, ok := r.entity.(*models.Branch)
+	if !ok {
+		return nil, false
+	}
+	return &branchResolver{bankFields{branch}, branch, nil}, true
+}
+
+type headquarterResolver struct {
+	bankFields
+	hq *models.Headquarter
+}
+
+func (r *headquarterResolver) Branches() []*branchResolver {
+	branches := make([]*branchResolver, len(r.hq.Branches))
+	for i := range r.hq.Branches {
+		branches[i] = &branchResolver{bankFields{&r.hq.Branches[i]}, &r.hq.Branches[i], r.hq}
+	}
+	return branches
+}
+
+type branchResolver struct {
+	bankFields
+	branch *models.Branch
+	// Set when the branch was reached through its headquarter
+	parent *models.Headquarter
+}
+
+// Branches found on their own share one batched lookup of their headquarters
+func (r *branchResolver) Headquarter(ctx context.Context) (*headquarterResolver, error) {
+	hq := r.parent
+	if hq == nil {
+		var err error
+		if hq, err = loaderFrom(ctx).Load(ctx, parentSwiftCode(r.branch.SwiftCode))(); err != nil {
+			return nil, err
+		}
+	}
+	if hq == nil {
+		return nil, nil
+	}
+	return &headquarterResolver{bankFields{hq}, hq}, nil
+}
+
+type countryResolver struct {
+	iso2 string
+	hqs  []models.Headquarter
+}
+
+func (r *countryResolver) Iso2() string {
+	return r.iso2
+}
+
+func (r *countryResolver) Name() string {
+	return r.hqs[0].CountryName
+}
+
+func (r *countryResolver) Headquarters() []*headquarterResolver {
+	hqs := make([]*headquarterResolver, len(r.hqs))
+	for i := range r.hqs {
+		hqs[i] = &headquarterResolver{bankFields{&r.hqs[i]}, &r.hqs[i]}
+	}
+	return hqs
+}
+
+func (r *countryResolver) SwiftCodes() []*bankResolver {
+	var banks []*bankResolver
+	for i := range r.hqs {
+		banks = append(banks, &bankResolver{bankFields{&r.hqs[i]}})
+		for j := range r.hqs[i].Branches {
+			banks = append(banks, &bankResolver{bankFields{&r.hqs[i].Branches[j]}})
+		}
+	}
+	return banks
+}
+
+// The handler checks the service manager before executing a query
+func bankService() *services.BankService {
+	return services.GetInstance().BankService
+}
+
+func parentSwiftCode(swiftCode string) string {
+	return swiftCode[0:8] + "XXX"
+}
+
+// Unknown codes and countries resolve to null instead of an error
+func ignoreNotFound(err error, resource, key string) error {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	return toError(err, resource, key)
+}
@@ -0,0 +1,148 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/MarcinZ20/bankAPI/api/middleware"
+	bankapiv1 "github.com/MarcinZ20/bankAPI/api/proto/bankapi/v1"
+	"github.com/MarcinZ20/bankAPI/internal/auth"
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Scope each SwiftCodeService method requires, methods of other services need none
+var methodScopes = map[string]string{
+	bankapiv1.SwiftCodeService_GetSwiftCode_FullMethodName:  models.ScopeRead,
+	bankapiv1.SwiftCodeService_ListByCountry_FullMethodName: models.ScopeRead,
+	bankapiv1.SwiftCodeService_BatchLookup_FullMethodName:   models.ScopeRead,
+	bankapiv1.SwiftCodeService_ExportAll_FullMethodName:     models.ScopeRead,
+	bankapiv1.SwiftCodeService_Create_FullMethodName:        models.ScopeWrite,
+	bankapiv1.SwiftCodeService_Delete_FullMethodName:        models.ScopeWrite,
+}
+
+type principalKey struct{}
+
+// Returns the caller authenticated by the interceptors
+func PrincipalFromContext(ctx context.Context) (*middleware.Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*middleware.Principal)
+	return principal, ok
+}
+
+// Authenticates calls with the credentials the REST API accepts, sent as x-api-key or authorization metadata
+type authorizer struct {
+	config      middleware.AuthConfig
+	publicReads bool
+}
+
+func newAuthorizer(opts Options) *authorizer {
+	return &authorizer{config: opts.Auth, publicReads: opts.PublicReads}
+}
+
+func (a *authorizer) unary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := a.authorize(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *authorizer) stream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.authorize(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+}
+
+// Identifies the caller and checks the scope of the method.
+// Calls without credentials are anonymous, invalid credentials are rejected.
+func (a *authorizer) authorize(ctx context.Context, method string) (context.Context, error) {
+	principal, err := a.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if principal != nil {
+		ctx = context.WithValue(ctx, principalKey{}, principal)
+	}
+
+	scope, ok := methodScopes[method]
+	if !ok || (scope == models.ScopeRead && a.publicReads) {
+		return ctx, nil
+	}
+	if principal == nil {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+	if !principal.HasScope(scope) {
+		return nil, status.Error(codes.PermissionDenied, "missing required scope: "+scope)
+	}
+	return ctx, nil
+}
+
+func (a *authorizer) authenticate(ctx context.Context) (*middleware.Principal, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	apiKey := firstValue(md, strings.ToLower(middleware.APIKeyHeader))
+	scheme, credentials, _ := strings.Cut(firstValue(md, "authorization"), " ")
+	credentials = strings.TrimSpace(credentials)
+	if apiKey == "" && strings.EqualFold(scheme, "ApiKey") {
+		apiKey = credentials
+	}
+
+	if apiKey != "" {
+		if a.config.APIKeys == nil {
+			return nil, status.Error(codes.Unauthenticated, "API key authentication is not enabled")
+		}
+		key, err := a.config.APIKeys.Authenticate(ctx, apiKey)
+		if errors.Is(err, auth.ErrInvalidCredentials) {
+			return nil, status.Error(codes.Unauthenticated, "invalid API key")
+		}
+		if err != nil {
+			return nil, status.Error(codes.Internal, "failed to check API key")
+		}
+		return &middleware.Principal{
+			Subject:    key.ID,
+			Method:     "api_key",
+			Scopes:     key.Scopes,
+			DailyQuota: key.DailyQuota,
+		}, nil
+	}
+
+	if strings.EqualFold(scheme, "Bearer") && credentials != "" {
+		if a.config.Tokens == nil {
+			return nil, status.Error(codes.Unauthenticated, "bearer token authentication is not enabled")
+		}
+		identity, err := a.config.Tokens.Verify(ctx, credentials)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, "invalid bearer token")
+		}
+		return &middleware.Principal{
+			Subject: identity.Subject,
+			Method:  "jwt",
+			Scopes:  identity.Scopes(),
+			Roles:   identity.Roles,
+		}, nil
+	}
+
+	return nil, nil
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return strings.TrimSpace(values[0])
+	}
+	return ""
+}
+
+// Carries the authenticated context into a streaming handler
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,74 @@
+package grpcapi
+
+import (
+	"context"
+	"time"
+
+	bankapiv1 "github.com/MarcinZ20/bankAPI/api/proto/bankapi/v1"
+	"github.com/MarcinZ20/bankAPI/internal/health"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// How often Watch re-evaluates readiness
+var watchInterval = 5 * time.Second
+
+// Implements the gRPC health checking protocol on top of the readiness of the REST probes,
+// the server and SwiftCodeService are serving once the initial import finished and every dependency is up
+type healthServer struct {
+	healthpb.UnimplementedHealthServer
+}
+
+func (s *healthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	if !isKnownService(req.GetService()) {
+		return nil, status.Errorf(codes.NotFound, "unknown service: %s", req.GetService())
+	}
+	return &healthpb.HealthCheckResponse{Status: servingStatus(ctx)}, nil
+}
+
+func (s *healthServer) List(ctx context.Context, _ *healthpb.HealthListRequest) (*healthpb.HealthListResponse, error) {
+	current := &healthpb.HealthCheckResponse{Status: servingStatus(ctx)}
+	return &healthpb.HealthListResponse{Statuses: map[string]*healthpb.HealthCheckResponse{
+		"": current,
+		bankapiv1.SwiftCodeService_ServiceDesc.ServiceName: current,
+	}}, nil
+}
+
+// Sends the current status and then every change until the client goes away
+func (s *healthServer) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	if !isKnownService(req.GetService()) {
+		return stream.Send(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVICE_UNKNOWN})
+	}
+
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	last := healthpb.HealthCheckResponse_UNKNOWN
+	for {
+		if current := servingStatus(stream.Context()); current != last {
+			if err := stream.Send(&healthpb.HealthCheckResponse{Status: current}); err != nil {
+				return err
+			}
+			last = current
+		}
+
+		select {
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func isKnownService(service string) bool {
+	return service == "" || service == bankapiv1.SwiftCodeService_ServiceDesc.ServiceName
+}
+
+func servingStatus(ctx context.Context) healthpb.HealthCheckResponse_ServingStatus {
+	checker := health.GetInstance()
+	if checker == nil || !checker.Report(ctx).Ready {
+		return healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	return healthpb.HealthCheckResponse_SERVING
+}
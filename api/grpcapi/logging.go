@@ -0,0 +1,39 @@
+package grpcapi
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// Logs every unary call with its status code, like the access log of the REST API
+func unaryLogging(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	logCall(ctx, info.FullMethod, start, err)
+	return resp, err
+}
+
+// Logs every streaming call once the stream has ended
+func streamLogging(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	logCall(ss.Context(), info.FullMethod, start, err)
+	return err
+}
+
+func logCall(ctx context.Context, method string, start time.Time, err error) {
+	code := status.Code(err)
+	level := slog.LevelInfo
+	if err != nil {
+		level = slog.LevelWarn
+	}
+	slog.Log(ctx, level, "grpc request",
+		"method", method,
+		"code", code.String(),
+		"duration", time.Since(start).String(),
+	)
+}
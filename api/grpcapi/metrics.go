@@ -0,0 +1,38 @@
+package grpcapi
+
+import (
+	"context"
+	"time"
+
+	"github.com/MarcinZ20/bankAPI/internal/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// Records call counts, latency and calls in flight per method and status code, like the REST metrics
+func unaryMetrics(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	metrics.GRPCInFlight.Inc()
+	defer metrics.GRPCInFlight.Dec()
+
+	resp, err := handler(ctx, req)
+	observeCall(info.FullMethod, start, err)
+	return resp, err
+}
+
+// Records streaming calls once the stream has ended
+func streamMetrics(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	metrics.GRPCInFlight.Inc()
+	defer metrics.GRPCInFlight.Dec()
+
+	err := handler(srv, ss)
+	observeCall(info.FullMethod, start, err)
+	return err
+}
+
+func observeCall(method string, start time.Time, err error) {
+	code := status.Code(err).String()
+	metrics.GRPCRequests.WithLabelValues(method, code).Inc()
+	metrics.GRPCDuration.WithLabelValues(method, code).Observe(time.Since(start).Seconds())
+}
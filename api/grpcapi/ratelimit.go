@@ -0,0 +1,129 @@
+package grpcapi
+
+import (
+	"context"
+	"math"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/MarcinZ20/bankAPI/internal/ratelimit"
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Applies the limiter of the REST API to calls, sharing its buckets and quotas per client.
+// Create and Delete count as writes, every other call as a read.
+type rateLimiter struct {
+	limiter *ratelimit.Limiter
+}
+
+// Throttles unary calls per client IP before they are authenticated
+func (r *rateLimiter) unaryIP(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if err := r.allowIP(ctx, func(md metadata.MD) error { return grpc.SetHeader(ctx, md) }); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// Throttles streaming calls per client IP before they are authenticated
+func (r *rateLimiter) streamIP(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := r.allowIP(ss.Context(), ss.SetHeader); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// Throttles unary calls per authenticated principal, or per client IP for anonymous calls.
+// Must run after the authorizer so the principal is known.
+func (r *rateLimiter) unary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if err := r.allow(ctx, info.FullMethod, func(md metadata.MD) error { return grpc.SetHeader(ctx, md) }); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// Throttles streaming calls like unary
+func (r *rateLimiter) stream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := r.allow(ss.Context(), info.FullMethod, ss.SetHeader); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+func (r *rateLimiter) allowIP(ctx context.Context, setHeader func(metadata.MD) error) error {
+	decision, err := r.limiter.AllowIP(ctx, peerIP(ctx))
+	if err != nil {
+		return status.Error(codes.Internal, "rate limiter error")
+	}
+	if decision.Allowed {
+		return nil
+	}
+	return enforce(decision, setHeader)
+}
+
+func (r *rateLimiter) allow(ctx context.Context, method string, setHeader func(metadata.MD) error) error {
+	client := "ip:" + peerIP(ctx)
+	var quota int64
+	if principal, ok := PrincipalFromContext(ctx); ok {
+		client = principal.Method + ":" + principal.Subject
+		quota = principal.DailyQuota
+	}
+
+	decision, err := r.limiter.Allow(ctx, client, methodScopes[method] == models.ScopeWrite, quota)
+	if err != nil {
+		return status.Error(codes.Internal, "rate limiter error")
+	}
+	return enforce(decision, setHeader)
+}
+
+// Sends the rate limit headers of a decision as metadata and rejects the call when it was not allowed
+func enforce(decision ratelimit.Decision, setHeader func(metadata.MD) error) error {
+	md := metadata.MD{}
+	if decision.Limit > 0 {
+		md.Set("ratelimit-limit", strconv.Itoa(decision.Limit))
+		md.Set("ratelimit-remaining", strconv.Itoa(decision.Remaining))
+		md.Set("ratelimit-reset", formatSeconds(decision.Reset))
+	}
+	if decision.QuotaLimit > 0 {
+		md.Set("x-quota-limit", strconv.FormatInt(decision.QuotaLimit, 10))
+		md.Set("x-quota-remaining", strconv.FormatInt(decision.QuotaRemaining, 10))
+	}
+	if !decision.Allowed {
+		md.Set("retry-after", formatSeconds(decision.RetryAfter))
+	}
+	if len(md) > 0 {
+		// Headers can no longer be set once the handler sent them, which does not affect the decision
+		_ = setHeader(md)
+	}
+
+	if !decision.Allowed {
+		if decision.QuotaExceeded {
+			return status.Error(codes.ResourceExhausted, "daily quota exceeded")
+		}
+		return status.Error(codes.ResourceExhausted, "rate limit exceeded")
+	}
+	return nil
+}
+
+// Returns the address of the caller without its port
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	addr := p.Addr.String()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// Formats a duration as whole seconds, rounded up so clients never retry too early
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
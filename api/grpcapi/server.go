@@ -0,0 +1,332 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/MarcinZ20/bankAPI/api/middleware"
+	bankapiv1 "github.com/MarcinZ20/bankAPI/api/proto/bankapi/v1"
+	"github.com/MarcinZ20/bankAPI/internal/ratelimit"
+	"github.com/MarcinZ20/bankAPI/internal/repository"
+	"github.com/MarcinZ20/bankAPI/internal/services"
+	"github.com/MarcinZ20/bankAPI/internal/transform"
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+	"github.com/MarcinZ20/bankAPI/pkg/utils"
+	"go.mongodb.org/mongo-driver/mongo"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// Most SWIFT codes a single BatchLookup call may ask for
+const maxBatchLookup = 100
+
+// Holds gRPC server settings
+type Options struct {
+	Auth        middleware.AuthConfig
+	PublicReads bool
+	// Shared with the REST API, nil disables rate limiting
+	RateLimiter *ratelimit.Limiter
+}
+
+// Creates a gRPC server exposing the SWIFT code service, health checking and reflection
+func NewServer(opts Options) *grpc.Server {
+	unary := []grpc.UnaryServerInterceptor{unaryLogging, unaryTracing, unaryMetrics}
+	stream := []grpc.StreamServerInterceptor{streamLogging, streamTracing, streamMetrics}
+
+	// Throttling by IP comes first so invalid credentials are throttled as well
+	auth := newAuthorizer(opts)
+	if opts.RateLimiter != nil {
+		limiter := &rateLimiter{limiter: opts.RateLimiter}
+		unary = append(unary, limiter.unaryIP, auth.unary, limiter.unary)
+		stream = append(stream, limiter.streamIP, auth.stream, limiter.stream)
+	} else {
+		unary = append(unary, auth.unary)
+		stream = append(stream, auth.stream)
+	}
+
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	)
+
+	bankapiv1.RegisterSwiftCodeServiceServer(server, &swiftCodeServer{})
+	healthpb.RegisterHealthServer(server, &healthServer{})
+	reflection.Register(server)
+
+	return server
+}
+
+// Serves SwiftCodeService with the same BankService the REST handlers use
+type swiftCodeServer struct {
+	bankapiv1.UnimplementedSwiftCodeServiceServer
+}
+
+func (s *swiftCodeServer) GetSwiftCode(ctx context.Context, req *bankapiv1.GetSwiftCodeRequest) (*bankapiv1.GetSwiftCodeResponse, error) {
+	sm, err := serviceManager()
+	if err != nil {
+		return nil, err
+	}
+
+	swiftCode := strings.ToUpper(req.GetSwiftCode())
+	if strings.HasSuffix(swiftCode, "XXX") {
+		hq, err := sm.BankService.GetHeadquarter(ctx, swiftCode)
+		if err != nil {
+			return nil, toStatus(err, "headquarter", swiftCode)
+		}
+		response := &bankapiv1.GetSwiftCodeResponse{Bank: headquarterToProto(hq)}
+		for i := range hq.Branches {
+			response.Branches = append(response.Branches, branchToProto(&hq.Branches[i]))
+		}
+		return response, nil
+	}
+
+	branch, err := sm.BankService.GetBranch(ctx, swiftCode)
+	if err != nil {
+		return nil, toStatus(err, "branch", swiftCode)
+	}
+	return &bankapiv1.GetSwiftCodeResponse{Bank: branchToProto(branch)}, nil
+}
+
+func (s *swiftCodeServer) ListByCountry(ctx context.Context, req *bankapiv1.ListByCountryRequest) (*bankapiv1.ListByCountryResponse, error) {
+	sm, err := serviceManager()
+	if err != nil {
+		return nil, err
+	}
+
+	countryCode := strings.ToUpper(req.GetCountryIso2())
+	hqs, err := sm.BankService.GetBanksByCountryCode(ctx, countryCode)
+	if err != nil {
+		return nil, toStatus(err, "records", countryCode)
+	}
+	if len(hqs) == 0 {
+		return nil, toStatus(mongo.ErrNoDocuments, "records", countryCode)
+	}
+
+	response := &bankapiv1.ListByCountryResponse{
+		CountryIso2: countryCode,
+		CountryName: hqs[0].CountryName,
+	}
+	for i := range hqs {
+		response.SwiftCodes = append(response.SwiftCodes, headquarterToProto(&hqs[i]))
+		for j := range hqs[i].Branches {
+			response.SwiftCodes = append(response.SwiftCodes, branchToProto(&hqs[i].Branches[j]))
+		}
+	}
+	return response, nil
+}
+
+// Invalid codes fail the whole call, unknown codes are reported in not_found
+func (s *swiftCodeServer) BatchLookup(ctx context.Context, req *bankapiv1.BatchLookupRequest) (*bankapiv1.BatchLookupResponse, error) {
+	sm, err := serviceManager()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(req.GetSwiftCodes()) > maxBatchLookup {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d SWIFT codes can be looked up at once", maxBatchLookup)
+	}
+	for _, swiftCode := range req.GetSwiftCodes() {
+		if !utils.IsValidSwiftCodeFormat(strings.ToUpper(swiftCode)) {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid SWIFT code format: %s", swiftCode)
+		}
+	}
+
+	// Branches are stored inside their headquarter, so one lookup of the parents answers every code
+	var parents []string
+	for _, swiftCode := range req.GetSwiftCodes() {
+		parent := strings.ToUpper(swiftCode)[0:8] + "XXX"
+		if !slices.Contains(parents, parent) {
+			parents = append(parents, parent)
+		}
+	}
+	hqs, err := sm.BankService.GetHeadquarters(ctx, parents)
+	if err != nil {
+		return nil, toStatus(err, "headquarters", "")
+	}
+
+	found := make(map[string]*bankapiv1.Bank)
+	for i := range hqs {
+		found[hqs[i].SwiftCode] = headquarterToProto(&hqs[i])
+		for j := range hqs[i].Branches {
+			found[hqs[i].Branches[j].SwiftCode] = branchToProto(&hqs[i].Branches[j])
+		}
+	}
+
+	response := &bankapiv1.BatchLookupResponse{}
+	for _, swiftCode := range req.GetSwiftCodes() {
+		swiftCode = strings.ToUpper(swiftCode)
+		if bank, ok := found[swiftCode]; ok {
+			response.Banks = append(response.Banks, bank)
+		} else {
+			response.NotFound = append(response.NotFound, swiftCode)
+		}
+	}
+	return response, nil
+}
+
+// Creates a headquarter or a branch of an existing headquarter, mirroring POST /v1/swift-codes
+func (s *swiftCodeServer) Create(ctx context.Context, req *bankapiv1.CreateRequest) (*bankapiv1.CreateResponse, error) {
+	sm, err := serviceManager()
+	if err != nil {
+		return nil, err
+	}
+	if req.GetBank() == nil {
+		return nil, status.Error(codes.InvalidArgument, "bank is required")
+	}
+
+	record := protoToBranch(req.GetBank())
+	transformer := transform.ModelTransformer{}
+	transformer.CleanRequestModel(record)
+
+	if !utils.IsValidSwiftCodeFormat(record.SwiftCode) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid SWIFT code format: %s", record.SwiftCode)
+	}
+	if !utils.IsValidCountryCode(record.CountryISO2) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid country code format: %s", record.CountryISO2)
+	}
+
+	if strings.HasSuffix(record.SwiftCode, "XXX") {
+		if !record.IsHeadquarter {
+			return nil, status.Error(codes.InvalidArgument, "SWIFT code ends with XXX, but is_headquarter is false")
+		}
+
+		hq := models.Headquarter{
+			SwiftCode:     record.SwiftCode,
+			BankName:      record.BankName,
+			Address:       record.Address,
+			CountryName:   record.CountryName,
+			CountryISO2:   record.CountryISO2,
+			IsHeadquarter: true,
+			Branches:      []models.Branch{},
+		}
+		if err := sm.BankService.AddHeadquarter(ctx, &hq); err != nil {
+			return nil, toStatus(err, "headquarter", record.SwiftCode)
+		}
+		return &bankapiv1.CreateResponse{Bank: headquarterToProto(&hq)}, nil
+	}
+
+	parentHqSwiftCode := record.SwiftCode[0:8] + "XXX"
+	if err := sm.BankService.AddBranch(ctx, parentHqSwiftCode, record); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, toStatus(err, "parent headquarter", parentHqSwiftCode)
+		}
+		return nil, toStatus(err, "branch", record.SwiftCode)
+	}
+	return &bankapiv1.CreateResponse{Bank: branchToProto(record)}, nil
+}
+
+// Deletes a headquarter with its branches or a single branch, mirroring DELETE /v1/swift-codes/:swiftCode
+func (s *swiftCodeServer) Delete(ctx context.Context, req *bankapiv1.DeleteRequest) (*bankapiv1.DeleteResponse, error) {
+	sm, err := serviceManager()
+	if err != nil {
+		return nil, err
+	}
+
+	swiftCode := strings.ToUpper(req.GetSwiftCode())
+	if !utils.IsValidSwiftCodeFormat(swiftCode) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid SWIFT code format: %s", swiftCode)
+	}
+
+	if strings.HasSuffix(swiftCode, "XXX") {
+		if err := sm.BankService.DeleteHeadquarter(ctx, swiftCode, nil); err != nil {
+			return nil, toStatus(err, "headquarter", swiftCode)
+		}
+		return &bankapiv1.DeleteResponse{}, nil
+	}
+
+	if err := sm.BankService.DeleteBranch(ctx, swiftCode, swiftCode[0:8]+"XXX", nil); err != nil {
+		return nil, toStatus(err, "branch", swiftCode)
+	}
+	return &bankapiv1.DeleteResponse{}, nil
+}
+
+// Sends one message per headquarter as the repository cursor yields it
+func (s *swiftCodeServer) ExportAll(req *bankapiv1.ExportAllRequest, stream grpc.ServerStreamingServer[bankapiv1.Headquarter]) error {
+	sm, err := serviceManager()
+	if err != nil {
+		return err
+	}
+
+	country := strings.ToUpper(req.GetCountryIso2())
+	if country != "" && !utils.IsValidCountryCode(country) {
+		return status.Errorf(codes.InvalidArgument, "invalid country code format: %s", country)
+	}
+
+	err = sm.BankService.StreamHeadquarters(stream.Context(), country, func(hq *models.Headquarter) error {
+		message := &bankapiv1.Headquarter{Bank: headquarterToProto(hq)}
+		for i := range hq.Branches {
+			message.Branches = append(message.Branches, branchToProto(&hq.Branches[i]))
+		}
+		return stream.Send(message)
+	})
+	if err != nil {
+		if _, ok := status.FromError(err); ok {
+			return err
+		}
+		return toStatus(err, "records", country)
+	}
+	return nil
+}
+
+func serviceManager() (*services.ServiceManager, error) {
+	sm := services.GetInstance()
+	if sm == nil || !sm.IsInitialized() {
+		return nil, status.Error(codes.Unavailable, "service not initialized")
+	}
+	return sm, nil
+}
+
+func headquarterToProto(hq *models.Headquarter) *bankapiv1.Bank {
+	return &bankapiv1.Bank{
+		SwiftCode:     hq.SwiftCode,
+		BankName:      hq.BankName,
+		Address:       hq.Address,
+		CountryIso2:   hq.CountryISO2,
+		CountryName:   hq.CountryName,
+		IsHeadquarter: true,
+	}
+}
+
+func branchToProto(branch *models.Branch) *bankapiv1.Bank {
+	return &bankapiv1.Bank{
+		SwiftCode:     branch.SwiftCode,
+		BankName:      branch.BankName,
+		Address:       branch.Address,
+		CountryIso2:   branch.CountryISO2,
+		CountryName:   branch.CountryName,
+		IsHeadquarter: branch.IsHeadquarter,
+	}
+}
+
+func protoToBranch(bank *bankapiv1.Bank) *models.Branch {
+	return &models.Branch{
+		SwiftCode:     bank.GetSwiftCode(),
+		BankName:      bank.GetBankName(),
+		Address:       bank.GetAddress(),
+		CountryISO2:   bank.GetCountryIso2(),
+		CountryName:   bank.GetCountryName(),
+		IsHeadquarter: bank.GetIsHeadquarter(),
+	}
+}
+
+// Maps a BankService error to a gRPC status with the messages of the REST error responses
+func toStatus(err error, resource, key string) error {
+	var validationErr *services.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		return status.Error(codes.InvalidArgument, validationErr.Message)
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return status.Errorf(codes.NotFound, "%s not found: %s", resource, key)
+	case errors.Is(err, repository.ErrAlreadyExists):
+		return status.Errorf(codes.AlreadyExists, "%s with SWIFT code %s already exists", resource, key)
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	default:
+		return status.Errorf(codes.Internal, "database error: %v", err)
+	}
+}
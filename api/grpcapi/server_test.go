@@ -0,0 +1,266 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/MarcinZ20/bankAPI/api/middleware"
+	bankapiv1 "github.com/MarcinZ20/bankAPI/api/proto/bankapi/v1"
+	"github.com/MarcinZ20/bankAPI/internal/auth/authtest"
+	"github.com/MarcinZ20/bankAPI/internal/health"
+	"github.com/MarcinZ20/bankAPI/internal/ratelimit"
+	"github.com/MarcinZ20/bankAPI/internal/repository"
+	"github.com/MarcinZ20/bankAPI/internal/services"
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+var repo = repository.NewMemoryRepository()
+
+func TestMain(m *testing.M) {
+	services.NewServiceManagerWithStore(repo)
+	health.NewChecker("test", "test", time.Second).SkipImport()
+	os.Exit(m.Run())
+}
+
+// Reads are public, writes need the writer key
+var testOptions = Options{
+	PublicReads: true,
+	Auth: middleware.AuthConfig{
+		APIKeys: authtest.APIKeys{
+			"bk_reader": {ID: "reader", Scopes: []string{models.ScopeRead}},
+			"bk_writer": {ID: "writer", Scopes: []string{models.ScopeRead, models.ScopeWrite}},
+		},
+	},
+}
+
+func withKey(key string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "x-api-key", key)
+}
+
+func loadBanks() {
+	repo.Load(map[string]models.Headquarter{
+		"AAAAPLPWXXX": {
+			SwiftCode: "AAAAPLPWXXX", BankName: "BANK A", Address: "STREET 1", CountryISO2: "PL", CountryName: "POLAND", IsHeadquarter: true,
+			Branches: []models.Branch{
+				{SwiftCode: "AAAAPLPW001", BankName: "BANK A", Address: "STREET 2", CountryISO2: "PL", CountryName: "POLAND"},
+			},
+		},
+		"BBBBDEFFXXX": {
+			SwiftCode: "BBBBDEFFXXX", BankName: "BANK B", CountryISO2: "DE", CountryName: "GERMANY", IsHeadquarter: true,
+		},
+	})
+}
+
+// Starts a server on an in-memory listener and returns a client connected to it
+func setupClient(t *testing.T, opts Options) (bankapiv1.SwiftCodeServiceClient, *grpc.ClientConn) {
+	t.Helper()
+	loadBanks()
+
+	listener := bufconn.Listen(1 << 20)
+	server := NewServer(opts)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return bankapiv1.NewSwiftCodeServiceClient(conn), conn
+}
+
+func TestSwiftCodeService_Lookups(t *testing.T) {
+	client, _ := setupClient(t, testOptions)
+	ctx := context.Background()
+
+	hq, err := client.GetSwiftCode(ctx, &bankapiv1.GetSwiftCodeRequest{SwiftCode: "aaaaplpwxxx"})
+	require.NoError(t, err)
+	assert.Equal(t, "BANK A", hq.GetBank().GetBankName())
+	assert.True(t, hq.GetBank().GetIsHeadquarter())
+	require.Len(t, hq.GetBranches(), 1)
+	assert.Equal(t, "AAAAPLPW001", hq.GetBranches()[0].GetSwiftCode())
+
+	branch, err := client.GetSwiftCode(ctx, &bankapiv1.GetSwiftCodeRequest{SwiftCode: "AAAAPLPW001"})
+	require.NoError(t, err)
+	assert.False(t, branch.GetBank().GetIsHeadquarter())
+	assert.Empty(t, branch.GetBranches())
+
+	country, err := client.ListByCountry(ctx, &bankapiv1.ListByCountryRequest{CountryIso2: "PL"})
+	require.NoError(t, err)
+	assert.Equal(t, "POLAND", country.GetCountryName())
+	assert.Len(t, country.GetSwiftCodes(), 2)
+
+	batch, err := client.BatchLookup(ctx, &bankapiv1.BatchLookupRequest{SwiftCodes: []string{"BBBBDEFFXXX", "CCCCFRPPXXX", "AAAAPLPW001"}})
+	require.NoError(t, err)
+	require.Len(t, batch.GetBanks(), 2)
+	assert.Equal(t, "BBBBDEFFXXX", batch.GetBanks()[0].GetSwiftCode())
+	assert.Equal(t, "AAAAPLPW001", batch.GetBanks()[1].GetSwiftCode())
+	assert.Equal(t, []string{"CCCCFRPPXXX"}, batch.GetNotFound())
+}
+
+func TestSwiftCodeService_ErrorCodes(t *testing.T) {
+	client, _ := setupClient(t, testOptions)
+	ctx := withKey("bk_writer")
+
+	_, err := client.GetSwiftCode(ctx, &bankapiv1.GetSwiftCodeRequest{SwiftCode: "INVALID"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.GetSwiftCode(ctx, &bankapiv1.GetSwiftCodeRequest{SwiftCode: "CCCCFRPPXXX"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.ListByCountry(ctx, &bankapiv1.ListByCountryRequest{CountryIso2: "FR"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.BatchLookup(ctx, &bankapiv1.BatchLookupRequest{SwiftCodes: []string{"AAAAPLPWXXX", "BAD"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.Create(ctx, &bankapiv1.CreateRequest{Bank: &bankapiv1.Bank{
+		SwiftCode: "AAAAPLPWXXX", BankName: "BANK A", CountryIso2: "PL", CountryName: "POLAND", IsHeadquarter: true,
+	}})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	_, err = client.Create(ctx, &bankapiv1.CreateRequest{Bank: &bankapiv1.Bank{
+		SwiftCode: "CCCCFRPP001", BankName: "BANK C", CountryIso2: "FR", CountryName: "FRANCE",
+	}})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.Delete(ctx, &bankapiv1.DeleteRequest{SwiftCode: "AAAAPLPW002"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestSwiftCodeService_CreateAndDelete(t *testing.T) {
+	client, _ := setupClient(t, testOptions)
+	ctx := withKey("bk_writer")
+
+	created, err := client.Create(ctx, &bankapiv1.CreateRequest{Bank: &bankapiv1.Bank{
+		SwiftCode: "aaaaplpw002", BankName: "Bank A", Address: " Street 3 ", CountryIso2: "pl", CountryName: "Poland",
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, "AAAAPLPW002", created.GetBank().GetSwiftCode())
+	assert.Equal(t, "BANK A", created.GetBank().GetBankName())
+
+	hq, err := client.GetSwiftCode(ctx, &bankapiv1.GetSwiftCodeRequest{SwiftCode: "AAAAPLPWXXX"})
+	require.NoError(t, err)
+	assert.Len(t, hq.GetBranches(), 2)
+
+	_, err = client.Delete(ctx, &bankapiv1.DeleteRequest{SwiftCode: "AAAAPLPW002"})
+	require.NoError(t, err)
+
+	_, err = client.GetSwiftCode(ctx, &bankapiv1.GetSwiftCodeRequest{SwiftCode: "AAAAPLPW002"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestSwiftCodeService_ExportAll(t *testing.T) {
+	client, _ := setupClient(t, testOptions)
+
+	stream, err := client.ExportAll(context.Background(), &bankapiv1.ExportAllRequest{})
+	require.NoError(t, err)
+
+	var swiftCodes []string
+	for {
+		hq, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		swiftCodes = append(swiftCodes, hq.GetBank().GetSwiftCode())
+	}
+	assert.Equal(t, []string{"AAAAPLPWXXX", "BBBBDEFFXXX"}, swiftCodes)
+
+	stream, err = client.ExportAll(context.Background(), &bankapiv1.ExportAllRequest{CountryIso2: "DE"})
+	require.NoError(t, err)
+	hq, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, "BBBBDEFFXXX", hq.GetBank().GetSwiftCode())
+	_, err = stream.Recv()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestSwiftCodeService_Auth(t *testing.T) {
+	opts := testOptions
+	opts.PublicReads = false
+	client, _ := setupClient(t, opts)
+	lookup := &bankapiv1.GetSwiftCodeRequest{SwiftCode: "AAAAPLPWXXX"}
+	remove := &bankapiv1.DeleteRequest{SwiftCode: "AAAAPLPW001"}
+
+	_, err := client.GetSwiftCode(context.Background(), lookup)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = client.GetSwiftCode(withKey("bk_unknown"), lookup)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = client.GetSwiftCode(withKey("bk_reader"), lookup)
+	assert.NoError(t, err)
+
+	_, err = client.Delete(withKey("bk_reader"), remove)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "ApiKey bk_writer")
+	_, err = client.Delete(ctx, remove)
+	assert.NoError(t, err)
+
+	stream, err := client.ExportAll(context.Background(), &bankapiv1.ExportAllRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestSwiftCodeService_RateLimit(t *testing.T) {
+	opts := testOptions
+	opts.RateLimiter = ratelimit.NewLimiter(ratelimit.NewMemoryStore(),
+		ratelimit.Limit{}, ratelimit.Limit{Rate: 0.001, Burst: 1}, ratelimit.Limit{Rate: 0.001, Burst: 5}, 0)
+	client, _ := setupClient(t, opts)
+	remove := &bankapiv1.DeleteRequest{SwiftCode: "AAAAPLPW001"}
+
+	_, err := client.Delete(withKey("bk_writer"), remove)
+	require.NoError(t, err)
+
+	var header metadata.MD
+	_, err = client.Delete(withKey("bk_writer"), remove, grpc.Header(&header))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, []string{"0"}, header.Get("ratelimit-remaining"))
+	assert.NotEmpty(t, header.Get("retry-after"))
+
+	// Reads use their own bucket, the IP bucket throttles every call before authentication
+	lookup := &bankapiv1.GetSwiftCodeRequest{SwiftCode: "AAAAPLPWXXX"}
+	for range 3 {
+		_, err = client.GetSwiftCode(withKey("bk_unknown"), lookup)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	}
+	_, err = client.GetSwiftCode(withKey("bk_unknown"), lookup)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestHealthServer(t *testing.T) {
+	_, conn := setupClient(t, Options{})
+	client := healthpb.NewHealthClient(conn)
+
+	resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+
+	resp, err = client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "bankapi.v1.SwiftCodeService"})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+
+	_, err = client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "unknown"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
@@ -0,0 +1,86 @@
+package grpcapi
+
+import (
+	"context"
+	"strings"
+
+	"github.com/MarcinZ20/bankAPI/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Starts a server span for every unary call, continuing the trace from incoming traceparent metadata
+func unaryTracing(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, span := startCall(ctx, info.FullMethod)
+	resp, err := handler(ctx, req)
+	endCall(span, err)
+	return resp, err
+}
+
+// Starts a server span covering the whole stream
+func streamTracing(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, span := startCall(ss.Context(), info.FullMethod)
+	err := handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	endCall(span, err)
+	return err
+}
+
+func startCall(ctx context.Context, method string) (context.Context, trace.Span) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+
+	service, name, _ := strings.Cut(strings.TrimPrefix(method, "/"), "/")
+	return tracing.Tracer().Start(ctx, strings.TrimPrefix(method, "/"),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("rpc.system", "grpc"),
+			attribute.String("rpc.service", service),
+			attribute.String("rpc.method", name),
+		),
+	)
+}
+
+// Marks the span failed for server side status codes, client errors such as NotFound are not failures of the server
+func endCall(span trace.Span, err error) {
+	defer span.End()
+
+	code := status.Code(err)
+	span.SetAttributes(attribute.Int("rpc.grpc.status_code", int(code)))
+	if err == nil {
+		return
+	}
+
+	span.RecordError(err)
+	switch code {
+	case codes.Unknown, codes.DeadlineExceeded, codes.Unimplemented, codes.Internal, codes.Unavailable, codes.DataLoss:
+		span.SetStatus(otelcodes.Error, code.String())
+	}
+}
+
+// Adapts incoming gRPC metadata to the OpenTelemetry propagation carrier, keys are lower case
+type metadataCarrier metadata.MD
+
+func (m metadataCarrier) Get(key string) string {
+	if values := metadata.MD(m).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (m metadataCarrier) Set(key, value string) {
+	metadata.MD(m).Set(key, value)
+}
+
+func (m metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
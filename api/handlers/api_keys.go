@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/MarcinZ20/bankAPI/api/middleware"
+	"github.com/MarcinZ20/bankAPI/api/responses"
+	"github.com/MarcinZ20/bankAPI/internal/services"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func CreateAPIKey(c *fiber.Ctx) error {
+	ctx, ok := middleware.GetRequestContext(c)
+	if !ok {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to get request context")
+	}
+
+	sm := services.GetInstance()
+	if sm == nil || !sm.APIKeyService.IsInitialized() {
+		return responses.DatabaseError(fmt.Errorf("api key service not initialized"))
+	}
+
+	request := new(services.CreateAPIKeyRequest)
+	if err := c.BodyParser(request); err != nil {
+		return responses.ValidationError(fmt.Sprintf("Invalid request body: %v", err))
+	}
+
+	key, rawKey, err := sm.APIKeyService.CreateKey(ctx, *request)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidAPIKeyRequest) {
+			return responses.ValidationError(err.Error())
+		}
+		return responses.DatabaseError(err)
+	}
+
+	return responses.NewSuccessResponse(c, responses.APIKeySecretResponse{
+		Key:    rawKey,
+		APIKey: responses.NewAPIKeyResponse(key),
+	})
+}
+
+func ListAPIKeys(c *fiber.Ctx) error {
+	ctx, ok := middleware.GetRequestContext(c)
+	if !ok {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to get request context")
+	}
+
+	sm := services.GetInstance()
+	if sm == nil || !sm.APIKeyService.IsInitialized() {
+		return responses.DatabaseError(fmt.Errorf("api key service not initialized"))
+	}
+
+	keys, err := sm.APIKeyService.ListKeys(ctx)
+	if err != nil {
+		return responses.DatabaseError(err)
+	}
+
+	response := make([]responses.APIKeyResponse, 0, len(keys))
+	for i := range keys {
+		response = append(response, responses.NewAPIKeyResponse(&keys[i]))
+	}
+
+	return responses.NewSuccessResponse(c, response)
+}
+
+func RotateAPIKey(c *fiber.Ctx) error {
+	ctx, ok := middleware.GetRequestContext(c)
+	if !ok {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to get request context")
+	}
+
+	sm := services.GetInstance()
+	if sm == nil || !sm.APIKeyService.IsInitialized() {
+		return responses.DatabaseError(fmt.Errorf("api key service not initialized"))
+	}
+
+	id := c.Params("id")
+	key, rawKey, err := sm.APIKeyService.RotateKey(ctx, id)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return responses.NotFoundError("api key", id)
+		}
+		return responses.DatabaseError(err)
+	}
+
+	return responses.NewSuccessResponse(c, responses.APIKeySecretResponse{
+		Key:    rawKey,
+		APIKey: responses.NewAPIKeyResponse(key),
+	})
+}
+
+func RevokeAPIKey(c *fiber.Ctx) error {
+	ctx, ok := middleware.GetRequestContext(c)
+	if !ok {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to get request context")
+	}
+
+	sm := services.GetInstance()
+	if sm == nil || !sm.APIKeyService.IsInitialized() {
+		return responses.DatabaseError(fmt.Errorf("api key service not initialized"))
+	}
+
+	id := c.Params("id")
+	if err := sm.APIKeyService.RevokeKey(ctx, id); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return responses.NotFoundError("api key", id)
+		}
+		return responses.DatabaseError(err)
+	}
+
+	return responses.NewSuccessResponse(c, fiber.Map{
+		"message": "API key was revoked successfully",
+	})
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/MarcinZ20/bankAPI/api/responses"
+	"github.com/MarcinZ20/bankAPI/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+func GetCacheStats(c *fiber.Ctx) error {
+	sm := services.GetInstance()
+	if sm == nil || !sm.IsInitialized() {
+		return responses.DatabaseError(fmt.Errorf("service not initialized"))
+	}
+
+	stats, ok := sm.BankService.CacheStats()
+	if !ok {
+		return responses.NotFoundError("cache", "caching is disabled")
+	}
+
+	return responses.NewSuccessResponse(c, stats)
+}
+
+func PurgeCache(c *fiber.Ctx) error {
+	sm := services.GetInstance()
+	if sm == nil || !sm.IsInitialized() {
+		return responses.DatabaseError(fmt.Errorf("service not initialized"))
+	}
+
+	sm.BankService.InvalidateCache()
+
+	return responses.NewSuccessResponse(c, fiber.Map{
+		"message": "Cache was purged successfully",
+	})
+}
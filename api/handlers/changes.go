@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/MarcinZ20/bankAPI/api/middleware"
+	"github.com/MarcinZ20/bankAPI/api/responses"
+	"github.com/MarcinZ20/bankAPI/internal/changes"
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Comment line sent on an idle stream so proxies and clients keep the connection open
+var streamHeartbeat = 15 * time.Second
+
+// Streams change events as server-sent events. A client resumes after a disconnect by sending the
+// last event ID it received in the Last-Event-ID header, or the lastEventId query parameter.
+// When the events after it are no longer available the stream starts with a reset event instead.
+func StreamChanges(c *fiber.Ctx) error {
+	ctx, ok := middleware.GetRequestContext(c)
+	if !ok {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to get request context")
+	}
+
+	feed := changes.GetInstance()
+	if feed == nil {
+		return responses.InternalServerError("Change feed is not enabled")
+	}
+
+	lastEventID := c.Get("Last-Event-ID", c.Query("lastEventId"))
+	var lastID uint64
+	if lastEventID != "" {
+		var err error
+		if lastID, err = strconv.ParseUint(lastEventID, 10, 64); err != nil {
+			return responses.ValidationError(fmt.Sprintf("Invalid last event ID: %v", lastEventID))
+		}
+	}
+	middleware.AddLogAttrs(c, slog.Uint64("lastEventId", lastID))
+
+	backlog, sub, missed := feed.Subscribe(lastID)
+	if missed {
+		slog.InfoContext(ctx, "change stream cannot resume, sending a reset", "lastEventId", lastID)
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	// Stops reverse proxies such as nginx from buffering the stream
+	c.Set("X-Accel-Buffering", "no")
+
+	// Like an export, the stream outlives the request context and ends with a failing write
+	// when the client goes away, or when the subscription is closed
+	ctx = context.WithoutCancel(ctx)
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer sub.Close()
+
+		if missed {
+			if err := writeResetEvent(w, sub.LastID()); err != nil {
+				return
+			}
+		}
+
+		sent := 0
+		for _, event := range backlog {
+			if err := writeChangeEvent(w, event); err != nil {
+				return
+			}
+			sent++
+		}
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		heartbeat := time.NewTicker(streamHeartbeat)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case event, ok := <-sub.Events():
+				if !ok {
+					slog.InfoContext(ctx, "change stream closed", "events", sent)
+					return
+				}
+				if err := writeChangeEvent(w, event); err != nil {
+					return
+				}
+				sent++
+			case <-heartbeat.C:
+				if _, err := w.WriteString(": keepalive\n\n"); err != nil {
+					return
+				}
+			}
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+	return nil
+}
+
+// Tells a client that it missed events and must refetch the data it follows.
+// The ID is the last event published before the stream started, so a reconnect resumes after it.
+func writeResetEvent(w *bufio.Writer, lastID uint64) error {
+	_, err := fmt.Fprintf(w, "id: %d\nevent: reset\ndata: {\"lastEventId\":%d}\n\n", lastID, lastID)
+	return err
+}
+
+// Writes an event in the server-sent events format, the JSON data fits on a single line
+func writeChangeEvent(w *bufio.Writer, event models.ChangeEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: change\ndata: %s\n\n", event.ID, data)
+	return err
+}
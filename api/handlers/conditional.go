@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/MarcinZ20/bankAPI/api/responses"
+	"github.com/MarcinZ20/bankAPI/internal/repository"
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Builds the precondition of a write from the If-Match header, nil when the request has none.
+// The header must hold the ETag a GET of the record returns now, in any representation.
+func ifMatch(c *fiber.Ctx) repository.Precondition {
+	header := c.Get(fiber.HeaderIfMatch)
+	if header == "" {
+		return nil
+	}
+
+	return func(current models.BankEntity) error {
+		tag, err := entityTag(current)
+		if err != nil {
+			return err
+		}
+		if !responses.MatchesETag(header, tag) {
+			return repository.ErrPreconditionFailed
+		}
+		return nil
+	}
+}
+
+// Computes the ETag of a record as GET /swift-codes/:swiftCode returns it
+func entityTag(entity models.BankEntity) (string, error) {
+	if hq, ok := entity.(*models.Headquarter); ok {
+		response := new(responses.HeadquarterResponse)
+		if err := response.FromModel(hq); err != nil {
+			return "", err
+		}
+		return responses.ETag(response)
+	}
+
+	response := new(responses.LongBankResponse)
+	if err := response.FromModel(entity); err != nil {
+		return "", err
+	}
+	return responses.ETag(response)
+}
+
+// Returns the Last-Modified time of a record, zero for records stored before modification times were kept
+func modifiedAt(updatedAt *time.Time) time.Time {
+	if updatedAt == nil {
+		return time.Time{}
+	}
+	return *updatedAt
+}
+
+// Returns the response to a write whose If-Match header did not hold
+func preconditionFailed(swiftCode string) error {
+	return responses.PreconditionFailedError("SWIFT code " + swiftCode + " was modified, fetch it again and retry with its current ETag")
+}
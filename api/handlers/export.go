@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/MarcinZ20/bankAPI/api/middleware"
+	"github.com/MarcinZ20/bankAPI/api/responses"
+	"github.com/MarcinZ20/bankAPI/internal/export"
+	"github.com/MarcinZ20/bankAPI/internal/services"
+	"github.com/MarcinZ20/bankAPI/internal/tracing"
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+	"github.com/MarcinZ20/bankAPI/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Streams all headquarters with their branches as JSON, CSV or NDJSON, optionally limited to one country.
+// The CSV export uses the spreadsheet layout, so it can be imported again with the csv source.
+func ExportSwiftCodes(c *fiber.Ctx) error {
+	ctx, ok := middleware.GetRequestContext(c)
+	if !ok {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to get request context")
+	}
+
+	sm := services.GetInstance()
+	if sm == nil || !sm.IsInitialized() {
+		return responses.DatabaseError(fmt.Errorf("service not initialized"))
+	}
+
+	format := strings.ToLower(c.Query("format", export.FormatJSON))
+	country := strings.ToUpper(c.Query("country"))
+	middleware.AddLogAttrs(c, slog.String("format", format), slog.String("countryISO2", country))
+	if !export.IsFormat(format) {
+		return responses.ValidationError(fmt.Sprintf("Invalid export format: %v, expected one of %s", format, strings.Join(export.Formats(), ", ")))
+	}
+	if country != "" && !utils.IsValidCountryCode(country) {
+		return responses.ValidationError(fmt.Sprintf("Invalid country code format: %v", country))
+	}
+
+	c.Set(fiber.HeaderContentType, export.ContentType(format))
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="swift-codes.%s"`, format))
+
+	// The body is written after the handler returns, when the request context is already canceled,
+	// a client that goes away stops the stream through the failing writes instead
+	ctx = context.WithoutCancel(ctx)
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		ctx, span := tracing.Start(ctx, "handlers.ExportSwiftCodes")
+		var err error
+		defer func() { tracing.End(span, err) }()
+
+		encoder, err := export.NewEncoder(w, format)
+		if err != nil {
+			return
+		}
+		rows := 0
+		err = sm.BankService.StreamHeadquarters(ctx, country, func(hq *models.Headquarter) error {
+			if err := encoder.Encode(hq); err != nil {
+				return err
+			}
+			rows += 1 + len(hq.Branches)
+			return w.Flush()
+		})
+		if err == nil {
+			err = encoder.Close()
+		}
+		if err == nil {
+			err = w.Flush()
+		}
+		if err != nil {
+			// The status line is already sent, the truncated body is all the client sees
+			slog.ErrorContext(ctx, "export interrupted", "format", format, "countryISO2", country, "rows", rows, "error", err)
+			return
+		}
+		slog.InfoContext(ctx, "export completed", "format", format, "countryISO2", country, "rows", rows)
+	})
+	return nil
+}
@@ -1,12 +1,17 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
+	"log/slog"
 	"strings"
+	"time"
 
 	"github.com/MarcinZ20/bankAPI/api/middleware"
 	"github.com/MarcinZ20/bankAPI/api/responses"
+	"github.com/MarcinZ20/bankAPI/internal/repository"
 	"github.com/MarcinZ20/bankAPI/internal/services"
+	"github.com/MarcinZ20/bankAPI/internal/tracing"
 	"github.com/MarcinZ20/bankAPI/internal/transform"
 	"github.com/MarcinZ20/bankAPI/pkg/models"
 	"github.com/MarcinZ20/bankAPI/pkg/utils"
@@ -20,12 +25,16 @@ func GetSwiftCodesBySwiftCode(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusInternalServerError, "Failed to get request context")
 	}
 
+	ctx, span := tracing.Start(ctx, "handlers.GetSwiftCodesBySwiftCode")
+	defer span.End()
+
 	sm := services.GetInstance()
 	if sm == nil || !sm.IsInitialized() {
 		return responses.DatabaseError(fmt.Errorf("service not initialized"))
 	}
 
 	swiftCode := c.Params("swiftCode")
+	middleware.AddLogAttrs(c, slog.String("swiftCode", swiftCode))
 	if !utils.IsValidSwiftCodeFormat(swiftCode) {
 		return responses.ValidationError(fmt.Sprintf("Invalid SWIFT code format: %v", swiftCode))
 	}
@@ -33,7 +42,7 @@ func GetSwiftCodesBySwiftCode(c *fiber.Ctx) error {
 	if strings.HasSuffix(swiftCode, "XXX") {
 		hq, err := sm.BankService.GetHeadquarter(ctx, swiftCode)
 		if err != nil {
-			if err == mongo.ErrNoDocuments {
+			if errors.Is(err, mongo.ErrNoDocuments) {
 				return responses.NotFoundError("headquarter", swiftCode)
 			}
 			return responses.DatabaseError(err)
@@ -44,12 +53,12 @@ func GetSwiftCodesBySwiftCode(c *fiber.Ctx) error {
 			return responses.FormattingResponseError("Error while formatting response")
 		}
 
-		return responses.NewSuccessResponse(c, response)
+		return responses.NewConditionalResponse(c, response, modifiedAt(hq.UpdatedAt))
 	}
 
 	branch, err := sm.BankService.GetBranch(ctx, swiftCode)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
+		if errors.Is(err, mongo.ErrNoDocuments) {
 			return responses.NotFoundError("branch", swiftCode)
 		}
 		return responses.DatabaseError(err)
@@ -60,7 +69,7 @@ func GetSwiftCodesBySwiftCode(c *fiber.Ctx) error {
 		return responses.FormattingResponseError("Error while formatting response")
 	}
 
-	return responses.NewSuccessResponse(c, response)
+	return responses.NewConditionalResponse(c, response, modifiedAt(branch.UpdatedAt))
 }
 
 func GetSwiftCodesByCountryCode(c *fiber.Ctx) error {
@@ -69,19 +78,23 @@ func GetSwiftCodesByCountryCode(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusInternalServerError, "Failed to get request context")
 	}
 
+	ctx, span := tracing.Start(ctx, "handlers.GetSwiftCodesByCountryCode")
+	defer span.End()
+
 	sm := services.GetInstance()
 	if sm == nil || !sm.IsInitialized() {
 		return responses.DatabaseError(fmt.Errorf("service not initialized"))
 	}
 
 	countryCode := c.Params("countryISO2")
+	middleware.AddLogAttrs(c, slog.String("countryISO2", countryCode))
 	if !utils.IsValidCountryCode(countryCode) {
 		return responses.ValidationError(fmt.Sprintf("Invalid country code format: %v", countryCode))
 	}
 
 	foundData, err := sm.BankService.GetBanksByCountryCode(ctx, countryCode)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
+		if errors.Is(err, mongo.ErrNoDocuments) {
 			return responses.NotFoundError("records", countryCode)
 		}
 		return responses.DatabaseError(err)
@@ -115,6 +128,49 @@ func GetSwiftCodesByCountryCode(c *fiber.Ctx) error {
 		}
 	}
 
+	// A removed bank leaves no modification time behind, so the listing is only validated by its ETag
+	return responses.NewConditionalResponse(c, response, time.Time{})
+}
+
+func SearchSwiftCodes(c *fiber.Ctx) error {
+	ctx, ok := middleware.GetRequestContext(c)
+	if !ok {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to get request context")
+	}
+
+	ctx, span := tracing.Start(ctx, "handlers.SearchSwiftCodes")
+	defer span.End()
+
+	sm := services.GetInstance()
+	if sm == nil || !sm.IsInitialized() {
+		return responses.DatabaseError(fmt.Errorf("service not initialized"))
+	}
+
+	prefix := strings.ToUpper(c.Query("prefix"))
+	middleware.AddLogAttrs(c, slog.String("prefix", prefix))
+	if !utils.IsValidSwiftCodePrefix(prefix) {
+		return responses.ValidationError(fmt.Sprintf("Invalid SWIFT code prefix: %v", prefix))
+	}
+
+	foundData, err := sm.BankService.SearchByPrefix(ctx, prefix)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return responses.NotFoundError("records", prefix)
+		}
+		return responses.DatabaseError(err)
+	}
+
+	response := responses.SearchSwiftCodesResponse{
+		Prefix:     prefix,
+		SwiftCodes: make([]responses.ShortBankResponse, len(foundData)),
+	}
+
+	for i, entity := range foundData {
+		if err := response.SwiftCodes[i].FromModel(entity); err != nil {
+			return responses.FormattingResponseError("Error while formatting response")
+		}
+	}
+
 	return responses.NewSuccessResponse(c, response)
 }
 
@@ -124,6 +180,9 @@ func AddNewSwiftCode(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusInternalServerError, "Failed to get request context")
 	}
 
+	ctx, span := tracing.Start(ctx, "handlers.AddNewSwiftCode")
+	defer span.End()
+
 	sm := services.GetInstance()
 	if sm == nil || !sm.IsInitialized() {
 		return responses.DatabaseError(fmt.Errorf("service not initialized"))
@@ -133,6 +192,7 @@ func AddNewSwiftCode(c *fiber.Ctx) error {
 	if err := c.BodyParser(record); err != nil {
 		return responses.ValidationError(fmt.Sprintf("Invalid request body: %v", err))
 	}
+	middleware.AddLogAttrs(c, slog.String("swiftCode", record.SwiftCode), slog.String("countryISO2", record.CountryISO2))
 
 	if !utils.IsValidSwiftCodeFormat(record.SwiftCode) {
 		return responses.ValidationError(fmt.Sprintf("Invalid SWIFT code format: %v", record))
@@ -161,31 +221,27 @@ func AddNewSwiftCode(c *fiber.Ctx) error {
 		}
 
 		if err := sm.BankService.AddHeadquarter(ctx, &hq); err != nil {
-			if err.Error() == "headquarter already exists" {
+			if errors.Is(err, repository.ErrAlreadyExists) {
 				return responses.AlreadyExistsError(fmt.Sprintf("Headquarter with SWIFT code %s already exists", record.SwiftCode))
 			}
 			return responses.DatabaseError(err)
 		}
 
-		return responses.NewSuccessResponse(c, fiber.Map{
-			"message": "Headquarter created successfully",
-		})
+		return responses.NewSuccessResponse(c, responses.MessageResponse{Message: "Headquarter created successfully"})
 	}
 
 	parentHqSwiftCode := record.SwiftCode[0:8] + "XXX"
 	if err := sm.BankService.AddBranch(ctx, parentHqSwiftCode, record); err != nil {
-		if err == mongo.ErrNoDocuments {
+		if errors.Is(err, mongo.ErrNoDocuments) {
 			return responses.NotFoundError("parent headquarter", parentHqSwiftCode)
 		}
-		if err.Error() == "branch already exists" {
+		if errors.Is(err, repository.ErrAlreadyExists) {
 			return responses.AlreadyExistsError(fmt.Sprintf("Branch with SWIFT code %s already exists", record.SwiftCode))
 		}
 		return responses.DatabaseError(err)
 	}
 
-	return responses.NewSuccessResponse(c, fiber.Map{
-		"message": "Branch added successfully",
-	})
+	return responses.NewSuccessResponse(c, responses.MessageResponse{Message: "Branch added successfully"})
 }
 
 func DeleteSwiftCode(c *fiber.Ctx) error {
@@ -194,38 +250,133 @@ func DeleteSwiftCode(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusInternalServerError, "Failed to get request context")
 	}
 
+	ctx, span := tracing.Start(ctx, "handlers.DeleteSwiftCode")
+	defer span.End()
+
 	sm := services.GetInstance()
 	if sm == nil || !sm.IsInitialized() {
 		return responses.DatabaseError(fmt.Errorf("service not initialized"))
 	}
 
 	swiftCode := c.Params("swiftCode")
+	middleware.AddLogAttrs(c, slog.String("swiftCode", swiftCode))
 	if !utils.IsValidSwiftCodeFormat(swiftCode) {
 		return responses.ValidationError(fmt.Sprintf("Invalid SWIFT code format: %v", swiftCode))
 	}
 
+	check := ifMatch(c)
 	if strings.HasSuffix(swiftCode, "XXX") {
-		if err := sm.BankService.DeleteHeadquarter(ctx, swiftCode); err != nil {
-			if err == mongo.ErrNoDocuments {
-				return responses.NotFoundError("headquarter", swiftCode)
-			}
-			return responses.DatabaseError(err)
+		if err := sm.BankService.DeleteHeadquarter(ctx, swiftCode, check); err != nil {
+			return writeError(err, check, "headquarter", swiftCode)
 		}
 
-		return responses.NewSuccessResponse(c, fiber.Map{
-			"message": "Headquarter was deleted successfully",
-		})
+		return responses.NewSuccessResponse(c, responses.MessageResponse{Message: "Headquarter was deleted successfully"})
 	}
 
 	parentHqSwiftCode := swiftCode[0:8] + "XXX"
-	if err := sm.BankService.DeleteBranch(ctx, swiftCode, parentHqSwiftCode); err != nil {
-		if err == mongo.ErrNoDocuments {
-			return responses.NotFoundError("branch", swiftCode)
+	if err := sm.BankService.DeleteBranch(ctx, swiftCode, parentHqSwiftCode, check); err != nil {
+		return writeError(err, check, "branch", swiftCode)
+	}
+
+	return responses.NewSuccessResponse(c, responses.MessageResponse{Message: "Branch was deleted successfully"})
+}
+
+func UpdateSwiftCode(c *fiber.Ctx) error {
+	ctx, ok := middleware.GetRequestContext(c)
+	if !ok {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to get request context")
+	}
+
+	ctx, span := tracing.Start(ctx, "handlers.UpdateSwiftCode")
+	defer span.End()
+
+	sm := services.GetInstance()
+	if sm == nil || !sm.IsInitialized() {
+		return responses.DatabaseError(fmt.Errorf("service not initialized"))
+	}
+
+	swiftCode := c.Params("swiftCode")
+	middleware.AddLogAttrs(c, slog.String("swiftCode", swiftCode))
+	if !utils.IsValidSwiftCodeFormat(swiftCode) {
+		return responses.ValidationError(fmt.Sprintf("Invalid SWIFT code format: %v", swiftCode))
+	}
+
+	record := new(models.Branch)
+	if err := c.BodyParser(record); err != nil {
+		return responses.ValidationError(fmt.Sprintf("Invalid request body: %v", err))
+	}
+
+	if record.SwiftCode == "" {
+		record.SwiftCode = swiftCode
+	}
+	if !utils.IsValidCountryCode(record.CountryISO2) {
+		return responses.ValidationError(fmt.Sprintf("Invalid country code format: %v", record.CountryISO2))
+	}
+
+	transformer := transform.ModelTransformer{}
+	transformer.CleanRequestModel(record)
+
+	if record.SwiftCode != swiftCode {
+		return responses.ValidationError(fmt.Sprintf("SWIFT code in the body (%s) does not match the path (%s)", record.SwiftCode, swiftCode))
+	}
+
+	check := ifMatch(c)
+	if strings.HasSuffix(swiftCode, "XXX") {
+		if !record.IsHeadquarter {
+			return responses.ValidationError("SWIFT code ends with XXX, but <isHeadquarter> is false")
 		}
-		return responses.DatabaseError(err)
+
+		hq := models.Headquarter{
+			SwiftCode:     record.SwiftCode,
+			BankName:      record.BankName,
+			Address:       record.Address,
+			CountryName:   record.CountryName,
+			CountryISO2:   record.CountryISO2,
+			IsHeadquarter: true,
+		}
+
+		updated, err := sm.BankService.UpdateHeadquarter(ctx, &hq, check)
+		if err != nil {
+			return writeError(err, check, "headquarter", swiftCode)
+		}
+
+		response := new(responses.HeadquarterResponse)
+		if err := response.FromModel(updated); err != nil {
+			return responses.FormattingResponseError("Error while formatting response")
+		}
+
+		return responses.NewTaggedResponse(c, response, modifiedAt(updated.UpdatedAt))
 	}
 
-	return responses.NewSuccessResponse(c, fiber.Map{
-		"message": "Branch was deleted successfully",
-	})
+	parentHqSwiftCode := swiftCode[0:8] + "XXX"
+	updated, err := sm.BankService.UpdateBranch(ctx, parentHqSwiftCode, record, check)
+	if err != nil {
+		return writeError(err, check, "branch", swiftCode)
+	}
+
+	response := new(responses.LongBankResponse)
+	if err := response.FromModel(updated); err != nil {
+		return responses.FormattingResponseError("Error while formatting response")
+	}
+
+	return responses.NewTaggedResponse(c, response, modifiedAt(updated.UpdatedAt))
+}
+
+// Maps the error of a write to a response. A record that does not exist fails an If-Match precondition too.
+func writeError(err error, check repository.Precondition, resourceType, swiftCode string) error {
+	var validationErr *services.ValidationError
+	switch {
+	case errors.Is(err, repository.ErrPreconditionFailed):
+		return preconditionFailed(swiftCode)
+	case errors.Is(err, mongo.ErrNoDocuments) && check != nil:
+		return responses.PreconditionFailedError(fmt.Sprintf("%s not found: %s", resourceType, swiftCode))
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return responses.NotFoundError(resourceType, swiftCode)
+	case errors.Is(err, repository.ErrImmutableField):
+		return responses.ValidationError(fmt.Sprintf("Invalid update of %s %s: %v", resourceType, swiftCode, err))
+	case errors.As(err, &validationErr):
+		return responses.ValidationError(validationErr.Message)
+	default:
+		return responses.DatabaseError(err)
+	}
 }
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/MarcinZ20/bankAPI/api/middleware"
+	"github.com/MarcinZ20/bankAPI/internal/health"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Liveness probe, succeeds whenever the process can serve requests
+func Liveness(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status": health.StatusUp,
+	})
+}
+
+// Readiness probe, fails until the initial import has finished and whenever a dependency is down
+func Readiness(c *fiber.Ctx) error {
+	report := healthReport(c)
+
+	status := fiber.StatusOK
+	if !report.Ready {
+		status = fiber.StatusServiceUnavailable
+	}
+
+	return c.Status(status).JSON(fiber.Map{
+		"ready": report.Ready,
+	})
+}
+
+// Detailed health of every dependency, the last import and the build
+func Health(c *fiber.Ctx) error {
+	report := healthReport(c)
+
+	status := fiber.StatusOK
+	if report.Status != health.StatusUp {
+		status = fiber.StatusServiceUnavailable
+	}
+
+	return c.Status(status).JSON(report)
+}
+
+func healthReport(c *fiber.Ctx) health.Report {
+	checker := health.GetInstance()
+	if checker == nil {
+		return health.Report{Status: health.StatusDown}
+	}
+
+	ctx, ok := middleware.GetRequestContext(c)
+	if !ok {
+		ctx = context.Background()
+	}
+
+	return checker.Report(ctx)
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+
+	"github.com/MarcinZ20/bankAPI/api/middleware"
+	"github.com/MarcinZ20/bankAPI/api/responses"
+	"github.com/MarcinZ20/bankAPI/internal/importer"
+	"github.com/MarcinZ20/bankAPI/internal/jobs"
+	"github.com/MarcinZ20/bankAPI/internal/scheduler"
+	"github.com/MarcinZ20/bankAPI/internal/tracing"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Optional import source overriding the configured one field by field
+type importRequest struct {
+	Source        string `json:"source"`
+	SpreadsheetID string `json:"spreadsheetId"`
+	Range         string `json:"range"`
+	File          string `json:"file"`
+}
+
+// Starts an import job from the configured or supplied source, a dry run job holds the diff once it finished.
+// A file named in the request must be inside dir.
+func CreateImport(source importer.Source, dir string, manager *jobs.Manager) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, ok := middleware.GetRequestContext(c)
+		if !ok {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to get request context")
+		}
+
+		ctx, span := tracing.Start(ctx, "handlers.CreateImport")
+		defer span.End()
+
+		source := source
+		if len(c.Body()) > 0 {
+			request := new(importRequest)
+			if err := c.BodyParser(request); err != nil {
+				return responses.ValidationError(fmt.Sprintf("Invalid request body: %v", err))
+			}
+			if request.File != "" {
+				file, err := resolveImportFile(dir, request.File)
+				if err != nil {
+					return responses.ForbiddenError(fmt.Sprintf("Invalid import file: %v", err))
+				}
+				request.File = file
+			}
+			source = request.apply(source)
+		}
+
+		dryRun := c.QueryBool("dryRun")
+		middleware.AddLogAttrs(c, slog.String("source", source.Kind), slog.Bool("dryRun", dryRun))
+
+		if err := source.Validate(); err != nil {
+			return responses.ValidationError(fmt.Sprintf("Invalid import source: %v", err))
+		}
+
+		// Fetching the source can take longer than a request, so dry runs are jobs too
+		start := manager.Start
+		if dryRun {
+			start = manager.StartDryRun
+		}
+
+		job, err := start(ctx, source)
+		if errors.Is(err, jobs.ErrRunning) {
+			return responses.AlreadyExistsError("An import is already running")
+		}
+		if err != nil {
+			return responses.InternalServerError(fmt.Sprintf("Failed to start import: %v", err))
+		}
+		return responses.NewAcceptedResponse(c, job)
+	}
+}
+
+// Lists import jobs, newest first
+func ListImports(manager *jobs.Manager) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return responses.NewSuccessResponse(c, manager.List())
+	}
+}
+
+// Shows the stages, row counts and errors of an import job
+func GetImport(manager *jobs.Manager) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Params("id")
+		job, err := manager.Get(id)
+		if err != nil {
+			return responses.NotFoundError("import job", id)
+		}
+		return responses.NewSuccessResponse(c, job)
+	}
+}
+
+// Cancels a running import job
+func CancelImport(manager *jobs.Manager) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Params("id")
+		job, err := manager.Cancel(id)
+		switch {
+		case errors.Is(err, jobs.ErrNotFound):
+			return responses.NotFoundError("import job", id)
+		case errors.Is(err, jobs.ErrNotRunning):
+			return responses.AlreadyExistsError(fmt.Sprintf("Import job %s already %s", id, job.Status))
+		}
+		return responses.NewAcceptedResponse(c, job)
+	}
+}
+
+// Reports the state of scheduled imports
+func GetImportSchedule(s *scheduler.Scheduler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if s == nil {
+			return responses.NotFoundError("import schedule", "scheduled imports are disabled")
+		}
+		return responses.NewSuccessResponse(c, s.Status())
+	}
+}
+
+// Resolves a file named in an import request, relative to dir, and makes sure it does not leave dir
+func resolveImportFile(dir, file string) (string, error) {
+	if dir == "" {
+		return "", errors.New("import requests cannot name files, set IMPORT_REQUEST_DIR to allow it")
+	}
+
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", fmt.Errorf("import directory unavailable: %w", err)
+	}
+	path := file
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	// Links inside the directory must not point out of it either
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+
+	if rel, err := filepath.Rel(root, path); err != nil || !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("%s is outside the import directory", file)
+	}
+	return path, nil
+}
+
+// Overrides the fields of source set in the request
+func (r *importRequest) apply(source importer.Source) importer.Source {
+	if r.Source != "" {
+		source.Kind = r.Source
+	}
+	if r.SpreadsheetID != "" {
+		source.SpreadsheetID = r.SpreadsheetID
+	}
+	if r.Range != "" {
+		source.Range = r.Range
+	}
+	if r.File != "" {
+		source.File = r.File
+	}
+	return source
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveImportFile(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	outside := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "banks.csv"), nil, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(outside, "secret.csv"), nil, 0o600))
+	require.NoError(t, os.Symlink(filepath.Join(outside, "secret.csv"), filepath.Join(dir, "link.csv")))
+
+	file, err := resolveImportFile(dir, "banks.csv")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "banks.csv"), file)
+
+	file, err = resolveImportFile(dir, filepath.Join(dir, "banks.csv"))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "banks.csv"), file)
+
+	for _, name := range []string{"../secret.csv", filepath.Join(outside, "secret.csv"), "/etc/passwd", "link.csv"} {
+		_, err := resolveImportFile(dir, name)
+		assert.Error(t, err, name)
+	}
+
+	_, err = resolveImportFile("", "banks.csv")
+	assert.Error(t, err, "files are rejected without a directory")
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/MarcinZ20/bankAPI/api/middleware"
+	"github.com/MarcinZ20/bankAPI/api/responses"
+	"github.com/MarcinZ20/bankAPI/internal/services"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Dead letters listed when the request sets no limit, and the most it may ask for
+const (
+	defaultDeadLetterLimit = 50
+	maxDeadLetterLimit     = 500
+)
+
+func CreateWebhook(c *fiber.Ctx) error {
+	ctx, ok := middleware.GetRequestContext(c)
+	if !ok {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to get request context")
+	}
+
+	sm := services.GetInstance()
+	if sm == nil || !sm.WebhookService.IsInitialized() {
+		return responses.DatabaseError(fmt.Errorf("webhook service not initialized"))
+	}
+
+	request := new(services.CreateWebhookRequest)
+	if err := c.BodyParser(request); err != nil {
+		return responses.ValidationError(fmt.Sprintf("Invalid request body: %v", err))
+	}
+
+	webhook, err := sm.WebhookService.CreateWebhook(ctx, *request)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidWebhookRequest) {
+			return responses.ValidationError(err.Error())
+		}
+		return responses.DatabaseError(err)
+	}
+
+	return responses.NewSuccessResponse(c, responses.WebhookSecretResponse{
+		Secret:  webhook.Secret,
+		Webhook: responses.NewWebhookResponse(webhook),
+	})
+}
+
+func ListWebhooks(c *fiber.Ctx) error {
+	ctx, ok := middleware.GetRequestContext(c)
+	if !ok {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to get request context")
+	}
+
+	sm := services.GetInstance()
+	if sm == nil || !sm.WebhookService.IsInitialized() {
+		return responses.DatabaseError(fmt.Errorf("webhook service not initialized"))
+	}
+
+	webhooks, err := sm.WebhookService.ListWebhooks(ctx)
+	if err != nil {
+		return responses.DatabaseError(err)
+	}
+
+	response := make([]responses.WebhookResponse, 0, len(webhooks))
+	for i := range webhooks {
+		response = append(response, responses.NewWebhookResponse(&webhooks[i]))
+	}
+
+	return responses.NewSuccessResponse(c, response)
+}
+
+func DeleteWebhook(c *fiber.Ctx) error {
+	ctx, ok := middleware.GetRequestContext(c)
+	if !ok {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to get request context")
+	}
+
+	sm := services.GetInstance()
+	if sm == nil || !sm.WebhookService.IsInitialized() {
+		return responses.DatabaseError(fmt.Errorf("webhook service not initialized"))
+	}
+
+	id := c.Params("id")
+	if err := sm.WebhookService.DeleteWebhook(ctx, id); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return responses.NotFoundError("webhook", id)
+		}
+		return responses.DatabaseError(err)
+	}
+
+	return responses.NewSuccessResponse(c, fiber.Map{
+		"message": "Webhook was deleted successfully",
+	})
+}
+
+// Lists the most recent events webhooks did not accept, newest first
+func ListDeadLetters(c *fiber.Ctx) error {
+	ctx, ok := middleware.GetRequestContext(c)
+	if !ok {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to get request context")
+	}
+
+	sm := services.GetInstance()
+	if sm == nil || !sm.WebhookService.IsInitialized() {
+		return responses.DatabaseError(fmt.Errorf("webhook service not initialized"))
+	}
+
+	limit := c.QueryInt("limit", defaultDeadLetterLimit)
+	if limit < 1 || limit > maxDeadLetterLimit {
+		return responses.ValidationError(fmt.Sprintf("Invalid limit: %d, expected 1 to %d", limit, maxDeadLetterLimit))
+	}
+
+	letters, err := sm.WebhookService.DeadLetters(ctx, int64(limit))
+	if err != nil {
+		return responses.DatabaseError(err)
+	}
+
+	return responses.NewSuccessResponse(c, letters)
+}
@@ -0,0 +1,151 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/MarcinZ20/bankAPI/api/responses"
+	"github.com/MarcinZ20/bankAPI/internal/auth"
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	APIKeyHeader = "X-API-Key"
+
+	principalKey = "principal"
+)
+
+// Resolves a plaintext API key into a stored key.
+// Errors wrap auth.ErrInvalidCredentials when the key is wrong, any other error means the key could not be checked.
+type KeyAuthenticator interface {
+	Authenticate(ctx context.Context, rawKey string) (*models.APIKey, error)
+}
+
+// Verifies a bearer token and resolves the caller identity
+type TokenVerifier interface {
+	Verify(ctx context.Context, rawToken string) (*auth.Identity, error)
+}
+
+// Holds authentication settings shared by all protected routes
+type AuthConfig struct {
+	APIKeys KeyAuthenticator
+	Tokens  TokenVerifier
+}
+
+// Describes the authenticated caller of a request
+type Principal struct {
+	Subject    string
+	Method     string
+	Scopes     []string
+	Roles      []string
+	DailyQuota int64
+}
+
+// Checks if the principal was granted the scope, admin implies every scope
+func (p *Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, models.ScopeAdmin) || slices.Contains(p.Scopes, scope)
+}
+
+// Identifies the caller from request credentials.
+// Requests without credentials pass through anonymously, invalid credentials are rejected.
+func Authenticate(config AuthConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, ok := GetRequestContext(c)
+		if !ok {
+			ctx = c.Context()
+		}
+
+		if rawKey := extractAPIKey(c); rawKey != "" {
+			if config.APIKeys == nil {
+				return responses.UnauthorizedError("API key authentication is not enabled")
+			}
+
+			key, err := config.APIKeys.Authenticate(ctx, rawKey)
+			if errors.Is(err, auth.ErrInvalidCredentials) {
+				return responses.UnauthorizedError("Invalid API key")
+			}
+			if err != nil {
+				return responses.DatabaseError(err)
+			}
+
+			c.Locals(principalKey, &Principal{
+				Subject:    key.ID,
+				Method:     "api_key",
+				Scopes:     key.Scopes,
+				DailyQuota: key.DailyQuota,
+			})
+
+			return c.Next()
+		}
+
+		if rawToken := extractBearerToken(c); rawToken != "" {
+			if config.Tokens == nil {
+				return responses.UnauthorizedError("Bearer token authentication is not enabled")
+			}
+
+			identity, err := config.Tokens.Verify(ctx, rawToken)
+			if err != nil {
+				c.Set(fiber.HeaderWWWAuthenticate, `Bearer error="invalid_token"`)
+				return responses.UnauthorizedError("Invalid bearer token")
+			}
+
+			c.Locals(principalKey, &Principal{
+				Subject: identity.Subject,
+				Method:  "jwt",
+				Scopes:  identity.Scopes(),
+				Roles:   identity.Roles,
+			})
+		}
+
+		return c.Next()
+	}
+}
+
+// Rejects requests whose principal lacks the scope
+func RequireScope(scope string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		principal, ok := GetPrincipal(c)
+		if !ok {
+			return responses.UnauthorizedError("Authentication required")
+		}
+
+		if !principal.HasScope(scope) {
+			return responses.ForbiddenError("Missing required scope: " + scope)
+		}
+
+		return c.Next()
+	}
+}
+
+// Retrieves the authenticated principal from fiber context
+func GetPrincipal(c *fiber.Ctx) (*Principal, bool) {
+	principal, ok := c.Locals(principalKey).(*Principal)
+	return principal, ok
+}
+
+// Reads an API key from the X-API-Key header or an "ApiKey" authorization header
+func extractAPIKey(c *fiber.Ctx) string {
+	if key := c.Get(APIKeyHeader); key != "" {
+		return strings.TrimSpace(key)
+	}
+
+	scheme, value, found := strings.Cut(c.Get(fiber.HeaderAuthorization), " ")
+	if found && strings.EqualFold(scheme, "ApiKey") {
+		return strings.TrimSpace(value)
+	}
+
+	return ""
+}
+
+// Reads a token from a "Bearer" authorization header
+func extractBearerToken(c *fiber.Ctx) string {
+	scheme, value, found := strings.Cut(c.Get(fiber.HeaderAuthorization), " ")
+	if found && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(value)
+	}
+
+	return ""
+}
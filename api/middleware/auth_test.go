@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/MarcinZ20/bankAPI/internal/auth/authtest"
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupAuthApp() *fiber.App {
+	app := fiber.New()
+	app.Use(WithTimeout(time.Second))
+	app.Use(Authenticate(AuthConfig{
+		APIKeys: authtest.APIKeys{
+			"bk_reader": {ID: "reader", Scopes: []string{models.ScopeRead}},
+			"bk_writer": {ID: "writer", Scopes: []string{models.ScopeRead, models.ScopeWrite}},
+			"bk_admin":  {ID: "admin", Scopes: []string{models.ScopeAdmin}},
+		},
+	}))
+
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+	app.Get("/public", ok)
+	app.Get("/read", RequireScope(models.ScopeRead), ok)
+	app.Post("/write", RequireScope(models.ScopeWrite), ok)
+
+	return app
+}
+
+func TestAuthenticate_RequireScope(t *testing.T) {
+	app := setupAuthApp()
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		headers        map[string]string
+		expectedStatus int
+	}{
+		{
+			name:           "Anonymous access to public route",
+			method:         "GET",
+			path:           "/public",
+			expectedStatus: fiber.StatusOK,
+		},
+		{
+			name:           "Invalid key is rejected on public route",
+			method:         "GET",
+			path:           "/public",
+			headers:        map[string]string{APIKeyHeader: "bk_unknown"},
+			expectedStatus: fiber.StatusUnauthorized,
+		},
+		{
+			name:           "Anonymous access to protected route",
+			method:         "GET",
+			path:           "/read",
+			expectedStatus: fiber.StatusUnauthorized,
+		},
+		{
+			name:           "Reader can read",
+			method:         "GET",
+			path:           "/read",
+			headers:        map[string]string{APIKeyHeader: "bk_reader"},
+			expectedStatus: fiber.StatusOK,
+		},
+		{
+			name:           "Reader cannot write",
+			method:         "POST",
+			path:           "/write",
+			headers:        map[string]string{APIKeyHeader: "bk_reader"},
+			expectedStatus: fiber.StatusForbidden,
+		},
+		{
+			name:           "Writer can write using authorization header",
+			method:         "POST",
+			path:           "/write",
+			headers:        map[string]string{fiber.HeaderAuthorization: "ApiKey bk_writer"},
+			expectedStatus: fiber.StatusOK,
+		},
+		{
+			name:           "Admin implies every scope",
+			method:         "POST",
+			path:           "/write",
+			headers:        map[string]string{APIKeyHeader: "bk_admin"},
+			expectedStatus: fiber.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+		})
+	}
+}
+
+type failingAuthenticator struct{}
+
+func (failingAuthenticator) Authenticate(context.Context, string) (*models.APIKey, error) {
+	return nil, errors.New("server selection timeout")
+}
+
+func TestAuthenticate_StoreFailure(t *testing.T) {
+	app := fiber.New()
+	app.Use(Authenticate(AuthConfig{APIKeys: failingAuthenticator{}}))
+	app.Get("/read", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+	req := httptest.NewRequest("GET", "/read", nil)
+	req.Header.Set(APIKeyHeader, "bk_reader")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+}
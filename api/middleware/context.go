@@ -7,10 +7,10 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
-// Adds a timeout context to the request
+// Adds a timeout context to the request, derived from the request context when one is already set
 func WithTimeout(timeout time.Duration) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		ctx, cancel := context.WithTimeout(c.Context(), timeout)
+		ctx, cancel := context.WithTimeout(baseContext(c), timeout)
 		defer cancel()
 
 		c.Locals("ctx", ctx)
@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/MarcinZ20/bankAPI/internal/logging"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+const logAttrsKey = "logAttrs"
+
+// Longest client supplied request ID that is accepted, longer ones are replaced
+const maxRequestIDLength = 128
+
+// Accepts the client's X-Request-ID or generates one, echoes it in the response
+// and attaches it to the request context so every log line can carry it
+func RequestID() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		requestID := utils.CopyString(c.Get(RequestIDHeader))
+		if !isValidRequestID(requestID) {
+			requestID = newRequestID()
+		}
+
+		c.Set(RequestIDHeader, requestID)
+		c.Locals("ctx", logging.WithRequestID(baseContext(c), requestID))
+
+		return c.Next()
+	}
+}
+
+// Logs one line per request with its method, route, status code and duration
+func AccessLog() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = fiber.StatusInternalServerError
+			var fiberErr *fiber.Error
+			if errors.As(err, &fiberErr) {
+				status = fiberErr.Code
+			}
+		}
+
+		level := slog.LevelInfo
+		if status >= fiber.StatusInternalServerError {
+			level = slog.LevelError
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Method()),
+			slog.String("path", c.Path()),
+			slog.String("route", c.Route().Path),
+			slog.Int("status", status),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("ip", c.IP()),
+		}
+		if handlerAttrs, ok := c.Locals(logAttrsKey).([]slog.Attr); ok {
+			attrs = append(attrs, handlerAttrs...)
+		}
+		if err != nil {
+			attrs = append(attrs, slog.String("error", err.Error()))
+		}
+
+		slog.LogAttrs(baseContext(c), level, "request completed", attrs...)
+		return err
+	}
+}
+
+// Adds attributes, such as the SWIFT code or country involved, to the request's access log line
+func AddLogAttrs(c *fiber.Ctx, attrs ...slog.Attr) {
+	existing, _ := c.Locals(logAttrsKey).([]slog.Attr)
+	c.Locals(logAttrsKey, append(existing, attrs...))
+}
+
+// Request IDs are echoed in headers and logs, so only short printable ASCII values are accepted
+func isValidRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(requestID); i++ {
+		if requestID[i] < 0x21 || requestID[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MarcinZ20/bankAPI/internal/logging"
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupLoggingApp(t *testing.T) (*fiber.App, *bytes.Buffer) {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	require.NoError(t, logging.Setup(&buf, logging.Config{Level: "info"}))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	app := fiber.New()
+	app.Use(RequestID())
+	app.Use(AccessLog())
+	app.Use(WithTimeout(time.Second))
+	app.Get("/swift-codes/:swiftCode", func(c *fiber.Ctx) error {
+		AddLogAttrs(c, slog.String("swiftCode", c.Params("swiftCode")))
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	return app, &buf
+}
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name      string
+		requestID string
+		accepted  bool
+	}{
+		{name: "Client ID is accepted", requestID: "abc-123", accepted: true},
+		{name: "Missing ID is generated"},
+		{name: "Overlong ID is replaced", requestID: strings.Repeat("a", maxRequestIDLength+1)},
+		{name: "ID with spaces is replaced", requestID: "abc 123"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app, buf := setupLoggingApp(t)
+
+			req := httptest.NewRequest("GET", "/swift-codes/DEUTDEFFXXX", nil)
+			if tt.requestID != "" {
+				req.Header.Set(RequestIDHeader, tt.requestID)
+			}
+
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+
+			requestID := resp.Header.Get(RequestIDHeader)
+			if tt.accepted {
+				assert.Equal(t, tt.requestID, requestID)
+			} else {
+				assert.Len(t, requestID, 32)
+			}
+
+			var entry map[string]any
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+			assert.Equal(t, "request completed", entry["msg"])
+			assert.Equal(t, requestID, entry["requestId"])
+			assert.Equal(t, "DEUTDEFFXXX", entry["swiftCode"])
+			assert.Equal(t, "/swift-codes/:swiftCode", entry["route"])
+			assert.Equal(t, float64(fiber.StatusOK), entry["status"])
+		})
+	}
+}
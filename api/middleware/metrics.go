@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/MarcinZ20/bankAPI/internal/metrics"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// Records request counts, latency and in-flight requests per route and status code
+func Metrics() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		metrics.HTTPInFlight.Inc()
+		defer metrics.HTTPInFlight.Dec()
+
+		err := c.Next()
+
+		// Errors are turned into responses by the error handler after this middleware returns
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = fiber.StatusInternalServerError
+			var fiberErr *fiber.Error
+			if errors.As(err, &fiberErr) {
+				status = fiberErr.Code
+			}
+		}
+
+		// Labels use the route pattern rather than the raw path to keep cardinality bounded.
+		// Requests that matched no handler report the path of the last middleware they passed.
+		// The method string points into the reused request buffer, label values outlive the request.
+		labels := []string{utils.CopyString(c.Method()), c.Route().Path, strconv.Itoa(status)}
+		metrics.HTTPRequests.WithLabelValues(labels...).Inc()
+		metrics.HTTPDuration.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+
+		return err
+	}
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MarcinZ20/bankAPI/internal/metrics"
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics_LabelsByRouteAndStatus(t *testing.T) {
+	app := fiber.New()
+	app.Use(Metrics())
+	app.Get("/items/:id", func(c *fiber.Ctx) error {
+		if c.Params("id") == "missing" {
+			return fiber.NewError(fiber.StatusNotFound, "not found")
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	requests := []struct {
+		path   string
+		route  string
+		status string
+	}{
+		{path: "/items/1", route: "/items/:id", status: "200"},
+		{path: "/items/missing", route: "/items/:id", status: "404"},
+		{path: "/unknown", route: "/", status: "404"},
+	}
+
+	for _, r := range requests {
+		before := testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues("GET", r.route, r.status))
+
+		_, err := app.Test(httptest.NewRequest("GET", r.path, nil))
+		require.NoError(t, err)
+
+		after := testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues("GET", r.route, r.status))
+		assert.Equal(t, before+1, after, r.path)
+	}
+
+	assert.Equal(t, float64(0), testutil.ToFloat64(metrics.HTTPInFlight))
+}
@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"github.com/MarcinZ20/bankAPI/api/responses"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Rejects requests whose Accept header none of mediaTypes satisfies with 406 before the handler runs,
+// so a write is never applied when its result cannot be returned
+func Negotiate(mediaTypes ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if c.Accepts(mediaTypes...) == "" {
+			return responses.NotAcceptableError(mediaTypes)
+		}
+		return c.Next()
+	}
+}
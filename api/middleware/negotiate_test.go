@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiate(t *testing.T) {
+	handled := 0
+	app := fiber.New()
+	app.Post("/", Negotiate(fiber.MIMEApplicationJSON, fiber.MIMEApplicationXML), func(c *fiber.Ctx) error {
+		handled++
+		return c.SendStatus(fiber.StatusCreated)
+	})
+
+	tests := []struct {
+		accept string
+		status int
+	}{
+		{"", fiber.StatusCreated},
+		{"*/*", fiber.StatusCreated},
+		{"application/xml", fiber.StatusCreated},
+		{"text/csv", fiber.StatusNotAcceptable},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(fiber.MethodPost, "/", nil)
+		req.Header.Set(fiber.HeaderAccept, tt.accept)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		assert.Equal(t, tt.status, resp.StatusCode, tt.accept)
+	}
+	assert.Equal(t, 3, handled)
+}
@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/MarcinZ20/bankAPI/api/responses"
+	"github.com/MarcinZ20/bankAPI/internal/ratelimit"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Reports whether a request modifies data, which decides the bucket it takes a token from
+type WriteClassifier func(c *fiber.Ctx) bool
+
+// Throttles requests per authenticated principal, or per client IP for anonymous requests.
+// Must be installed after Authenticate so the principal is known.
+func RateLimit(limiter *ratelimit.Limiter) fiber.Handler {
+	return RateLimitBy(limiter, func(c *fiber.Ctx) bool { return isWriteMethod(c.Method()) })
+}
+
+// Throttles requests like RateLimit, for endpoints whose HTTP method does not tell reads from writes
+func RateLimitBy(limiter *ratelimit.Limiter, isWrite WriteClassifier) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, ok := GetRequestContext(c)
+		if !ok {
+			ctx = c.Context()
+		}
+
+		client := "ip:" + c.IP()
+		var quota int64
+		if principal, ok := GetPrincipal(c); ok {
+			client = principal.Method + ":" + principal.Subject
+			quota = principal.DailyQuota
+		}
+
+		decision, err := limiter.Allow(ctx, client, isWrite(c), quota)
+		if err != nil {
+			return responses.InternalServerError(fmt.Sprintf("Rate limiter error: %v", err))
+		}
+
+		return enforce(c, decision)
+	}
+}
+
+// Throttles requests per client IP before they are authenticated.
+// Must be installed before Authenticate so invalid credentials are throttled as well.
+func IPRateLimit(limiter *ratelimit.Limiter) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, ok := GetRequestContext(c)
+		if !ok {
+			ctx = c.Context()
+		}
+
+		decision, err := limiter.AllowIP(ctx, c.IP())
+		if err != nil {
+			return responses.InternalServerError(fmt.Sprintf("Rate limiter error: %v", err))
+		}
+		if !decision.Allowed {
+			return enforce(c, decision)
+		}
+
+		return c.Next()
+	}
+}
+
+// Sets the rate limit headers of a decision and rejects the request when it was not allowed
+func enforce(c *fiber.Ctx, decision ratelimit.Decision) error {
+	if decision.Limit > 0 {
+		c.Set("RateLimit-Limit", strconv.Itoa(decision.Limit))
+		c.Set("RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+		c.Set("RateLimit-Reset", formatSeconds(decision.Reset))
+	}
+	if decision.QuotaLimit > 0 {
+		c.Set("X-Quota-Limit", strconv.FormatInt(decision.QuotaLimit, 10))
+		c.Set("X-Quota-Remaining", strconv.FormatInt(decision.QuotaRemaining, 10))
+	}
+
+	if !decision.Allowed {
+		c.Set(fiber.HeaderRetryAfter, formatSeconds(decision.RetryAfter))
+		if decision.QuotaExceeded {
+			return responses.TooManyRequestsError("Daily quota exceeded")
+		}
+		return responses.TooManyRequestsError("Rate limit exceeded")
+	}
+
+	return c.Next()
+}
+
+// Checks if the HTTP method modifies data
+func isWriteMethod(method string) bool {
+	switch method {
+	case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+		return false
+	default:
+		return true
+	}
+}
+
+// Formats a duration as whole seconds, rounded up so clients never retry too early
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/MarcinZ20/bankAPI/internal/tracing"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Starts a server span for every request, continuing the trace from an incoming traceparent header.
+// The span context is stored in Locals("ctx") so later middleware and handlers build on it.
+func Tracing() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := otel.GetTextMapPropagator().Extract(baseContext(c), requestHeaderCarrier{&c.Request().Header})
+
+		method := utils.CopyString(c.Method())
+		ctx, span := tracing.Tracer().Start(ctx, method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", method),
+				attribute.String("url.path", utils.CopyString(c.Path())),
+			),
+		)
+		defer span.End()
+
+		c.Locals("ctx", ctx)
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = fiber.StatusInternalServerError
+			var fiberErr *fiber.Error
+			if errors.As(err, &fiberErr) {
+				status = fiberErr.Code
+			}
+			span.RecordError(err)
+		}
+
+		route := c.Route().Path
+		span.SetName(method + " " + route)
+		span.SetAttributes(
+			attribute.String("http.route", route),
+			attribute.Int("http.response.status_code", status),
+		)
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, strconv.Itoa(status))
+		}
+
+		return err
+	}
+}
+
+// Returns the request context set by an earlier middleware, or the fasthttp context
+func baseContext(c *fiber.Ctx) context.Context {
+	if ctx, ok := GetRequestContext(c); ok {
+		return ctx
+	}
+	return c.Context()
+}
+
+// Adapts fasthttp request headers to the OpenTelemetry propagation carrier
+type requestHeaderCarrier struct {
+	header *fasthttp.RequestHeader
+}
+
+func (h requestHeaderCarrier) Get(key string) string {
+	return string(h.header.Peek(key))
+}
+
+func (h requestHeaderCarrier) Set(key, value string) {
+	h.header.Set(key, value)
+}
+
+func (h requestHeaderCarrier) Keys() []string {
+	keys := make([]string, 0, h.header.Len())
+	h.header.VisitAll(func(key, _ []byte) {
+		keys = append(keys, string(key))
+	})
+	return keys
+}
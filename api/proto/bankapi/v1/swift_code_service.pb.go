@@ -0,0 +1,802 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v5.29.3
+// source: bankapi/v1/swift_code_service.proto
+
+package bankapiv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Bank struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SwiftCode     string                 `protobuf:"bytes,1,opt,name=swift_code,json=swiftCode,proto3" json:"swift_code,omitempty"`
+	BankName      string                 `protobuf:"bytes,2,opt,name=bank_name,json=bankName,proto3" json:"bank_name,omitempty"`
+	Address       string                 `protobuf:"bytes,3,opt,name=address,proto3" json:"address,omitempty"`
+	CountryIso2   string                 `protobuf:"bytes,4,opt,name=country_iso2,json=countryIso2,proto3" json:"country_iso2,omitempty"`
+	CountryName   string                 `protobuf:"bytes,5,opt,name=country_name,json=countryName,proto3" json:"country_name,omitempty"`
+	IsHeadquarter bool                   `protobuf:"varint,6,opt,name=is_headquarter,json=isHeadquarter,proto3" json:"is_headquarter,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Bank) Reset() {
+	*x = Bank{}
+	mi := &file_bankapi_v1_swift_code_service_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Bank) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Bank) ProtoMessage() {}
+
+func (x *Bank) ProtoReflect() protoreflect.Message {
+	mi := &file_bankapi_v1_swift_code_service_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Bank.ProtoReflect.Descriptor instead.
+func (*Bank) Descriptor() ([]byte, []int) {
+	return file_bankapi_v1_swift_code_service_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Bank) GetSwiftCode() string {
+	if x != nil {
+		return x.SwiftCode
+	}
+	return ""
+}
+
+func (x *Bank) GetBankName() string {
+	if x != nil {
+		return x.BankName
+	}
+	return ""
+}
+
+func (x *Bank) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *Bank) GetCountryIso2() string {
+	if x != nil {
+		return x.CountryIso2
+	}
+	return ""
+}
+
+func (x *Bank) GetCountryName() string {
+	if x != nil {
+		return x.CountryName
+	}
+	return ""
+}
+
+func (x *Bank) GetIsHeadquarter() bool {
+	if x != nil {
+		return x.IsHeadquarter
+	}
+	return false
+}
+
+type Headquarter struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Bank          *Bank                  `protobuf:"bytes,1,opt,name=bank,proto3" json:"bank,omitempty"`
+	Branches      []*Bank                `protobuf:"bytes,2,rep,name=branches,proto3" json:"branches,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Headquarter) Reset() {
+	*x = Headquarter{}
+	mi := &file_bankapi_v1_swift_code_service_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Headquarter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Headquarter) ProtoMessage() {}
+
+func (x *Headquarter) ProtoReflect() protoreflect.Message {
+	mi := &file_bankapi_v1_swift_code_service_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Headquarter.ProtoReflect.Descriptor instead.
+func (*Headquarter) Descriptor() ([]byte, []int) {
+	return file_bankapi_v1_swift_code_service_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Headquarter) GetBank() *Bank {
+	if x != nil {
+		return x.Bank
+	}
+	return nil
+}
+
+func (x *Headquarter) GetBranches() []*Bank {
+	if x != nil {
+		return x.Branches
+	}
+	return nil
+}
+
+type GetSwiftCodeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SwiftCode     string                 `protobuf:"bytes,1,opt,name=swift_code,json=swiftCode,proto3" json:"swift_code,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSwiftCodeRequest) Reset() {
+	*x = GetSwiftCodeRequest{}
+	mi := &file_bankapi_v1_swift_code_service_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSwiftCodeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSwiftCodeRequest) ProtoMessage() {}
+
+func (x *GetSwiftCodeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bankapi_v1_swift_code_service_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSwiftCodeRequest.ProtoReflect.Descriptor instead.
+func (*GetSwiftCodeRequest) Descriptor() ([]byte, []int) {
+	return file_bankapi_v1_swift_code_service_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetSwiftCodeRequest) GetSwiftCode() string {
+	if x != nil {
+		return x.SwiftCode
+	}
+	return ""
+}
+
+type GetSwiftCodeResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Bank  *Bank                  `protobuf:"bytes,1,opt,name=bank,proto3" json:"bank,omitempty"`
+	// Branches of a headquarter, empty for a branch
+	Branches      []*Bank `protobuf:"bytes,2,rep,name=branches,proto3" json:"branches,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSwiftCodeResponse) Reset() {
+	*x = GetSwiftCodeResponse{}
+	mi := &file_bankapi_v1_swift_code_service_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSwiftCodeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSwiftCodeResponse) ProtoMessage() {}
+
+func (x *GetSwiftCodeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bankapi_v1_swift_code_service_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSwiftCodeResponse.ProtoReflect.Descriptor instead.
+func (*GetSwiftCodeResponse) Descriptor() ([]byte, []int) {
+	return file_bankapi_v1_swift_code_service_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetSwiftCodeResponse) GetBank() *Bank {
+	if x != nil {
+		return x.Bank
+	}
+	return nil
+}
+
+func (x *GetSwiftCodeResponse) GetBranches() []*Bank {
+	if x != nil {
+		return x.Branches
+	}
+	return nil
+}
+
+type ListByCountryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CountryIso2   string                 `protobuf:"bytes,1,opt,name=country_iso2,json=countryIso2,proto3" json:"country_iso2,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListByCountryRequest) Reset() {
+	*x = ListByCountryRequest{}
+	mi := &file_bankapi_v1_swift_code_service_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListByCountryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListByCountryRequest) ProtoMessage() {}
+
+func (x *ListByCountryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bankapi_v1_swift_code_service_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListByCountryRequest.ProtoReflect.Descriptor instead.
+func (*ListByCountryRequest) Descriptor() ([]byte, []int) {
+	return file_bankapi_v1_swift_code_service_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListByCountryRequest) GetCountryIso2() string {
+	if x != nil {
+		return x.CountryIso2
+	}
+	return ""
+}
+
+type ListByCountryResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CountryIso2   string                 `protobuf:"bytes,1,opt,name=country_iso2,json=countryIso2,proto3" json:"country_iso2,omitempty"`
+	CountryName   string                 `protobuf:"bytes,2,opt,name=country_name,json=countryName,proto3" json:"country_name,omitempty"`
+	SwiftCodes    []*Bank                `protobuf:"bytes,3,rep,name=swift_codes,json=swiftCodes,proto3" json:"swift_codes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListByCountryResponse) Reset() {
+	*x = ListByCountryResponse{}
+	mi := &file_bankapi_v1_swift_code_service_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListByCountryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListByCountryResponse) ProtoMessage() {}
+
+func (x *ListByCountryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bankapi_v1_swift_code_service_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListByCountryResponse.ProtoReflect.Descriptor instead.
+func (*ListByCountryResponse) Descriptor() ([]byte, []int) {
+	return file_bankapi_v1_swift_code_service_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListByCountryResponse) GetCountryIso2() string {
+	if x != nil {
+		return x.CountryIso2
+	}
+	return ""
+}
+
+func (x *ListByCountryResponse) GetCountryName() string {
+	if x != nil {
+		return x.CountryName
+	}
+	return ""
+}
+
+func (x *ListByCountryResponse) GetSwiftCodes() []*Bank {
+	if x != nil {
+		return x.SwiftCodes
+	}
+	return nil
+}
+
+type BatchLookupRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SwiftCodes    []string               `protobuf:"bytes,1,rep,name=swift_codes,json=swiftCodes,proto3" json:"swift_codes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchLookupRequest) Reset() {
+	*x = BatchLookupRequest{}
+	mi := &file_bankapi_v1_swift_code_service_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchLookupRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchLookupRequest) ProtoMessage() {}
+
+func (x *BatchLookupRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bankapi_v1_swift_code_service_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchLookupRequest.ProtoReflect.Descriptor instead.
+func (*BatchLookupRequest) Descriptor() ([]byte, []int) {
+	return file_bankapi_v1_swift_code_service_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *BatchLookupRequest) GetSwiftCodes() []string {
+	if x != nil {
+		return x.SwiftCodes
+	}
+	return nil
+}
+
+type BatchLookupResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Banks         []*Bank                `protobuf:"bytes,1,rep,name=banks,proto3" json:"banks,omitempty"`
+	NotFound      []string               `protobuf:"bytes,2,rep,name=not_found,json=notFound,proto3" json:"not_found,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchLookupResponse) Reset() {
+	*x = BatchLookupResponse{}
+	mi := &file_bankapi_v1_swift_code_service_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchLookupResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchLookupResponse) ProtoMessage() {}
+
+func (x *BatchLookupResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bankapi_v1_swift_code_service_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchLookupResponse.ProtoReflect.Descriptor instead.
+func (*BatchLookupResponse) Descriptor() ([]byte, []int) {
+	return file_bankapi_v1_swift_code_service_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *BatchLookupResponse) GetBanks() []*Bank {
+	if x != nil {
+		return x.Banks
+	}
+	return nil
+}
+
+func (x *BatchLookupResponse) GetNotFound() []string {
+	if x != nil {
+		return x.NotFound
+	}
+	return nil
+}
+
+type CreateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Bank          *Bank                  `protobuf:"bytes,1,opt,name=bank,proto3" json:"bank,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateRequest) Reset() {
+	*x = CreateRequest{}
+	mi := &file_bankapi_v1_swift_code_service_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateRequest) ProtoMessage() {}
+
+func (x *CreateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bankapi_v1_swift_code_service_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateRequest.ProtoReflect.Descriptor instead.
+func (*CreateRequest) Descriptor() ([]byte, []int) {
+	return file_bankapi_v1_swift_code_service_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *CreateRequest) GetBank() *Bank {
+	if x != nil {
+		return x.Bank
+	}
+	return nil
+}
+
+type CreateResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Bank          *Bank                  `protobuf:"bytes,1,opt,name=bank,proto3" json:"bank,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateResponse) Reset() {
+	*x = CreateResponse{}
+	mi := &file_bankapi_v1_swift_code_service_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateResponse) ProtoMessage() {}
+
+func (x *CreateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bankapi_v1_swift_code_service_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateResponse.ProtoReflect.Descriptor instead.
+func (*CreateResponse) Descriptor() ([]byte, []int) {
+	return file_bankapi_v1_swift_code_service_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *CreateResponse) GetBank() *Bank {
+	if x != nil {
+		return x.Bank
+	}
+	return nil
+}
+
+type DeleteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SwiftCode     string                 `protobuf:"bytes,1,opt,name=swift_code,json=swiftCode,proto3" json:"swift_code,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteRequest) Reset() {
+	*x = DeleteRequest{}
+	mi := &file_bankapi_v1_swift_code_service_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteRequest) ProtoMessage() {}
+
+func (x *DeleteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bankapi_v1_swift_code_service_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteRequest.ProtoReflect.Descriptor instead.
+func (*DeleteRequest) Descriptor() ([]byte, []int) {
+	return file_bankapi_v1_swift_code_service_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *DeleteRequest) GetSwiftCode() string {
+	if x != nil {
+		return x.SwiftCode
+	}
+	return ""
+}
+
+type DeleteResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteResponse) Reset() {
+	*x = DeleteResponse{}
+	mi := &file_bankapi_v1_swift_code_service_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteResponse) ProtoMessage() {}
+
+func (x *DeleteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bankapi_v1_swift_code_service_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteResponse.ProtoReflect.Descriptor instead.
+func (*DeleteResponse) Descriptor() ([]byte, []int) {
+	return file_bankapi_v1_swift_code_service_proto_rawDescGZIP(), []int{11}
+}
+
+type ExportAllRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Limits the export to one country when set
+	CountryIso2   string `protobuf:"bytes,1,opt,name=country_iso2,json=countryIso2,proto3" json:"country_iso2,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportAllRequest) Reset() {
+	*x = ExportAllRequest{}
+	mi := &file_bankapi_v1_swift_code_service_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportAllRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportAllRequest) ProtoMessage() {}
+
+func (x *ExportAllRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bankapi_v1_swift_code_service_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportAllRequest.ProtoReflect.Descriptor instead.
+func (*ExportAllRequest) Descriptor() ([]byte, []int) {
+	return file_bankapi_v1_swift_code_service_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ExportAllRequest) GetCountryIso2() string {
+	if x != nil {
+		return x.CountryIso2
+	}
+	return ""
+}
+
+var File_bankapi_v1_swift_code_service_proto protoreflect.FileDescriptor
+
+const file_bankapi_v1_swift_code_service_proto_rawDesc = "" +
+	"\n" +
+	"#bankapi/v1/swift_code_service.proto\x12\n" +
+	"bankapi.v1\"\xc9\x01\n" +
+	"\x04Bank\x12\x1d\n" +
+	"\n" +
+	"swift_code\x18\x01 \x01(\tR\tswiftCode\x12\x1b\n" +
+	"\tbank_name\x18\x02 \x01(\tR\bbankName\x12\x18\n" +
+	"\aaddress\x18\x03 \x01(\tR\aaddress\x12!\n" +
+	"\fcountry_iso2\x18\x04 \x01(\tR\vcountryIso2\x12!\n" +
+	"\fcountry_name\x18\x05 \x01(\tR\vcountryName\x12%\n" +
+	"\x0eis_headquarter\x18\x06 \x01(\bR\risHeadquarter\"a\n" +
+	"\vHeadquarter\x12$\n" +
+	"\x04bank\x18\x01 \x01(\v2\x10.bankapi.v1.BankR\x04bank\x12,\n" +
+	"\bbranches\x18\x02 \x03(\v2\x10.bankapi.v1.BankR\bbranches\"4\n" +
+	"\x13GetSwiftCodeRequest\x12\x1d\n" +
+	"\n" +
+	"swift_code\x18\x01 \x01(\tR\tswiftCode\"j\n" +
+	"\x14GetSwiftCodeResponse\x12$\n" +
+	"\x04bank\x18\x01 \x01(\v2\x10.bankapi.v1.BankR\x04bank\x12,\n" +
+	"\bbranches\x18\x02 \x03(\v2\x10.bankapi.v1.BankR\bbranches\"9\n" +
+	"\x14ListByCountryRequest\x12!\n" +
+	"\fcountry_iso2\x18\x01 \x01(\tR\vcountryIso2\"\x90\x01\n" +
+	"\x15ListByCountryResponse\x12!\n" +
+	"\fcountry_iso2\x18\x01 \x01(\tR\vcountryIso2\x12!\n" +
+	"\fcountry_name\x18\x02 \x01(\tR\vcountryName\x121\n" +
+	"\vswift_codes\x18\x03 \x03(\v2\x10.bankapi.v1.BankR\n" +
+	"swiftCodes\"5\n" +
+	"\x12BatchLookupRequest\x12\x1f\n" +
+	"\vswift_codes\x18\x01 \x03(\tR\n" +
+	"swiftCodes\"Z\n" +
+	"\x13BatchLookupResponse\x12&\n" +
+	"\x05banks\x18\x01 \x03(\v2\x10.bankapi.v1.BankR\x05banks\x12\x1b\n" +
+	"\tnot_found\x18\x02 \x03(\tR\bnotFound\"5\n" +
+	"\rCreateRequest\x12$\n" +
+	"\x04bank\x18\x01 \x01(\v2\x10.bankapi.v1.BankR\x04bank\"6\n" +
+	"\x0eCreateResponse\x12$\n" +
+	"\x04bank\x18\x01 \x01(\v2\x10.bankapi.v1.BankR\x04bank\".\n" +
+	"\rDeleteRequest\x12\x1d\n" +
+	"\n" +
+	"swift_code\x18\x01 \x01(\tR\tswiftCode\"\x10\n" +
+	"\x0eDeleteResponse\"5\n" +
+	"\x10ExportAllRequest\x12!\n" +
+	"\fcountry_iso2\x18\x01 \x01(\tR\vcountryIso22\xd3\x03\n" +
+	"\x10SwiftCodeService\x12Q\n" +
+	"\fGetSwiftCode\x12\x1f.bankapi.v1.GetSwiftCodeRequest\x1a .bankapi.v1.GetSwiftCodeResponse\x12T\n" +
+	"\rListByCountry\x12 .bankapi.v1.ListByCountryRequest\x1a!.bankapi.v1.ListByCountryResponse\x12N\n" +
+	"\vBatchLookup\x12\x1e.bankapi.v1.BatchLookupRequest\x1a\x1f.bankapi.v1.BatchLookupResponse\x12?\n" +
+	"\x06Create\x12\x19.bankapi.v1.CreateRequest\x1a\x1a.bankapi.v1.CreateResponse\x12?\n" +
+	"\x06Delete\x12\x19.bankapi.v1.DeleteRequest\x1a\x1a.bankapi.v1.DeleteResponse\x12D\n" +
+	"\tExportAll\x12\x1c.bankapi.v1.ExportAllRequest\x1a\x17.bankapi.v1.Headquarter0\x01B=Z;github.com/MarcinZ20/bankAPI/api/proto/bankapi/v1;bankapiv1b\x06proto3"
+
+var (
+	file_bankapi_v1_swift_code_service_proto_rawDescOnce sync.Once
+	file_bankapi_v1_swift_code_service_proto_rawDescData []byte
+)
+
+func file_bankapi_v1_swift_code_service_proto_rawDescGZIP() []byte {
+	file_bankapi_v1_swift_code_service_proto_rawDescOnce.Do(func() {
+		file_bankapi_v1_swift_code_service_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_bankapi_v1_swift_code_service_proto_rawDesc), len(file_bankapi_v1_swift_code_service_proto_rawDesc)))
+	})
+	return file_bankapi_v1_swift_code_service_proto_rawDescData
+}
+
+var file_bankapi_v1_swift_code_service_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
+var file_bankapi_v1_swift_code_service_proto_goTypes = []any{
+	(*Bank)(nil),                  // 0: bankapi.v1.Bank
+	(*Headquarter)(nil),           // 1: bankapi.v1.Headquarter
+	(*GetSwiftCodeRequest)(nil),   // 2: bankapi.v1.GetSwiftCodeRequest
+	(*GetSwiftCodeResponse)(nil),  // 3: bankapi.v1.GetSwiftCodeResponse
+	(*ListByCountryRequest)(nil),  // 4: bankapi.v1.ListByCountryRequest
+	(*ListByCountryResponse)(nil), // 5: bankapi.v1.ListByCountryResponse
+	(*BatchLookupRequest)(nil),    // 6: bankapi.v1.BatchLookupRequest
+	(*BatchLookupResponse)(nil),   // 7: bankapi.v1.BatchLookupResponse
+	(*CreateRequest)(nil),         // 8: bankapi.v1.CreateRequest
+	(*CreateResponse)(nil),        // 9: bankapi.v1.CreateResponse
+	(*DeleteRequest)(nil),         // 10: bankapi.v1.DeleteRequest
+	(*DeleteResponse)(nil),        // 11: bankapi.v1.DeleteResponse
+	(*ExportAllRequest)(nil),      // 12: bankapi.v1.ExportAllRequest
+}
+var file_bankapi_v1_swift_code_service_proto_depIdxs = []int32{
+	0,  // 0: bankapi.v1.Headquarter.bank:type_name -> bankapi.v1.Bank
+	0,  // 1: bankapi.v1.Headquarter.branches:type_name -> bankapi.v1.Bank
+	0,  // 2: bankapi.v1.GetSwiftCodeResponse.bank:type_name -> bankapi.v1.Bank
+	0,  // 3: bankapi.v1.GetSwiftCodeResponse.branches:type_name -> bankapi.v1.Bank
+	0,  // 4: bankapi.v1.ListByCountryResponse.swift_codes:type_name -> bankapi.v1.Bank
+	0,  // 5: bankapi.v1.BatchLookupResponse.banks:type_name -> bankapi.v1.Bank
+	0,  // 6: bankapi.v1.CreateRequest.bank:type_name -> bankapi.v1.Bank
+	0,  // 7: bankapi.v1.CreateResponse.bank:type_name -> bankapi.v1.Bank
+	2,  // 8: bankapi.v1.SwiftCodeService.GetSwiftCode:input_type -> bankapi.v1.GetSwiftCodeRequest
+	4,  // 9: bankapi.v1.SwiftCodeService.ListByCountry:input_type -> bankapi.v1.ListByCountryRequest
+	6,  // 10: bankapi.v1.SwiftCodeService.BatchLookup:input_type -> bankapi.v1.BatchLookupRequest
+	8,  // 11: bankapi.v1.SwiftCodeService.Create:input_type -> bankapi.v1.CreateRequest
+	10, // 12: bankapi.v1.SwiftCodeService.Delete:input_type -> bankapi.v1.DeleteRequest
+	12, // 13: bankapi.v1.SwiftCodeService.ExportAll:input_type -> bankapi.v1.ExportAllRequest
+	3,  // 14: bankapi.v1.SwiftCodeService.GetSwiftCode:output_type -> bankapi.v1.GetSwiftCodeResponse
+	5,  // 15: bankapi.v1.SwiftCodeService.ListByCountry:output_type -> bankapi.v1.ListByCountryResponse
+	7,  // 16: bankapi.v1.SwiftCodeService.BatchLookup:output_type -> bankapi.v1.BatchLookupResponse
+	9,  // 17: bankapi.v1.SwiftCodeService.Create:output_type -> bankapi.v1.CreateResponse
+	11, // 18: bankapi.v1.SwiftCodeService.Delete:output_type -> bankapi.v1.DeleteResponse
+	1,  // 19: bankapi.v1.SwiftCodeService.ExportAll:output_type -> bankapi.v1.Headquarter
+	14, // [14:20] is the sub-list for method output_type
+	8,  // [8:14] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_bankapi_v1_swift_code_service_proto_init() }
+func file_bankapi_v1_swift_code_service_proto_init() {
+	if File_bankapi_v1_swift_code_service_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_bankapi_v1_swift_code_service_proto_rawDesc), len(file_bankapi_v1_swift_code_service_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   13,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_bankapi_v1_swift_code_service_proto_goTypes,
+		DependencyIndexes: file_bankapi_v1_swift_code_service_proto_depIdxs,
+		MessageInfos:      file_bankapi_v1_swift_code_service_proto_msgTypes,
+	}.Build()
+	File_bankapi_v1_swift_code_service_proto = out.File
+	file_bankapi_v1_swift_code_service_proto_goTypes = nil
+	file_bankapi_v1_swift_code_service_proto_depIdxs = nil
+}
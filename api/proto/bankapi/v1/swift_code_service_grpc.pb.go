@@ -0,0 +1,331 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: bankapi/v1/swift_code_service.proto
+
+package bankapiv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	SwiftCodeService_GetSwiftCode_FullMethodName  = "/bankapi.v1.SwiftCodeService/GetSwiftCode"
+	SwiftCodeService_ListByCountry_FullMethodName = "/bankapi.v1.SwiftCodeService/ListByCountry"
+	SwiftCodeService_BatchLookup_FullMethodName   = "/bankapi.v1.SwiftCodeService/BatchLookup"
+	SwiftCodeService_Create_FullMethodName        = "/bankapi.v1.SwiftCodeService/Create"
+	SwiftCodeService_Delete_FullMethodName        = "/bankapi.v1.SwiftCodeService/Delete"
+	SwiftCodeService_ExportAll_FullMethodName     = "/bankapi.v1.SwiftCodeService/ExportAll"
+)
+
+// SwiftCodeServiceClient is the client API for SwiftCodeService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Looks up and maintains SWIFT codes, the gRPC counterpart of the /v1/swift-codes REST endpoints
+type SwiftCodeServiceClient interface {
+	// Returns a headquarter with its branches or a single branch
+	GetSwiftCode(ctx context.Context, in *GetSwiftCodeRequest, opts ...grpc.CallOption) (*GetSwiftCodeResponse, error)
+	// Returns all headquarters and branches of a country
+	ListByCountry(ctx context.Context, in *ListByCountryRequest, opts ...grpc.CallOption) (*ListByCountryResponse, error)
+	// Looks up several SWIFT codes at once, unknown codes are listed instead of failing the call
+	BatchLookup(ctx context.Context, in *BatchLookupRequest, opts ...grpc.CallOption) (*BatchLookupResponse, error)
+	// Adds a headquarter or a branch of an existing headquarter
+	Create(ctx context.Context, in *CreateRequest, opts ...grpc.CallOption) (*CreateResponse, error)
+	// Deletes a headquarter with its branches or a single branch
+	Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error)
+	// Streams every headquarter with its branches, ordered by SWIFT code
+	ExportAll(ctx context.Context, in *ExportAllRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Headquarter], error)
+}
+
+type swiftCodeServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSwiftCodeServiceClient(cc grpc.ClientConnInterface) SwiftCodeServiceClient {
+	return &swiftCodeServiceClient{cc}
+}
+
+func (c *swiftCodeServiceClient) GetSwiftCode(ctx context.Context, in *GetSwiftCodeRequest, opts ...grpc.CallOption) (*GetSwiftCodeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetSwiftCodeResponse)
+	err := c.cc.Invoke(ctx, SwiftCodeService_GetSwiftCode_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *swiftCodeServiceClient) ListByCountry(ctx context.Context, in *ListByCountryRequest, opts ...grpc.CallOption) (*ListByCountryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListByCountryResponse)
+	err := c.cc.Invoke(ctx, SwiftCodeService_ListByCountry_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *swiftCodeServiceClient) BatchLookup(ctx context.Context, in *BatchLookupRequest, opts ...grpc.CallOption) (*BatchLookupResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchLookupResponse)
+	err := c.cc.Invoke(ctx, SwiftCodeService_BatchLookup_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *swiftCodeServiceClient) Create(ctx context.Context, in *CreateRequest, opts ...grpc.CallOption) (*CreateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateResponse)
+	err := c.cc.Invoke(ctx, SwiftCodeService_Create_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *swiftCodeServiceClient) Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteResponse)
+	err := c.cc.Invoke(ctx, SwiftCodeService_Delete_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *swiftCodeServiceClient) ExportAll(ctx context.Context, in *ExportAllRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Headquarter], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &SwiftCodeService_ServiceDesc.Streams[0], SwiftCodeService_ExportAll_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ExportAllRequest, Headquarter]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type SwiftCodeService_ExportAllClient = grpc.ServerStreamingClient[Headquarter]
+
+// SwiftCodeServiceServer is the server API for SwiftCodeService service.
+// All implementations must embed UnimplementedSwiftCodeServiceServer
+// for forward compatibility.
+//
+// Looks up and maintains SWIFT codes, the gRPC counterpart of the /v1/swift-codes REST endpoints
+type SwiftCodeServiceServer interface {
+	// Returns a headquarter with its branches or a single branch
+	GetSwiftCode(context.Context, *GetSwiftCodeRequest) (*GetSwiftCodeResponse, error)
+	// Returns all headquarters and branches of a country
+	ListByCountry(context.Context, *ListByCountryRequest) (*ListByCountryResponse, error)
+	// Looks up several SWIFT codes at once, unknown codes are listed instead of failing the call
+	BatchLookup(context.Context, *BatchLookupRequest) (*BatchLookupResponse, error)
+	// Adds a headquarter or a branch of an existing headquarter
+	Create(context.Context, *CreateRequest) (*CreateResponse, error)
+	// Deletes a headquarter with its branches or a single branch
+	Delete(context.Context, *DeleteRequest) (*DeleteResponse, error)
+	// Streams every headquarter with its branches, ordered by SWIFT code
+	ExportAll(*ExportAllRequest, grpc.ServerStreamingServer[Headquarter]) error
+	mustEmbedUnimplementedSwiftCodeServiceServer()
+}
+
+// UnimplementedSwiftCodeServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedSwiftCodeServiceServer struct{}
+
+func (UnimplementedSwiftCodeServiceServer) GetSwiftCode(context.Context, *GetSwiftCodeRequest) (*GetSwiftCodeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSwiftCode not implemented")
+}
+func (UnimplementedSwiftCodeServiceServer) ListByCountry(context.Context, *ListByCountryRequest) (*ListByCountryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListByCountry not implemented")
+}
+func (UnimplementedSwiftCodeServiceServer) BatchLookup(context.Context, *BatchLookupRequest) (*BatchLookupResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchLookup not implemented")
+}
+func (UnimplementedSwiftCodeServiceServer) Create(context.Context, *CreateRequest) (*CreateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Create not implemented")
+}
+func (UnimplementedSwiftCodeServiceServer) Delete(context.Context, *DeleteRequest) (*DeleteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
+}
+func (UnimplementedSwiftCodeServiceServer) ExportAll(*ExportAllRequest, grpc.ServerStreamingServer[Headquarter]) error {
+	return status.Errorf(codes.Unimplemented, "method ExportAll not implemented")
+}
+func (UnimplementedSwiftCodeServiceServer) mustEmbedUnimplementedSwiftCodeServiceServer() {}
+func (UnimplementedSwiftCodeServiceServer) testEmbeddedByValue()                          {}
+
+// UnsafeSwiftCodeServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SwiftCodeServiceServer will
+// result in compilation errors.
+type UnsafeSwiftCodeServiceServer interface {
+	mustEmbedUnimplementedSwiftCodeServiceServer()
+}
+
+func RegisterSwiftCodeServiceServer(s grpc.ServiceRegistrar, srv SwiftCodeServiceServer) {
+	// If the following call pancis, it indicates UnimplementedSwiftCodeServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&SwiftCodeService_ServiceDesc, srv)
+}
+
+func _SwiftCodeService_GetSwiftCode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSwiftCodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SwiftCodeServiceServer).GetSwiftCode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SwiftCodeService_GetSwiftCode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SwiftCodeServiceServer).GetSwiftCode(ctx, req.(*GetSwiftCodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SwiftCodeService_ListByCountry_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListByCountryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SwiftCodeServiceServer).ListByCountry(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SwiftCodeService_ListByCountry_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SwiftCodeServiceServer).ListByCountry(ctx, req.(*ListByCountryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SwiftCodeService_BatchLookup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchLookupRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SwiftCodeServiceServer).BatchLookup(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SwiftCodeService_BatchLookup_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SwiftCodeServiceServer).BatchLookup(ctx, req.(*BatchLookupRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SwiftCodeService_Create_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SwiftCodeServiceServer).Create(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SwiftCodeService_Create_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SwiftCodeServiceServer).Create(ctx, req.(*CreateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SwiftCodeService_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SwiftCodeServiceServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SwiftCodeService_Delete_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SwiftCodeServiceServer).Delete(ctx, req.(*DeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SwiftCodeService_ExportAll_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportAllRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SwiftCodeServiceServer).ExportAll(m, &grpc.GenericServerStream[ExportAllRequest, Headquarter]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type SwiftCodeService_ExportAllServer = grpc.ServerStreamingServer[Headquarter]
+
+// SwiftCodeService_ServiceDesc is the grpc.ServiceDesc for SwiftCodeService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var SwiftCodeService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "bankapi.v1.SwiftCodeService",
+	HandlerType: (*SwiftCodeServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetSwiftCode",
+			Handler:    _SwiftCodeService_GetSwiftCode_Handler,
+		},
+		{
+			MethodName: "ListByCountry",
+			Handler:    _SwiftCodeService_ListByCountry_Handler,
+		},
+		{
+			MethodName: "BatchLookup",
+			Handler:    _SwiftCodeService_BatchLookup_Handler,
+		},
+		{
+			MethodName: "Create",
+			Handler:    _SwiftCodeService_Create_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _SwiftCodeService_Delete_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ExportAll",
+			Handler:       _SwiftCodeService_ExportAll_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "bankapi/v1/swift_code_service.proto",
+}
@@ -0,0 +1,43 @@
+package responses
+
+import (
+	"time"
+
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+)
+
+type APIKeyResponse struct {
+	ID         string            `json:"id"`
+	Name       string            `json:"name"`
+	Prefix     string            `json:"prefix"`
+	Scopes     []string          `json:"scopes"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+	DailyQuota int64             `json:"dailyQuota,omitempty"`
+	CreatedAt  time.Time         `json:"createdAt"`
+	ExpiresAt  *time.Time        `json:"expiresAt,omitempty"`
+	RotatedAt  *time.Time        `json:"rotatedAt,omitempty"`
+	RevokedAt  *time.Time        `json:"revokedAt,omitempty"`
+	LastUsedAt *time.Time        `json:"lastUsedAt,omitempty"`
+}
+
+// Returned once when a key is created or rotated, it is the only time the secret is shown
+type APIKeySecretResponse struct {
+	Key    string         `json:"key"`
+	APIKey APIKeyResponse `json:"apiKey"`
+}
+
+func NewAPIKeyResponse(key *models.APIKey) APIKeyResponse {
+	return APIKeyResponse{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		Metadata:   key.Metadata,
+		DailyQuota: key.DailyQuota,
+		CreatedAt:  key.CreatedAt,
+		ExpiresAt:  key.ExpiresAt,
+		RotatedAt:  key.RotatedAt,
+		RevokedAt:  key.RevokedAt,
+		LastUsedAt: key.LastUsedAt,
+	}
+}
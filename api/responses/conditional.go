@@ -0,0 +1,107 @@
+package responses
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Appended to the entity tag of a representation, so every media type of the same content has its own strong tag.
+// JSON, the default representation, has none.
+var tagSuffixes = map[string]string{
+	MIMEXML:     "-xml",
+	MIMETextXML: "-textxml",
+	MIMECSV:     "-csv",
+}
+
+// Computes the strong entity tag of a response from its content, equal content always gets the same tag
+func ETag(data any) (string, error) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`, nil
+}
+
+// Reports whether an If-Match header matches a record whose content has the given entity tag, in any
+// representation. Tags are compared strongly, so weak ones never match, and "*" matches every record.
+func MatchesETag(header, tag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strings.HasPrefix(candidate, "W/") {
+			continue
+		}
+		for _, suffix := range tagSuffixes {
+			if base, ok := strings.CutSuffix(candidate, suffix+`"`); ok {
+				candidate = base + `"`
+				break
+			}
+		}
+		if candidate == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Writes data like NewSuccessResponse with its ETag and, unless modified is zero, Last-Modified header
+func NewTaggedResponse(c *fiber.Ctx, data any, modified time.Time) error {
+	return respond(c, data, modified, false)
+}
+
+// Writes data like NewTaggedResponse, or 304 Not Modified without a body when If-None-Match or, without it,
+// If-Modified-Since shows the client already has the current version
+func NewConditionalResponse(c *fiber.Ctx, data any, modified time.Time) error {
+	return respond(c, data, modified, true)
+}
+
+func respond(c *fiber.Ctx, data any, modified time.Time, conditional bool) error {
+	mediaType, err := negotiate(c, data)
+	if err != nil {
+		return err
+	}
+
+	tag, err := ETag(data)
+	if err != nil {
+		return FormattingResponseError("Error while formatting response")
+	}
+	if suffix := tagSuffixes[mediaType]; suffix != "" {
+		tag = strings.TrimSuffix(tag, `"`) + suffix + `"`
+	}
+
+	c.Set(fiber.HeaderETag, tag)
+	if !modified.IsZero() {
+		c.Set(fiber.HeaderLastModified, modified.UTC().Format(http.TimeFormat))
+	}
+
+	if conditional && notModified(c, tag, modified) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+	return write(c, fiber.StatusOK, mediaType, data)
+}
+
+// Reports whether the client's copy is current. If-None-Match compares weakly, as it does for every GET,
+// and If-Modified-Since is ignored when it is present.
+func notModified(c *fiber.Ctx, tag string, modified time.Time) bool {
+	if header := c.Get(fiber.HeaderIfNoneMatch); header != "" {
+		for _, candidate := range strings.Split(header, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == "*" || candidate == tag {
+				return true
+			}
+		}
+		return false
+	}
+
+	since, err := http.ParseTime(c.Get(fiber.HeaderIfModifiedSince))
+	return err == nil && !modified.IsZero() && !modified.Truncate(time.Second).After(since)
+}
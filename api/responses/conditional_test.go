@@ -0,0 +1,95 @@
+package responses
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testModified = time.Date(2026, 3, 14, 9, 26, 53, 589_000_000, time.UTC)
+
+func conditional(t *testing.T, headers map[string]string) *http.Response {
+	t.Helper()
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		return NewConditionalResponse(c, testHeadquarter, testModified)
+	})
+
+	req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	return resp
+}
+
+func TestConditionalResponse(t *testing.T) {
+	tag, err := ETag(testHeadquarter)
+	require.NoError(t, err)
+
+	resp := conditional(t, nil)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, tag, resp.Header.Get(fiber.HeaderETag))
+	assert.Equal(t, "Sat, 14 Mar 2026 09:26:53 GMT", resp.Header.Get(fiber.HeaderLastModified))
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		status  int
+	}{
+		{"matching tag", map[string]string{fiber.HeaderIfNoneMatch: tag}, fiber.StatusNotModified},
+		{"weak matching tag", map[string]string{fiber.HeaderIfNoneMatch: `"other", W/` + tag}, fiber.StatusNotModified},
+		{"other tag", map[string]string{fiber.HeaderIfNoneMatch: `"other"`}, fiber.StatusOK},
+		{"json tag for csv", map[string]string{fiber.HeaderIfNoneMatch: tag, fiber.HeaderAccept: MIMECSV}, fiber.StatusOK},
+		{"not modified since", map[string]string{fiber.HeaderIfModifiedSince: "Sat, 14 Mar 2026 09:26:53 GMT"}, fiber.StatusNotModified},
+		{"modified since", map[string]string{fiber.HeaderIfModifiedSince: "Sat, 14 Mar 2026 09:26:52 GMT"}, fiber.StatusOK},
+		{
+			name:    "tag takes precedence over date",
+			headers: map[string]string{fiber.HeaderIfNoneMatch: `"other"`, fiber.HeaderIfModifiedSince: "Sat, 14 Mar 2026 10:00:00 GMT"},
+			status:  fiber.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := conditional(t, tt.headers)
+			assert.Equal(t, tt.status, resp.StatusCode)
+			assert.NotEmpty(t, resp.Header.Get(fiber.HeaderETag))
+		})
+	}
+}
+
+func TestConditionalResponseTagPerRepresentation(t *testing.T) {
+	json := conditional(t, nil).Header.Get(fiber.HeaderETag)
+	csv := conditional(t, map[string]string{fiber.HeaderAccept: MIMECSV}).Header.Get(fiber.HeaderETag)
+	xml := conditional(t, map[string]string{fiber.HeaderAccept: MIMEXML}).Header.Get(fiber.HeaderETag)
+
+	assert.NotEqual(t, json, csv)
+	assert.NotEqual(t, json, xml)
+
+	// Any representation's tag identifies the record a write applies to
+	assert.True(t, MatchesETag(csv, json))
+	assert.True(t, MatchesETag(`"other", `+xml, json))
+}
+
+func TestMatchesETag(t *testing.T) {
+	tag, err := ETag(testHeadquarter)
+	require.NoError(t, err)
+
+	changed := *testHeadquarter
+	changed.Address = "UL. PROSTA 20"
+	other, err := ETag(&changed)
+	require.NoError(t, err)
+
+	assert.NotEqual(t, tag, other)
+	assert.True(t, MatchesETag(tag, tag))
+	assert.True(t, MatchesETag("*", other))
+	assert.False(t, MatchesETag(other, tag))
+	assert.False(t, MatchesETag("W/"+tag, tag))
+}
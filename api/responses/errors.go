@@ -35,3 +35,23 @@ func FormattingResponseError(message string) error {
 func InternalServerError(message string) error {
 	return fiber.NewError(fiber.StatusInternalServerError, message)
 }
+
+// Returns a consistent unauthorized error response
+func UnauthorizedError(message string) error {
+	return fiber.NewError(fiber.StatusUnauthorized, message)
+}
+
+// Returns a consistent forbidden error response
+func ForbiddenError(message string) error {
+	return fiber.NewError(fiber.StatusForbidden, message)
+}
+
+// Returns a consistent too many requests error response
+func TooManyRequestsError(message string) error {
+	return fiber.NewError(fiber.StatusTooManyRequests, message)
+}
+
+// Returns a consistent response for a conditional request whose precondition does not hold
+func PreconditionFailedError(message string) error {
+	return fiber.NewError(fiber.StatusPreconditionFailed, message)
+}
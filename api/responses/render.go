@@ -0,0 +1,115 @@
+package responses
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Media types the renderer can produce, JSON is preferred when the client accepts several
+const (
+	MIMEJSON    = fiber.MIMEApplicationJSON
+	MIMEXML     = fiber.MIMEApplicationXML
+	MIMETextXML = fiber.MIMETextXML
+	MIMECSV     = "text/csv"
+)
+
+// Every media type a response may be rendered as
+var MediaTypes = []string{MIMEJSON, MIMEXML, MIMETextXML, MIMECSV}
+
+// Implemented by responses that can be rendered as CSV, the first record is the header
+type CSVMarshaler interface {
+	MarshalCSV() ([][]string, error)
+}
+
+// Header of the CSV rows describing a single bank
+var bankCSVHeader = []string{"swiftCode", "bankName", "address", "countryISO2", "countryName", "isHeadquarter"}
+
+// Writes data with the given status in the representation the Accept header asks for.
+// Every response renders as JSON, responses with an XMLName field as XML and CSVMarshalers as CSV.
+func Render(c *fiber.Ctx, status int, data any) error {
+	mediaType, err := negotiate(c, data)
+	if err != nil {
+		return err
+	}
+	return write(c, status, mediaType, data)
+}
+
+// Picks the media type data is rendered as
+func negotiate(c *fiber.Ctx, data any) (string, error) {
+	offers := mediaTypes(data)
+	c.Vary(fiber.HeaderAccept)
+
+	mediaType := c.Accepts(offers...)
+	if mediaType == "" {
+		return "", NotAcceptableError(offers)
+	}
+	return mediaType, nil
+}
+
+// Writes data as the negotiated media type
+func write(c *fiber.Ctx, status int, mediaType string, data any) error {
+	switch mediaType {
+	case MIMEXML, MIMETextXML:
+		body, err := xml.Marshal(data)
+		if err != nil {
+			return FormattingResponseError("Error while formatting response")
+		}
+		c.Set(fiber.HeaderContentType, mediaType+"; charset=utf-8")
+		return c.Status(status).Send(append([]byte(xml.Header), body...))
+	case MIMECSV:
+		records, err := data.(CSVMarshaler).MarshalCSV()
+		if err != nil {
+			return FormattingResponseError("Error while formatting response")
+		}
+		var buf bytes.Buffer
+		if err := csv.NewWriter(&buf).WriteAll(records); err != nil {
+			return FormattingResponseError("Error while formatting response")
+		}
+		c.Set(fiber.HeaderContentType, MIMECSV+"; charset=utf-8")
+		return c.Status(status).Send(buf.Bytes())
+	default:
+		return c.Status(status).JSON(data)
+	}
+}
+
+// Returns a consistent error for an Accept header none of the offered media types satisfies
+func NotAcceptableError(offers []string) error {
+	return fiber.NewError(fiber.StatusNotAcceptable,
+		fmt.Sprintf("Not acceptable, supported media types: %s", strings.Join(offers, ", ")))
+}
+
+// Lists the media types data can be rendered as
+func mediaTypes(data any) []string {
+	offers := []string{MIMEJSON}
+	if hasXMLName(data) {
+		offers = append(offers, MIMEXML, MIMETextXML)
+	}
+	if _, ok := data.(CSVMarshaler); ok {
+		offers = append(offers, MIMECSV)
+	}
+	return offers
+}
+
+// Reports whether data is a struct declaring its XML root element
+func hasXMLName(data any) bool {
+	t := reflect.TypeOf(data)
+	if t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return false
+	}
+	_, ok := t.FieldByName("XMLName")
+	return ok
+}
+
+func bankCSVRecord(swiftCode, bankName, address, countryISO2, countryName string, isHeadquarter bool) []string {
+	return []string{swiftCode, bankName, address, countryISO2, countryName, strconv.FormatBool(isHeadquarter)}
+}
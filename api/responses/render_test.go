@@ -0,0 +1,101 @@
+package responses
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testHeadquarter = &HeadquarterResponse{
+	Address:       "UL. PROSTA 18",
+	BankName:      "MBANK S.A.",
+	CountryISO2:   "PL",
+	CountryName:   "POLAND",
+	IsHeadquarter: true,
+	SwiftCode:     "BREXPLPWXXX",
+	Branches: []ShortBankResponse{
+		{Address: "RYNEK 1", BankName: "MBANK S.A.", CountryISO2: "PL", SwiftCode: "BREXPLPWKRA"},
+	},
+}
+
+func render(t *testing.T, accept string, data any) (int, string, string) {
+	t.Helper()
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		return NewSuccessResponse(c, data)
+	})
+
+	req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+	if accept != "" {
+		req.Header.Set(fiber.HeaderAccept, accept)
+	}
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, resp.Header.Get(fiber.HeaderContentType), string(body)
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name        string
+		accept      string
+		contentType string
+		body        string
+	}{
+		{
+			name:        "default",
+			contentType: "application/json",
+			body:        `{"address":"UL. PROSTA 18","bankName":"MBANK S.A.","countryISO2":"PL","countryName":"POLAND","isHeadquarter":true,"swiftCode":"BREXPLPWXXX","branches":[{"address":"RYNEK 1","bankName":"MBANK S.A.","countryISO2":"PL","isHeadquarter":false,"swiftCode":"BREXPLPWKRA"}]}`,
+		},
+		{
+			name:        "xml",
+			accept:      "application/xml",
+			contentType: "application/xml; charset=utf-8",
+			body: `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+				`<headquarter><address>UL. PROSTA 18</address><bankName>MBANK S.A.</bankName><countryISO2>PL</countryISO2>` +
+				`<countryName>POLAND</countryName><isHeadquarter>true</isHeadquarter><swiftCode>BREXPLPWXXX</swiftCode>` +
+				`<branches><branch><address>RYNEK 1</address><bankName>MBANK S.A.</bankName><countryISO2>PL</countryISO2>` +
+				`<isHeadquarter>false</isHeadquarter><swiftCode>BREXPLPWKRA</swiftCode></branch></branches></headquarter>`,
+		},
+		{
+			name:        "csv preferred by quality",
+			accept:      "application/json;q=0.5, text/csv",
+			contentType: "text/csv; charset=utf-8",
+			body: "swiftCode,bankName,address,countryISO2,countryName,isHeadquarter\n" +
+				"BREXPLPWXXX,MBANK S.A.,UL. PROSTA 18,PL,POLAND,true\n" +
+				"BREXPLPWKRA,MBANK S.A.,RYNEK 1,PL,POLAND,false\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, contentType, body := render(t, tt.accept, testHeadquarter)
+			assert.Equal(t, fiber.StatusOK, status)
+			assert.Equal(t, tt.contentType, contentType)
+			assert.Equal(t, tt.body, body)
+		})
+	}
+}
+
+func TestRenderNotAcceptable(t *testing.T) {
+	status, _, body := render(t, "application/pdf", testHeadquarter)
+	assert.Equal(t, fiber.StatusNotAcceptable, status)
+	assert.Contains(t, body, "text/csv")
+
+	// Responses without an XML or CSV form are only offered as JSON
+	status, _, _ = render(t, "text/csv", fiber.Map{"status": "ok"})
+	assert.Equal(t, fiber.StatusNotAcceptable, status)
+}
+
+func TestMessageResponse(t *testing.T) {
+	_, _, body := render(t, "text/xml", MessageResponse{Message: "Branch added successfully"})
+	assert.Contains(t, body, "<response><message>Branch added successfully</message></response>")
+
+	_, _, body = render(t, "text/csv", MessageResponse{Message: "Branch added successfully"})
+	assert.Equal(t, "message\nBranch added successfully\n", body)
+}
@@ -1,6 +1,7 @@
 package responses
 
 import (
+	"encoding/xml"
 	"fmt"
 
 	"github.com/MarcinZ20/bankAPI/pkg/models"
@@ -14,40 +15,102 @@ type Response interface {
 
 // Creates a consistent success response with status 200 OK
 func NewSuccessResponse(c *fiber.Ctx, data any) error {
-	return c.Status(fiber.StatusOK).JSON(data)
+	return Render(c, fiber.StatusOK, data)
+}
+
+// Creates a consistent response with status 202 Accepted for work that continues in the background
+func NewAcceptedResponse(c *fiber.Ctx, data any) error {
+	return Render(c, fiber.StatusAccepted, data)
+}
+
+// Confirms a write that has no other result
+type MessageResponse struct {
+	XMLName xml.Name `json:"-" xml:"response"`
+	Message string   `json:"message" xml:"message"`
 }
 
 type HeadquarterResponse struct {
-	Address       string              `json:"address"`
-	BankName      string              `json:"bankName"`
-	CountryISO2   string              `json:"countryISO2"`
-	CountryName   string              `json:"countryName"`
-	IsHeadquarter bool                `json:"isHeadquarter"`
-	SwiftCode     string              `json:"swiftCode"`
-	Branches      []ShortBankResponse `json:"branches,omitempty"`
+	XMLName       xml.Name            `json:"-" xml:"headquarter"`
+	Address       string              `json:"address" xml:"address"`
+	BankName      string              `json:"bankName" xml:"bankName"`
+	CountryISO2   string              `json:"countryISO2" xml:"countryISO2"`
+	CountryName   string              `json:"countryName" xml:"countryName"`
+	IsHeadquarter bool                `json:"isHeadquarter" xml:"isHeadquarter"`
+	SwiftCode     string              `json:"swiftCode" xml:"swiftCode"`
+	Branches      []ShortBankResponse `json:"branches,omitempty" xml:"branches>branch,omitempty"`
 }
 
 type ShortBankResponse struct {
-	Address       string `json:"address"`
-	BankName      string `json:"bankName"`
-	CountryISO2   string `json:"countryISO2"`
-	IsHeadquarter bool   `json:"isHeadquarter"`
-	SwiftCode     string `json:"swiftCode"`
+	Address       string `json:"address" xml:"address"`
+	BankName      string `json:"bankName" xml:"bankName"`
+	CountryISO2   string `json:"countryISO2" xml:"countryISO2"`
+	IsHeadquarter bool   `json:"isHeadquarter" xml:"isHeadquarter"`
+	SwiftCode     string `json:"swiftCode" xml:"swiftCode"`
 }
 
 type LongBankResponse struct {
-	Address       string `json:"address"`
-	BankName      string `json:"bankName"`
-	CountryISO2   string `json:"countryISO2"`
-	CountryName   string `json:"countryName"`
-	IsHeadquarter bool   `json:"isHeadquarter"`
-	SwiftCode     string `json:"swiftCode"`
+	XMLName       xml.Name `json:"-" xml:"bank"`
+	Address       string   `json:"address" xml:"address"`
+	BankName      string   `json:"bankName" xml:"bankName"`
+	CountryISO2   string   `json:"countryISO2" xml:"countryISO2"`
+	CountryName   string   `json:"countryName" xml:"countryName"`
+	IsHeadquarter bool     `json:"isHeadquarter" xml:"isHeadquarter"`
+	SwiftCode     string   `json:"swiftCode" xml:"swiftCode"`
 }
 
 type GetSwiftCodesByCountryCodeResponse struct {
-	CountryISO2 string              `json:"countryISO2"`
-	CountryName string              `json:"countryName"`
-	SwiftCodes  []ShortBankResponse `json:"swiftCodes"`
+	XMLName     xml.Name            `json:"-" xml:"country"`
+	CountryISO2 string              `json:"countryISO2" xml:"countryISO2"`
+	CountryName string              `json:"countryName" xml:"countryName"`
+	SwiftCodes  []ShortBankResponse `json:"swiftCodes" xml:"swiftCodes>swiftCode"`
+}
+
+type SearchSwiftCodesResponse struct {
+	XMLName    xml.Name            `json:"-" xml:"search"`
+	Prefix     string              `json:"prefix" xml:"prefix"`
+	SwiftCodes []ShortBankResponse `json:"swiftCodes" xml:"swiftCodes>swiftCode"`
+}
+
+// Renders the message as a single column
+func (r MessageResponse) MarshalCSV() ([][]string, error) {
+	return [][]string{{"message"}, {r.Message}}, nil
+}
+
+// Renders the headquarter followed by its branches, which share its country name
+func (r *HeadquarterResponse) MarshalCSV() ([][]string, error) {
+	records := [][]string{
+		bankCSVHeader,
+		bankCSVRecord(r.SwiftCode, r.BankName, r.Address, r.CountryISO2, r.CountryName, r.IsHeadquarter),
+	}
+	for _, branch := range r.Branches {
+		records = append(records, bankCSVRecord(branch.SwiftCode, branch.BankName, branch.Address, branch.CountryISO2, r.CountryName, branch.IsHeadquarter))
+	}
+	return records, nil
+}
+
+func (r *LongBankResponse) MarshalCSV() ([][]string, error) {
+	return [][]string{
+		bankCSVHeader,
+		bankCSVRecord(r.SwiftCode, r.BankName, r.Address, r.CountryISO2, r.CountryName, r.IsHeadquarter),
+	}, nil
+}
+
+// Renders one row per SWIFT code of the country
+func (r GetSwiftCodesByCountryCodeResponse) MarshalCSV() ([][]string, error) {
+	records := [][]string{bankCSVHeader}
+	for _, bank := range r.SwiftCodes {
+		records = append(records, bankCSVRecord(bank.SwiftCode, bank.BankName, bank.Address, bank.CountryISO2, r.CountryName, bank.IsHeadquarter))
+	}
+	return records, nil
+}
+
+// Renders one row per matching SWIFT code, the country name is not part of search results
+func (r SearchSwiftCodesResponse) MarshalCSV() ([][]string, error) {
+	records := [][]string{bankCSVHeader}
+	for _, bank := range r.SwiftCodes {
+		records = append(records, bankCSVRecord(bank.SwiftCode, bank.BankName, bank.Address, bank.CountryISO2, "", bank.IsHeadquarter))
+	}
+	return records, nil
 }
 
 func (r *HeadquarterResponse) FromModel(model models.BankEntity) error {
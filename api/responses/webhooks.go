@@ -0,0 +1,29 @@
+package responses
+
+import (
+	"time"
+
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+)
+
+type WebhookResponse struct {
+	ID         string    `json:"id"`
+	URL        string    `json:"url"`
+	Operations []string  `json:"operations,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// Returned once when a webhook is registered, it is the only time the signing secret is shown
+type WebhookSecretResponse struct {
+	Secret  string          `json:"secret"`
+	Webhook WebhookResponse `json:"webhook"`
+}
+
+func NewWebhookResponse(webhook *models.Webhook) WebhookResponse {
+	return WebhookResponse{
+		ID:         webhook.ID,
+		URL:        webhook.URL,
+		Operations: webhook.Operations,
+		CreatedAt:  webhook.CreatedAt,
+	}
+}
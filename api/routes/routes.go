@@ -1,13 +1,123 @@
 package routes
 
 import (
+	"github.com/MarcinZ20/bankAPI/api/gqlapi"
 	"github.com/MarcinZ20/bankAPI/api/handlers"
+	"github.com/MarcinZ20/bankAPI/api/middleware"
+	"github.com/MarcinZ20/bankAPI/api/responses"
+	"github.com/MarcinZ20/bankAPI/internal/importer"
+	"github.com/MarcinZ20/bankAPI/internal/jobs"
+	"github.com/MarcinZ20/bankAPI/internal/metrics"
+	"github.com/MarcinZ20/bankAPI/internal/ratelimit"
+	"github.com/MarcinZ20/bankAPI/internal/scheduler"
+	"github.com/MarcinZ20/bankAPI/pkg/models"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
 )
 
-func BankRoutes(app *fiber.App) {
-	app.Get("/v1/swift-codes/:swiftCode", handlers.GetSwiftCodesBySwiftCode)
-	app.Get("/v1/swift-codes/country/:countryISO2", handlers.GetSwiftCodesByCountryCode)
-	app.Post("/v1/swift-codes", handlers.AddNewSwiftCode)
-	app.Delete("/v1/swift-codes/:swiftCode", handlers.DeleteSwiftCode)
+// Holds route access settings
+type Options struct {
+	Auth        middleware.AuthConfig
+	PublicReads bool
+	RateLimiter *ratelimit.Limiter
+	// Source imported when a request does not supply one
+	ImportSource importer.Source
+	// Directory holding the files import requests may name, empty rejects requests naming a file
+	ImportDir string
+	// Runs imports started through the admin API
+	Imports *jobs.Manager
+	// Background import scheduler, nil when scheduled imports are disabled
+	Scheduler *scheduler.Scheduler
+}
+
+// Registers all versioned API routes
+func Register(app *fiber.App, opts Options) {
+	HealthRoutes(app)
+
+	v1 := app.Group("/v1", authenticate(opts, middleware.RateLimit)...)
+
+	BankRoutes(v1, opts)
+	AdminRoutes(v1, opts)
+	GraphQLRoutes(app, opts)
+	MetricsRoutes(app)
+}
+
+// Exposes the GraphQL endpoint with the authentication and rate limits of the versioned API,
+// scopes are checked per field since one request may mix reads and writes.
+// Every request is a POST, so mutations rather than the method take from the write bucket.
+func GraphQLRoutes(app *fiber.App, opts Options) {
+	rateLimit := func(limiter *ratelimit.Limiter) fiber.Handler {
+		return middleware.RateLimitBy(limiter, gqlapi.IsMutation)
+	}
+	chain := append(authenticate(opts, rateLimit), gqlapi.Handler(gqlapi.Options{PublicReads: opts.PublicReads}))
+
+	app.Post("/graphql", chain...)
+}
+
+// Returns the handlers identifying and throttling callers of the API. The IP limit comes first, so
+// credentials are not looked up for clients already over it.
+func authenticate(opts Options, rateLimit func(limiter *ratelimit.Limiter) fiber.Handler) []fiber.Handler {
+	if opts.RateLimiter == nil {
+		return []fiber.Handler{middleware.Authenticate(opts.Auth)}
+	}
+
+	return []fiber.Handler{
+		middleware.IPRateLimit(opts.RateLimiter),
+		middleware.Authenticate(opts.Auth),
+		rateLimit(opts.RateLimiter),
+	}
+}
+
+// Exposes probes for orchestrators outside the versioned API, without authentication
+func HealthRoutes(app *fiber.App) {
+	app.Get("/healthz", handlers.Liveness)
+	app.Get("/readyz", handlers.Readiness)
+	app.Get("/health", handlers.Health)
+}
+
+// Exposes Prometheus metrics outside the versioned API, without authentication
+func MetricsRoutes(app *fiber.App) {
+	app.Get("/metrics", adaptor.HTTPHandler(metrics.Handler()))
+}
+
+func BankRoutes(router fiber.Router, opts Options) {
+	read := middleware.RequireScope(models.ScopeRead)
+	if opts.PublicReads {
+		read = func(c *fiber.Ctx) error { return c.Next() }
+	}
+	write := middleware.RequireScope(models.ScopeWrite)
+	accept := middleware.Negotiate(responses.MediaTypes...)
+
+	router.Get("/swift-codes", read, accept, handlers.SearchSwiftCodes)
+	router.Get("/swift-codes/:swiftCode", read, accept, handlers.GetSwiftCodesBySwiftCode)
+	router.Get("/swift-codes/country/:countryISO2", read, accept, handlers.GetSwiftCodesByCountryCode)
+	router.Get("/export", read, handlers.ExportSwiftCodes)
+	router.Get("/changes/stream", read, handlers.StreamChanges)
+	router.Post("/swift-codes", write, accept, handlers.AddNewSwiftCode)
+	router.Put("/swift-codes/:swiftCode", write, accept, handlers.UpdateSwiftCode)
+	router.Delete("/swift-codes/:swiftCode", write, accept, handlers.DeleteSwiftCode)
+}
+
+func AdminRoutes(router fiber.Router, opts Options) {
+	// Admin responses are only rendered as JSON
+	admin := router.Group("/admin", middleware.RequireScope(models.ScopeAdmin), middleware.Negotiate(responses.MIMEJSON))
+
+	admin.Get("/api-keys", handlers.ListAPIKeys)
+	admin.Post("/api-keys", handlers.CreateAPIKey)
+	admin.Post("/api-keys/:id/rotate", handlers.RotateAPIKey)
+	admin.Delete("/api-keys/:id", handlers.RevokeAPIKey)
+
+	admin.Get("/webhooks", handlers.ListWebhooks)
+	admin.Post("/webhooks", handlers.CreateWebhook)
+	admin.Get("/webhooks/dead-letters", handlers.ListDeadLetters)
+	admin.Delete("/webhooks/:id", handlers.DeleteWebhook)
+
+	admin.Get("/cache", handlers.GetCacheStats)
+	admin.Delete("/cache", handlers.PurgeCache)
+
+	admin.Get("/imports", handlers.ListImports(opts.Imports))
+	admin.Post("/imports", handlers.CreateImport(opts.ImportSource, opts.ImportDir, opts.Imports))
+	admin.Get("/imports/schedule", handlers.GetImportSchedule(opts.Scheduler))
+	admin.Get("/imports/:id", handlers.GetImport(opts.Imports))
+	admin.Post("/imports/:id/cancel", handlers.CancelImport(opts.Imports))
 }
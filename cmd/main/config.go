@@ -0,0 +1,18 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	"github.com/MarcinZ20/bankAPI/internal/config"
+)
+
+// Prints the configuration loaded from the file and environment as YAML with secrets redacted
+func runConfig(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := newFlagSet("config", "config [flags]")
+	if err := parseFlags(fs, args, cfg); err != nil {
+		return err
+	}
+
+	return cfg.Print(os.Stdout)
+}
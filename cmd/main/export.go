@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/MarcinZ20/bankAPI/internal/config"
+	"github.com/MarcinZ20/bankAPI/internal/export"
+	"github.com/MarcinZ20/bankAPI/internal/repository"
+	"github.com/MarcinZ20/bankAPI/pkg/utils"
+)
+
+// Streams all stored headquarters with their branches as JSON, CSV or NDJSON
+func runExport(ctx context.Context, cfg *config.Config, args []string) (err error) {
+	fs := newFlagSet("export", "export [flags]")
+	format := fs.String("format", export.FormatJSON, "output format: json, csv or ndjson")
+	country := fs.String("country", "", "export only the given ISO2 country code")
+	output := fs.String("output", "-", "output file, - writes to stdout")
+	mongoFlags(fs, &cfg.Mongo)
+	if err := parseFlags(fs, args, cfg); err != nil {
+		return err
+	}
+
+	if !export.IsFormat(*format) {
+		return fmt.Errorf("unknown export format: %s", *format)
+	}
+	if *country != "" && !utils.IsValidCountryCode(*country) {
+		return fmt.Errorf("invalid country code: %s", *country)
+	}
+
+	db, err := connect(ctx, cfg.Mongo, false)
+	if err != nil {
+		return err
+	}
+	defer db.Disconnect(context.Background())
+
+	var w io.Writer = os.Stdout
+	if *output != "-" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer func() {
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+		}()
+		w = file
+	}
+
+	encoder, err := export.NewEncoder(w, *format)
+	if err != nil {
+		return err
+	}
+	err = repository.NewBankRepository(db.Collection).StreamHeadquarters(ctx, *country, encoder.Encode)
+	if err != nil {
+		return fmt.Errorf("failed to export bank data: %w", err)
+	}
+	return encoder.Close()
+}
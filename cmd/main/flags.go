@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/MarcinZ20/bankAPI/internal/config"
+	"github.com/MarcinZ20/bankAPI/internal/database"
+	"github.com/MarcinZ20/bankAPI/internal/importer"
+	"github.com/MarcinZ20/bankAPI/internal/parser"
+	"github.com/MarcinZ20/bankAPI/internal/spreadsheet"
+)
+
+// Creates a flag set for a subcommand, flag errors are returned instead of exiting
+func newFlagSet(cmd string, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(cmd, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: bankapi %s\n\nFlags:\n", usage)
+		fs.PrintDefaults()
+	}
+
+	// Read by configPath before the flags are parsed, registered so parsing accepts it
+	fs.String("config", "", "YAML or TOML configuration file (CONFIG_FILE)")
+	return fs
+}
+
+// Finds the --config flag value in args before any flag set is parsed
+func configPath(args []string) string {
+	for i, arg := range args {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || name != "config" {
+			continue
+		}
+		if hasValue {
+			return value
+		}
+		if i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+// Parses the flags over the loaded configuration and validates the result
+func parseFlags(fs *flag.FlagSet, args []string, cfg *config.Config) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	importer.Timeout = cfg.Import.Timeout
+	spreadsheet.SetDefaultClient(spreadsheet.NewClient(newSpreadsheetConfig(cfg.Import)))
+	return nil
+}
+
+// Registers MongoDB connection flags, defaulting to the loaded configuration
+func mongoFlags(fs *flag.FlagSet, cfg *config.Mongo) {
+	fs.StringVar(&cfg.URI, "mongo-uri", cfg.URI, "MongoDB connection URI (MONGO_URI)")
+	fs.StringVar(&cfg.Database, "mongo-database", cfg.Database, "MongoDB database (MONGO_DATABASE)")
+	fs.StringVar(&cfg.Collection, "mongo-collection", cfg.Collection, "MongoDB bank collection (MONGO_COLLECTION)")
+	fs.DurationVar(&cfg.Timeout, "mongo-timeout", cfg.Timeout, "timeout of every MongoDB operation (MONGO_TIMEOUT)")
+}
+
+// Registers import source flags, defaulting to the loaded configuration
+func sourceFlags(fs *flag.FlagSet, cfg *config.Import) {
+	fs.StringVar(&cfg.Source, "source", cfg.Source, "import source: spreadsheet, sheets, csv, file, swiftref or multi (IMPORT_SOURCE)")
+	fs.StringVar(&cfg.SpreadsheetID, "spreadsheet-id", cfg.SpreadsheetID, "Google Spreadsheet ID for the spreadsheet and sheets sources (SPREADSHEET_ID)")
+	fs.StringVar(&cfg.SheetRange, "sheet-range", cfg.SheetRange, "tab, named range or A1 range read by the sheets source (IMPORT_SHEET_RANGE)")
+	fs.StringVar(&cfg.File, "file", cfg.File, "path of the CSV or JSON file for the csv and file sources (IMPORT_FILE)")
+	fs.DurationVar(&cfg.Timeout, "import-timeout", cfg.Timeout, "timeout of a whole import (IMPORT_TIMEOUT)")
+}
+
+// Returns the import source described by the configuration
+func newSource(cfg config.Import) importer.Source {
+	source := importer.Source{
+		Kind:          cfg.Source,
+		SpreadsheetID: cfg.SpreadsheetID,
+		Range:         cfg.SheetRange,
+		File:          cfg.File,
+		Duplicates:    cfg.Duplicates,
+	}
+	for _, part := range cfg.Sources {
+		source.Sources = append(source.Sources, importer.Source{
+			Kind:          part.Source,
+			Name:          part.Name,
+			SpreadsheetID: part.SpreadsheetID,
+			Range:         part.Range,
+			File:          part.File,
+			Columns:       newColumnMapping(part.Columns),
+		})
+	}
+	return source
+}
+
+// Returns nil when no column is renamed so the part is read by position
+func newColumnMapping(cfg config.Columns) *parser.ColumnMapping {
+	if cfg == (config.Columns{}) {
+		return nil
+	}
+	return &parser.ColumnMapping{
+		CountryISO2: cfg.CountryISO2,
+		SwiftCode:   cfg.SwiftCode,
+		Name:        cfg.Name,
+		Address:     cfg.Address,
+		CountryName: cfg.CountryName,
+		TownName:    cfg.TownName,
+	}
+}
+
+// Connects to MongoDB, creating collections and indexes first when migrate is set
+func connect(ctx context.Context, cfg config.Mongo, migrate bool) (*database.Config, error) {
+	db, err := database.Connect(ctx, newDatabaseSettings(cfg))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	if migrate {
+		if err := db.Migrate(ctx); err != nil {
+			db.Disconnect(ctx)
+			return nil, fmt.Errorf("failed to migrate database: %w", err)
+		}
+	}
+
+	return db, nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+
+	"github.com/MarcinZ20/bankAPI/internal/config"
+	"github.com/MarcinZ20/bankAPI/internal/importer"
+	"github.com/MarcinZ20/bankAPI/internal/scheduler"
+	"github.com/MarcinZ20/bankAPI/internal/services"
+)
+
+// Imports bank data from the configured source into MongoDB
+func runImport(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := newFlagSet("import", "import [flags]")
+	dryRun := fs.Bool("dry-run", false, "print the changes the import would make as JSON without writing anything")
+	fs.BoolVar(&cfg.Mongo.MigrateOnStart, "migrate", cfg.Mongo.MigrateOnStart, "create collections and indexes before importing (MIGRATE_ON_START)")
+	mongoFlags(fs, &cfg.Mongo)
+	sourceFlags(fs, &cfg.Import)
+	if err := parseFlags(fs, args, cfg); err != nil {
+		return err
+	}
+
+	source := newSource(cfg.Import)
+	if err := source.Validate(); err != nil {
+		return err
+	}
+
+	// A dry run writes nothing, not even indexes
+	db, err := connect(ctx, cfg.Mongo, cfg.Mongo.MigrateOnStart && !*dryRun)
+	if err != nil {
+		return err
+	}
+	defer db.Disconnect(context.Background())
+
+	if *dryRun {
+		services.NewServiceManager(db)
+		diff, err := importer.DryRun(ctx, source)
+		if err != nil {
+			return err
+		}
+
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(diff)
+	}
+
+	// Waits for an import a running server may be doing
+	slog.Info("starting data import", "source", source.Kind)
+	if err := scheduler.ImportLocked(ctx, newImportLock(db), source, importer.ImportIncremental); err != nil {
+		return err
+	}
+	slog.Info("data import completed successfully")
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/MarcinZ20/bankAPI/api/responses"
+	"github.com/MarcinZ20/bankAPI/internal/config"
+	"github.com/MarcinZ20/bankAPI/internal/services"
+	"github.com/MarcinZ20/bankAPI/pkg/utils"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Prints the details of a SWIFT code in the API response format
+func runLookup(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := newFlagSet("lookup", "lookup [flags] <swift>")
+	mongoFlags(fs, &cfg.Mongo)
+	if err := parseFlags(fs, args, cfg); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("lookup takes exactly one SWIFT code")
+	}
+
+	swiftCode := strings.ToUpper(fs.Arg(0))
+	if !utils.IsValidSwiftCodeFormat(swiftCode) {
+		return fmt.Errorf("invalid SWIFT code format: %s", swiftCode)
+	}
+
+	db, err := connect(ctx, cfg.Mongo, false)
+	if err != nil {
+		return err
+	}
+	defer db.Disconnect(context.Background())
+
+	bankService := services.NewServiceManager(db).BankService
+
+	var response any
+	if strings.HasSuffix(swiftCode, "XXX") {
+		hq, err := bankService.GetHeadquarter(ctx, swiftCode)
+		if err != nil {
+			return lookupError(err, swiftCode)
+		}
+		hqResponse := new(responses.HeadquarterResponse)
+		if err := hqResponse.FromModel(hq); err != nil {
+			return err
+		}
+		response = hqResponse
+	} else {
+		branch, err := bankService.GetBranch(ctx, swiftCode)
+		if err != nil {
+			return lookupError(err, swiftCode)
+		}
+		branchResponse := new(responses.LongBankResponse)
+		if err := branchResponse.FromModel(branch); err != nil {
+			return err
+		}
+		response = branchResponse
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(response)
+}
+
+func lookupError(err error, swiftCode string) error {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return fmt.Errorf("SWIFT code not found: %s", swiftCode)
+	}
+	return err
+}
@@ -2,95 +2,130 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"io/fs"
+	"log/slog"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
-	"github.com/MarcinZ20/bankAPI/api/routes"
-	"github.com/MarcinZ20/bankAPI/internal/app"
-	"github.com/MarcinZ20/bankAPI/internal/database"
-	"github.com/MarcinZ20/bankAPI/internal/importer"
-	"github.com/MarcinZ20/bankAPI/internal/services"
+	"github.com/MarcinZ20/bankAPI/internal/config"
+	"github.com/MarcinZ20/bankAPI/internal/logging"
+	"github.com/MarcinZ20/bankAPI/internal/tracing"
 	"github.com/joho/godotenv"
 )
 
+// Runs a subcommand with its remaining arguments
+type command struct {
+	name    string
+	usage   string
+	summary string
+	run     func(ctx context.Context, cfg *config.Config, args []string) error
+}
+
+var commands = []command{
+	{name: "serve", usage: "serve [flags]", summary: "Import data and serve the HTTP API (default)", run: runServe},
+	{name: "import", usage: "import [flags]", summary: "Import bank data into MongoDB", run: runImport},
+	{name: "export", usage: "export [flags]", summary: "Export stored bank data as JSON or CSV", run: runExport},
+	{name: "validate", usage: "validate [flags] <file>", summary: "Parse and validate a CSV or JSON file without storing it", run: runValidate},
+	{name: "lookup", usage: "lookup [flags] <swift>", summary: "Print the details of a SWIFT code", run: runLookup},
+	{name: "migrate", usage: "migrate [flags]", summary: "Create MongoDB collections and indexes", run: runMigrate},
+	{name: "config", usage: "config [flags]", summary: "Print the effective configuration with secrets redacted", run: runConfig},
+}
+
 func main() {
-	// Load environment variables
-	if err := godotenv.Load(); err != nil {
-		log.Fatalf("Error loading .env file: %v", err)
+	// Load environment variables, the .env file is optional
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		fatal("error loading .env file", "error", err)
 	}
 
-	// Create a base context with cancellation
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	// Without a subcommand the API server is started
+	name, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
 
-	// Handle graceful shutdown
-	shutdown := make(chan os.Signal, 1)
-	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
+	if name == "help" {
+		usage()
+		return
+	}
 
-	go func() {
-		<-shutdown
-		log.Println("Received shutdown signal. Initiating graceful shutdown...")
-		cancel()
-	}()
+	cmd, ok := findCommand(name)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command: %s\n\n", name)
+		usage()
+		os.Exit(2)
+	}
 
-	// Initialize database
-	db, err := database.Connect(ctx)
+	// Defaults, then the configuration file, then environment variables, flags are applied by the command
+	path := configPath(args)
+	if path == "" {
+		path = os.Getenv("CONFIG_FILE")
+	}
+	cfg, err := config.Load(path)
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		fatal("failed to load configuration", "error", err)
 	}
-	defer db.Disconnect(ctx)
 
-	log.Println("Successfully connected to database")
+	// Configure structured logging, logs go to stderr so command output stays clean
+	if err := logging.Setup(os.Stderr, logging.Config{
+		Level:  cfg.Logging.Level,
+		Format: cfg.Logging.Format,
+	}); err != nil {
+		fatal("failed to configure logging", "error", err)
+	}
 
-	// Initialize services
-	serviceManager := services.NewServiceManager(db)
-	if !serviceManager.IsInitialized() {
-		log.Fatal("Failed to initialize services")
+	// Cancel the context on interrupt so commands can shut down gracefully
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Configure tracing before anything creates spans
+	shutdownTracing, err := tracing.Setup(ctx, newTracingConfig(cfg.Tracing))
+	if err != nil {
+		fatal("failed to initialize tracing", "error", err)
 	}
-	log.Println("Services initialized successfully")
 
-	// Import data from spreadsheet
-	spreadsheetID := os.Getenv("SPREADSHEET_ID")
-	if spreadsheetID == "" {
-		log.Fatal("SPREADSHEET_ID environment variable is not set")
+	err = cmd.run(ctx, &cfg, args)
+	if errors.Is(err, flag.ErrHelp) {
+		err = nil
 	}
 
-	log.Println("Starting data import...")
-	if err := importer.ImportSpreadsheetData(ctx, spreadsheetID); err != nil {
-		log.Fatalf("Failed to import data: %v", err)
+	flushCtx, flushCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer flushCancel()
+	if flushErr := shutdownTracing(flushCtx); flushErr != nil {
+		slog.Error("failed to flush traces", "error", flushErr)
 	}
-	log.Println("Data import completed successfully")
 
-	// Initialize and configure API server
-	appConfig := app.Initialize()
-	routes.BankRoutes(appConfig.Server)
+	if err != nil {
+		fatal(fmt.Sprintf("%s failed", cmd.name), "error", err)
+	}
+}
 
-	serverErrors := make(chan error, 1)
-	go func() {
-		port := os.Getenv("API_SERVER_PORT")
-		if port == "" {
-			port = ":8080"
-		}
-		log.Printf("Starting server on port %s\n", port)
-		if err := appConfig.Server.Listen(port); err != nil {
-			serverErrors <- fmt.Errorf("server error: %w", err)
-		}
-	}()
-
-	select {
-	case err := <-serverErrors:
-		log.Printf("Server error: %v\n", err)
-	case <-ctx.Done():
-		log.Println("Shutting down server...")
-		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer shutdownCancel()
-
-		if err := appConfig.Server.ShutdownWithContext(shutdownCtx); err != nil {
-			log.Printf("Error during server shutdown: %v\n", err)
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
 		}
 	}
+	return command{}, false
+}
+
+// Prints the list of commands
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: bankapi <command> [flags]")
+	fmt.Fprintln(os.Stderr, "\nCommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-26s %s\n", cmd.usage, cmd.summary)
+	}
+	fmt.Fprintln(os.Stderr, "\nRun 'bankapi <command> -h' for the flags of a command.")
+}
+
+// Logs an error and exits
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
 }
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/MarcinZ20/bankAPI/internal/config"
+)
+
+// Creates the MongoDB collections and indexes
+func runMigrate(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := newFlagSet("migrate", "migrate [flags]")
+	mongoFlags(fs, &cfg.Mongo)
+	if err := parseFlags(fs, args, cfg); err != nil {
+		return err
+	}
+
+	db, err := connect(ctx, cfg.Mongo, true)
+	if err != nil {
+		return err
+	}
+	defer db.Disconnect(context.Background())
+
+	slog.Info("database migrated successfully")
+	return nil
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"time"
+
+	"github.com/MarcinZ20/bankAPI/api/grpcapi"
+	"github.com/MarcinZ20/bankAPI/api/middleware"
+	"github.com/MarcinZ20/bankAPI/api/routes"
+	"github.com/MarcinZ20/bankAPI/internal/app"
+	"github.com/MarcinZ20/bankAPI/internal/changes"
+	"github.com/MarcinZ20/bankAPI/internal/config"
+	"github.com/MarcinZ20/bankAPI/internal/database"
+	"github.com/MarcinZ20/bankAPI/internal/health"
+	"github.com/MarcinZ20/bankAPI/internal/importer"
+	"github.com/MarcinZ20/bankAPI/internal/jobs"
+	"github.com/MarcinZ20/bankAPI/internal/repository"
+	"github.com/MarcinZ20/bankAPI/internal/scheduler"
+	"github.com/MarcinZ20/bankAPI/internal/services"
+	"github.com/MarcinZ20/bankAPI/internal/version"
+	"github.com/MarcinZ20/bankAPI/internal/webhooks"
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+	"google.golang.org/grpc"
+)
+
+// Imports the data and serves the HTTP API until ctx is done
+func runServe(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := newFlagSet("serve", "serve [flags]")
+	fs.StringVar(&cfg.Server.Port, "port", cfg.Server.Port, "address the server listens on (API_SERVER_PORT)")
+	fs.StringVar(&cfg.Server.GRPCPort, "grpc-port", cfg.Server.GRPCPort, "address the gRPC API listens on, empty disables it (GRPC_SERVER_PORT)")
+	fs.DurationVar(&cfg.Server.RequestTimeout, "request-timeout", cfg.Server.RequestTimeout, "timeout of a single request (SERVER_REQUEST_TIMEOUT)")
+	fs.StringVar(&cfg.Storage.Mode, "storage", cfg.Storage.Mode, "storage mode: mongo or memory (STORAGE_MODE)")
+	fs.BoolVar(&cfg.Import.OnStart, "import", cfg.Import.OnStart, "import data before serving (IMPORT_ON_START)")
+	fs.BoolVar(&cfg.Mongo.MigrateOnStart, "migrate", cfg.Mongo.MigrateOnStart, "create collections and indexes on start (MIGRATE_ON_START)")
+	fs.StringVar(&cfg.Storage.SnapshotFile, "snapshot-file", cfg.Storage.SnapshotFile, "JSON snapshot loaded instead of the import source in memory mode (SNAPSHOT_FILE)")
+	fs.DurationVar(&cfg.Storage.SnapshotReloadInterval, "snapshot-reload-interval", cfg.Storage.SnapshotReloadInterval, "periodic snapshot reload in memory mode, 0 disables it (SNAPSHOT_RELOAD_INTERVAL)")
+	mongoFlags(fs, &cfg.Mongo)
+	sourceFlags(fs, &cfg.Import)
+	if err := parseFlags(fs, args, cfg); err != nil {
+		return err
+	}
+
+	// Memory mode serves data from process memory without MongoDB
+	memoryMode := cfg.Storage.Mode == "memory"
+
+	// The snapshot file can replace the import source in memory mode
+	source := newSource(cfg.Import)
+	if memoryMode && cfg.Storage.SnapshotFile != "" {
+		source = importer.Source{Kind: importer.SourceFile, File: cfg.Storage.SnapshotFile}
+	}
+	if cfg.Import.OnStart || memoryMode {
+		if err := source.Validate(); err != nil {
+			return err
+		}
+	}
+
+	var db *database.Config
+	var memoryRepo *repository.MemoryRepository
+	var serviceManager *services.ServiceManager
+
+	if memoryMode {
+		memoryRepo = repository.NewMemoryRepository()
+		serviceManager = services.NewServiceManagerWithStore(memoryRepo)
+		slog.Info("running in memory storage mode")
+	} else {
+		var err error
+		if db, err = connect(ctx, cfg.Mongo, cfg.Mongo.MigrateOnStart); err != nil {
+			return err
+		}
+		defer db.Disconnect(context.Background())
+
+		slog.Info("successfully connected to database")
+		serviceManager = services.NewServiceManager(db)
+	}
+
+	// Initialize services
+	if !serviceManager.IsInitialized() {
+		return fmt.Errorf("failed to initialize services")
+	}
+	slog.Info("services initialized successfully")
+
+	// Put a read-through cache in front of the repository
+	if cfg.Cache.Enabled {
+		serviceManager.BankService.UseCache(newCacheConfig(cfg.Cache))
+	}
+
+	// Publish every change to the stream endpoint, and to the registered webhooks when MongoDB holds them
+	feed := changes.NewFeed(changes.Config{BufferSize: cfg.Changes.BufferSize})
+	serviceManager.BankService.UseChangeFeed(feed)
+
+	var dispatcher *webhooks.Dispatcher
+	if serviceManager.WebhookService != nil {
+		dispatcher = webhooks.New(newWebhooksConfig(cfg.Webhooks, serviceManager.WebhookService))
+		if err := dispatcher.Refresh(ctx); err != nil {
+			return err
+		}
+		go dispatcher.Run(ctx)
+	}
+
+	// With the outbox, webhooks and the other sinks receive the events stored with the writes,
+	// otherwise webhooks receive the events as they are published
+	switch {
+	case db != nil && db.Outbox != nil:
+		if err := db.SupportsTransactions(ctx); err != nil {
+			return fmt.Errorf("the outbox is enabled: %w", err)
+		}
+		relay, err := newOutboxRelay(cfg.Outbox, db, dispatcher)
+		if err != nil {
+			return err
+		}
+		slog.Info("outbox relay enabled", "sinks", cfg.Outbox.Sinks)
+		go relay.Run(ctx)
+	case dispatcher != nil:
+		feed.AddListener(dispatcher.Enqueue)
+	}
+
+	// Bootstrap the admin API key so the key management endpoints are reachable
+	if adminKey := cfg.Auth.AdminAPIKey; adminKey != "" && serviceManager.APIKeyService != nil {
+		if err := serviceManager.APIKeyService.EnsureKey(ctx, "bootstrap-admin", adminKey, []string{models.ScopeAdmin}); err != nil {
+			return fmt.Errorf("failed to bootstrap admin API key: %w", err)
+		}
+	}
+
+	// Configure bearer token verification when a JWKS source is set
+	tokenVerifier, err := newTokenVerifier(ctx, cfg.Auth.JWT)
+	if err != nil {
+		return fmt.Errorf("failed to initialize token verifier: %w", err)
+	}
+
+	// Configure per-client rate limiting
+	rateLimiter, err := newRateLimiter(cfg.RateLimit, db)
+	if err != nil {
+		return fmt.Errorf("failed to initialize rate limiter: %w", err)
+	}
+
+	// Readiness requires a finished import and a reachable database
+	checker := health.NewChecker(version.Version, version.Commit, 2*time.Second)
+	if db != nil {
+		checker.AddCheck("mongodb", db.Ping)
+	}
+
+	// Memory mode swaps in whole snapshots, MongoDB mode writes only the changed documents
+	var loader *importer.SnapshotLoader
+	apply := importer.ImportIncremental
+	if memoryMode {
+		loader = &importer.SnapshotLoader{Repo: memoryRepo, Source: source}
+		apply = loader.Apply
+	}
+
+	// Scheduled imports, import jobs and the import on start take turns, on every replica sharing MongoDB
+	importLock := newImportLock(db)
+	runImport := func(ctx context.Context, source importer.Source) error {
+		return scheduler.ImportLocked(ctx, importLock, source, apply)
+	}
+
+	// Re-import the source in the background when a schedule is set
+	importScheduler, err := newScheduler(cfg.Import.Schedule, source, importLock, apply)
+	if err != nil {
+		return err
+	}
+
+	// A nil *APIKeyService must not end up in the interface, API keys are unavailable without MongoDB
+	var apiKeys middleware.KeyAuthenticator
+	if serviceManager.APIKeyService != nil {
+		apiKeys = serviceManager.APIKeyService
+	}
+
+	// Initialize and configure API server
+	appConfig := app.Initialize(app.Options{
+		ReadTimeout:    cfg.Server.ReadTimeout,
+		RequestTimeout: cfg.Server.RequestTimeout,
+	})
+	routes.Register(appConfig.Server, routes.Options{
+		Auth: middleware.AuthConfig{
+			APIKeys: apiKeys,
+			Tokens:  tokenVerifier,
+		},
+		PublicReads:  cfg.Auth.PublicReads,
+		RateLimiter:  rateLimiter,
+		ImportSource: source,
+		ImportDir:    cfg.Import.RequestDir,
+		Imports:      jobs.NewManager(jobs.Config{Run: runImport, DryRun: importer.DryRun}),
+		Scheduler:    importScheduler,
+	})
+
+	serverErrors := make(chan error, 2)
+	go func() {
+		slog.Info("starting server", "port", cfg.Server.Port)
+		if err := appConfig.Server.Listen(cfg.Server.Port); err != nil {
+			serverErrors <- fmt.Errorf("server error: %w", err)
+		}
+	}()
+
+	// Serve the gRPC API from the same process on its own port
+	var grpcServer *grpc.Server
+	if cfg.Server.GRPCPort != "" {
+		listener, err := net.Listen("tcp", cfg.Server.GRPCPort)
+		if err != nil {
+			return fmt.Errorf("failed to listen for gRPC: %w", err)
+		}
+		grpcServer = grpcapi.NewServer(grpcapi.Options{
+			Auth: middleware.AuthConfig{
+				APIKeys: apiKeys,
+				Tokens:  tokenVerifier,
+			},
+			PublicReads: cfg.Auth.PublicReads,
+			RateLimiter: rateLimiter,
+		})
+		go func() {
+			slog.Info("starting gRPC server", "port", cfg.Server.GRPCPort)
+			if err := grpcServer.Serve(listener); err != nil {
+				serverErrors <- fmt.Errorf("gRPC server error: %w", err)
+			}
+		}()
+	}
+
+	// Import data, the server reports not ready until this finishes
+	switch {
+	case memoryMode:
+		slog.Info("starting data import", "source", source.Kind)
+		if err := loader.Reload(ctx); err != nil {
+			return fmt.Errorf("failed to import data: %w", err)
+		}
+		go loader.Watch(ctx, cfg.Storage.SnapshotReloadInterval)
+		slog.Info("data import completed successfully")
+	case cfg.Import.OnStart:
+		// Replicas starting together take turns, each one writes only what the previous one left different
+		slog.Info("starting data import", "source", source.Kind)
+		if err := runImport(ctx, source); err != nil {
+			return fmt.Errorf("failed to import data: %w", err)
+		}
+		slog.Info("data import completed successfully")
+	default:
+		// The data is already in MongoDB, serve it right away
+		checker.SkipImport()
+	}
+
+	if importScheduler != nil {
+		slog.Info("scheduled imports enabled", "schedule", cfg.Import.Schedule)
+		go importScheduler.Run(ctx)
+	}
+
+	select {
+	case err := <-serverErrors:
+		return err
+	case <-ctx.Done():
+		slog.Info("shutting down server")
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+		defer shutdownCancel()
+
+		// Change streams never finish on their own, closing the feed ends them
+		feed.Close()
+
+		if err := appConfig.Server.ShutdownWithContext(shutdownCtx); err != nil {
+			slog.Error("error during server shutdown", "error", err)
+		}
+		if grpcServer != nil {
+			stopGRPC(shutdownCtx, grpcServer)
+		}
+	}
+
+	return nil
+}
+
+// Lets running calls finish, streams still open when ctx is done are cut off
+func stopGRPC(ctx context.Context, server *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		slog.Error("gRPC server did not stop in time, closing open calls")
+		server.Stop()
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MarcinZ20/bankAPI/api/middleware"
+	"github.com/MarcinZ20/bankAPI/internal/auth"
+	"github.com/MarcinZ20/bankAPI/internal/cache"
+	"github.com/MarcinZ20/bankAPI/internal/config"
+	"github.com/MarcinZ20/bankAPI/internal/database"
+	"github.com/MarcinZ20/bankAPI/internal/importer"
+	"github.com/MarcinZ20/bankAPI/internal/outbox"
+	"github.com/MarcinZ20/bankAPI/internal/ratelimit"
+	"github.com/MarcinZ20/bankAPI/internal/repository"
+	"github.com/MarcinZ20/bankAPI/internal/scheduler"
+	"github.com/MarcinZ20/bankAPI/internal/spreadsheet"
+	"github.com/MarcinZ20/bankAPI/internal/tracing"
+	"github.com/MarcinZ20/bankAPI/internal/webhooks"
+)
+
+// Creates a JWT verifier, returns nil when no JWKS source is configured
+func newTokenVerifier(ctx context.Context, cfg config.JWT) (middleware.TokenVerifier, error) {
+	verifierConfig := auth.VerifierConfig{
+		JWKSFile:    cfg.JWKSFile,
+		JWKSURL:     cfg.JWKSURL,
+		Issuer:      cfg.Issuer,
+		Audience:    cfg.Audience,
+		RoleClaim:   cfg.RoleClaim,
+		RoleMapping: map[string]string{},
+		JWKSMaxAge:  time.Hour,
+	}
+
+	if verifierConfig.JWKSFile == "" && verifierConfig.JWKSURL == "" {
+		return nil, nil
+	}
+
+	// The role mapping maps claim values to roles, e.g. "bankapi-admins=admin,bankapi-editors=editor"
+	for _, pair := range strings.Split(cfg.RoleMapping, ",") {
+		value, role, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if !found {
+			continue
+		}
+		if !auth.IsValidRole(role) {
+			return nil, fmt.Errorf("unknown role in JWT role mapping: %s", role)
+		}
+		verifierConfig.RoleMapping[value] = role
+	}
+
+	verifier, err := auth.NewVerifier(ctx, verifierConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return verifier, nil
+}
+
+// Creates a rate limiter, returns nil when rate limiting is disabled
+func newRateLimiter(cfg config.RateLimit, db *database.Config) (*ratelimit.Limiter, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	var store ratelimit.Store
+	switch cfg.Store {
+	case "memory":
+		store = ratelimit.NewMemoryStore()
+	case "mongo":
+		if db == nil {
+			return nil, fmt.Errorf("the mongo rate limit store requires MongoDB storage")
+		}
+		store = ratelimit.NewMongoStore(db.RateLimits)
+	default:
+		return nil, fmt.Errorf("unknown rate limit store: %s", cfg.Store)
+	}
+
+	read := ratelimit.Limit{Rate: cfg.ReadRPS, Burst: cfg.ReadBurst}
+	write := ratelimit.Limit{Rate: cfg.WriteRPS, Burst: cfg.WriteBurst}
+	ip := ratelimit.Limit{Rate: cfg.IPRPS, Burst: cfg.IPBurst}
+
+	return ratelimit.NewLimiter(store, read, write, ip, cfg.DailyQuota), nil
+}
+
+// Maps the tracing settings to the tracing package configuration
+func newTracingConfig(cfg config.Tracing) tracing.Config {
+	return tracing.Config{
+		Exporter:    cfg.Exporter,
+		ServiceName: cfg.ServiceName,
+		SampleRatio: cfg.SampleRatio,
+	}
+}
+
+// Maps the cache settings to the cache package configuration
+func newCacheConfig(cfg config.Cache) cache.Config {
+	return cache.Config{
+		Size:        cfg.Size,
+		TTL:         cfg.TTL,
+		NegativeTTL: cfg.NegativeTTL,
+	}
+}
+
+// Maps the MongoDB settings to the database package settings
+func newDatabaseSettings(cfg config.Mongo) database.Settings {
+	return database.Settings{
+		URI:                   cfg.URI,
+		Database:              cfg.Database,
+		Collection:            cfg.Collection,
+		APIKeysCollection:     cfg.APIKeysCollection,
+		RateLimitsCollection:  cfg.RateLimitsCollection,
+		LocksCollection:       cfg.LocksCollection,
+		WebhooksCollection:    cfg.WebhooksCollection,
+		DeadLettersCollection: cfg.DeadLettersCollection,
+		OutboxCollection:      cfg.OutboxCollection,
+		ConnectTimeout:        cfg.ConnectTimeout,
+		Timeout:               cfg.Timeout,
+	}
+}
+
+// Creates the outbox relay with the configured sinks, the webhook sink delivers through dispatcher
+func newOutboxRelay(cfg config.Outbox, db *database.Config, dispatcher *webhooks.Dispatcher) (*outbox.Relay, error) {
+	var sinks []outbox.Sink
+	for _, name := range cfg.SinkNames() {
+		switch name {
+		case outbox.SinkWebhook:
+			if dispatcher == nil {
+				return nil, fmt.Errorf("the webhook sink needs the webhooks collection")
+			}
+			sinks = append(sinks, outbox.NewWebhookSink(dispatcher))
+		case outbox.SinkFile:
+			sink, err := outbox.NewFileSink(cfg.File)
+			if err != nil {
+				return nil, err
+			}
+			sinks = append(sinks, sink)
+		case outbox.SinkStdout:
+			sinks = append(sinks, outbox.NewStdoutSink())
+		default:
+			return nil, fmt.Errorf("unknown outbox sink: %s", name)
+		}
+	}
+
+	return outbox.New(outbox.Config{
+		Store:        repository.NewOutbox(db.Outbox),
+		Sinks:        sinks,
+		Lock:         scheduler.NewMongoLock(db.Locks, "outbox"),
+		PollInterval: cfg.PollInterval,
+		BatchSize:    cfg.BatchSize,
+	})
+}
+
+// Maps the webhook settings to the webhooks package configuration
+func newWebhooksConfig(cfg config.Webhooks, store webhooks.Store) webhooks.Config {
+	return webhooks.Config{
+		Store:           store,
+		Timeout:         cfg.Timeout,
+		MaxAttempts:     cfg.MaxAttempts,
+		RetryBackoff:    cfg.RetryBackoff,
+		QueueSize:       cfg.QueueSize,
+		RefreshInterval: cfg.RefreshInterval,
+	}
+}
+
+// Creates the import scheduler, returns nil when no schedule is set
+func newScheduler(schedule string, source importer.Source, lock scheduler.Lock, apply scheduler.ApplyFunc) (*scheduler.Scheduler, error) {
+	if schedule == "" {
+		return nil, nil
+	}
+
+	return scheduler.New(scheduler.Config{
+		Schedule: schedule,
+		Source:   source,
+		Lock:     lock,
+		Apply:    apply,
+	})
+}
+
+// Creates the lock every import takes. Replicas sharing MongoDB take turns, in memory mode each one keeps its own data.
+func newImportLock(db *database.Config) scheduler.Lock {
+	if db != nil {
+		return scheduler.NewMongoLock(db.Locks, "import")
+	}
+	return scheduler.NewLocalLock()
+}
+
+// Maps the import settings to spreadsheet download settings
+func newSpreadsheetConfig(cfg config.Import) spreadsheet.Config {
+	return spreadsheet.Config{
+		BaseURL:       cfg.SpreadsheetBaseURL,
+		Timeout:       cfg.FetchTimeout,
+		MaxRetries:    cfg.FetchRetries,
+		RetryDelay:    cfg.FetchRetryDelay,
+		MaxRetryDelay: cfg.FetchMaxRetryDelay,
+		MaxBodySize:   cfg.MaxDownloadSize,
+		CacheDir:      cfg.CacheDir,
+
+		SheetsEndpoint:  cfg.SheetsEndpoint,
+		CredentialsFile: cfg.CredentialsFile,
+	}
+}
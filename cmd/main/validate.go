@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/MarcinZ20/bankAPI/internal/config"
+	"github.com/MarcinZ20/bankAPI/internal/importer"
+)
+
+// Parses and validates a CSV or JSON snapshot file without storing anything
+func runValidate(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := newFlagSet("validate", "validate [flags] <file>")
+	kind := fs.String("source", "", "file format: csv, file or swiftref, detected from the extension by default")
+	if err := parseFlags(fs, args, cfg); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("validate takes exactly one file")
+	}
+
+	source := importer.Source{Kind: *kind, File: fs.Arg(0)}
+	if source.Kind == "" {
+		switch ext := filepath.Ext(source.File); {
+		case strings.EqualFold(ext, ".json"):
+			source.Kind = importer.SourceFile
+		case strings.EqualFold(ext, ".txt"):
+			source.Kind = importer.SourceSWIFTRef
+		default:
+			source.Kind = importer.SourceCSV
+		}
+	}
+	if source.Kind != importer.SourceCSV && source.Kind != importer.SourceFile && source.Kind != importer.SourceSWIFTRef {
+		return fmt.Errorf("validate only supports the csv, file and swiftref sources")
+	}
+
+	data, err := source.Load(ctx)
+	if err != nil {
+		return err
+	}
+
+	branches := 0
+	for _, hq := range *data {
+		branches += len(hq.Branches)
+	}
+	fmt.Printf("%s: %d headquarters, %d branches\n", source.File, len(*data), branches)
+
+	return nil
+}
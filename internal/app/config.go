@@ -13,10 +13,18 @@ type Config struct {
 	Server *fiber.App
 }
 
+// Holds HTTP server timeouts
+type Options struct {
+	// Limits reading a whole request
+	ReadTimeout time.Duration
+	// Limits the request context passed to handlers
+	RequestTimeout time.Duration
+}
+
 var instance *Config
 
 // Sets up the application configuration
-func Initialize() *Config {
+func Initialize(options Options) *Config {
 	if instance != nil {
 		return instance
 	}
@@ -27,11 +35,15 @@ func Initialize() *Config {
 		StrictRouting: true,
 		JSONEncoder:   json.Marshal,
 		JSONDecoder:   json.Unmarshal,
-		ReadTimeout:   5 * time.Second,
+		ReadTimeout:   options.ReadTimeout,
 	}
 
 	server := fiber.New(fiberConfig)
-	server.Use(middleware.WithTimeout(5 * time.Second))
+	server.Use(middleware.Metrics())
+	server.Use(middleware.Tracing())
+	server.Use(middleware.RequestID())
+	server.Use(middleware.AccessLog())
+	server.Use(middleware.WithTimeout(options.RequestTimeout))
 
 	instance = &Config{
 		Server: server,
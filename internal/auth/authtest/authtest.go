@@ -0,0 +1,19 @@
+// Package authtest provides credential fakes for tests of the APIs.
+package authtest
+
+import (
+	"context"
+
+	"github.com/MarcinZ20/bankAPI/internal/auth"
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+)
+
+// Authenticates the raw API keys it maps to stored keys, every other key is invalid
+type APIKeys map[string]*models.APIKey
+
+func (k APIKeys) Authenticate(_ context.Context, rawKey string) (*models.APIKey, error) {
+	if key, ok := k[rawKey]; ok {
+		return key, nil
+	}
+	return nil, auth.ErrInvalidCredentials
+}
@@ -0,0 +1,253 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Keys are not refetched more often than this when an unknown key ID shows up
+const minRefreshInterval = time.Minute
+
+// JSON Web Key as defined in RFC 7517, limited to the fields needed for RSA and EC public keys
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+// Holds the public keys used to verify token signatures
+type KeySet struct {
+	mu          sync.RWMutex
+	keys        map[string]crypto.PublicKey
+	fetchedAt   time.Time
+	maxAge      time.Duration
+	lastAttempt time.Time
+	load        func(ctx context.Context) ([]byte, error)
+}
+
+// Creates a key set backed by a JWKS file on disk
+func NewFileKeySet(path string) *KeySet {
+	return &KeySet{
+		load: func(context.Context) ([]byte, error) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read JWKS file: %w", err)
+			}
+			return data, nil
+		},
+	}
+}
+
+// Creates a key set backed by a JWKS endpoint, keys are refetched after maxAge
+func NewURLKeySet(url string, client *http.Client, maxAge time.Duration) *KeySet {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &KeySet{
+		maxAge: maxAge,
+		load: func(ctx context.Context) ([]byte, error) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			if err != nil {
+				return nil, fmt.Errorf("error creating request: %w", err)
+			}
+
+			response, err := client.Do(req)
+			if err != nil {
+				return nil, fmt.Errorf("error while fetching JWKS: %w", err)
+			}
+			defer response.Body.Close()
+
+			if response.StatusCode != http.StatusOK {
+				return nil, fmt.Errorf("unexpected JWKS response status: %s", response.Status)
+			}
+
+			return io.ReadAll(io.LimitReader(response.Body, 1<<20))
+		},
+	}
+}
+
+// Loads the keys from the underlying source
+func (s *KeySet) Refresh(ctx context.Context) error {
+	s.mu.Lock()
+	s.lastAttempt = time.Now()
+	s.mu.Unlock()
+
+	data, err := s.load(ctx)
+	if err != nil {
+		return err
+	}
+
+	keys, err := ParseJWKS(data)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.keys = keys
+	s.fetchedAt = time.Now()
+	s.mu.Unlock()
+
+	return nil
+}
+
+// Returns the key with the given ID, refreshing the set when it is stale or the key is unknown.
+// An empty key ID is accepted only when the set holds exactly one key.
+func (s *KeySet) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	if key, ok := s.lookup(kid); ok && !s.isStale() {
+		return key, nil
+	}
+
+	if s.canRefresh() {
+		if err := s.Refresh(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	key, ok := s.lookup(kid)
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key: %q", kid)
+	}
+
+	return key, nil
+}
+
+func (s *KeySet) lookup(kid string) (crypto.PublicKey, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true
+		}
+	}
+
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+func (s *KeySet) isStale() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.maxAge > 0 && time.Since(s.fetchedAt) > s.maxAge
+}
+
+func (s *KeySet) canRefresh() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.keys == nil || time.Since(s.lastAttempt) > minRefreshInterval
+}
+
+// Parses a JWKS document into public keys indexed by key ID.
+// Keys of unsupported types or curves and malformed keys are skipped, so a provider publishing keys
+// this service cannot use does not break verification with the ones it can.
+func ParseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var set jsonWebKeySet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid JWKS document: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for i, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.publicKey()
+		if err != nil {
+			slog.Warn("skipping unusable JWKS key", "index", i, "kid", jwk.Kid, "error", err)
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("invalid JWKS document: no usable signing keys found")
+	}
+
+	return keys, nil
+}
+
+// Converts a JSON Web Key into an RSA or ECDSA public key
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus: %w", err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent: %w", err)
+		}
+		if !e.IsInt64() || e.Int64() < 2 || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("invalid exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve: %q", k.Crv)
+		}
+
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid x coordinate: %w", err)
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid y coordinate: %w", err)
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("point is not on curve %s", k.Crv)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported key type: %q", k.Kty)
+	}
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	if value == "" {
+		return nil, fmt.Errorf("value is empty")
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(data), nil
+}
@@ -0,0 +1,155 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Wrapped by credential checks when the credentials are wrong, as opposed to the check itself failing
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// Holds bearer token verification settings
+type VerifierConfig struct {
+	JWKSFile    string
+	JWKSURL     string
+	JWKSMaxAge  time.Duration
+	Issuer      string
+	Audience    string
+	RoleClaim   string
+	RoleMapping map[string]string
+	Leeway      time.Duration
+}
+
+// Describes the caller identified by a verified token
+type Identity struct {
+	Subject string
+	Roles   []string
+}
+
+// Returns the permission scopes granted by the identity roles
+func (i *Identity) Scopes() []string {
+	return ScopesForRoles(i.Roles)
+}
+
+// Validates RS256 and ES256 signed JWTs against a JWKS
+type Verifier struct {
+	keys        *KeySet
+	parser      *jwt.Parser
+	roleClaim   string
+	roleMapping map[string]string
+}
+
+// Creates a new verifier and loads its signing keys
+func NewVerifier(ctx context.Context, config VerifierConfig) (*Verifier, error) {
+	if config.Issuer == "" || config.Audience == "" {
+		return nil, fmt.Errorf("issuer and audience must be configured")
+	}
+
+	var keys *KeySet
+	switch {
+	case config.JWKSFile != "":
+		keys = NewFileKeySet(config.JWKSFile)
+	case config.JWKSURL != "":
+		keys = NewURLKeySet(config.JWKSURL, nil, config.JWKSMaxAge)
+	default:
+		return nil, fmt.Errorf("either a JWKS file or a JWKS URL must be configured")
+	}
+
+	if err := keys.Refresh(ctx); err != nil {
+		return nil, fmt.Errorf("failed to load signing keys: %w", err)
+	}
+
+	return newVerifier(keys, config), nil
+}
+
+func newVerifier(keys *KeySet, config VerifierConfig) *Verifier {
+	roleClaim := config.RoleClaim
+	if roleClaim == "" {
+		roleClaim = "roles"
+	}
+
+	parser := jwt.NewParser(
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg()}),
+		jwt.WithIssuer(config.Issuer),
+		jwt.WithAudience(config.Audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(config.Leeway),
+	)
+
+	return &Verifier{
+		keys:        keys,
+		parser:      parser,
+		roleClaim:   roleClaim,
+		roleMapping: config.RoleMapping,
+	}
+}
+
+// Verifies the token signature and claims and resolves the caller roles
+func (v *Verifier) Verify(ctx context.Context, rawToken string) (*Identity, error) {
+	claims := jwt.MapClaims{}
+
+	_, err := v.parser.ParseWithClaims(rawToken, claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		return v.keys.Key(ctx, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+
+	subject, err := claims.GetSubject()
+	if err != nil || subject == "" {
+		return nil, fmt.Errorf("invalid token: missing subject")
+	}
+
+	return &Identity{
+		Subject: subject,
+		Roles:   v.mapRoles(claimValues(claims, v.roleClaim)),
+	}, nil
+}
+
+// Translates claim values into known roles, unknown values are dropped
+func (v *Verifier) mapRoles(values []string) []string {
+	roles := []string{}
+	for _, value := range values {
+		role := value
+		if mapped, ok := v.roleMapping[value]; ok {
+			role = mapped
+		}
+		if IsValidRole(role) {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// Reads a string or string list claim, nested claims are addressed with dots (e.g. "realm_access.roles")
+func claimValues(claims jwt.MapClaims, path string) []string {
+	var current any = map[string]any(claims)
+	for _, part := range strings.Split(path, ".") {
+		object, ok := current.(map[string]any)
+		if !ok {
+			return nil
+		}
+		current = object[part]
+	}
+
+	switch value := current.(type) {
+	case string:
+		return strings.Fields(value)
+	case []any:
+		values := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
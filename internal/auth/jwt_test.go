@@ -0,0 +1,224 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testIssuer   = "https://issuer.test"
+	testAudience = "bankapi"
+)
+
+func encodeBigInt(value *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(value.Bytes())
+}
+
+// Writes a JWKS file with the public parts of the given keys
+func writeJWKS(t *testing.T, rsaKey *rsa.PrivateKey, ecKey *ecdsa.PrivateKey) string {
+	t.Helper()
+
+	set := jsonWebKeySet{Keys: []jsonWebKey{
+		{
+			Kty: "RSA",
+			Kid: "rsa-1",
+			Use: "sig",
+			Alg: "RS256",
+			N:   encodeBigInt(rsaKey.N),
+			E:   encodeBigInt(big.NewInt(int64(rsaKey.E))),
+		},
+		{
+			Kty: "EC",
+			Kid: "ec-1",
+			Use: "sig",
+			Alg: "ES256",
+			Crv: "P-256",
+			X:   encodeBigInt(ecKey.X),
+			Y:   encodeBigInt(ecKey.Y),
+		},
+	}}
+
+	data, err := json.Marshal(set)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+
+	return path
+}
+
+func signToken(t *testing.T, method jwt.SigningMethod, kid string, key any, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+
+	return signed
+}
+
+func validClaims(roles ...string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":   testIssuer,
+		"aud":   testAudience,
+		"sub":   "user-1",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"roles": roles,
+	}
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	verifier, err := NewVerifier(context.Background(), VerifierConfig{
+		JWKSFile:    writeJWKS(t, rsaKey, ecKey),
+		Issuer:      testIssuer,
+		Audience:    testAudience,
+		RoleMapping: map[string]string{"bankapi-admins": RoleAdmin},
+	})
+	require.NoError(t, err)
+
+	expired := validClaims(RoleReader)
+	expired["exp"] = time.Now().Add(-time.Hour).Unix()
+
+	wrongIssuer := validClaims(RoleReader)
+	wrongIssuer["iss"] = "https://evil.test"
+
+	wrongAudience := validClaims(RoleReader)
+	wrongAudience["aud"] = "other-service"
+
+	noExpiry := validClaims(RoleReader)
+	delete(noExpiry, "exp")
+
+	tests := []struct {
+		name           string
+		token          string
+		wantErr        bool
+		expectedRoles  []string
+		expectedScopes []string
+	}{
+		{
+			name:           "Valid RS256 token",
+			token:          signToken(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, validClaims(RoleReader)),
+			expectedRoles:  []string{RoleReader},
+			expectedScopes: []string{models.ScopeRead},
+		},
+		{
+			name:           "Valid ES256 token",
+			token:          signToken(t, jwt.SigningMethodES256, "ec-1", ecKey, validClaims(RoleEditor)),
+			expectedRoles:  []string{RoleEditor},
+			expectedScopes: []string{models.ScopeRead, models.ScopeWrite},
+		},
+		{
+			name:           "Mapped and unknown claim values",
+			token:          signToken(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, validClaims("bankapi-admins", "superuser")),
+			expectedRoles:  []string{RoleAdmin},
+			expectedScopes: []string{models.ScopeAdmin},
+		},
+		{
+			name:    "Expired token",
+			token:   signToken(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, expired),
+			wantErr: true,
+		},
+		{
+			name:    "Missing expiry",
+			token:   signToken(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, noExpiry),
+			wantErr: true,
+		},
+		{
+			name:    "Wrong issuer",
+			token:   signToken(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, wrongIssuer),
+			wantErr: true,
+		},
+		{
+			name:    "Wrong audience",
+			token:   signToken(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, wrongAudience),
+			wantErr: true,
+		},
+		{
+			name:    "Signed with unknown key",
+			token:   signToken(t, jwt.SigningMethodRS256, "rsa-1", otherKey, validClaims(RoleReader)),
+			wantErr: true,
+		},
+		{
+			name:    "Unsupported algorithm",
+			token:   signToken(t, jwt.SigningMethodHS256, "rsa-1", []byte("secret"), validClaims(RoleAdmin)),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, err := verifier.Verify(context.Background(), tt.token)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, "user-1", identity.Subject)
+			assert.Equal(t, tt.expectedRoles, identity.Roles)
+			assert.Equal(t, tt.expectedScopes, identity.Scopes())
+		})
+	}
+}
+
+func TestClaimValues(t *testing.T) {
+	claims := jwt.MapClaims{
+		"scope":        "reader editor",
+		"realm_access": map[string]any{"roles": []any{"admin", 42}},
+	}
+
+	assert.Equal(t, []string{"reader", "editor"}, claimValues(claims, "scope"))
+	assert.Equal(t, []string{"admin"}, claimValues(claims, "realm_access.roles"))
+	assert.Nil(t, claimValues(claims, "missing.path"))
+}
+
+func TestParseJWKS_SkipsUnsupportedKeys(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	set := jsonWebKeySet{Keys: []jsonWebKey{
+		{Kty: "OKP", Kid: "ed-1", Crv: "Ed25519", X: "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"},
+		{Kty: "EC", Kid: "ec-k", Crv: "secp256k1", X: "AQ", Y: "AQ"},
+		{Kty: "RSA", Kid: "rsa-1", N: encodeBigInt(rsaKey.N), E: encodeBigInt(big.NewInt(int64(rsaKey.E)))},
+	}}
+	data, err := json.Marshal(set)
+	require.NoError(t, err)
+
+	keys, err := ParseJWKS(data)
+	require.NoError(t, err)
+	assert.Len(t, keys, 1)
+	assert.Contains(t, keys, "rsa-1")
+
+	set.Keys = set.Keys[:2]
+	data, err = json.Marshal(set)
+	require.NoError(t, err)
+
+	_, err = ParseJWKS(data)
+	assert.ErrorContains(t, err, "no usable signing keys")
+}
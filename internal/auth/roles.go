@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"slices"
+
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+)
+
+// Roles that can be granted through a token claim
+const (
+	RoleReader = "reader"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+var roleScopes = map[string][]string{
+	RoleReader: {models.ScopeRead},
+	RoleEditor: {models.ScopeRead, models.ScopeWrite},
+	RoleAdmin:  {models.ScopeAdmin},
+}
+
+// Checks if the role is a known role
+func IsValidRole(role string) bool {
+	_, ok := roleScopes[role]
+	return ok
+}
+
+// Returns the permission scopes granted by a list of roles
+func ScopesForRoles(roles []string) []string {
+	scopes := []string{}
+	for _, role := range roles {
+		for _, scope := range roleScopes[role] {
+			if !slices.Contains(scopes, scope) {
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+	return scopes
+}
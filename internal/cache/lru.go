@@ -0,0 +1,127 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+type entry[V any] struct {
+	key       string
+	value     V
+	expiresAt time.Time
+}
+
+// Fixed size least recently used cache with per-entry expiry, safe for concurrent use
+type LRU[V any] struct {
+	mu       sync.Mutex
+	capacity int
+	items    map[string]*list.Element
+	order    *list.List
+	now      func() time.Time
+
+	evictions uint64
+}
+
+// Creates a new LRU cache holding at most capacity entries
+func NewLRU[V any](capacity int) *LRU[V] {
+	return &LRU[V]{
+		capacity: max(capacity, 1),
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+		now:      time.Now,
+	}
+}
+
+// Returns the value stored under key if present and not expired
+func (c *LRU[V]) Get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero V
+	element, ok := c.items[key]
+	if !ok {
+		return zero, false
+	}
+
+	e := element.Value.(*entry[V])
+	if !c.now().Before(e.expiresAt) {
+		c.removeElement(element)
+		return zero, false
+	}
+
+	c.order.MoveToFront(element)
+	return e.value, true
+}
+
+// Stores a value under key for the given time, evicting the least recently used entry when full
+func (c *LRU[V]) Set(key string, value V, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := c.now().Add(ttl)
+	if element, ok := c.items[key]; ok {
+		e := element.Value.(*entry[V])
+		e.value = value
+		e.expiresAt = expiresAt
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&entry[V]{key: key, value: value, expiresAt: expiresAt})
+
+	for c.order.Len() > c.capacity {
+		c.removeElement(c.order.Back())
+		c.evictions++
+	}
+}
+
+// Removes the entry stored under key
+func (c *LRU[V]) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.items[key]; ok {
+		c.removeElement(element)
+	}
+}
+
+// Removes every entry whose key matches the predicate
+func (c *LRU[V]) DeleteFunc(match func(key string) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, element := range c.items {
+		if match(key) {
+			c.removeElement(element)
+		}
+	}
+}
+
+// Removes all entries
+func (c *LRU[V]) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.items = make(map[string]*list.Element)
+	c.order.Init()
+}
+
+// Returns the number of stored entries, including expired ones not yet removed
+func (c *LRU[V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// Returns the number of entries evicted to make room for new ones
+func (c *LRU[V]) Evictions() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.evictions
+}
+
+func (c *LRU[V]) removeElement(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.items, element.Value.(*entry[V]).key)
+}
@@ -0,0 +1,280 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/MarcinZ20/bankAPI/internal/repository"
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Holds cache settings
+type Config struct {
+	Size        int
+	TTL         time.Duration
+	NegativeTTL time.Duration
+}
+
+// Snapshot of cache counters
+type Stats struct {
+	Hits          uint64 `json:"hits"`
+	Misses        uint64 `json:"misses"`
+	NegativeHits  uint64 `json:"negativeHits"`
+	Evictions     uint64 `json:"evictions"`
+	Invalidations uint64 `json:"invalidations"`
+	Entries       int    `json:"entries"`
+}
+
+// Cached outcome of a lookup, err is set for negative results
+type lookup struct {
+	hq       *models.Headquarter
+	branch   *models.Branch
+	banks    []models.Headquarter
+	entities []models.BankEntity
+	err      error
+}
+
+// Read-through cache in front of a bank store.
+// Lookups by SWIFT code, by country and by prefix are cached, unknown codes are cached for a shorter time.
+// Every write through the cache invalidates the affected entries.
+type CachedRepository struct {
+	store    repository.BankStore
+	config   Config
+	entries  *LRU[lookup]
+	listings *LRU[lookup]
+
+	// Bumped on every invalidation so lookups started before a write don't store stale results.
+	// Stores hold the read lock and invalidations the write lock, so no store can slip in between.
+	mu         sync.RWMutex
+	generation atomic.Uint64
+
+	hits          atomic.Uint64
+	misses        atomic.Uint64
+	negativeHits  atomic.Uint64
+	invalidations atomic.Uint64
+}
+
+var _ repository.BankStore = (*CachedRepository)(nil)
+
+// Wraps a bank store with a cache
+func NewCachedRepository(store repository.BankStore, config Config) *CachedRepository {
+	return &CachedRepository{
+		store:    store,
+		config:   config,
+		entries:  NewLRU[lookup](config.Size),
+		listings: NewLRU[lookup](config.Size),
+	}
+}
+
+// Finds a headquarter by SWIFT code
+func (r *CachedRepository) FindHeadquarter(ctx context.Context, swiftCode string) (*models.Headquarter, error) {
+	result, err := r.get(ctx, r.entries, "hq:"+swiftCode, func() (lookup, error) {
+		hq, err := r.store.FindHeadquarter(ctx, swiftCode)
+		return lookup{hq: hq}, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return cloneHeadquarter(result.hq), nil
+}
+
+// Finds headquarters by SWIFT codes, batched lookups bypass the cache
+func (r *CachedRepository) FindHeadquarters(ctx context.Context, swiftCodes []string) ([]models.Headquarter, error) {
+	return r.store.FindHeadquarters(ctx, swiftCodes)
+}
+
+// Finds a branch by SWIFT code
+func (r *CachedRepository) FindBranch(ctx context.Context, swiftCode, parentSwiftCode string) (*models.Branch, error) {
+	result, err := r.get(ctx, r.entries, "br:"+swiftCode, func() (lookup, error) {
+		branch, err := r.store.FindBranch(ctx, swiftCode, parentSwiftCode)
+		return lookup{branch: branch}, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	branch := *result.branch
+	return &branch, nil
+}
+
+// Finds all banks in a given country
+func (r *CachedRepository) FindBanksByCountry(ctx context.Context, countryCode string) ([]models.Headquarter, error) {
+	result, err := r.get(ctx, r.listings, "country:"+countryCode, func() (lookup, error) {
+		banks, err := r.store.FindBanksByCountry(ctx, countryCode)
+		return lookup{banks: banks}, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	banks := make([]models.Headquarter, len(result.banks))
+	for i := range result.banks {
+		banks[i] = *cloneHeadquarter(&result.banks[i])
+	}
+	return banks, nil
+}
+
+// Finds all headquarters and branches whose SWIFT code starts with prefix
+func (r *CachedRepository) FindByPrefix(ctx context.Context, prefix string) ([]models.BankEntity, error) {
+	result, err := r.get(ctx, r.listings, "prefix:"+prefix, func() (lookup, error) {
+		entities, err := r.store.FindByPrefix(ctx, prefix)
+		return lookup{entities: entities}, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	entities := make([]models.BankEntity, len(result.entities))
+	for i, entity := range result.entities {
+		switch e := entity.(type) {
+		case *models.Headquarter:
+			entities[i] = cloneHeadquarter(e)
+		case *models.Branch:
+			branch := *e
+			entities[i] = &branch
+		default:
+			entities[i] = entity
+		}
+	}
+	return entities, nil
+}
+
+// Finds all headquarters with their branches, full listings bypass the cache
+func (r *CachedRepository) FindAll(ctx context.Context) ([]models.Headquarter, error) {
+	return r.store.FindAll(ctx)
+}
+
+// Streams headquarters with their branches, streams bypass the cache
+func (r *CachedRepository) StreamHeadquarters(ctx context.Context, countryCode string, fn func(hq *models.Headquarter) error) error {
+	return r.store.StreamHeadquarters(ctx, countryCode, fn)
+}
+
+// Creates a new headquarter
+func (r *CachedRepository) CreateHeadquarter(ctx context.Context, hq *models.Headquarter) error {
+	defer r.invalidate(hq.SwiftCode)
+	return r.store.CreateHeadquarter(ctx, hq)
+}
+
+// Adds a new branch to a headquarter
+func (r *CachedRepository) AddBranch(ctx context.Context, parentSwiftCode string, branch *models.Branch) error {
+	defer r.invalidate(parentSwiftCode)
+	return r.store.AddBranch(ctx, parentSwiftCode, branch)
+}
+
+// Changes a headquarter
+func (r *CachedRepository) UpdateHeadquarter(ctx context.Context, hq *models.Headquarter, check repository.Precondition) (*models.Headquarter, error) {
+	defer r.invalidate(hq.SwiftCode)
+	return r.store.UpdateHeadquarter(ctx, hq, check)
+}
+
+// Changes a branch
+func (r *CachedRepository) UpdateBranch(ctx context.Context, parentSwiftCode string, branch *models.Branch, check repository.Precondition) (*models.Branch, error) {
+	defer r.invalidate(parentSwiftCode)
+	return r.store.UpdateBranch(ctx, parentSwiftCode, branch, check)
+}
+
+// Deletes a headquarter and all its branches
+func (r *CachedRepository) DeleteHeadquarter(ctx context.Context, swiftCode string, check repository.Precondition) error {
+	defer r.invalidate(swiftCode)
+	return r.store.DeleteHeadquarter(ctx, swiftCode, check)
+}
+
+// Removes a branch from its headquarter
+func (r *CachedRepository) DeleteBranch(ctx context.Context, swiftCode, parentSwiftCode string, check repository.Precondition) error {
+	defer r.invalidate(parentSwiftCode)
+	return r.store.DeleteBranch(ctx, swiftCode, parentSwiftCode, check)
+}
+
+// Drops every cached entry, used after the whole dataset was replaced
+func (r *CachedRepository) Purge() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.generation.Add(1)
+	r.invalidations.Add(1)
+	r.entries.Purge()
+	r.listings.Purge()
+}
+
+// Returns the current cache counters
+func (r *CachedRepository) Stats() Stats {
+	return Stats{
+		Hits:          r.hits.Load(),
+		Misses:        r.misses.Load(),
+		NegativeHits:  r.negativeHits.Load(),
+		Evictions:     r.entries.Evictions() + r.listings.Evictions(),
+		Invalidations: r.invalidations.Load(),
+		Entries:       r.entries.Len() + r.listings.Len(),
+	}
+}
+
+// Returns a cached lookup or loads it from the store.
+// Not found results are cached for NegativeTTL, other errors are never cached.
+func (r *CachedRepository) get(ctx context.Context, cache *LRU[lookup], key string, load func() (lookup, error)) (lookup, error) {
+	if result, ok := cache.Get(key); ok {
+		if result.err != nil {
+			r.negativeHits.Add(1)
+			return lookup{}, result.err
+		}
+		r.hits.Add(1)
+		return result, nil
+	}
+
+	r.misses.Add(1)
+	generation := r.generation.Load()
+
+	result, err := load()
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) && r.config.NegativeTTL > 0 && ctx.Err() == nil {
+			r.put(cache, key, lookup{err: err}, r.config.NegativeTTL, generation)
+		}
+		return lookup{}, err
+	}
+
+	r.put(cache, key, result, r.config.TTL, generation)
+	return result, nil
+}
+
+func (r *CachedRepository) put(cache *LRU[lookup], key string, result lookup, ttl time.Duration, generation uint64) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.generation.Load() == generation {
+		cache.Set(key, result, ttl)
+	}
+}
+
+// Drops entries of every SWIFT code that belongs to the same institution and location
+// as swiftCode (same first 8 characters), and all country and prefix listings
+func (r *CachedRepository) invalidate(swiftCode string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.generation.Add(1)
+	r.invalidations.Add(1)
+
+	institution := swiftCode
+	if len(institution) > 8 {
+		institution = institution[:8]
+	}
+
+	r.entries.DeleteFunc(func(key string) bool {
+		_, code, _ := strings.Cut(key, ":")
+		return strings.HasPrefix(code, institution)
+	})
+	r.listings.Purge()
+}
+
+// Copies a headquarter so callers cannot modify cached data
+func cloneHeadquarter(hq *models.Headquarter) *models.Headquarter {
+	clone := *hq
+	clone.Branches = slices.Clone(hq.Branches)
+	return &clone
+}
@@ -0,0 +1,336 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MarcinZ20/bankAPI/internal/repository"
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Minimal in-memory store counting the lookups that reach it
+type fakeStore struct {
+	hqs   map[string]models.Headquarter
+	calls int
+}
+
+func (f *fakeStore) FindHeadquarter(_ context.Context, swiftCode string) (*models.Headquarter, error) {
+	f.calls++
+	hq, ok := f.hqs[swiftCode]
+	if !ok {
+		return nil, fmt.Errorf("failed to find headquarter: %w", mongo.ErrNoDocuments)
+	}
+	return &hq, nil
+}
+
+func (f *fakeStore) FindBranch(_ context.Context, swiftCode, parentSwiftCode string) (*models.Branch, error) {
+	f.calls++
+	for _, branch := range f.hqs[parentSwiftCode].Branches {
+		if branch.SwiftCode == swiftCode {
+			return &branch, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (f *fakeStore) FindBanksByCountry(_ context.Context, countryCode string) ([]models.Headquarter, error) {
+	f.calls++
+	var banks []models.Headquarter
+	for _, hq := range f.hqs {
+		if hq.CountryISO2 == countryCode {
+			banks = append(banks, hq)
+		}
+	}
+	if len(banks) == 0 {
+		return nil, mongo.ErrNoDocuments
+	}
+	return banks, nil
+}
+
+func (f *fakeStore) FindByPrefix(_ context.Context, prefix string) ([]models.BankEntity, error) {
+	f.calls++
+	var entities []models.BankEntity
+	for _, hq := range f.hqs {
+		if strings.HasPrefix(hq.SwiftCode, prefix) {
+			entities = append(entities, &hq)
+		}
+	}
+	if len(entities) == 0 {
+		return nil, mongo.ErrNoDocuments
+	}
+	return entities, nil
+}
+
+func (f *fakeStore) FindAll(_ context.Context) ([]models.Headquarter, error) {
+	f.calls++
+	var banks []models.Headquarter
+	for _, hq := range f.hqs {
+		banks = append(banks, hq)
+	}
+	return banks, nil
+}
+
+func (f *fakeStore) FindHeadquarters(ctx context.Context, swiftCodes []string) ([]models.Headquarter, error) {
+	var hqs []models.Headquarter
+	for _, swiftCode := range swiftCodes {
+		if hq, err := f.FindHeadquarter(ctx, swiftCode); err == nil {
+			hqs = append(hqs, *hq)
+		}
+	}
+	return hqs, nil
+}
+
+func (f *fakeStore) StreamHeadquarters(ctx context.Context, _ string, fn func(hq *models.Headquarter) error) error {
+	hqs, _ := f.FindAll(ctx)
+	for i := range hqs {
+		if err := fn(&hqs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeStore) CreateHeadquarter(_ context.Context, hq *models.Headquarter) error {
+	f.hqs[hq.SwiftCode] = *hq
+	return nil
+}
+
+func (f *fakeStore) AddBranch(_ context.Context, parentSwiftCode string, branch *models.Branch) error {
+	hq := f.hqs[parentSwiftCode]
+	hq.Branches = append(hq.Branches, *branch)
+	f.hqs[parentSwiftCode] = hq
+	return nil
+}
+
+func (f *fakeStore) UpdateHeadquarter(_ context.Context, hq *models.Headquarter, _ repository.Precondition) (*models.Headquarter, error) {
+	stored := f.hqs[hq.SwiftCode]
+	stored.BankName = hq.BankName
+	f.hqs[hq.SwiftCode] = stored
+	return &stored, nil
+}
+
+func (f *fakeStore) UpdateBranch(_ context.Context, parentSwiftCode string, branch *models.Branch, _ repository.Precondition) (*models.Branch, error) {
+	hq := f.hqs[parentSwiftCode]
+	for i := range hq.Branches {
+		if hq.Branches[i].SwiftCode == branch.SwiftCode {
+			hq.Branches[i].BankName = branch.BankName
+			return &hq.Branches[i], nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (f *fakeStore) DeleteHeadquarter(_ context.Context, swiftCode string, _ repository.Precondition) error {
+	delete(f.hqs, swiftCode)
+	return nil
+}
+
+func (f *fakeStore) DeleteBranch(_ context.Context, swiftCode, parentSwiftCode string, _ repository.Precondition) error {
+	hq := f.hqs[parentSwiftCode]
+	for i, branch := range hq.Branches {
+		if branch.SwiftCode == swiftCode {
+			hq.Branches = append(hq.Branches[:i], hq.Branches[i+1:]...)
+			break
+		}
+	}
+	f.hqs[parentSwiftCode] = hq
+	return nil
+}
+
+func newTestCache() (*CachedRepository, *fakeStore) {
+	store := &fakeStore{hqs: map[string]models.Headquarter{
+		"DEUTDEFFXXX": {
+			SwiftCode:     "DEUTDEFFXXX",
+			BankName:      "DEUTSCHE BANK",
+			CountryISO2:   "DE",
+			IsHeadquarter: true,
+			Branches:      []models.Branch{{SwiftCode: "DEUTDEFF100", CountryISO2: "DE"}},
+		},
+	}}
+
+	return NewCachedRepository(store, Config{Size: 100, TTL: time.Minute, NegativeTTL: time.Minute}), store
+}
+
+func TestCachedRepository_ReadThrough(t *testing.T) {
+	repo, store := newTestCache()
+	ctx := context.Background()
+
+	for range 3 {
+		hq, err := repo.FindHeadquarter(ctx, "DEUTDEFFXXX")
+		require.NoError(t, err)
+		assert.Equal(t, "DEUTSCHE BANK", hq.BankName)
+	}
+
+	for range 2 {
+		_, err := repo.FindBranch(ctx, "DEUTDEFF100", "DEUTDEFFXXX")
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, 2, store.calls)
+
+	stats := repo.Stats()
+	assert.Equal(t, uint64(3), stats.Hits)
+	assert.Equal(t, uint64(2), stats.Misses)
+	assert.Equal(t, 2, stats.Entries)
+}
+
+func TestCachedRepository_NegativeResults(t *testing.T) {
+	repo, store := newTestCache()
+	ctx := context.Background()
+
+	for range 2 {
+		_, err := repo.FindHeadquarter(ctx, "UNKNOWNXXXX")
+		assert.ErrorIs(t, err, mongo.ErrNoDocuments)
+	}
+
+	assert.Equal(t, 1, store.calls)
+	assert.Equal(t, uint64(1), repo.Stats().NegativeHits)
+
+	// Creating the headquarter replaces the cached miss
+	require.NoError(t, repo.CreateHeadquarter(ctx, &models.Headquarter{SwiftCode: "UNKNOWNXXXX", CountryISO2: "PL"}))
+
+	hq, err := repo.FindHeadquarter(ctx, "UNKNOWNXXXX")
+	require.NoError(t, err)
+	assert.Equal(t, "UNKNOWNXXXX", hq.SwiftCode)
+}
+
+func TestCachedRepository_Invalidation(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		mutate func(*CachedRepository) error
+		verify func(*testing.T, *CachedRepository)
+	}{
+		{
+			name: "AddBranch invalidates headquarter and country",
+			mutate: func(r *CachedRepository) error {
+				return r.AddBranch(ctx, "DEUTDEFFXXX", &models.Branch{SwiftCode: "DEUTDEFF200", CountryISO2: "DE"})
+			},
+			verify: func(t *testing.T, r *CachedRepository) {
+				hq, err := r.FindHeadquarter(ctx, "DEUTDEFFXXX")
+				require.NoError(t, err)
+				assert.Len(t, hq.Branches, 2)
+
+				banks, err := r.FindBanksByCountry(ctx, "DE")
+				require.NoError(t, err)
+				assert.Len(t, banks[0].Branches, 2)
+			},
+		},
+		{
+			name: "UpdateBranch invalidates headquarter and branch",
+			mutate: func(r *CachedRepository) error {
+				_, err := r.UpdateBranch(ctx, "DEUTDEFFXXX", &models.Branch{SwiftCode: "DEUTDEFF100", BankName: "DEUTSCHE BANK AG"}, nil)
+				return err
+			},
+			verify: func(t *testing.T, r *CachedRepository) {
+				branch, err := r.FindBranch(ctx, "DEUTDEFF100", "DEUTDEFFXXX")
+				require.NoError(t, err)
+				assert.Equal(t, "DEUTSCHE BANK AG", branch.BankName)
+
+				hq, err := r.FindHeadquarter(ctx, "DEUTDEFFXXX")
+				require.NoError(t, err)
+				assert.Equal(t, "DEUTSCHE BANK AG", hq.Branches[0].BankName)
+			},
+		},
+		{
+			name: "DeleteBranch invalidates branch",
+			mutate: func(r *CachedRepository) error {
+				return r.DeleteBranch(ctx, "DEUTDEFF100", "DEUTDEFFXXX", nil)
+			},
+			verify: func(t *testing.T, r *CachedRepository) {
+				_, err := r.FindBranch(ctx, "DEUTDEFF100", "DEUTDEFFXXX")
+				assert.ErrorIs(t, err, mongo.ErrNoDocuments)
+			},
+		},
+		{
+			name: "DeleteHeadquarter invalidates headquarter and branches",
+			mutate: func(r *CachedRepository) error {
+				return r.DeleteHeadquarter(ctx, "DEUTDEFFXXX", nil)
+			},
+			verify: func(t *testing.T, r *CachedRepository) {
+				_, err := r.FindHeadquarter(ctx, "DEUTDEFFXXX")
+				assert.ErrorIs(t, err, mongo.ErrNoDocuments)
+
+				_, err = r.FindBranch(ctx, "DEUTDEFF100", "DEUTDEFFXXX")
+				assert.ErrorIs(t, err, mongo.ErrNoDocuments)
+
+				_, err = r.FindBanksByCountry(ctx, "DE")
+				assert.ErrorIs(t, err, mongo.ErrNoDocuments)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, _ := newTestCache()
+
+			// Warm up the cache
+			_, err := repo.FindHeadquarter(ctx, "DEUTDEFFXXX")
+			require.NoError(t, err)
+			_, err = repo.FindBranch(ctx, "DEUTDEFF100", "DEUTDEFFXXX")
+			require.NoError(t, err)
+			_, err = repo.FindBanksByCountry(ctx, "DE")
+			require.NoError(t, err)
+
+			require.NoError(t, tt.mutate(repo))
+			tt.verify(t, repo)
+		})
+	}
+}
+
+func TestCachedRepository_ReturnsCopies(t *testing.T) {
+	repo, _ := newTestCache()
+	ctx := context.Background()
+
+	hq, err := repo.FindHeadquarter(ctx, "DEUTDEFFXXX")
+	require.NoError(t, err)
+	hq.Branches[0].SwiftCode = "CHANGED"
+
+	hq, err = repo.FindHeadquarter(ctx, "DEUTDEFFXXX")
+	require.NoError(t, err)
+	assert.Equal(t, "DEUTDEFF100", hq.Branches[0].SwiftCode)
+}
+
+func TestLRU_EvictionAndExpiry(t *testing.T) {
+	now := time.Now()
+	lru := NewLRU[int](2)
+	lru.now = func() time.Time { return now }
+
+	lru.Set("a", 1, time.Minute)
+	lru.Set("b", 2, time.Minute)
+	_, _ = lru.Get("a")
+	lru.Set("c", 3, time.Second)
+
+	_, ok := lru.Get("b")
+	assert.False(t, ok, "least recently used entry should be evicted")
+	assert.Equal(t, uint64(1), lru.Evictions())
+
+	value, ok := lru.Get("c")
+	assert.True(t, ok)
+	assert.Equal(t, 3, value)
+
+	now = now.Add(2 * time.Second)
+	_, ok = lru.Get("c")
+	assert.False(t, ok, "expired entry should not be returned")
+
+	_, ok = lru.Get("a")
+	assert.True(t, ok)
+}
+
+func BenchmarkCachedRepository_FindHeadquarter(b *testing.B) {
+	repo, _ := newTestCache()
+	ctx := context.Background()
+
+	for b.Loop() {
+		if _, err := repo.FindHeadquarter(ctx, "DEUTDEFFXXX"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
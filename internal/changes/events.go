@@ -0,0 +1,52 @@
+package changes
+
+import "github.com/MarcinZ20/bankAPI/pkg/models"
+
+// Builds the event of an added headquarter or branch
+func Created(source string, entity models.BankEntity) models.ChangeEvent {
+	return models.ChangeEvent{
+		Operation: models.ChangeCreated,
+		Source:    source,
+		SwiftCode: entity.GetSwiftCode(),
+		After:     models.NewBankSnapshot(entity),
+	}
+}
+
+// Builds the events of an added headquarter and the branches added with it
+func CreatedHeadquarter(source string, hq *models.Headquarter) []models.ChangeEvent {
+	events := []models.ChangeEvent{Created(source, hq)}
+	for i := range hq.Branches {
+		events = append(events, Created(source, &hq.Branches[i]))
+	}
+	return events
+}
+
+// Builds the event of a modified headquarter or branch
+func Updated(source string, before, after models.BankEntity) models.ChangeEvent {
+	return models.ChangeEvent{
+		Operation: models.ChangeUpdated,
+		Source:    source,
+		SwiftCode: after.GetSwiftCode(),
+		Before:    models.NewBankSnapshot(before),
+		After:     models.NewBankSnapshot(after),
+	}
+}
+
+// Builds the event of a removed headquarter or branch
+func Deleted(source string, entity models.BankEntity) models.ChangeEvent {
+	return models.ChangeEvent{
+		Operation: models.ChangeDeleted,
+		Source:    source,
+		SwiftCode: entity.GetSwiftCode(),
+		Before:    models.NewBankSnapshot(entity),
+	}
+}
+
+// Builds the events of a deleted headquarter and the branches deleted with it
+func DeletedHeadquarter(source string, hq *models.Headquarter) []models.ChangeEvent {
+	events := []models.ChangeEvent{Deleted(source, hq)}
+	for i := range hq.Branches {
+		events = append(events, Deleted(source, &hq.Branches[i]))
+	}
+	return events
+}
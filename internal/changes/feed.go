@@ -0,0 +1,184 @@
+package changes
+
+import (
+	"sync"
+	"time"
+
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+)
+
+// Events a subscriber may fall behind by before it is dropped
+const subscriberBuffer = 256
+
+// Holds change feed settings
+type Config struct {
+	// Number of recent events kept for subscribers resuming after a disconnect
+	BufferSize int
+}
+
+// Listener called with every published event, it runs while the feed is locked and must not block
+type Listener func(event models.ChangeEvent)
+
+// Fans change events out to subscribers and listeners and keeps the most recent ones for resuming.
+// Event IDs increase monotonically and start at the process start time in microseconds, so IDs of a
+// restarted process are larger than any ID handed out before.
+type Feed struct {
+	mu          sync.Mutex
+	nextID      uint64
+	buffer      []models.ChangeEvent
+	start       int
+	size        int
+	subscribers map[*Subscription]struct{}
+	listeners   []Listener
+	closed      bool
+	now         func() time.Time
+}
+
+var instance *Feed
+
+// Creates the change feed
+func NewFeed(config Config) *Feed {
+	instance = &Feed{
+		nextID:      uint64(time.Now().UnixMicro()),
+		buffer:      make([]models.ChangeEvent, max(config.BufferSize, 1)),
+		subscribers: make(map[*Subscription]struct{}),
+		now:         time.Now,
+	}
+	return instance
+}
+
+// Returns the change feed, nil when change events are disabled
+func GetInstance() *Feed {
+	return instance
+}
+
+// Registers a listener for every event published from now on
+func (f *Feed) AddListener(listener Listener) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.listeners = append(f.listeners, listener)
+}
+
+// Assigns IDs and times to the events and delivers them in order, a nil feed discards them
+func (f *Feed) Publish(events ...models.ChangeEvent) {
+	if f == nil || len(events) == 0 {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return
+	}
+
+	now := f.now().UTC()
+	for _, event := range events {
+		event.ID = f.nextID
+		event.Time = now
+		f.nextID++
+
+		f.append(event)
+		for _, listener := range f.listeners {
+			listener(event)
+		}
+		for sub := range f.subscribers {
+			select {
+			case sub.events <- event:
+			default:
+				// A slow subscriber is dropped, it resumes from the buffer with its last event ID
+				f.remove(sub)
+			}
+		}
+	}
+}
+
+// Subscribes to events published after the event with ID lastID, buffered events newer than lastID are
+// returned as the backlog. A zero lastID only subscribes to new events.
+// Missed is set when events after lastID are no longer buffered, or lastID was never handed out by this feed,
+// e.g. it comes from another replica. The subscriber then has to refetch its state, the backlog is empty.
+func (f *Feed) Subscribe(lastID uint64) (backlog []models.ChangeEvent, sub *Subscription, missed bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sub = &Subscription{feed: f, events: make(chan models.ChangeEvent, subscriberBuffer), lastID: f.nextID - 1}
+	if f.closed {
+		close(sub.events)
+		return nil, sub, false
+	}
+	f.subscribers[sub] = struct{}{}
+
+	if lastID == 0 {
+		return nil, sub, false
+	}
+
+	// IDs are consecutive, so nothing is missing when the oldest buffered event directly follows lastID
+	oldest := f.nextID
+	if f.size > 0 {
+		oldest = f.buffer[f.start].ID
+	}
+	if lastID+1 < oldest || lastID >= f.nextID {
+		return nil, sub, true
+	}
+
+	for i := range f.size {
+		if event := f.buffer[(f.start+i)%len(f.buffer)]; event.ID > lastID {
+			backlog = append(backlog, event)
+		}
+	}
+	return backlog, sub, false
+}
+
+// Ends all subscriptions, later events are discarded
+func (f *Feed) Close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.closed = true
+	for sub := range f.subscribers {
+		f.remove(sub)
+	}
+}
+
+// Stores the event in the ring buffer, overwriting the oldest one when it is full
+func (f *Feed) append(event models.ChangeEvent) {
+	if f.size < len(f.buffer) {
+		f.buffer[(f.start+f.size)%len(f.buffer)] = event
+		f.size++
+		return
+	}
+	f.buffer[f.start] = event
+	f.start = (f.start + 1) % len(f.buffer)
+}
+
+func (f *Feed) remove(sub *Subscription) {
+	if _, ok := f.subscribers[sub]; ok {
+		delete(f.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// Receives the events published after it was created
+type Subscription struct {
+	feed   *Feed
+	events chan models.ChangeEvent
+	// ID of the last event published before the subscription started
+	lastID uint64
+}
+
+// Returns the ID of the last event published before the subscription started, a client that missed events
+// resumes from it once it refetched its state
+func (s *Subscription) LastID() uint64 {
+	return s.lastID
+}
+
+// Delivers the events in order, it is closed when the subscriber falls behind or the feed is closed
+func (s *Subscription) Events() <-chan models.ChangeEvent {
+	return s.events
+}
+
+// Stops the subscription
+func (s *Subscription) Close() {
+	s.feed.mu.Lock()
+	defer s.feed.mu.Unlock()
+	s.feed.remove(s)
+}
@@ -0,0 +1,123 @@
+package changes
+
+import (
+	"testing"
+
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func event(swiftCode string) models.ChangeEvent {
+	return models.ChangeEvent{Operation: models.ChangeCreated, Source: models.ChangeSourceAPI, SwiftCode: swiftCode}
+}
+
+func TestPublishDeliversInOrder(t *testing.T) {
+	feed := NewFeed(Config{BufferSize: 10})
+	var heard []string
+	feed.AddListener(func(e models.ChangeEvent) { heard = append(heard, e.SwiftCode) })
+
+	backlog, sub, missed := feed.Subscribe(0)
+	defer sub.Close()
+	assert.Empty(t, backlog)
+	assert.False(t, missed)
+
+	feed.Publish(event("AAAAPLPWXXX"), event("BBBBPLPWXXX"))
+
+	first := <-sub.Events()
+	second := <-sub.Events()
+	assert.Equal(t, "AAAAPLPWXXX", first.SwiftCode)
+	assert.Equal(t, "BBBBPLPWXXX", second.SwiftCode)
+	assert.Equal(t, first.ID+1, second.ID)
+	assert.False(t, first.Time.IsZero())
+	assert.Equal(t, []string{"AAAAPLPWXXX", "BBBBPLPWXXX"}, heard)
+}
+
+func TestSubscribeResumesFromBuffer(t *testing.T) {
+	feed := NewFeed(Config{BufferSize: 3})
+	_, sub, _ := feed.Subscribe(0)
+	feed.Publish(event("AAAAPLPWXXX"), event("BBBBPLPWXXX"))
+	first := <-sub.Events()
+	second := <-sub.Events()
+	sub.Close()
+
+	backlog, resumed, missed := feed.Subscribe(first.ID)
+	defer resumed.Close()
+	assert.False(t, missed)
+	require.Len(t, backlog, 1)
+	assert.Equal(t, "BBBBPLPWXXX", backlog[0].SwiftCode)
+	assert.Equal(t, second.ID, resumed.LastID())
+
+	// A client that received everything resumes without a backlog
+	backlog, current, missed := feed.Subscribe(second.ID)
+	defer current.Close()
+	assert.False(t, missed)
+	assert.Empty(t, backlog)
+
+	// The oldest events are overwritten once the buffer is full
+	feed.Publish(event("CCCCPLPWXXX"), event("DDDDPLPWXXX"), event("EEEEPLPWXXX"))
+	backlog, later, missed := feed.Subscribe(second.ID)
+	defer later.Close()
+	assert.False(t, missed)
+	require.Len(t, backlog, 3)
+	assert.Equal(t, "CCCCPLPWXXX", backlog[0].SwiftCode)
+	assert.Equal(t, "EEEEPLPWXXX", backlog[2].SwiftCode)
+}
+
+func TestSubscribeReportsMissedEvents(t *testing.T) {
+	feed := NewFeed(Config{BufferSize: 2})
+	_, sub, _ := feed.Subscribe(0)
+	feed.Publish(event("AAAAPLPWXXX"), event("BBBBPLPWXXX"), event("CCCCPLPWXXX"), event("DDDDPLPWXXX"))
+	first := <-sub.Events()
+	sub.Close()
+
+	// The event after first was overwritten
+	backlog, resumed, missed := feed.Subscribe(first.ID)
+	defer resumed.Close()
+	assert.True(t, missed)
+	assert.Empty(t, backlog)
+
+	// IDs this feed never handed out, e.g. from another replica
+	_, future, missed := feed.Subscribe(resumed.LastID() + 10)
+	defer future.Close()
+	assert.True(t, missed)
+
+	_, older, missed := NewFeed(Config{BufferSize: 2}).Subscribe(1)
+	defer older.Close()
+	assert.True(t, missed)
+}
+
+func TestSlowSubscriberIsDropped(t *testing.T) {
+	feed := NewFeed(Config{BufferSize: 1})
+	_, sub, _ := feed.Subscribe(0)
+
+	for range subscriberBuffer + 1 {
+		feed.Publish(event("AAAAPLPWXXX"))
+	}
+
+	received := 0
+	for range sub.Events() {
+		received++
+	}
+	assert.Equal(t, subscriberBuffer, received)
+}
+
+func TestCloseEndsSubscriptions(t *testing.T) {
+	feed := NewFeed(Config{BufferSize: 1})
+	_, sub, _ := feed.Subscribe(0)
+
+	feed.Close()
+	_, ok := <-sub.Events()
+	assert.False(t, ok)
+
+	// Events published after closing are discarded
+	feed.Publish(event("AAAAPLPWXXX"))
+	_, late, _ := feed.Subscribe(0)
+	_, ok = <-late.Events()
+	assert.False(t, ok)
+}
+
+func TestPublishOnNilFeed(t *testing.T) {
+	var feed *Feed
+	assert.NotPanics(t, func() { feed.Publish(event("AAAAPLPWXXX")) })
+}
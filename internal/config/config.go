@@ -0,0 +1,517 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/robfig/cron/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// Replaces secret values when the configuration is printed
+const redacted = "[REDACTED]"
+
+// Application configuration, loaded from defaults, then a YAML or TOML file, then environment variables
+type Config struct {
+	Server    Server    `yaml:"server" toml:"server"`
+	Storage   Storage   `yaml:"storage" toml:"storage"`
+	Mongo     Mongo     `yaml:"mongo" toml:"mongo"`
+	Import    Import    `yaml:"import" toml:"import"`
+	Auth      Auth      `yaml:"auth" toml:"auth"`
+	RateLimit RateLimit `yaml:"rateLimit" toml:"rateLimit"`
+	Cache     Cache     `yaml:"cache" toml:"cache"`
+	Changes   Changes   `yaml:"changes" toml:"changes"`
+	Webhooks  Webhooks  `yaml:"webhooks" toml:"webhooks"`
+	Outbox    Outbox    `yaml:"outbox" toml:"outbox"`
+	Tracing   Tracing   `yaml:"tracing" toml:"tracing"`
+	Logging   Logging   `yaml:"logging" toml:"logging"`
+}
+
+// HTTP and gRPC server settings
+type Server struct {
+	Port            string        `yaml:"port" toml:"port" env:"API_SERVER_PORT"`
+	GRPCPort        string        `yaml:"grpcPort" toml:"grpcPort" env:"GRPC_SERVER_PORT"`
+	ReadTimeout     time.Duration `yaml:"readTimeout" toml:"readTimeout" env:"SERVER_READ_TIMEOUT"`
+	RequestTimeout  time.Duration `yaml:"requestTimeout" toml:"requestTimeout" env:"SERVER_REQUEST_TIMEOUT"`
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout" toml:"shutdownTimeout" env:"SERVER_SHUTDOWN_TIMEOUT"`
+}
+
+// Storage backend settings
+type Storage struct {
+	Mode                   string        `yaml:"mode" toml:"mode" env:"STORAGE_MODE"`
+	SnapshotFile           string        `yaml:"snapshotFile" toml:"snapshotFile" env:"SNAPSHOT_FILE"`
+	SnapshotReloadInterval time.Duration `yaml:"snapshotReloadInterval" toml:"snapshotReloadInterval" env:"SNAPSHOT_RELOAD_INTERVAL"`
+}
+
+// MongoDB connection settings
+type Mongo struct {
+	URI                  string        `yaml:"uri" toml:"uri" env:"MONGO_URI"`
+	Database             string        `yaml:"database" toml:"database" env:"MONGO_DATABASE"`
+	Collection           string        `yaml:"collection" toml:"collection" env:"MONGO_COLLECTION"`
+	APIKeysCollection    string        `yaml:"apiKeysCollection" toml:"apiKeysCollection" env:"MONGO_API_KEYS_COLLECTION"`
+	RateLimitsCollection string        `yaml:"rateLimitsCollection" toml:"rateLimitsCollection" env:"MONGO_RATE_LIMITS_COLLECTION"`
+	LocksCollection      string        `yaml:"locksCollection" toml:"locksCollection" env:"MONGO_LOCKS_COLLECTION"`
+	ConnectTimeout       time.Duration `yaml:"connectTimeout" toml:"connectTimeout" env:"MONGO_CONNECT_TIMEOUT"`
+	Timeout              time.Duration `yaml:"timeout" toml:"timeout" env:"MONGO_TIMEOUT"`
+	MigrateOnStart       bool          `yaml:"migrateOnStart" toml:"migrateOnStart" env:"MIGRATE_ON_START"`
+	// Webhook registrations and the events they did not accept
+	WebhooksCollection    string `yaml:"webhooksCollection" toml:"webhooksCollection" env:"MONGO_WEBHOOKS_COLLECTION"`
+	DeadLettersCollection string `yaml:"deadLettersCollection" toml:"deadLettersCollection" env:"MONGO_DEAD_LETTERS_COLLECTION"`
+	// Transactional outbox of change events, empty disables it, it needs a replica set
+	OutboxCollection string `yaml:"outboxCollection" toml:"outboxCollection" env:"MONGO_OUTBOX_COLLECTION"`
+}
+
+// Data import settings
+type Import struct {
+	Source        string        `yaml:"source" toml:"source" env:"IMPORT_SOURCE"`
+	SpreadsheetID string        `yaml:"spreadsheetId" toml:"spreadsheetId" env:"SPREADSHEET_ID"`
+	File          string        `yaml:"file" toml:"file" env:"IMPORT_FILE"`
+	OnStart       bool          `yaml:"onStart" toml:"onStart" env:"IMPORT_ON_START"`
+	Timeout       time.Duration `yaml:"timeout" toml:"timeout" env:"IMPORT_TIMEOUT"`
+	FetchTimeout  time.Duration `yaml:"fetchTimeout" toml:"fetchTimeout" env:"IMPORT_FETCH_TIMEOUT"`
+	// Spreadsheet download retries and limits
+	SpreadsheetBaseURL string        `yaml:"spreadsheetBaseUrl" toml:"spreadsheetBaseUrl" env:"SPREADSHEET_BASE_URL"`
+	FetchRetries       int           `yaml:"fetchRetries" toml:"fetchRetries" env:"IMPORT_FETCH_RETRIES"`
+	FetchRetryDelay    time.Duration `yaml:"fetchRetryDelay" toml:"fetchRetryDelay" env:"IMPORT_FETCH_RETRY_DELAY"`
+	FetchMaxRetryDelay time.Duration `yaml:"fetchMaxRetryDelay" toml:"fetchMaxRetryDelay" env:"IMPORT_FETCH_MAX_RETRY_DELAY"`
+	MaxDownloadSize    int64         `yaml:"maxDownloadSize" toml:"maxDownloadSize" env:"IMPORT_MAX_DOWNLOAD_SIZE"`
+	// Directory keeping the last good spreadsheet download, empty disables the fallback
+	CacheDir string `yaml:"cacheDir" toml:"cacheDir" env:"IMPORT_CACHE_DIR"`
+	// Directory holding the files admin import requests may name, empty rejects requests naming a file
+	RequestDir string `yaml:"requestDir" toml:"requestDir" env:"IMPORT_REQUEST_DIR"`
+	// Sheets API access for the sheets source
+	SheetRange      string `yaml:"sheetRange" toml:"sheetRange" env:"IMPORT_SHEET_RANGE"`
+	SheetsEndpoint  string `yaml:"sheetsEndpoint" toml:"sheetsEndpoint" env:"SHEETS_API_ENDPOINT"`
+	CredentialsFile string `yaml:"credentialsFile" toml:"credentialsFile" env:"GOOGLE_APPLICATION_CREDENTIALS"`
+	// Cron expression for background re-imports in serve, empty disables them
+	Schedule string `yaml:"schedule" toml:"schedule" env:"IMPORT_SCHEDULE"`
+	// Parts of the multi source, only set in the configuration file
+	Sources []ImportSource `yaml:"sources" toml:"sources"`
+	// Rule for SWIFT codes found in several parts: first, last or fail
+	Duplicates string `yaml:"duplicates" toml:"duplicates" env:"IMPORT_DUPLICATES"`
+}
+
+// Single part of the multi source
+type ImportSource struct {
+	Name          string  `yaml:"name" toml:"name"`
+	Source        string  `yaml:"source" toml:"source"`
+	SpreadsheetID string  `yaml:"spreadsheetId" toml:"spreadsheetId"`
+	Range         string  `yaml:"range" toml:"range"`
+	File          string  `yaml:"file" toml:"file"`
+	Columns       Columns `yaml:"columns" toml:"columns"`
+}
+
+// Header names of the columns read from a part, empty names use the export columns
+type Columns struct {
+	CountryISO2 string `yaml:"countryIso2" toml:"countryIso2"`
+	SwiftCode   string `yaml:"swiftCode" toml:"swiftCode"`
+	Name        string `yaml:"name" toml:"name"`
+	Address     string `yaml:"address" toml:"address"`
+	CountryName string `yaml:"countryName" toml:"countryName"`
+	TownName    string `yaml:"townName" toml:"townName"`
+}
+
+// API key and bearer token settings
+type Auth struct {
+	AdminAPIKey string `yaml:"adminApiKey" toml:"adminApiKey" env:"ADMIN_API_KEY"`
+	PublicReads bool   `yaml:"publicReads" toml:"publicReads" env:"AUTH_PUBLIC_READS"`
+	JWT         JWT    `yaml:"jwt" toml:"jwt"`
+}
+
+// Bearer token verification settings, verification is disabled without a JWKS source
+type JWT struct {
+	JWKSFile    string `yaml:"jwksFile" toml:"jwksFile" env:"JWT_JWKS_FILE"`
+	JWKSURL     string `yaml:"jwksUrl" toml:"jwksUrl" env:"JWT_JWKS_URL"`
+	Issuer      string `yaml:"issuer" toml:"issuer" env:"JWT_ISSUER"`
+	Audience    string `yaml:"audience" toml:"audience" env:"JWT_AUDIENCE"`
+	RoleClaim   string `yaml:"roleClaim" toml:"roleClaim" env:"JWT_ROLE_CLAIM"`
+	RoleMapping string `yaml:"roleMapping" toml:"roleMapping" env:"JWT_ROLE_MAPPING"`
+}
+
+// Per-client rate limiting settings, zero rates disable a limit
+type RateLimit struct {
+	Enabled    bool    `yaml:"enabled" toml:"enabled" env:"RATE_LIMIT_ENABLED"`
+	Store      string  `yaml:"store" toml:"store" env:"RATE_LIMIT_STORE"`
+	ReadRPS    float64 `yaml:"readRps" toml:"readRps" env:"RATE_LIMIT_READ_RPS"`
+	ReadBurst  int     `yaml:"readBurst" toml:"readBurst" env:"RATE_LIMIT_READ_BURST"`
+	WriteRPS   float64 `yaml:"writeRps" toml:"writeRps" env:"RATE_LIMIT_WRITE_RPS"`
+	WriteBurst int     `yaml:"writeBurst" toml:"writeBurst" env:"RATE_LIMIT_WRITE_BURST"`
+	// Checked per IP address before credentials are, whoever the client turns out to be
+	IPRPS      float64 `yaml:"ipRps" toml:"ipRps" env:"RATE_LIMIT_IP_RPS"`
+	IPBurst    int     `yaml:"ipBurst" toml:"ipBurst" env:"RATE_LIMIT_IP_BURST"`
+	DailyQuota int64   `yaml:"dailyQuota" toml:"dailyQuota" env:"RATE_LIMIT_DAILY_QUOTA"`
+}
+
+// Lookup cache settings
+type Cache struct {
+	Enabled     bool          `yaml:"enabled" toml:"enabled" env:"CACHE_ENABLED"`
+	Size        int           `yaml:"size" toml:"size" env:"CACHE_SIZE"`
+	TTL         time.Duration `yaml:"ttl" toml:"ttl" env:"CACHE_TTL"`
+	NegativeTTL time.Duration `yaml:"negativeTtl" toml:"negativeTtl" env:"CACHE_NEGATIVE_TTL"`
+}
+
+// Change event settings
+type Changes struct {
+	// Recent events kept for stream clients resuming with Last-Event-ID
+	BufferSize int `yaml:"bufferSize" toml:"bufferSize" env:"CHANGES_BUFFER_SIZE"`
+}
+
+// Webhook delivery settings, webhooks are registered through the admin API and need MongoDB
+type Webhooks struct {
+	Timeout         time.Duration `yaml:"timeout" toml:"timeout" env:"WEBHOOK_TIMEOUT"`
+	MaxAttempts     int           `yaml:"maxAttempts" toml:"maxAttempts" env:"WEBHOOK_MAX_ATTEMPTS"`
+	RetryBackoff    time.Duration `yaml:"retryBackoff" toml:"retryBackoff" env:"WEBHOOK_RETRY_BACKOFF"`
+	QueueSize       int           `yaml:"queueSize" toml:"queueSize" env:"WEBHOOK_QUEUE_SIZE"`
+	RefreshInterval time.Duration `yaml:"refreshInterval" toml:"refreshInterval" env:"WEBHOOK_REFRESH_INTERVAL"`
+}
+
+// Outbox relay settings, used when mongo.outboxCollection is set
+type Outbox struct {
+	// Comma separated sinks receiving the outbox entries: webhook, file and stdout
+	Sinks        string        `yaml:"sinks" toml:"sinks" env:"OUTBOX_SINKS"`
+	File         string        `yaml:"file" toml:"file" env:"OUTBOX_FILE"`
+	PollInterval time.Duration `yaml:"pollInterval" toml:"pollInterval" env:"OUTBOX_POLL_INTERVAL"`
+	BatchSize    int64         `yaml:"batchSize" toml:"batchSize" env:"OUTBOX_BATCH_SIZE"`
+}
+
+// Returns the configured sink names
+func (o Outbox) SinkNames() []string {
+	var names []string
+	for _, name := range strings.Split(o.Sinks, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Trace export settings
+type Tracing struct {
+	Exporter    string  `yaml:"exporter" toml:"exporter" env:"TRACING_EXPORTER"`
+	ServiceName string  `yaml:"serviceName" toml:"serviceName" env:"OTEL_SERVICE_NAME"`
+	SampleRatio float64 `yaml:"sampleRatio" toml:"sampleRatio" env:"TRACING_SAMPLE_RATIO"`
+}
+
+// Log output settings
+type Logging struct {
+	Level  string `yaml:"level" toml:"level" env:"LOG_LEVEL"`
+	Format string `yaml:"format" toml:"format" env:"LOG_FORMAT"`
+}
+
+// Returns the configuration used when nothing is overridden
+func Default() Config {
+	return Config{
+		Server: Server{
+			Port:            ":8080",
+			GRPCPort:        ":9090",
+			ReadTimeout:     5 * time.Second,
+			RequestTimeout:  5 * time.Second,
+			ShutdownTimeout: 10 * time.Second,
+		},
+		Storage: Storage{
+			Mode: "mongo",
+		},
+		Mongo: Mongo{
+			APIKeysCollection:    "api_keys",
+			RateLimitsCollection: "rate_limits",
+			LocksCollection:      "locks",
+			ConnectTimeout:       5 * time.Second,
+			Timeout:              3 * time.Second,
+			MigrateOnStart:       true,
+
+			WebhooksCollection:    "webhooks",
+			DeadLettersCollection: "webhook_dead_letters",
+		},
+		Import: Import{
+			Source:       "spreadsheet",
+			OnStart:      true,
+			Timeout:      5 * time.Minute,
+			FetchTimeout: 10 * time.Second,
+
+			SpreadsheetBaseURL: "https://docs.google.com/spreadsheets/d",
+			FetchRetries:       3,
+			FetchRetryDelay:    500 * time.Millisecond,
+			FetchMaxRetryDelay: 10 * time.Second,
+			MaxDownloadSize:    32 << 20,
+			CacheDir:           filepath.Join(os.TempDir(), "bankapi"),
+			SheetsEndpoint:     "https://sheets.googleapis.com",
+			Duplicates:         "fail",
+		},
+		RateLimit: RateLimit{
+			Store: "memory",
+		},
+		Cache: Cache{
+			Size:        10000,
+			TTL:         5 * time.Minute,
+			NegativeTTL: 30 * time.Second,
+		},
+		Changes: Changes{
+			BufferSize: 1000,
+		},
+		Webhooks: Webhooks{
+			Timeout:         5 * time.Second,
+			MaxAttempts:     5,
+			RetryBackoff:    time.Second,
+			QueueSize:       1000,
+			RefreshInterval: 30 * time.Second,
+		},
+		Outbox: Outbox{
+			Sinks:        "webhook",
+			PollInterval: time.Second,
+			BatchSize:    100,
+		},
+		Tracing: Tracing{
+			Exporter:    "none",
+			ServiceName: "bankapi",
+			SampleRatio: 1,
+		},
+		Logging: Logging{
+			Level:  "info",
+			Format: "json",
+		},
+	}
+}
+
+// Loads the defaults, overridden by the file at path when set and then by environment variables
+func Load(path string) (Config, error) {
+	config := Default()
+
+	if path != "" {
+		if err := config.loadFile(path); err != nil {
+			return config, err
+		}
+	}
+
+	if err := config.loadEnv(); err != nil {
+		return config, err
+	}
+
+	return config, nil
+}
+
+// Decodes a YAML or TOML file, chosen by its extension, over the current values
+func (c *Config) loadFile(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(content))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(c); err != nil && err != io.EOF {
+			return fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	case ".toml":
+		metadata, err := toml.Decode(string(content), c)
+		if err != nil {
+			return fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+		if undecoded := metadata.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("unknown keys in config file %s: %v", path, undecoded)
+		}
+	default:
+		return fmt.Errorf("unsupported config file format: %s", ext)
+	}
+
+	return nil
+}
+
+// Overrides fields tagged with env by the environment variables that are set and not empty
+func (c *Config) loadEnv() error {
+	return walk(reflect.ValueOf(c).Elem(), func(field reflect.Value, name string) error {
+		value := os.Getenv(name)
+		if value == "" {
+			return nil
+		}
+		if err := setValue(field, value); err != nil {
+			return fmt.Errorf("invalid %s: %w", name, err)
+		}
+		return nil
+	})
+}
+
+// Calls fn for every field tagged with env, descending into nested structs
+func walk(v reflect.Value, fn func(field reflect.Value, name string) error) error {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct {
+			if err := walk(field, fn); err != nil {
+				return err
+			}
+			continue
+		}
+		if name := v.Type().Field(i).Tag.Get("env"); name != "" {
+			if err := fn(field, name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func setValue(field reflect.Value, value string) error {
+	if field.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
+
+// Checks that every setting has an allowed value
+func (c Config) Validate() error {
+	var errs []string
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Sprintf(format, args...))
+		}
+	}
+
+	check(c.Server.Port != "", "server.port must be set")
+	check(c.Server.ReadTimeout > 0, "server.readTimeout must be positive")
+	check(c.Server.RequestTimeout > 0, "server.requestTimeout must be positive")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdownTimeout must be positive")
+
+	check(oneOf(c.Storage.Mode, "mongo", "memory"), "storage.mode must be mongo or memory, got %q", c.Storage.Mode)
+	check(c.Storage.SnapshotReloadInterval >= 0, "storage.snapshotReloadInterval must not be negative")
+
+	check(c.Mongo.ConnectTimeout > 0, "mongo.connectTimeout must be positive")
+	check(c.Mongo.Timeout > 0, "mongo.timeout must be positive")
+
+	check(oneOf(c.Import.Source, "spreadsheet", "sheets", "csv", "file", "swiftref", "multi"), "import.source must be spreadsheet, sheets, csv, file, swiftref or multi, got %q", c.Import.Source)
+	check(c.Import.Timeout > 0, "import.timeout must be positive")
+	check(c.Import.FetchTimeout > 0, "import.fetchTimeout must be positive")
+	check(isHTTPURL(c.Import.SpreadsheetBaseURL), "import.spreadsheetBaseUrl must be an http or https URL, got %q", c.Import.SpreadsheetBaseURL)
+	check(isHTTPURL(c.Import.SheetsEndpoint), "import.sheetsEndpoint must be an http or https URL, got %q", c.Import.SheetsEndpoint)
+	check(c.Import.Source != "sheets" || c.Import.CredentialsFile != "", "the sheets source requires import.credentialsFile")
+	check(c.Import.Source != "multi" || len(c.Import.Sources) > 0, "the multi source requires import.sources")
+	check(oneOf(c.Import.Duplicates, "first", "last", "fail"), "import.duplicates must be first, last or fail, got %q", c.Import.Duplicates)
+	for i, part := range c.Import.Sources {
+		check(oneOf(part.Source, "spreadsheet", "sheets", "csv", "swiftref"), "import.sources[%d].source must be spreadsheet, sheets, csv or swiftref, got %q", i, part.Source)
+		check(part.Source != "sheets" || c.Import.CredentialsFile != "", "import.sources[%d] uses the sheets source, which requires import.credentialsFile", i)
+	}
+	check(c.Import.FetchRetries >= 0, "import.fetchRetries must not be negative")
+	check(c.Import.FetchRetryDelay > 0 && c.Import.FetchMaxRetryDelay > 0, "import fetch retry delays must be positive")
+	check(c.Import.MaxDownloadSize > 0, "import.maxDownloadSize must be positive")
+	if c.Import.Schedule != "" {
+		_, err := cron.ParseStandard(c.Import.Schedule)
+		check(err == nil, "import.schedule is not a valid cron expression: %v", err)
+	}
+
+	check(oneOf(c.RateLimit.Store, "memory", "mongo"), "rateLimit.store must be memory or mongo, got %q", c.RateLimit.Store)
+	check(c.RateLimit.ReadRPS >= 0 && c.RateLimit.WriteRPS >= 0 && c.RateLimit.IPRPS >= 0, "rateLimit rates must not be negative")
+	check(c.RateLimit.ReadBurst >= 0 && c.RateLimit.WriteBurst >= 0 && c.RateLimit.IPBurst >= 0, "rateLimit bursts must not be negative")
+	check(c.RateLimit.DailyQuota >= 0, "rateLimit.dailyQuota must not be negative")
+
+	check(c.Cache.Size > 0, "cache.size must be positive")
+	check(c.Cache.TTL > 0, "cache.ttl must be positive")
+	check(c.Cache.NegativeTTL >= 0, "cache.negativeTtl must not be negative")
+
+	check(c.Changes.BufferSize > 0, "changes.bufferSize must be positive")
+
+	check(c.Webhooks.Timeout > 0, "webhooks.timeout must be positive")
+	check(c.Webhooks.MaxAttempts > 0, "webhooks.maxAttempts must be positive")
+	check(c.Webhooks.RetryBackoff > 0, "webhooks.retryBackoff must be positive")
+	check(c.Webhooks.QueueSize > 0, "webhooks.queueSize must be positive")
+	check(c.Webhooks.RefreshInterval > 0, "webhooks.refreshInterval must be positive")
+
+	if c.Mongo.OutboxCollection != "" {
+		sinks := c.Outbox.SinkNames()
+		check(len(sinks) > 0, "outbox.sinks must name at least one sink")
+		for _, sink := range sinks {
+			check(oneOf(sink, "webhook", "file", "stdout"), "outbox.sinks must be webhook, file or stdout, got %q", sink)
+		}
+		check(!slices.Contains(sinks, "file") || c.Outbox.File != "", "the file sink requires outbox.file")
+		check(c.Outbox.PollInterval > 0, "outbox.pollInterval must be positive")
+		check(c.Outbox.BatchSize > 0, "outbox.batchSize must be positive")
+	}
+
+	check(oneOf(c.Tracing.Exporter, "none", "otlp", "stdout"), "tracing.exporter must be none, otlp or stdout, got %q", c.Tracing.Exporter)
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sampleRatio must be between 0 and 1")
+
+	check(oneOf(strings.ToLower(c.Logging.Level), "debug", "info", "warn", "error"), "logging.level must be debug, info, warn or error, got %q", c.Logging.Level)
+	check(oneOf(strings.ToLower(c.Logging.Format), "json", "text"), "logging.format must be json or text, got %q", c.Logging.Format)
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// Checks that value is an absolute http or https URL
+func isHTTPURL(value string) bool {
+	parsed, err := url.Parse(value)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+func oneOf(value string, allowed ...string) bool {
+	return slices.Contains(allowed, value)
+}
+
+// Returns a copy with secrets replaced, safe to print or log
+func (c Config) Redacted() Config {
+	c.Mongo.URI = redactURI(c.Mongo.URI)
+	if c.Auth.AdminAPIKey != "" {
+		c.Auth.AdminAPIKey = redacted
+	}
+	return c
+}
+
+// Hides the password in a connection URI, keeping the rest readable
+func redactURI(uri string) string {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return redacted
+	}
+	return parsed.Redacted()
+}
+
+// Writes the configuration as YAML with secrets redacted
+func (c Config) Print(w io.Writer) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(c.Redacted()); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
@@ -3,7 +3,6 @@ package database
 import (
 	"context"
 	"fmt"
-	"os"
 	"time"
 
 	"slices"
@@ -16,27 +15,54 @@ import (
 
 // Holds database configuration and connection details
 type Config struct {
-	Client     *mongo.Client
-	Collection *mongo.Collection
+	Client      *mongo.Client
+	Collection  *mongo.Collection
+	APIKeys     *mongo.Collection
+	RateLimits  *mongo.Collection
+	Locks       *mongo.Collection
+	Webhooks    *mongo.Collection
+	DeadLetters *mongo.Collection
+	// Transactional outbox of change events, nil when it is disabled
+	Outbox *mongo.Collection
+}
+
+// Holds MongoDB connection settings
+type Settings struct {
+	URI                   string
+	Database              string
+	Collection            string
+	APIKeysCollection     string
+	RateLimitsCollection  string
+	LocksCollection       string
+	WebhooksCollection    string
+	DeadLettersCollection string
+	// Empty disables the transactional outbox
+	OutboxCollection string
+	// Limits connecting and the initial ping
+	ConnectTimeout time.Duration
+	// Limits every operation of the client
+	Timeout time.Duration
 }
 
 var instance *Config
 
-// Establishes database connection and initializes indexes
-func Connect(ctx context.Context) (*Config, error) {
+// Establishes the database connection, Migrate creates the collections and indexes
+func Connect(ctx context.Context, settings Settings) (*Config, error) {
 	if instance != nil {
 		return instance, nil
 	}
 
-	mongoUri := os.Getenv("MONGO_URI")
-	if mongoUri == "" {
-		return nil, fmt.Errorf("MONGO_URI environment variable is not set")
+	if settings.URI == "" {
+		return nil, fmt.Errorf("MONGO_URI is not set")
+	}
+	if settings.Database == "" || settings.Collection == "" {
+		return nil, fmt.Errorf("MONGO_DATABASE and MONGO_COLLECTION must be set")
 	}
 
-	connCtx, connCancel := context.WithTimeout(ctx, 5*time.Second)
+	connCtx, connCancel := context.WithTimeout(ctx, settings.ConnectTimeout)
 	defer connCancel()
 
-	clientOptions := options.Client().SetTimeout(3 * time.Second).ApplyURI(mongoUri)
+	clientOptions := options.Client().SetTimeout(settings.Timeout).ApplyURI(settings.URI)
 	client, err := mongo.Connect(connCtx, clientOptions)
 	if err != nil {
 		return nil, fmt.Errorf("error while connecting to mongoDB: %w", err)
@@ -48,44 +74,65 @@ func Connect(ctx context.Context) (*Config, error) {
 		}
 	}()
 
-	pingCtx, pingCancel := context.WithTimeout(ctx, 5*time.Second)
+	pingCtx, pingCancel := context.WithTimeout(ctx, settings.ConnectTimeout)
 	defer pingCancel()
 
-	if err := client.Ping(pingCtx, readpref.Primary()); err != nil {
+	if err = client.Ping(pingCtx, readpref.Primary()); err != nil {
 		return nil, fmt.Errorf("error while pinging the database: %w", err)
 	}
 
-	dbName := os.Getenv("MONGO_DATABASE")
-	collName := os.Getenv("MONGO_COLLECTION")
-	if dbName == "" || collName == "" {
-		return nil, fmt.Errorf("MONGO_DATABASE and MONGO_COLLECTION environment variables must be set")
+	db := client.Database(settings.Database)
+	instance = &Config{
+		Client:      client,
+		Collection:  db.Collection(settings.Collection),
+		APIKeys:     db.Collection(settings.APIKeysCollection),
+		RateLimits:  db.Collection(settings.RateLimitsCollection),
+		Locks:       db.Collection(settings.LocksCollection),
+		Webhooks:    db.Collection(settings.WebhooksCollection),
+		DeadLetters: db.Collection(settings.DeadLettersCollection),
+	}
+	if settings.OutboxCollection != "" {
+		instance.Outbox = db.Collection(settings.OutboxCollection)
 	}
 
-	collectionFilter := bson.D{{}}
+	return instance, nil
+}
+
+// Creates missing collections and (re)creates all indexes
+func (c *Config) Migrate(ctx context.Context) error {
+	db := c.Collection.Database()
 
-	collections, err := client.Database(dbName).ListCollectionNames(ctx, collectionFilter)
+	collections, err := db.ListCollectionNames(ctx, bson.D{{}})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get collections")
+		return fmt.Errorf("failed to get collections")
 	}
 
-	if !slices.Contains(collections, collName) {
-		if err := createCollection(client.Database(dbName), collName); err != nil {
-			return nil, fmt.Errorf("there was no existing collection named %v", collName)
+	if !slices.Contains(collections, c.Collection.Name()) {
+		if err := createCollection(db, c.Collection.Name()); err != nil {
+			return fmt.Errorf("there was no existing collection named %v", c.Collection.Name())
 		}
 	}
 
-	collection := client.Database(dbName).Collection(collName)
+	if err := createIndexes(ctx, c.Collection); err != nil {
+		return fmt.Errorf("failed to create indexes: %w", err)
+	}
 
-	if err := createIndexes(ctx, collection); err != nil {
-		return nil, fmt.Errorf("failed to create indexes: %w", err)
+	if err := createAPIKeyIndexes(ctx, c.APIKeys); err != nil {
+		return fmt.Errorf("failed to create api key indexes: %w", err)
 	}
 
-	instance = &Config{
-		Client:     client,
-		Collection: collection,
+	if err := createExpiryIndex(ctx, c.RateLimits); err != nil {
+		return fmt.Errorf("failed to create rate limit indexes: %w", err)
 	}
 
-	return instance, nil
+	// Collections cannot be created implicitly inside a transaction on every server version
+	if c.Outbox != nil && !slices.Contains(collections, c.Outbox.Name()) {
+		if err := createCollection(db, c.Outbox.Name()); err != nil {
+			return fmt.Errorf("failed to create outbox collection: %w", err)
+		}
+	}
+
+	return nil
 }
 
 // Ensures all required indexes exist
@@ -149,6 +196,40 @@ func createIndexes(ctx context.Context, collection *mongo.Collection) error {
 	return nil
 }
 
+// Ensures the API key lookup index exists
+func createAPIKeyIndexes(ctx context.Context, collection *mongo.Collection) error {
+	indexCtx, indexCancel := context.WithTimeout(ctx, 10*time.Second)
+	defer indexCancel()
+
+	index := mongo.IndexModel{
+		Keys:    bson.D{{Key: "hash", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("hash_unique"),
+	}
+
+	if _, err := collection.Indexes().CreateOne(indexCtx, index); err != nil {
+		return fmt.Errorf("failed to create index: %w", err)
+	}
+
+	return nil
+}
+
+// Ensures documents are removed once their expiresAt date passes
+func createExpiryIndex(ctx context.Context, collection *mongo.Collection) error {
+	indexCtx, indexCancel := context.WithTimeout(ctx, 10*time.Second)
+	defer indexCancel()
+
+	index := mongo.IndexModel{
+		Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0).SetName("expiresAt_ttl"),
+	}
+
+	if _, err := collection.Indexes().CreateOne(indexCtx, index); err != nil {
+		return fmt.Errorf("failed to create index: %w", err)
+	}
+
+	return nil
+}
+
 func createCollection(db *mongo.Database, name string) error {
 	ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
 	defer cancel()
@@ -172,3 +253,26 @@ func (c *Config) Disconnect(ctx context.Context) error {
 	}
 	return nil
 }
+
+// Checks that the server runs transactions, which needs a replica set or a sharded cluster
+func (c *Config) SupportsTransactions(ctx context.Context) error {
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	if err := c.Client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		return fmt.Errorf("failed to query the server topology: %w", err)
+	}
+	if hello.SetName == "" && hello.Msg != "isdbgrid" {
+		return fmt.Errorf("transactions need a replica set or a sharded cluster, the server is a standalone instance")
+	}
+	return nil
+}
+
+// Checks that the primary is reachable
+func (c *Config) Ping(ctx context.Context) error {
+	if c.Client == nil {
+		return fmt.Errorf("database client not initialized")
+	}
+	return c.Client.Ping(ctx, readpref.Primary())
+}
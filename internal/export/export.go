@@ -0,0 +1,204 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+)
+
+// Supported export formats
+const (
+	// Array of headquarters with their branches, the format read by the file import source
+	FormatJSON = "json"
+	// Rows in the spreadsheet export format, the format read by the CSV import source
+	FormatCSV = "csv"
+	// One headquarter with its branches per line
+	FormatNDJSON = "ndjson"
+)
+
+// Column headers of the spreadsheet export format
+var csvHeader = []string{
+	"COUNTRY ISO2 CODE", "SWIFT CODE", "CODE TYPE", "NAME", "ADDRESS", "TOWN NAME", "COUNTRY NAME", "TIME ZONE",
+}
+
+// Content types of the export formats
+var contentTypes = map[string]string{
+	FormatJSON:   "application/json",
+	FormatCSV:    "text/csv; charset=utf-8",
+	FormatNDJSON: "application/x-ndjson",
+}
+
+// Writes headquarters one at a time so an export never holds the whole dataset
+type Encoder interface {
+	// Writes a headquarter with its branches
+	Encode(hq *models.Headquarter) error
+	// Completes the output, nothing is written after it
+	Close() error
+}
+
+// Returns the supported formats in a stable order
+func Formats() []string {
+	return []string{FormatJSON, FormatCSV, FormatNDJSON}
+}
+
+// Reports whether format is a supported export format
+func IsFormat(format string) bool {
+	return slices.Contains(Formats(), format)
+}
+
+// Returns the HTTP content type of a supported format
+func ContentType(format string) string {
+	return contentTypes[format]
+}
+
+// Creates an encoder writing the given format to w
+func NewEncoder(w io.Writer, format string) (Encoder, error) {
+	switch format {
+	case FormatJSON:
+		return &jsonEncoder{w: w}, nil
+	case FormatCSV:
+		return &csvEncoder{writer: csv.NewWriter(w)}, nil
+	case FormatNDJSON:
+		return &ndjsonEncoder{encoder: json.NewEncoder(w)}, nil
+	default:
+		return nil, fmt.Errorf("unknown export format: %s", format)
+	}
+}
+
+// Writes headquarters with their branches to w in the given format
+func Write(w io.Writer, format string, hqs []models.Headquarter) error {
+	encoder, err := NewEncoder(w, format)
+	if err != nil {
+		return err
+	}
+	for i := range hqs {
+		if err := encoder.Encode(&hqs[i]); err != nil {
+			return err
+		}
+	}
+	return encoder.Close()
+}
+
+// Writes headquarters as an indented JSON array
+func WriteJSON(w io.Writer, hqs []models.Headquarter) error {
+	return Write(w, FormatJSON, hqs)
+}
+
+// Writes one row per headquarter and branch, each headquarter followed by its branches
+func WriteCSV(w io.Writer, hqs []models.Headquarter) error {
+	return Write(w, FormatCSV, hqs)
+}
+
+// Writes the elements of an indented JSON array as they arrive
+type jsonEncoder struct {
+	w     io.Writer
+	count int
+}
+
+func (e *jsonEncoder) Encode(hq *models.Headquarter) error {
+	data, err := json.MarshalIndent(hq, "  ", "  ")
+	if err != nil {
+		return err
+	}
+
+	separator := ",\n  "
+	if e.count == 0 {
+		separator = "[\n  "
+	}
+	e.count++
+
+	if _, err := io.WriteString(e.w, separator); err != nil {
+		return err
+	}
+	_, err = e.w.Write(data)
+	return err
+}
+
+func (e *jsonEncoder) Close() error {
+	closing := "\n]\n"
+	if e.count == 0 {
+		closing = "[]\n"
+	}
+	_, err := io.WriteString(e.w, closing)
+	return err
+}
+
+type csvEncoder struct {
+	writer        *csv.Writer
+	headerWritten bool
+}
+
+// Writes the headquarter row followed by its branches, the header precedes the first row
+func (e *csvEncoder) Encode(hq *models.Headquarter) error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	if err := e.writer.Write(csvRow(hq)); err != nil {
+		return err
+	}
+	for i := range hq.Branches {
+		if err := e.writer.Write(csvRow(&hq.Branches[i])); err != nil {
+			return err
+		}
+	}
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+// An empty export still has the header so it can be imported
+func (e *csvEncoder) Close() error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+func (e *csvEncoder) writeHeader() error {
+	if e.headerWritten {
+		return nil
+	}
+	e.headerWritten = true
+	return e.writer.Write(csvHeader)
+}
+
+type ndjsonEncoder struct {
+	encoder *json.Encoder
+}
+
+func (e *ndjsonEncoder) Encode(hq *models.Headquarter) error {
+	return e.encoder.Encode(hq)
+}
+
+func (e *ndjsonEncoder) Close() error {
+	return nil
+}
+
+// Fills the spreadsheet columns, the code type follows from the length of the SWIFT code.
+// The time zone is not stored and left empty. The layout has no columns for the SWIFTRef directory details or the
+// provenance, so the CSV drops them, the json and ndjson formats keep every stored field.
+func csvRow(entity models.BankEntity) []string {
+	var townName string
+	switch e := entity.(type) {
+	case *models.Headquarter:
+		townName = e.TownName
+	case *models.Branch:
+		townName = e.TownName
+	}
+
+	return []string{
+		entity.GetCountryISO2(),
+		entity.GetSwiftCode(),
+		"BIC" + strconv.Itoa(len(entity.GetSwiftCode())),
+		entity.GetBankName(),
+		entity.GetAddress(),
+		townName,
+		entity.GetCountryName(),
+		"",
+	}
+}
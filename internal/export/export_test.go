@@ -0,0 +1,94 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/MarcinZ20/bankAPI/internal/parser"
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testHeadquarters = []models.Headquarter{
+	{
+		SwiftCode:     "BREXPLPWXXX",
+		BankName:      "MBANK S.A.",
+		Address:       "UL. PROSTA 18, WARSZAWA",
+		CountryISO2:   "PL",
+		CountryName:   "POLAND",
+		IsHeadquarter: true,
+		Branches: []models.Branch{
+			{
+				SwiftCode:   "BREXPLPWWAL",
+				BankName:    "MBANK S.A.",
+				Address:     "UL. PROSTA 18, WARSZAWA",
+				TownName:    "WARSZAWA",
+				CountryISO2: "PL",
+				CountryName: "POLAND",
+			},
+		},
+	},
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, FormatCSV, testHeadquarters))
+
+	// The export must be readable by the CSV import source
+	var banks []models.Bank
+	require.NoError(t, parser.NewParser().ParseBankData(buf.String(), &banks))
+	require.Len(t, banks, 2)
+
+	assert.Equal(t, "BREXPLPWXXX", banks[0].SwiftCode)
+	assert.Equal(t, "BREXPLPWWAL", banks[1].SwiftCode)
+	assert.Equal(t, "UL. PROSTA 18, WARSZAWA", banks[1].Address)
+	assert.Equal(t, "WARSZAWA", banks[1].TownName)
+	assert.Equal(t, "PL", banks[1].CountryISO2Code)
+	assert.Contains(t, buf.String(), "BREXPLPWWAL,BIC11,")
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, FormatJSON, testHeadquarters))
+
+	var hqs []models.Headquarter
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &hqs))
+	assert.Equal(t, testHeadquarters, hqs)
+}
+
+func TestWriteUnknownFormat(t *testing.T) {
+	assert.Error(t, Write(&bytes.Buffer{}, "xml", testHeadquarters))
+}
+
+func TestWriteNDJSON(t *testing.T) {
+	hqs := append(testHeadquarters, models.Headquarter{
+		SwiftCode: "DEUTDEFFXXX", BankName: "DEUTSCHE BANK", CountryISO2: "DE", CountryName: "GERMANY", IsHeadquarter: true,
+	})
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, FormatNDJSON, hqs))
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+	for i, line := range lines {
+		var hq models.Headquarter
+		require.NoError(t, json.Unmarshal(line, &hq))
+		assert.Equal(t, hqs[i], hq)
+	}
+}
+
+func TestWriteEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, FormatJSON, nil))
+	assert.Equal(t, "[]\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, Write(&buf, FormatCSV, nil))
+	assert.Equal(t, "COUNTRY ISO2 CODE,SWIFT CODE,CODE TYPE,NAME,ADDRESS,TOWN NAME,COUNTRY NAME,TIME ZONE\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, Write(&buf, FormatNDJSON, nil))
+	assert.Empty(t, buf.String())
+}
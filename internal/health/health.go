@@ -0,0 +1,176 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Dependency check states
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// States of the initial import, the service is ready in every state but pending
+const (
+	ImportPending     = "pending"
+	ImportCompleted   = "completed"
+	ImportNotRequired = "not_required"
+)
+
+// Checks a single dependency, returning an error when it is unavailable
+type Check func(ctx context.Context) error
+
+// Result of a single dependency check
+type DependencyStatus struct {
+	Status   string `json:"status"`
+	Duration string `json:"duration"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Detailed health of the service
+type Report struct {
+	Status       string                      `json:"status"`
+	Ready        bool                        `json:"ready"`
+	Version      string                      `json:"version"`
+	Commit       string                      `json:"commit"`
+	Uptime       string                      `json:"uptime"`
+	Import       string                      `json:"import"`
+	LastImportAt *time.Time                  `json:"lastImportAt,omitempty"`
+	RecordCount  int64                       `json:"recordCount"`
+	Dependencies map[string]DependencyStatus `json:"dependencies"`
+}
+
+// Tracks import progress and runs dependency checks for the health endpoints
+type Checker struct {
+	version   string
+	commit    string
+	startedAt time.Time
+	timeout   time.Duration
+
+	mu     sync.RWMutex
+	names  []string
+	checks map[string]Check
+
+	importState  atomic.Value
+	lastImportAt atomic.Pointer[time.Time]
+	records      atomic.Int64
+}
+
+var instance *Checker
+
+// Creates the health checker, each dependency check is given at most timeout
+func NewChecker(version, commit string, timeout time.Duration) *Checker {
+	instance = &Checker{
+		version:   version,
+		commit:    commit,
+		startedAt: time.Now(),
+		timeout:   timeout,
+		checks:    make(map[string]Check),
+	}
+	instance.importState.Store(ImportPending)
+	return instance
+}
+
+// Returns the current health checker instance
+func GetInstance() *Checker {
+	return instance
+}
+
+// Registers a dependency check, readiness fails while any check fails
+func (c *Checker) AddCheck(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.checks[name]; !ok {
+		c.names = append(c.names, name)
+	}
+	c.checks[name] = check
+}
+
+// Records a successful import of records SWIFT codes, the service is ready from the first one on
+func (c *Checker) RecordImport(at time.Time, records int) {
+	c.lastImportAt.Store(&at)
+	c.records.Store(int64(records))
+	c.importState.Store(ImportCompleted)
+}
+
+// Marks the service ready without an import, for data already stored before it started.
+// No import time is reported until an import actually runs.
+func (c *Checker) SkipImport() {
+	c.importState.CompareAndSwap(ImportPending, ImportNotRequired)
+}
+
+// Returns the state of the initial import
+func (c *Checker) ImportState() string {
+	return c.importState.Load().(string)
+}
+
+// Checks if the initial import has finished or is not required
+func (c *Checker) Imported() bool {
+	return c.ImportState() != ImportPending
+}
+
+// Runs all dependency checks concurrently and reports the overall health
+func (c *Checker) Report(ctx context.Context) Report {
+	c.mu.RLock()
+	names := append([]string(nil), c.names...)
+	checks := make([]Check, len(names))
+	for i, name := range names {
+		checks[i] = c.checks[name]
+	}
+	c.mu.RUnlock()
+
+	results := make([]DependencyStatus, len(names))
+	var wg sync.WaitGroup
+	for i := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = c.run(ctx, checks[i])
+		}()
+	}
+	wg.Wait()
+
+	report := Report{
+		Status:       StatusUp,
+		Ready:        c.Imported(),
+		Version:      c.version,
+		Commit:       c.commit,
+		Uptime:       time.Since(c.startedAt).Round(time.Second).String(),
+		Import:       c.ImportState(),
+		LastImportAt: c.lastImportAt.Load(),
+		RecordCount:  c.records.Load(),
+		Dependencies: make(map[string]DependencyStatus, len(names)),
+	}
+
+	for i, name := range names {
+		report.Dependencies[name] = results[i]
+		if results[i].Status != StatusUp {
+			report.Status = StatusDown
+			report.Ready = false
+		}
+	}
+
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, check Check) DependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+
+	status := DependencyStatus{
+		Status:   StatusUp,
+		Duration: time.Since(start).String(),
+	}
+	if err != nil {
+		status.Status = StatusDown
+		status.Error = err.Error()
+	}
+	return status
+}
@@ -0,0 +1,72 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecker_Report(t *testing.T) {
+	ctx := context.Background()
+	checker := NewChecker("v1.0.0", "abc123", time.Second)
+
+	var dbErr error
+	checker.AddCheck("mongodb", func(context.Context) error { return dbErr })
+
+	report := checker.Report(ctx)
+	assert.Equal(t, StatusUp, report.Status)
+	assert.False(t, report.Ready, "not ready before the first import")
+	assert.Equal(t, ImportPending, report.Import)
+	assert.Nil(t, report.LastImportAt)
+
+	checker.RecordImport(time.Now(), 42)
+
+	report = checker.Report(ctx)
+	assert.True(t, report.Ready)
+	assert.Equal(t, ImportCompleted, report.Import)
+	assert.Equal(t, int64(42), report.RecordCount)
+	assert.Equal(t, "v1.0.0", report.Version)
+	require.NotNil(t, report.LastImportAt)
+
+	dbErr = errors.New("connection refused")
+
+	report = checker.Report(ctx)
+	assert.Equal(t, StatusDown, report.Status)
+	assert.False(t, report.Ready, "not ready while a dependency is down")
+	assert.Equal(t, StatusDown, report.Dependencies["mongodb"].Status)
+	assert.Equal(t, "connection refused", report.Dependencies["mongodb"].Error)
+}
+
+func TestChecker_CheckTimeout(t *testing.T) {
+	checker := NewChecker("dev", "unknown", 10*time.Millisecond)
+	checker.AddCheck("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	checker.RecordImport(time.Now(), 1)
+
+	report := checker.Report(context.Background())
+	assert.False(t, report.Ready)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Dependencies["slow"].Error)
+}
+
+func TestChecker_SkipImport(t *testing.T) {
+	checker := NewChecker("dev", "unknown", time.Second)
+	checker.SkipImport()
+
+	report := checker.Report(context.Background())
+	assert.True(t, report.Ready)
+	assert.Equal(t, ImportNotRequired, report.Import)
+	assert.Nil(t, report.LastImportAt, "no import is reported before one runs")
+
+	checker.RecordImport(time.Now(), 3)
+	checker.SkipImport()
+
+	report = checker.Report(context.Background())
+	assert.Equal(t, ImportCompleted, report.Import)
+	assert.Equal(t, int64(3), report.RecordCount)
+}
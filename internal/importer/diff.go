@@ -0,0 +1,256 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MarcinZ20/bankAPI/internal/changes"
+	"github.com/MarcinZ20/bankAPI/internal/services"
+	"github.com/MarcinZ20/bankAPI/internal/tracing"
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Kinds of entities in a diff
+const (
+	EntityHeadquarter = "headquarter"
+	EntityBranch      = "branch"
+)
+
+// Changes a new dataset would make to the stored one
+type Diff struct {
+	Summary  DiffSummary `json:"summary"`
+	Added    []DiffEntry `json:"added"`
+	Removed  []DiffEntry `json:"removed"`
+	Modified []DiffEntry `json:"modified"`
+}
+
+// Counts of changed headquarters and branches
+type DiffSummary struct {
+	HeadquartersAdded    int `json:"headquartersAdded"`
+	HeadquartersRemoved  int `json:"headquartersRemoved"`
+	HeadquartersModified int `json:"headquartersModified"`
+	BranchesAdded        int `json:"branchesAdded"`
+	BranchesRemoved      int `json:"branchesRemoved"`
+	BranchesModified     int `json:"branchesModified"`
+}
+
+// A single added, removed or modified headquarter or branch
+type DiffEntry struct {
+	SwiftCode string        `json:"swiftCode"`
+	Kind      string        `json:"kind"`
+	Changes   []FieldChange `json:"changes,omitempty"`
+}
+
+// Old and new value of a modified field
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// Checks if applying the diff would change anything
+func (d *Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Modified) == 0
+}
+
+// Loads the source and compares it with the stored dataset without writing anything
+func DryRun(ctx context.Context, source Source) (_ *Diff, err error) {
+	ctx, span := tracing.Start(ctx, "import.DryRun", attribute.String("import.source", source.Kind))
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+
+	sm := services.GetInstance()
+	if sm == nil || !sm.IsInitialized() {
+		return nil, fmt.Errorf("service not initialized")
+	}
+
+	next, err := source.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := sm.BankService.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stored data: %w", err)
+	}
+
+	diff := Compare(current, *next)
+	slog.InfoContext(ctx, "import dry run completed",
+		"added", len(diff.Added), "removed", len(diff.Removed), "modified", len(diff.Modified))
+
+	return diff, nil
+}
+
+// Compares the stored headquarters with a new dataset, entries are ordered by SWIFT code
+func Compare(current []models.Headquarter, next map[string]models.Headquarter) *Diff {
+	before := flatten(current)
+	after := flatten(slices.Collect(maps.Values(next)))
+
+	diff := &Diff{Added: []DiffEntry{}, Removed: []DiffEntry{}, Modified: []DiffEntry{}}
+
+	for code, entity := range after {
+		old, ok := before[code]
+		if !ok {
+			diff.Added = append(diff.Added, DiffEntry{SwiftCode: code, Kind: kindOf(entity)})
+			continue
+		}
+		if changes := compareFields(old, entity); len(changes) > 0 {
+			diff.Modified = append(diff.Modified, DiffEntry{SwiftCode: code, Kind: kindOf(entity), Changes: changes})
+		}
+	}
+	for code, entity := range before {
+		if _, ok := after[code]; !ok {
+			diff.Removed = append(diff.Removed, DiffEntry{SwiftCode: code, Kind: kindOf(entity)})
+		}
+	}
+
+	for _, entries := range [][]DiffEntry{diff.Added, diff.Removed, diff.Modified} {
+		slices.SortFunc(entries, func(a, b DiffEntry) int {
+			return strings.Compare(a.SwiftCode, b.SwiftCode)
+		})
+	}
+
+	diff.Summary = DiffSummary{
+		HeadquartersAdded:    countKind(diff.Added, EntityHeadquarter),
+		HeadquartersRemoved:  countKind(diff.Removed, EntityHeadquarter),
+		HeadquartersModified: countKind(diff.Modified, EntityHeadquarter),
+		BranchesAdded:        countKind(diff.Added, EntityBranch),
+		BranchesRemoved:      countKind(diff.Removed, EntityBranch),
+		BranchesModified:     countKind(diff.Modified, EntityBranch),
+	}
+
+	return diff
+}
+
+// Builds the change events that turn the stored headquarters into next, ordered by SWIFT code
+func changeEvents(current []models.Headquarter, next map[string]models.Headquarter) []models.ChangeEvent {
+	before := flatten(current)
+	after := flatten(slices.Collect(maps.Values(next)))
+
+	var events []models.ChangeEvent
+	for code, entity := range after {
+		old, ok := before[code]
+		switch {
+		case !ok:
+			events = append(events, changes.Created(models.ChangeSourceImport, entity))
+		case len(compareFields(old, entity)) > 0:
+			events = append(events, changes.Updated(models.ChangeSourceImport, old, entity))
+		}
+	}
+	for code, entity := range before {
+		if _, ok := after[code]; !ok {
+			events = append(events, changes.Deleted(models.ChangeSourceImport, entity))
+		}
+	}
+
+	slices.SortFunc(events, func(a, b models.ChangeEvent) int {
+		return strings.Compare(a.SwiftCode, b.SwiftCode)
+	})
+	return events
+}
+
+// Publishes the changes an import made to the stored headquarters, loading into an empty store creates every record
+func publishChanges(current []models.Headquarter, next map[string]models.Headquarter) {
+	feed := changes.GetInstance()
+	if feed == nil {
+		return
+	}
+	feed.Publish(changeEvents(current, next)...)
+}
+
+// Indexes headquarters and their branches by SWIFT code
+func flatten(hqs []models.Headquarter) map[string]models.BankEntity {
+	entities := make(map[string]models.BankEntity)
+	for _, hq := range hqs {
+		for _, branch := range hq.Branches {
+			entities[branch.SwiftCode] = &branch
+		}
+		hq.Branches = nil
+		entities[hq.SwiftCode] = &hq
+	}
+	return entities
+}
+
+// Lists the fields that differ between two versions of a headquarter or branch. Provenance, versions and
+// modification times describe the import rather than the record and are not compared.
+func compareFields(old, new models.BankEntity) []FieldChange {
+	oldDetails, newDetails := detailsOf(old), detailsOf(new)
+	fields := []struct {
+		name     string
+		old, new string
+	}{
+		{"bankName", old.GetBankName(), new.GetBankName()},
+		{"address", old.GetAddress(), new.GetAddress()},
+		{"countryISO2", old.GetCountryISO2(), new.GetCountryISO2()},
+		{"countryName", old.GetCountryName(), new.GetCountryName()},
+		{"isHeadquarter", strconv.FormatBool(old.IsHq()), strconv.FormatBool(new.IsHq())},
+		{"townName", oldDetails.townName, newDetails.townName},
+		{"zipCode", oldDetails.zipCode, newDetails.zipCode},
+		{"branchInformation", oldDetails.branchInformation, newDetails.branchInformation},
+		{"institutionType", oldDetails.institutionType, newDetails.institutionType},
+		{"validFrom", formatDate(oldDetails.validFrom), formatDate(newDetails.validFrom)},
+		{"validTo", formatDate(oldDetails.validTo), formatDate(newDetails.validTo)},
+	}
+
+	var changes []FieldChange
+	for _, field := range fields {
+		if field.old != field.new {
+			changes = append(changes, FieldChange{Field: field.name, Old: field.old, New: field.new})
+		}
+	}
+	return changes
+}
+
+// Directory details of a headquarter or branch, empty unless the import source provides them
+type details struct {
+	townName          string
+	zipCode           string
+	branchInformation string
+	institutionType   string
+	validFrom         *time.Time
+	validTo           *time.Time
+}
+
+func detailsOf(entity models.BankEntity) details {
+	switch e := entity.(type) {
+	case *models.Headquarter:
+		return details{e.TownName, e.ZipCode, e.BranchInformation, e.InstitutionType, e.ValidFrom, e.ValidTo}
+	case *models.Branch:
+		return details{e.TownName, e.ZipCode, e.BranchInformation, e.InstitutionType, e.ValidFrom, e.ValidTo}
+	default:
+		return details{}
+	}
+}
+
+func formatDate(date *time.Time) string {
+	if date == nil {
+		return ""
+	}
+	return date.Format(time.DateOnly)
+}
+
+func kindOf(entity models.BankEntity) string {
+	if _, ok := entity.(*models.Headquarter); ok {
+		return EntityHeadquarter
+	}
+	return EntityBranch
+}
+
+func countKind(entries []DiffEntry, kind string) int {
+	count := 0
+	for _, entry := range entries {
+		if entry.Kind == kind {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,154 @@
+package importer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MarcinZ20/bankAPI/internal/changes"
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompare(t *testing.T) {
+	current := []models.Headquarter{
+		{
+			SwiftCode: "BREXPLPWXXX", BankName: "MBANK S.A.", Address: "PROSTA 18", CountryISO2: "PL", CountryName: "POLAND", IsHeadquarter: true,
+			Branches: []models.Branch{
+				{SwiftCode: "BREXPLPWWAL", BankName: "MBANK S.A.", Address: "PROSTA 18", CountryISO2: "PL", CountryName: "POLAND"},
+				{SwiftCode: "BREXPLPWKRA", BankName: "MBANK S.A.", Address: "RYNEK 1", CountryISO2: "PL", CountryName: "POLAND"},
+			},
+		},
+		{SwiftCode: "DEUTDEFFXXX", BankName: "DEUTSCHE BANK", Address: "TAUNUSANLAGE 12", CountryISO2: "DE", CountryName: "GERMANY", IsHeadquarter: true},
+	}
+
+	next := map[string]models.Headquarter{
+		"BREXPLPW": {
+			SwiftCode: "BREXPLPWXXX", BankName: "MBANK S.A.", Address: "PROSTA 20", CountryISO2: "PL", CountryName: "POLAND", IsHeadquarter: true,
+			Branches: []models.Branch{
+				{SwiftCode: "BREXPLPWWAL", BankName: "MBANK S.A.", Address: "PROSTA 18", CountryISO2: "PL", CountryName: "POLAND"},
+				{SwiftCode: "BREXPLPWGDA", BankName: "MBANK S.A.", Address: "DLUGA 5", CountryISO2: "PL", CountryName: "POLAND"},
+			},
+		},
+		"BKSACLRM": {SwiftCode: "BKSACLRMXXX", BankName: "BANCO SANTANDER", Address: "BANDERA 140", CountryISO2: "CL", CountryName: "CHILE", IsHeadquarter: true},
+	}
+
+	diff := Compare(current, next)
+
+	assert.Equal(t, []DiffEntry{
+		{SwiftCode: "BKSACLRMXXX", Kind: EntityHeadquarter},
+		{SwiftCode: "BREXPLPWGDA", Kind: EntityBranch},
+	}, diff.Added)
+	assert.Equal(t, []DiffEntry{
+		{SwiftCode: "BREXPLPWKRA", Kind: EntityBranch},
+		{SwiftCode: "DEUTDEFFXXX", Kind: EntityHeadquarter},
+	}, diff.Removed)
+	assert.Equal(t, []DiffEntry{
+		{SwiftCode: "BREXPLPWXXX", Kind: EntityHeadquarter, Changes: []FieldChange{
+			{Field: "address", Old: "PROSTA 18", New: "PROSTA 20"},
+		}},
+	}, diff.Modified)
+	assert.Equal(t, DiffSummary{
+		HeadquartersAdded:    1,
+		HeadquartersRemoved:  1,
+		HeadquartersModified: 1,
+		BranchesAdded:        1,
+		BranchesRemoved:      1,
+	}, diff.Summary)
+	assert.False(t, diff.Empty())
+}
+
+func TestCompareUnchanged(t *testing.T) {
+	hq := models.Headquarter{SwiftCode: "DEUTDEFFXXX", BankName: "DEUTSCHE BANK", CountryISO2: "DE", IsHeadquarter: true}
+
+	diff := Compare([]models.Headquarter{hq}, map[string]models.Headquarter{hq.SwiftCode: hq})
+
+	assert.True(t, diff.Empty())
+	assert.Equal(t, DiffSummary{}, diff.Summary)
+}
+
+func TestCompareDirectoryDetails(t *testing.T) {
+	validFrom := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	validTo := time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	hq := models.Headquarter{
+		SwiftCode: "DEUTDEFFXXX", BankName: "DEUTSCHE BANK", CountryISO2: "DE", IsHeadquarter: true,
+		TownName: "FRANKFURT", ZipCode: "60325", InstitutionType: "FIIN", ValidFrom: &validFrom,
+		Branches: []models.Branch{{SwiftCode: "DEUTDEFF500", BankName: "DEUTSCHE BANK", CountryISO2: "DE", BranchInformation: "FILIALE"}},
+	}
+	changed := hq
+	changed.TownName = "FRANKFURT AM MAIN"
+	changed.ValidTo = &validTo
+	changed.Branches = []models.Branch{{SwiftCode: "DEUTDEFF500", BankName: "DEUTSCHE BANK", CountryISO2: "DE", BranchInformation: "FILIALE 500"}}
+
+	diff := Compare([]models.Headquarter{hq}, map[string]models.Headquarter{"DEUTDEFF": changed})
+
+	assert.Equal(t, []DiffEntry{
+		{SwiftCode: "DEUTDEFF500", Kind: EntityBranch, Changes: []FieldChange{
+			{Field: "branchInformation", Old: "FILIALE", New: "FILIALE 500"},
+		}},
+		{SwiftCode: "DEUTDEFFXXX", Kind: EntityHeadquarter, Changes: []FieldChange{
+			{Field: "townName", Old: "FRANKFURT", New: "FRANKFURT AM MAIN"},
+			{Field: "validTo", Old: "", New: "2026-12-31"},
+		}},
+	}, diff.Modified)
+	assert.Len(t, changeEvents([]models.Headquarter{hq}, map[string]models.Headquarter{"DEUTDEFF": changed}), 2)
+}
+
+func TestPublishChangesOfInitialLoad(t *testing.T) {
+	feed := changes.NewFeed(changes.Config{BufferSize: 10})
+	defer feed.Close()
+	_, sub, _ := feed.Subscribe(0)
+
+	publishChanges(nil, map[string]models.Headquarter{
+		"BREXPLPW": {
+			SwiftCode: "BREXPLPWXXX", BankName: "MBANK S.A.", CountryISO2: "PL", CountryName: "POLAND", IsHeadquarter: true,
+			Branches: []models.Branch{{SwiftCode: "BREXPLPWKRA", BankName: "MBANK S.A.", CountryISO2: "PL", CountryName: "POLAND"}},
+		},
+	})
+
+	for _, swiftCode := range []string{"BREXPLPWKRA", "BREXPLPWXXX"} {
+		event := <-sub.Events()
+		assert.Equal(t, swiftCode, event.SwiftCode)
+		assert.Equal(t, models.ChangeCreated, event.Operation)
+	}
+}
+
+func TestChangeEvents(t *testing.T) {
+	current := []models.Headquarter{
+		{
+			SwiftCode: "BREXPLPWXXX", BankName: "MBANK S.A.", Address: "PROSTA 18", CountryISO2: "PL", CountryName: "POLAND", IsHeadquarter: true,
+			Branches: []models.Branch{
+				{SwiftCode: "BREXPLPWKRA", BankName: "MBANK S.A.", Address: "RYNEK 1", CountryISO2: "PL", CountryName: "POLAND"},
+			},
+		},
+		{SwiftCode: "DEUTDEFFXXX", BankName: "DEUTSCHE BANK", Address: "TAUNUSANLAGE 12", CountryISO2: "DE", CountryName: "GERMANY", IsHeadquarter: true},
+	}
+
+	next := map[string]models.Headquarter{
+		"BREXPLPW": {SwiftCode: "BREXPLPWXXX", BankName: "MBANK S.A.", Address: "PROSTA 20", CountryISO2: "PL", CountryName: "POLAND", IsHeadquarter: true},
+		"DEUTDEFF": {SwiftCode: "DEUTDEFFXXX", BankName: "DEUTSCHE BANK", Address: "TAUNUSANLAGE 12", CountryISO2: "DE", CountryName: "GERMANY", IsHeadquarter: true},
+		"BKSACLRM": {SwiftCode: "BKSACLRMXXX", BankName: "BANCO SANTANDER", Address: "BANDERA 140", CountryISO2: "CL", CountryName: "CHILE", IsHeadquarter: true},
+	}
+
+	events := changeEvents(current, next)
+
+	assert.Len(t, events, 3)
+	assert.Equal(t, "BKSACLRMXXX", events[0].SwiftCode)
+	assert.Equal(t, models.ChangeCreated, events[0].Operation)
+	assert.Nil(t, events[0].Before)
+	assert.Equal(t, "BANDERA 140", events[0].After.Address)
+
+	assert.Equal(t, "BREXPLPWKRA", events[1].SwiftCode)
+	assert.Equal(t, models.ChangeDeleted, events[1].Operation)
+	assert.Equal(t, "RYNEK 1", events[1].Before.Address)
+	assert.Nil(t, events[1].After)
+
+	assert.Equal(t, "BREXPLPWXXX", events[2].SwiftCode)
+	assert.Equal(t, models.ChangeUpdated, events[2].Operation)
+	assert.Equal(t, "PROSTA 18", events[2].Before.Address)
+	assert.Equal(t, "PROSTA 20", events[2].After.Address)
+
+	for _, event := range events {
+		assert.Equal(t, models.ChangeSourceImport, event.Source)
+	}
+}
@@ -0,0 +1,208 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/MarcinZ20/bankAPI/internal/database"
+	"github.com/MarcinZ20/bankAPI/internal/metrics"
+	"github.com/MarcinZ20/bankAPI/internal/repository"
+	"github.com/MarcinZ20/bankAPI/internal/services"
+	"github.com/MarcinZ20/bankAPI/internal/tracing"
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Replaces the stored dataset with data by writing only the headquarters that changed, readers never see an empty collection
+func ImportIncremental(ctx context.Context, data map[string]models.Headquarter) (err error) {
+	start := time.Now()
+	defer func() { metrics.ObserveImport(start, err) }()
+
+	ctx, span := tracing.Start(ctx, "import.ImportIncremental", attribute.Int("import.documents", len(data)))
+	defer func() { tracing.End(span, err) }()
+
+	return writeChanges(ctx, data)
+}
+
+// Writes the headquarters of data that differ from the stored ones and deletes the ones data lacks
+func writeChanges(ctx context.Context, data map[string]models.Headquarter) (err error) {
+	ctx, span := tracing.Start(ctx, "import.apply")
+	defer func() { tracing.End(span, err) }()
+
+	finish := startStage(ctx, StageStore)
+	writes := 0
+	defer func() { finish(writes, err) }()
+
+	db := database.GetInstance()
+	if db == nil {
+		return fmt.Errorf("database connection not initialized")
+	}
+
+	current, err := repository.NewBankRepository(db.Collection).FindAll(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read stored data: %w", err)
+	}
+
+	stamp(current, data, time.Now())
+	updates := changedDocuments(current, data)
+	span.SetAttributes(attribute.Int("import.writes", len(updates)))
+
+	if len(updates) > 0 {
+		if err := applyUpdates(ctx, db, updates, current, data); err != nil {
+			slog.ErrorContext(ctx, "import stage failed", "stage", "apply", "error", err)
+			return fmt.Errorf("failed to apply changes: %w", err)
+		}
+
+		// Only the changed entries are stale, but finding them all is not worth it after a bulk update
+		if sm := services.GetInstance(); sm != nil && sm.IsInitialized() {
+			sm.BankService.InvalidateCache()
+		}
+		publishChanges(current, data)
+	}
+	writes = len(updates)
+	slog.InfoContext(ctx, "import stage completed", "stage", "apply", "writes", writes)
+
+	recordImport(data)
+	return nil
+}
+
+// Writes the updates, together with the outbox entries of their changes when the outbox is enabled. The updates
+// are one transaction whenever the server runs transactions, so a canceled or failed import changes nothing.
+func applyUpdates(ctx context.Context, db *database.Config, updates []mongo.WriteModel, current []models.Headquarter, next map[string]models.Headquarter) error {
+	if db.Outbox == nil {
+		if db.SupportsTransactions(ctx) != nil {
+			_, err := db.Collection.BulkWrite(ctx, updates)
+			return err
+		}
+
+		session, err := db.Client.StartSession()
+		if err != nil {
+			return fmt.Errorf("failed to start session: %w", err)
+		}
+		defer session.EndSession(ctx)
+
+		_, err = session.WithTransaction(ctx, func(ctx mongo.SessionContext) (any, error) {
+			return db.Collection.BulkWrite(ctx, updates)
+		})
+		return err
+	}
+
+	return repository.NewOutbox(db.Outbox).WithTransaction(ctx, func(ctx context.Context) ([]models.ChangeEvent, error) {
+		if _, err := db.Collection.BulkWrite(ctx, updates); err != nil {
+			return nil, err
+		}
+		return changeEvents(current, next), nil
+	})
+}
+
+// Builds upserts for new and changed headquarters and deletes for the ones missing from next
+func changedDocuments(current []models.Headquarter, next map[string]models.Headquarter) []mongo.WriteModel {
+	stored := make(map[string]models.Headquarter, len(current))
+	for _, hq := range current {
+		stored[hq.SwiftCode] = normalize(hq)
+	}
+
+	var writes []mongo.WriteModel
+	seen := make(map[string]bool, len(next))
+	for _, hq := range next {
+		hq = normalize(hq)
+		seen[hq.SwiftCode] = true
+		if old, ok := stored[hq.SwiftCode]; ok && reflect.DeepEqual(withoutRows(old), withoutRows(hq)) {
+			continue
+		}
+		writes = append(writes, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"swiftCode": hq.SwiftCode}).
+			SetReplacement(hq).
+			SetUpsert(true))
+	}
+
+	for code := range stored {
+		if !seen[code] {
+			writes = append(writes, mongo.NewDeleteOneModel().SetFilter(bson.M{"swiftCode": code}))
+		}
+	}
+
+	return writes
+}
+
+// Orders branches by SWIFT code so equal headquarters compare equal
+func normalize(hq models.Headquarter) models.Headquarter {
+	branches := slices.Clone(hq.Branches)
+	if branches == nil {
+		branches = []models.Branch{}
+	}
+	slices.SortFunc(branches, func(a, b models.Branch) int {
+		return strings.Compare(a.SwiftCode, b.SwiftCode)
+	})
+	hq.Branches = branches
+	return hq
+}
+
+// Copies a headquarter without the source rows in its provenance, for comparisons. Rows shift whenever a row
+// above is added or removed, which does not change the record, so a stored record keeps its row until it changes.
+func withoutRows(hq models.Headquarter) models.Headquarter {
+	hq.Provenance = withoutRow(hq.Provenance)
+	hq.Branches = slices.Clone(hq.Branches)
+	for i := range hq.Branches {
+		hq.Branches[i].Provenance = withoutRow(hq.Branches[i].Provenance)
+	}
+	return hq
+}
+
+func withoutRow(provenance *models.Provenance) *models.Provenance {
+	if provenance == nil {
+		return nil
+	}
+	source := *provenance
+	source.Row = 0
+	return &source
+}
+
+// Carries the version and modification times of unchanged headquarters and branches over to next. Changed
+// headquarters get the next version, so a conditional write based on the stored one fails, and changed
+// records are marked as modified at now.
+func stamp(current []models.Headquarter, next map[string]models.Headquarter, now time.Time) {
+	modified := now.UTC().Truncate(time.Millisecond)
+	stored := make(map[string]models.Headquarter, len(current))
+	for _, hq := range current {
+		stored[hq.SwiftCode] = hq
+	}
+
+	for key, hq := range next {
+		old, ok := stored[hq.SwiftCode]
+		branches := make(map[string]models.Branch, len(old.Branches))
+		for _, branch := range old.Branches {
+			branches[branch.SwiftCode] = branch
+		}
+
+		hq.Branches = slices.Clone(hq.Branches)
+		for i := range hq.Branches {
+			branch := &hq.Branches[i]
+			branch.UpdatedAt = &modified
+			if prev, ok := branches[branch.SwiftCode]; ok {
+				candidate := *branch
+				candidate.UpdatedAt = prev.UpdatedAt
+				candidate.Provenance, prev.Provenance = withoutRow(candidate.Provenance), withoutRow(prev.Provenance)
+				if reflect.DeepEqual(prev, candidate) {
+					branch.UpdatedAt = prev.UpdatedAt
+				}
+			}
+		}
+
+		candidate := hq
+		candidate.Version, candidate.UpdatedAt = old.Version, old.UpdatedAt
+		if ok && reflect.DeepEqual(withoutRows(normalize(old)), withoutRows(normalize(candidate))) {
+			hq = candidate
+		} else {
+			hq.Version, hq.UpdatedAt = old.Version+1, &modified
+		}
+		next[key] = hq
+	}
+}
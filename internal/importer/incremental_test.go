@@ -0,0 +1,94 @@
+package importer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStamp(t *testing.T) {
+	imported := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	now := time.Date(2026, 2, 1, 8, 30, 0, 0, time.UTC)
+
+	current := []models.Headquarter{
+		{
+			SwiftCode: "BREXPLPWXXX", BankName: "MBANK S.A.", CountryISO2: "PL", IsHeadquarter: true, UpdatedAt: &imported, Version: 3,
+			Branches: []models.Branch{
+				{SwiftCode: "BREXPLPWKRA", BankName: "MBANK S.A.", Address: "RYNEK 1", CountryISO2: "PL", UpdatedAt: &imported},
+				{SwiftCode: "BREXPLPWWAL", BankName: "MBANK S.A.", Address: "PROSTA 18", CountryISO2: "PL", UpdatedAt: &imported},
+			},
+		},
+		{SwiftCode: "DEUTDEFFXXX", BankName: "DEUTSCHE BANK", CountryISO2: "DE", IsHeadquarter: true, UpdatedAt: &imported, Version: 1},
+	}
+
+	next := map[string]models.Headquarter{
+		"BREXPLPW": {
+			SwiftCode: "BREXPLPWXXX", BankName: "MBANK S.A.", CountryISO2: "PL", IsHeadquarter: true,
+			Branches: []models.Branch{
+				{SwiftCode: "BREXPLPWWAL", BankName: "MBANK S.A.", Address: "PROSTA 18", CountryISO2: "PL"},
+				{SwiftCode: "BREXPLPWKRA", BankName: "MBANK S.A.", Address: "RYNEK 2", CountryISO2: "PL"},
+			},
+		},
+		"DEUTDEFF": {SwiftCode: "DEUTDEFFXXX", BankName: "DEUTSCHE BANK", CountryISO2: "DE", IsHeadquarter: true},
+		"BKSACLRM": {SwiftCode: "BKSACLRMXXX", BankName: "BANCO SANTANDER", CountryISO2: "CL", IsHeadquarter: true},
+	}
+
+	stamp(current, next, now)
+
+	// The changed branch changes its headquarter, the other branch keeps its time
+	mbank := next["BREXPLPW"]
+	assert.Equal(t, int64(4), mbank.Version)
+	require.NotNil(t, mbank.UpdatedAt)
+	assert.Equal(t, now, *mbank.UpdatedAt)
+	assert.Equal(t, imported, *mbank.Branches[0].UpdatedAt)
+	assert.Equal(t, now, *mbank.Branches[1].UpdatedAt)
+
+	deutsche := next["DEUTDEFF"]
+	assert.Equal(t, int64(1), deutsche.Version)
+	assert.Equal(t, imported, *deutsche.UpdatedAt)
+
+	santander := next["BKSACLRM"]
+	assert.Equal(t, int64(1), santander.Version)
+	assert.Equal(t, now, *santander.UpdatedAt)
+
+	// Stamped documents of unchanged headquarters are not written again
+	assert.Len(t, changedDocuments(current, next), 2)
+}
+
+func TestStampIgnoresShiftedRows(t *testing.T) {
+	imported := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	now := time.Date(2026, 2, 1, 8, 30, 0, 0, time.UTC)
+	source := func(row int) *models.Provenance {
+		return &models.Provenance{Source: "swift-codes.csv", File: "swift-codes.csv", Row: row}
+	}
+
+	current := []models.Headquarter{{
+		SwiftCode: "BREXPLPWXXX", BankName: "MBANK S.A.", CountryISO2: "PL", IsHeadquarter: true,
+		Provenance: source(2), UpdatedAt: &imported, Version: 2,
+		Branches: []models.Branch{
+			{SwiftCode: "BREXPLPWWAL", BankName: "MBANK S.A.", CountryISO2: "PL", Provenance: source(3), UpdatedAt: &imported},
+		},
+	}}
+
+	// A row inserted above the record moves it down without changing it
+	next := map[string]models.Headquarter{
+		"BREXPLPW": {
+			SwiftCode: "BREXPLPWXXX", BankName: "MBANK S.A.", CountryISO2: "PL", IsHeadquarter: true, Provenance: source(3),
+			Branches: []models.Branch{
+				{SwiftCode: "BREXPLPWWAL", BankName: "MBANK S.A.", CountryISO2: "PL", Provenance: source(4)},
+			},
+		},
+	}
+
+	stamp(current, next, now)
+
+	mbank := next["BREXPLPW"]
+	assert.Equal(t, int64(2), mbank.Version)
+	assert.Equal(t, imported, *mbank.UpdatedAt)
+	assert.Equal(t, imported, *mbank.Branches[0].UpdatedAt)
+	assert.Empty(t, changedDocuments(current, next))
+	assert.True(t, Compare(current, next).Empty())
+}
@@ -0,0 +1,112 @@
+package importer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+)
+
+// Rules resolving a SWIFT code found in several parts of a multi source
+const (
+	// Keeps the row of the first part listing the code
+	DuplicatesFirst = "first"
+	// Keeps the row of the last part listing the code
+	DuplicatesLast = "last"
+	// Rejects the import, listing the conflicting rows
+	DuplicatesFail = "fail"
+)
+
+// Number of conflicts listed in the error of the fail rule
+const maxReportedConflicts = 10
+
+func (s Source) validateParts() error {
+	if len(s.Sources) == 0 {
+		return fmt.Errorf("%s source requires at least one part", s.Kind)
+	}
+	switch s.Duplicates {
+	case "", DuplicatesFirst, DuplicatesLast, DuplicatesFail:
+	default:
+		return fmt.Errorf("unknown duplicates rule: %s", s.Duplicates)
+	}
+
+	for i, part := range s.Sources {
+		switch part.Kind {
+		case SourceSpreadsheet, SourceSheets, SourceCSV, SourceSWIFTRef:
+		default:
+			return fmt.Errorf("part %d of the %s source: unsupported source: %s", i+1, s.Kind, part.Kind)
+		}
+		if err := part.Validate(); err != nil {
+			return fmt.Errorf("part %d of the %s source: %w", i+1, s.Kind, err)
+		}
+	}
+	return nil
+}
+
+// Reads every part, the hash covers all of them so a change in any part is detected
+func (s Source) fetchParts(ctx context.Context) (*Content, error) {
+	content := Content{Parts: make([]*Content, 0, len(s.Sources))}
+	hash := sha256.New()
+	for _, part := range s.Sources {
+		partContent, err := part.fetch(ctx, "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch %s: %w", part.name(), err)
+		}
+		content.Parts = append(content.Parts, partContent)
+		hash.Write([]byte(partContent.Hash))
+	}
+	content.Hash = hex.EncodeToString(hash.Sum(nil))
+	return &content, nil
+}
+
+// Parses every part and merges the rows by SWIFT code according to the duplicates rule
+func (s Source) mergeParts(ctx context.Context, parts []*Content) ([]models.Bank, error) {
+	if len(parts) != len(s.Sources) {
+		return nil, fmt.Errorf("expected content of %d parts, got %d", len(s.Sources), len(parts))
+	}
+
+	type owner struct{ part, index int }
+
+	var merged []models.Bank
+	owners := make(map[string]owner)
+	var conflicts []string
+	for i, part := range s.Sources {
+		rows, err := part.parse(ctx, parts[i].Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", part.name(), err)
+		}
+
+		for _, row := range rows {
+			// Repeated codes within one part are left to the transformer as in single source imports
+			existing, ok := owners[row.SwiftCode]
+			if !ok || existing.part == i {
+				owners[row.SwiftCode] = owner{part: i, index: len(merged)}
+				merged = append(merged, row)
+				continue
+			}
+
+			switch s.Duplicates {
+			case DuplicatesFirst:
+			case DuplicatesLast:
+				merged[existing.index] = row
+				owners[row.SwiftCode] = owner{part: i, index: existing.index}
+			default:
+				first := merged[existing.index].Provenance
+				conflicts = append(conflicts, fmt.Sprintf("%s in %s row %d and %s row %d",
+					row.SwiftCode, first.Source, first.Row, row.Provenance.Source, row.Provenance.Row))
+			}
+			slog.DebugContext(ctx, "duplicate swift code", "swiftCode", row.SwiftCode,
+				"source", row.Provenance.Source, "row", row.Provenance.Row, "rule", s.Duplicates)
+		}
+	}
+
+	if len(conflicts) > 0 {
+		listed := conflicts[:min(len(conflicts), maxReportedConflicts)]
+		return nil, fmt.Errorf("%d duplicate swift codes across sources: %s", len(conflicts), strings.Join(listed, "; "))
+	}
+	return merged, nil
+}
@@ -0,0 +1,110 @@
+package importer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MarcinZ20/bankAPI/internal/parser"
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const exportHeader = "COUNTRY ISO2 CODE,SWIFT CODE,CODE TYPE,NAME,ADDRESS,TOWN NAME,COUNTRY NAME,TIME ZONE\n"
+
+func writeCSV(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func multiSource(t *testing.T, duplicates string) Source {
+	t.Helper()
+	registry := writeCSV(t, "registry.csv", exportHeader+
+		"PL,BREXPLPWXXX,BIC11,MBANK S.A.,PROSTA 18,WARSZAWA,POLAND,Europe/Warsaw\n"+
+		"PL,BREXPLPWWAL,BIC11,MBANK S.A.,PROSTA 18,WARSZAWA,POLAND,Europe/Warsaw\n")
+	// A differently laid out sheet read by header name
+	partner := writeCSV(t, "partner.csv", "Bank,BIC,Street,Country,ISO\n"+
+		"MBANK SA,BREXPLPWXXX,PROSTA 20,POLAND,PL\n"+
+		"DEUTSCHE BANK,DEUTDEFFXXX,TAUNUSANLAGE 12,GERMANY,DE\n")
+
+	return Source{
+		Kind:       SourceMulti,
+		Duplicates: duplicates,
+		Sources: []Source{
+			{Kind: SourceCSV, Name: "registry", File: registry},
+			{Kind: SourceCSV, File: partner, Columns: &parser.ColumnMapping{
+				CountryISO2: "ISO", SwiftCode: "BIC", Name: "Bank", Address: "Street", CountryName: "Country",
+			}},
+		},
+	}
+}
+
+func TestMultiSourceDuplicates(t *testing.T) {
+	tests := []struct {
+		duplicates string
+		wantName   string
+		wantSource string
+		wantRow    int
+	}{
+		{DuplicatesFirst, "MBANK S.A.", "registry", 2},
+		{DuplicatesLast, "MBANK SA", "csv:", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.duplicates, func(t *testing.T) {
+			source := multiSource(t, tt.duplicates)
+			data, err := source.Load(context.Background())
+			require.NoError(t, err)
+			require.Len(t, *data, 2)
+
+			hq := (*data)["BREXPLPW"]
+			assert.Equal(t, tt.wantName, hq.BankName)
+			require.NotNil(t, hq.Provenance)
+			assert.Contains(t, hq.Provenance.Source, tt.wantSource)
+			assert.Equal(t, tt.wantRow, hq.Provenance.Row)
+
+			require.Len(t, hq.Branches, 1)
+			assert.Equal(t, &models.Provenance{
+				Source: "registry", File: source.Sources[0].File, Row: 3,
+			}, hq.Branches[0].Provenance)
+
+			de := (*data)["DEUTDEFF"]
+			require.NotNil(t, de.Provenance)
+			assert.Equal(t, "csv:"+source.Sources[1].File, de.Provenance.Source)
+			assert.Equal(t, 3, de.Provenance.Row)
+		})
+	}
+}
+
+func TestMultiSourceDuplicatesFail(t *testing.T) {
+	source := multiSource(t, "")
+
+	_, err := source.Load(context.Background())
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "1 duplicate swift codes across sources")
+	assert.ErrorContains(t, err, "BREXPLPWXXX in registry row 2")
+}
+
+func TestMultiSourceValidate(t *testing.T) {
+	assert.ErrorContains(t, Source{Kind: SourceMulti}.Validate(), "at least one part")
+	assert.ErrorContains(t, Source{Kind: SourceMulti, Duplicates: "newest", Sources: []Source{{Kind: SourceCSV, File: "a.csv"}}}.Validate(), "unknown duplicates rule")
+	assert.ErrorContains(t, Source{Kind: SourceMulti, Sources: []Source{{Kind: SourceFile, File: "a.json"}}}.Validate(), "unsupported source: file")
+	assert.ErrorContains(t, Source{Kind: SourceMulti, Sources: []Source{{Kind: SourceCSV}}}.Validate(), "part 1 of the multi source")
+}
+
+func TestMultiSourceHashCoversParts(t *testing.T) {
+	source := multiSource(t, DuplicatesFirst)
+
+	before, err := source.Fetch(context.Background(), "")
+	require.NoError(t, err)
+	require.Len(t, before.Parts, 2)
+
+	require.NoError(t, os.WriteFile(source.Sources[1].File, []byte("Bank,BIC,Street,Country,ISO\n"), 0o600))
+	after, err := source.Fetch(context.Background(), "")
+	require.NoError(t, err)
+	assert.NotEqual(t, before.Hash, after.Hash)
+}
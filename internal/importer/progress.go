@@ -0,0 +1,46 @@
+package importer
+
+import "context"
+
+// Import stages in the order they run
+const (
+	StageFetch     = "fetch"
+	StageParse     = "parse"
+	StageValidate  = "validate"
+	StageTransform = "transform"
+	StageStore     = "store"
+)
+
+// Receives stage updates from a running import, calls come from the importing goroutine
+type Progress interface {
+	// Marks the stage as running
+	StageStarted(stage string)
+	// Marks the stage as done, rows is the number of records it produced
+	StageCompleted(stage string, rows int)
+	// Marks the stage as failed
+	StageFailed(stage string, err error)
+}
+
+type progressKey struct{}
+
+// Returns a context whose imports report their stages to progress
+func WithProgress(ctx context.Context, progress Progress) context.Context {
+	return context.WithValue(ctx, progressKey{}, progress)
+}
+
+// Starts a stage on the progress in ctx, the returned func finishes it
+func startStage(ctx context.Context, stage string) func(rows int, err error) {
+	progress, ok := ctx.Value(progressKey{}).(Progress)
+	if !ok {
+		return func(int, error) {}
+	}
+
+	progress.StageStarted(stage)
+	return func(rows int, err error) {
+		if err != nil {
+			progress.StageFailed(stage, err)
+			return
+		}
+		progress.StageCompleted(stage, rows)
+	}
+}
@@ -0,0 +1,89 @@
+package importer
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/MarcinZ20/bankAPI/internal/metrics"
+	"github.com/MarcinZ20/bankAPI/internal/repository"
+	"github.com/MarcinZ20/bankAPI/internal/services"
+	"github.com/MarcinZ20/bankAPI/internal/tracing"
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+)
+
+// Loads bank data snapshots from a source into an in-memory repository
+type SnapshotLoader struct {
+	Repo   *repository.MemoryRepository
+	Source Source
+}
+
+// Loads a fresh snapshot and swaps it in, the previous data is kept when loading fails
+func (l *SnapshotLoader) Reload(ctx context.Context) (err error) {
+	start := time.Now()
+	defer func() { metrics.ObserveImport(start, err) }()
+
+	ctx, span := tracing.Start(ctx, "import.SnapshotLoader.Reload")
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+
+	data, err := l.Source.Load(ctx)
+	if err != nil {
+		return err
+	}
+
+	return l.Apply(ctx, *data)
+}
+
+// Swaps in an already loaded dataset
+func (l *SnapshotLoader) Apply(ctx context.Context, data map[string]models.Headquarter) error {
+	finish := startStage(ctx, StageStore)
+	current, _ := l.Repo.FindAll(ctx)
+	stamp(current, data, time.Now())
+	l.Repo.Load(data)
+	finish(len(data), nil)
+	recordImport(data)
+	publishChanges(current, data)
+
+	// The whole dataset was replaced, cached lookups are no longer valid
+	if sm := services.GetInstance(); sm != nil && sm.IsInitialized() {
+		sm.BankService.InvalidateCache()
+	}
+
+	return nil
+}
+
+// Reloads the snapshot on SIGHUP and, when interval is positive, periodically until ctx is done
+func (l *SnapshotLoader) Watch(ctx context.Context, interval time.Duration) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+			slog.InfoContext(ctx, "received SIGHUP, reloading snapshot")
+		case <-tick:
+		}
+
+		if err := l.Reload(ctx); err != nil {
+			slog.ErrorContext(ctx, "failed to reload snapshot", "error", err)
+			continue
+		}
+		slog.InfoContext(ctx, "snapshot reloaded", "swiftCodes", l.Repo.Count())
+	}
+}
@@ -0,0 +1,255 @@
+package importer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/MarcinZ20/bankAPI/internal/parser"
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+)
+
+// Supported import sources
+const (
+	// Google Spreadsheet exported as CSV
+	SourceSpreadsheet = "spreadsheet"
+	// Private Google Spreadsheet read through the Sheets API with service account credentials
+	SourceSheets = "sheets"
+	// Local CSV file in the spreadsheet export format
+	SourceCSV = "csv"
+	// Local JSON snapshot, an array of headquarters with their branches
+	SourceFile = "file"
+	// Local SWIFTRef BIC directory file, delimited or fixed-width
+	SourceSWIFTRef = "swiftref"
+	// Several spreadsheet, sheets or csv sources merged into one dataset
+	SourceMulti = "multi"
+)
+
+// Describes where imported data comes from
+type Source struct {
+	Kind string
+	// Identifies the source in provenance metadata, derived from its location when empty
+	Name          string
+	SpreadsheetID string
+	// Tab, named range or A1 range read by the sheets source, empty reads the first tab
+	Range string
+	File  string
+	// Header names of the columns to read, nil reads the export columns by position
+	Columns *parser.ColumnMapping
+	// Parts of the multi source, merged in order
+	Sources []Source
+	// Resolves SWIFT codes found in several parts of the multi source, DuplicatesFail when empty
+	Duplicates string
+}
+
+// Checks that the source has everything it needs to be loaded
+func (s Source) Validate() error {
+	switch s.Kind {
+	case SourceSpreadsheet, SourceSheets:
+		if s.SpreadsheetID == "" {
+			return fmt.Errorf("%s source requires a spreadsheet ID", s.Kind)
+		}
+	case SourceCSV, SourceFile, SourceSWIFTRef:
+		if s.File == "" {
+			return fmt.Errorf("%s source requires a file", s.Kind)
+		}
+	case SourceMulti:
+		return s.validateParts()
+	default:
+		return fmt.Errorf("unknown import source: %s", s.Kind)
+	}
+	return nil
+}
+
+// Raw source data with the markers used to detect changes between imports
+type Content struct {
+	Data []byte
+	// Version reported by the server, only set for the spreadsheet source
+	ETag string
+	// SHA-256 of Data in hex
+	Hash string
+	// Set when the source still matches the ETag passed to Fetch, Data is empty then
+	NotModified bool
+	// Content of every part of the multi source, Data is empty then
+	Parts []*Content
+
+	// Stores a downloaded spreadsheet as the fallback for outages, nil for other sources
+	keep func(ctx context.Context)
+}
+
+// Keeps downloaded spreadsheets, including those of every part, as the last good copy
+func (c *Content) Keep(ctx context.Context) {
+	if c.keep != nil {
+		c.keep(ctx)
+	}
+	for _, part := range c.Parts {
+		part.Keep(ctx)
+	}
+}
+
+// Loads, validates and transforms the source data without storing it
+func (s Source) Load(ctx context.Context) (*map[string]models.Headquarter, error) {
+	content, err := s.Fetch(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	return s.Decode(ctx, content)
+}
+
+// Reads the raw source data, a spreadsheet that still matches etag is not downloaded again
+func (s Source) Fetch(ctx context.Context, etag string) (_ *Content, err error) {
+	finish := startStage(ctx, StageFetch)
+	defer func() { finish(0, err) }()
+
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return s.fetch(ctx, etag)
+}
+
+func (s Source) fetch(ctx context.Context, etag string) (*Content, error) {
+	if s.Kind == SourceMulti {
+		return s.fetchParts(ctx)
+	}
+
+	var content Content
+	switch s.Kind {
+	case SourceSpreadsheet:
+		response, err := fetchSpreadsheet(ctx, s.SpreadsheetID, etag)
+		if err != nil {
+			return nil, err
+		}
+		if response.NotModified {
+			return &Content{ETag: response.ETag, NotModified: true}, nil
+		}
+		content.Data = []byte(response.Body)
+		content.ETag = response.ETag
+		content.keep = response.Keep
+	case SourceSheets:
+		response, err := fetchSheet(ctx, s.SpreadsheetID, s.Range)
+		if err != nil {
+			return nil, err
+		}
+		content.Data = []byte(response.Body)
+		content.keep = response.Keep
+	default:
+		data, err := os.ReadFile(s.File)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s file: %w", s.Kind, err)
+		}
+		content.Data = data
+	}
+
+	sum := sha256.Sum256(content.Data)
+	content.Hash = hex.EncodeToString(sum[:])
+	return &content, nil
+}
+
+// Parses, validates and transforms fetched source data, a download that passes becomes the fallback copy
+func (s Source) Decode(ctx context.Context, content *Content) (*map[string]models.Headquarter, error) {
+	data, err := s.decode(ctx, content)
+	if err != nil {
+		return nil, err
+	}
+	content.Keep(ctx)
+	return data, nil
+}
+
+func (s Source) decode(ctx context.Context, content *Content) (*map[string]models.Headquarter, error) {
+	finish := startStage(ctx, StageParse)
+	if s.Kind == SourceFile {
+		snapshot, err := decodeSnapshot(content.Data)
+		if err != nil {
+			finish(0, err)
+			return nil, err
+		}
+		finish(len(*snapshot), nil)
+		return snapshot, nil
+	}
+
+	var rows []models.Bank
+	var err error
+	if s.Kind == SourceMulti {
+		rows, err = s.mergeParts(ctx, content.Parts)
+	} else {
+		rows, err = s.parse(ctx, content.Data)
+	}
+	finish(len(rows), err)
+	if err != nil {
+		return nil, err
+	}
+
+	return processRows(ctx, rows)
+}
+
+// Parses the source data and records the source of every row
+func (s Source) parse(ctx context.Context, data []byte) ([]models.Bank, error) {
+	rows, err := parseRows(ctx, string(data), s.parser())
+	if err != nil {
+		return nil, err
+	}
+
+	name := s.name()
+	for i := range rows {
+		rows[i].Provenance = &models.Provenance{
+			Source:        name,
+			SpreadsheetID: s.SpreadsheetID,
+			Range:         s.Range,
+			File:          s.File,
+			Row:           i + 2,
+		}
+	}
+	return rows, nil
+}
+
+// Returns the parser reading the format of the source
+func (s Source) parser() bankParser {
+	if s.Kind == SourceSWIFTRef {
+		return parser.NewSWIFTRefParser()
+	}
+	p := parser.NewParser()
+	if s.Columns != nil {
+		p.WithColumns(*s.Columns)
+	}
+	return p
+}
+
+// Returns the configured name or one derived from the location of the source
+func (s Source) name() string {
+	switch {
+	case s.Name != "":
+		return s.Name
+	case s.SpreadsheetID != "" && s.Range != "":
+		return s.Kind + ":" + s.SpreadsheetID + "/" + s.Range
+	case s.SpreadsheetID != "":
+		return s.Kind + ":" + s.SpreadsheetID
+	default:
+		return s.Kind + ":" + s.File
+	}
+}
+
+// Reads a JSON snapshot, an array of headquarters with their branches
+func LoadSnapshotFile(path string) (*map[string]models.Headquarter, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot file: %w", err)
+	}
+	return decodeSnapshot(content)
+}
+
+func decodeSnapshot(content []byte) (*map[string]models.Headquarter, error) {
+	var hqs []models.Headquarter
+	if err := json.Unmarshal(content, &hqs); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot file: %w", err)
+	}
+
+	data := make(map[string]models.Headquarter, len(hqs))
+	for _, hq := range hqs {
+		data[hq.SwiftCode] = hq
+	}
+
+	return &data, nil
+}
@@ -0,0 +1,33 @@
+package importer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSWIFTRefSource(t *testing.T) {
+	file := writeCSV(t, "bic-directory.txt",
+		"BIC8\tBRANCH BIC\tINSTITUTION NAME\tBRANCH INFORMATION\tSTREET ADDRESS 1\tCITY\tZIP CODE\tCOUNTRY NAME\tISO COUNTRY CODE\tSUBTYPE INDICATOR\tVALID FROM\n"+
+			"BREXPLPW\tXXX\tMBANK S.A.\t\tUL. PROSTA 18\tWARSZAWA\t00-850\tPOLAND\tPL\tSUPE\t2018-11-19\n"+
+			"BREXPLPW\tKRA\tMBANK S.A.\tKRAKOW BRANCH\tRYNEK 1\tKRAKOW\t31-042\tPOLAND\tPL\tBANK\t2020-01-01\n")
+
+	source := Source{Kind: SourceSWIFTRef, File: file}
+	data, err := source.Load(context.Background())
+	require.NoError(t, err)
+	require.Len(t, *data, 1)
+
+	hq := (*data)["BREXPLPW"]
+	assert.Equal(t, "BREXPLPWXXX", hq.SwiftCode)
+	assert.Equal(t, "WARSZAWA", hq.TownName)
+	assert.Equal(t, "SUPE", hq.InstitutionType)
+	require.NotNil(t, hq.ValidFrom)
+	assert.Equal(t, "2018-11-19", hq.ValidFrom.Format("2006-01-02"))
+	assert.Equal(t, "swiftref:"+file, hq.Provenance.Source)
+
+	require.Len(t, hq.Branches, 1)
+	assert.Equal(t, "KRAKOW BRANCH", hq.Branches[0].BranchInformation)
+	assert.Equal(t, "31-042", hq.Branches[0].ZipCode)
+}
@@ -3,71 +3,190 @@ package importer
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"time"
 
-	"github.com/MarcinZ20/bankAPI/internal/database"
+	"github.com/MarcinZ20/bankAPI/internal/health"
+	"github.com/MarcinZ20/bankAPI/internal/metrics"
 	"github.com/MarcinZ20/bankAPI/internal/parser"
 	"github.com/MarcinZ20/bankAPI/internal/spreadsheet"
+	"github.com/MarcinZ20/bankAPI/internal/tracing"
 	"github.com/MarcinZ20/bankAPI/internal/transform"
 	"github.com/MarcinZ20/bankAPI/internal/validation"
 	"github.com/MarcinZ20/bankAPI/pkg/models"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+// Limits a whole import, from loading the source to storing the data, overridden from the configuration at startup
+var Timeout = 5 * time.Minute
+
 // Handles the data import process from a Google Spreadsheet
 func ImportSpreadsheetData(ctx context.Context, spreadsheetID string) error {
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	return Import(ctx, Source{Kind: SourceSpreadsheet, SpreadsheetID: spreadsheetID})
+}
+
+// Loads data from source and replaces the stored dataset with it. Only the changed headquarters are written, like
+// ImportIncremental does, so readers never see an empty collection and the outbox entries are stored with the data.
+func Import(ctx context.Context, source Source) (err error) {
+	start := time.Now()
+	defer func() { metrics.ObserveImport(start, err) }()
+
+	ctx, span := tracing.Start(ctx, "import.Import", attribute.String("import.source", source.Kind))
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
 	defer cancel()
 
-	db := database.GetInstance()
-	if db == nil {
-		return fmt.Errorf("database connection not initialized")
+	data, err := source.Load(ctx)
+	if err != nil {
+		return err
+	}
+
+	return writeChanges(ctx, *data)
+}
+
+// Fetches, parses, validates and transforms spreadsheet data without storing it
+func LoadSpreadsheetData(ctx context.Context, spreadsheetID string) (*map[string]models.Headquarter, error) {
+	response, err := fetchSpreadsheet(ctx, spreadsheetID, "")
+	if err != nil {
+		return nil, err
 	}
 
+	data, err := ProcessSpreadsheetData(ctx, response.Body)
+	if err != nil {
+		return nil, err
+	}
+	response.Keep(ctx)
+	return data, nil
+}
+
+// Downloads the spreadsheet export unless it still matches etag
+func fetchSpreadsheet(ctx context.Context, spreadsheetID, etag string) (*spreadsheet.Response, error) {
 	googleSpreadsheet := &models.GoogleSpreadsheet{
 		SpreadsheetId: spreadsheetID,
 	}
 
-	response, err := spreadsheet.FetchData(googleSpreadsheet)
+	fetchCtx, span := tracing.Start(ctx, "import.fetch")
+	response, err := spreadsheet.Fetch(fetchCtx, googleSpreadsheet, etag)
+	if response != nil {
+		span.SetAttributes(attribute.Bool("import.cached", response.Cached))
+	}
+	tracing.End(span, err)
 	if err != nil {
-		return fmt.Errorf("failed to fetch spreadsheet data: %w", err)
+		slog.ErrorContext(ctx, "import stage failed", "stage", "fetch", "spreadsheetId", spreadsheetID, "error", err)
+		return nil, fmt.Errorf("failed to fetch spreadsheet data: %w", err)
 	}
+	slog.InfoContext(ctx, "import stage completed", "stage", "fetch", "spreadsheetId", spreadsheetID,
+		"notModified", response.NotModified, "cached", response.Cached)
+
+	return response, nil
+}
 
+// Reads a range of a private spreadsheet through the Sheets API as CSV
+func fetchSheet(ctx context.Context, spreadsheetID, sheetRange string) (*spreadsheet.Response, error) {
+	googleSpreadsheet := &models.GoogleSpreadsheet{
+		SpreadsheetId: spreadsheetID,
+	}
+
+	fetchCtx, span := tracing.Start(ctx, "import.fetch", attribute.String("import.range", sheetRange))
+	response, err := spreadsheet.FetchSheet(fetchCtx, googleSpreadsheet, sheetRange)
+	if response != nil {
+		span.SetAttributes(attribute.Bool("import.cached", response.Cached))
+	}
+	tracing.End(span, err)
+	if err != nil {
+		slog.ErrorContext(ctx, "import stage failed", "stage", "fetch", "spreadsheetId", spreadsheetID, "range", sheetRange, "error", err)
+		return nil, fmt.Errorf("failed to read spreadsheet through the Sheets API: %w", err)
+	}
+	slog.InfoContext(ctx, "import stage completed", "stage", "fetch", "spreadsheetId", spreadsheetID, "range", sheetRange,
+		"cached", response.Cached)
+
+	return response, nil
+}
+
+// Parses, validates and transforms CSV data in the spreadsheet export format
+func ProcessSpreadsheetData(ctx context.Context, response string) (*map[string]models.Headquarter, error) {
+	finish := startStage(ctx, StageParse)
+	rawData, err := parseRows(ctx, response, parser.NewParser())
+	finish(len(rawData), err)
+	if err != nil {
+		return nil, err
+	}
+	return processRows(ctx, rawData)
+}
+
+// Reads source data into banks, implemented by the spreadsheet and SWIFTRef parsers
+type bankParser interface {
+	ParseBankData(response string, data *[]models.Bank) error
+}
+
+// Parses source data with the parser of its format
+func parseRows(ctx context.Context, data string, parser bankParser) ([]models.Bank, error) {
 	var rawData []models.Bank
-	var parser = parser.NewParser()
-	if err := parser.ParseBankData(response, &rawData); err != nil {
-		return fmt.Errorf("failed to parse bank data: %w", err)
+	_, span := tracing.Start(ctx, "import.parse")
+	err := parser.ParseBankData(data, &rawData)
+	span.SetAttributes(attribute.Int("import.rows", len(rawData)))
+	tracing.End(span, err)
+	if err != nil {
+		slog.ErrorContext(ctx, "import stage failed", "stage", "parse", "error", err)
+		return nil, fmt.Errorf("failed to parse bank data: %w", err)
 	}
+	slog.InfoContext(ctx, "import stage completed", "stage", "parse", "rows", len(rawData))
+
+	metrics.ImportRows.WithLabelValues("parsed").Add(float64(len(rawData)))
+	return rawData, nil
+}
 
+// Validates and transforms parsed rows
+func processRows(ctx context.Context, rawData []models.Bank) (*map[string]models.Headquarter, error) {
+
+	finish := startStage(ctx, StageValidate)
+	_, span := tracing.Start(ctx, "import.validate")
 	var validationErrors []error
 	for i, bank := range rawData {
 		result := validation.ValidateBankEntity(bank)
 		if !result.IsValid {
+			slog.WarnContext(ctx, "invalid row", "stage", "validate", "index", i,
+				"swiftCode", bank.SwiftCode, "countryISO2", bank.CountryISO2Code, "errors", result.Errors)
 			validationErrors = append(validationErrors,
 				fmt.Errorf("validation failed for bank at index %d: %v", i, result.Errors))
 		}
 	}
+	span.SetAttributes(attribute.Int("import.invalid_rows", len(validationErrors)))
 
 	if len(validationErrors) > 0 {
-		return fmt.Errorf("validation errors occurred: %v", validationErrors)
+		err := fmt.Errorf("validation errors occurred: %v", validationErrors)
+		tracing.End(span, err)
+		finish(len(rawData)-len(validationErrors), err)
+		metrics.ImportRows.WithLabelValues("invalid").Add(float64(len(validationErrors)))
+		slog.ErrorContext(ctx, "import stage failed", "stage", "validate", "invalidRows", len(validationErrors))
+		return nil, err
 	}
+	span.End()
+	finish(len(rawData), nil)
+	slog.InfoContext(ctx, "import stage completed", "stage", "validate", "rows", len(rawData))
+
+	finish = startStage(ctx, StageTransform)
+	_, span = tracing.Start(ctx, "import.transform")
+	defer span.End()
 
 	transformer := transform.ModelTransformer{}
 	transformedData := transformer.TransformBankData(&rawData)
+	finish(len(*transformedData), nil)
+	slog.InfoContext(ctx, "import stage completed", "stage", "transform", "headquarters", len(*transformedData))
 
-	// For clean setup, clean existing data
-	if err := db.Collection.Drop(ctx); err != nil {
-		return fmt.Errorf("failed to clear existing data: %w", err)
-	}
+	return transformedData, nil
+}
 
-	var documents []any
-	for _, bank := range *transformedData {
-		documents = append(documents, bank)
-	}
+// Publishes the size of a successfully stored dataset to metrics and health checks
+func recordImport(data map[string]models.Headquarter) {
+	metrics.RecordDataset(data)
 
-	_, err = db.Collection.InsertMany(ctx, documents)
-	if err != nil {
-		return fmt.Errorf("failed to insert data: %w", err)
+	if checker := health.GetInstance(); checker != nil {
+		records := 0
+		for _, hq := range data {
+			records += 1 + len(hq.Branches)
+		}
+		checker.RecordImport(time.Now(), records)
 	}
-
-	return nil
 }
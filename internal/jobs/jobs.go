@@ -0,0 +1,307 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/MarcinZ20/bankAPI/internal/importer"
+)
+
+// Job statuses
+const (
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusCanceled  = "canceled"
+)
+
+// Stage statuses
+const (
+	StageRunning   = "running"
+	StageCompleted = "completed"
+	StageFailed    = "failed"
+)
+
+// Number of finished jobs kept when the configuration does not set it
+const defaultHistory = 50
+
+var (
+	ErrNotFound      = errors.New("import job not found")
+	ErrRunning       = errors.New("an import is already running")
+	ErrNotRunning    = errors.New("import job is not running")
+	ErrInvalidSource = errors.New("invalid import source")
+)
+
+// Loads source and stores its data, e.g. importer.Import
+type RunFunc func(ctx context.Context, source importer.Source) error
+
+// Loads source and compares it with the stored data, e.g. importer.DryRun
+type DryRunFunc func(ctx context.Context, source importer.Source) (*importer.Diff, error)
+
+// Holds job manager settings
+type Config struct {
+	Run    RunFunc
+	DryRun DryRunFunc
+	// Number of finished jobs kept, older ones are dropped
+	History int
+}
+
+// Progress of a single import stage
+type Stage struct {
+	Name      string    `json:"name"`
+	Status    string    `json:"status"`
+	Rows      int       `json:"rows"`
+	Error     string    `json:"error,omitempty"`
+	StartedAt time.Time `json:"startedAt"`
+	Duration  string    `json:"duration,omitempty"`
+}
+
+// Import started through the admin API
+type Job struct {
+	ID            string  `json:"id"`
+	Source        string  `json:"source"`
+	SpreadsheetID string  `json:"spreadsheetId,omitempty"`
+	Range         string  `json:"range,omitempty"`
+	File          string  `json:"file,omitempty"`
+	DryRun        bool    `json:"dryRun,omitempty"`
+	Status        string  `json:"status"`
+	Stages        []Stage `json:"stages"`
+	// Changes a succeeded dry run found
+	Diff       *importer.Diff `json:"diff,omitempty"`
+	Error      string         `json:"error,omitempty"`
+	StartedAt  time.Time      `json:"startedAt"`
+	FinishedAt *time.Time     `json:"finishedAt,omitempty"`
+	Duration   string         `json:"duration,omitempty"`
+}
+
+type entry struct {
+	job    Job
+	cancel context.CancelFunc
+}
+
+// Runs imports in the background one at a time and keeps their history
+type Manager struct {
+	config Config
+
+	mu      sync.Mutex
+	jobs    map[string]*entry
+	order   []string
+	running string
+}
+
+// Creates a job manager without any jobs
+func NewManager(config Config) *Manager {
+	if config.History <= 0 {
+		config.History = defaultHistory
+	}
+	return &Manager{config: config, jobs: make(map[string]*entry)}
+}
+
+// Starts importing source in the background, the job outlives ctx but keeps its values
+func (m *Manager) Start(ctx context.Context, source importer.Source) (Job, error) {
+	return m.start(ctx, source, false, func(ctx context.Context, _ *entry) error {
+		return m.config.Run(ctx, source)
+	})
+}
+
+// Starts comparing source with the stored data in the background like Start, without writing anything.
+// The job holds the diff once it succeeded.
+func (m *Manager) StartDryRun(ctx context.Context, source importer.Source) (Job, error) {
+	return m.start(ctx, source, true, func(ctx context.Context, e *entry) error {
+		diff, err := m.config.DryRun(ctx, source)
+		if err != nil {
+			return err
+		}
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		e.job.Diff = diff
+		return nil
+	})
+}
+
+func (m *Manager) start(ctx context.Context, source importer.Source, dryRun bool, run func(ctx context.Context, e *entry) error) (Job, error) {
+	if err := source.Validate(); err != nil {
+		return Job{}, fmt.Errorf("%w: %v", ErrInvalidSource, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.running != "" {
+		return Job{}, ErrRunning
+	}
+
+	id, err := newJobID()
+	if err != nil {
+		return Job{}, err
+	}
+
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	e := &entry{
+		job: Job{
+			ID:            id,
+			Source:        source.Kind,
+			SpreadsheetID: source.SpreadsheetID,
+			Range:         source.Range,
+			File:          source.File,
+			DryRun:        dryRun,
+			Status:        StatusRunning,
+			Stages:        []Stage{},
+			StartedAt:     time.Now(),
+		},
+		cancel: cancel,
+	}
+	m.jobs[id] = e
+	m.order = append(m.order, id)
+	m.running = id
+	m.prune()
+
+	go m.run(ctx, e, source, run)
+
+	return m.snapshot(e), nil
+}
+
+// Returns the job with the given ID
+func (m *Manager) Get(id string) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.jobs[id]
+	if !ok {
+		return Job{}, ErrNotFound
+	}
+	return m.snapshot(e), nil
+}
+
+// Returns all kept jobs, newest first
+func (m *Manager) List() []Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	jobs := make([]Job, 0, len(m.order))
+	for i := len(m.order) - 1; i >= 0; i-- {
+		jobs = append(jobs, m.snapshot(m.jobs[m.order[i]]))
+	}
+	return jobs
+}
+
+// Cancels a running job, the stored data is left as the interrupted stage leaves it
+func (m *Manager) Cancel(id string) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.jobs[id]
+	if !ok {
+		return Job{}, ErrNotFound
+	}
+	if e.job.Status != StatusRunning {
+		return m.snapshot(e), ErrNotRunning
+	}
+
+	e.cancel()
+	return m.snapshot(e), nil
+}
+
+func (m *Manager) run(ctx context.Context, e *entry, source importer.Source, run func(ctx context.Context, e *entry) error) {
+	defer e.cancel()
+
+	slog.InfoContext(ctx, "import job started", "jobId", e.job.ID, "source", source.Kind, "dryRun", e.job.DryRun)
+	err := run(importer.WithProgress(ctx, &progress{manager: m, entry: e}), e)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	e.job.FinishedAt = &now
+	e.job.Duration = now.Sub(e.job.StartedAt).String()
+	switch {
+	case err == nil:
+		e.job.Status = StatusSucceeded
+	case errors.Is(ctx.Err(), context.Canceled):
+		e.job.Status = StatusCanceled
+		e.job.Error = err.Error()
+	default:
+		e.job.Status = StatusFailed
+		e.job.Error = err.Error()
+	}
+	m.running = ""
+	m.prune()
+
+	slog.InfoContext(ctx, "import job finished", "jobId", e.job.ID, "status", e.job.Status, "duration", e.job.Duration)
+}
+
+// Drops the oldest finished jobs beyond the history limit, callers hold mu
+func (m *Manager) prune() {
+	for len(m.order) > m.config.History {
+		oldest := m.order[0]
+		if oldest == m.running {
+			return
+		}
+		delete(m.jobs, oldest)
+		m.order = m.order[1:]
+	}
+}
+
+// Copies a job so callers can read it without holding mu
+func (m *Manager) snapshot(e *entry) Job {
+	job := e.job
+	job.Stages = append([]Stage{}, e.job.Stages...)
+	return job
+}
+
+// Records importer stages on a job
+type progress struct {
+	manager *Manager
+	entry   *entry
+}
+
+func (p *progress) StageStarted(stage string) {
+	p.manager.mu.Lock()
+	defer p.manager.mu.Unlock()
+
+	p.entry.job.Stages = append(p.entry.job.Stages, Stage{Name: stage, Status: StageRunning, StartedAt: time.Now()})
+}
+
+func (p *progress) StageCompleted(stage string, rows int) {
+	p.finish(stage, func(s *Stage) {
+		s.Status = StageCompleted
+		s.Rows = rows
+	})
+}
+
+func (p *progress) StageFailed(stage string, err error) {
+	p.finish(stage, func(s *Stage) {
+		s.Status = StageFailed
+		s.Error = err.Error()
+	})
+}
+
+// Updates the latest run of stage
+func (p *progress) finish(stage string, update func(s *Stage)) {
+	p.manager.mu.Lock()
+	defer p.manager.mu.Unlock()
+
+	stages := p.entry.job.Stages
+	for i := len(stages) - 1; i >= 0; i-- {
+		if stages[i].Name == stage {
+			update(&stages[i])
+			stages[i].Duration = time.Since(stages[i].StartedAt).String()
+			return
+		}
+	}
+}
+
+func newJobID() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate job id: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,150 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/MarcinZ20/bankAPI/internal/importer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const snapshot = `[{"swiftCode":"BREXPLPWXXX","bankName":"MBANK S.A.","address":"PROSTA 18","countryISO2":"PL","countryName":"POLAND","isHeadquarter":true,"branches":[]}]`
+
+func fileSource(t *testing.T, content string) importer.Source {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "snapshot.json")
+	require.NoError(t, os.WriteFile(file, []byte(content), 0o644))
+	return importer.Source{Kind: importer.SourceFile, File: file}
+}
+
+// Runs the load stages of an import without storing anything
+func loadOnly(ctx context.Context, source importer.Source) error {
+	_, err := source.Load(ctx)
+	return err
+}
+
+func waitFor(t *testing.T, m *Manager, id string) Job {
+	t.Helper()
+	var job Job
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = m.Get(id)
+		return err == nil && job.Status != StatusRunning
+	}, time.Second, 5*time.Millisecond)
+	return job
+}
+
+func TestStartSucceeds(t *testing.T) {
+	m := NewManager(Config{Run: loadOnly})
+
+	job, err := m.Start(context.Background(), fileSource(t, snapshot))
+	require.NoError(t, err)
+	assert.Equal(t, StatusRunning, job.Status)
+
+	job = waitFor(t, m, job.ID)
+	assert.Equal(t, StatusSucceeded, job.Status)
+	assert.Empty(t, job.Error)
+	assert.NotNil(t, job.FinishedAt)
+	require.Len(t, job.Stages, 2)
+	assert.Equal(t, importer.StageFetch, job.Stages[0].Name)
+	assert.Equal(t, StageCompleted, job.Stages[0].Status)
+	assert.Equal(t, importer.StageParse, job.Stages[1].Name)
+	assert.Equal(t, 1, job.Stages[1].Rows)
+}
+
+func TestStartFails(t *testing.T) {
+	m := NewManager(Config{Run: loadOnly})
+
+	job, err := m.Start(context.Background(), fileSource(t, "not json"))
+	require.NoError(t, err)
+
+	job = waitFor(t, m, job.ID)
+	assert.Equal(t, StatusFailed, job.Status)
+	assert.NotEmpty(t, job.Error)
+	require.Len(t, job.Stages, 2)
+	assert.Equal(t, StageFailed, job.Stages[1].Status)
+}
+
+func TestStartDryRun(t *testing.T) {
+	diff := &importer.Diff{}
+	m := NewManager(Config{DryRun: func(ctx context.Context, source importer.Source) (*importer.Diff, error) {
+		if _, err := source.Load(ctx); err != nil {
+			return nil, err
+		}
+		return diff, nil
+	}})
+
+	job, err := m.StartDryRun(context.Background(), fileSource(t, snapshot))
+	require.NoError(t, err)
+	assert.True(t, job.DryRun)
+
+	job = waitFor(t, m, job.ID)
+	assert.Equal(t, StatusSucceeded, job.Status)
+	assert.Same(t, diff, job.Diff)
+	require.Len(t, job.Stages, 2)
+}
+
+func TestStartInvalidSource(t *testing.T) {
+	m := NewManager(Config{Run: loadOnly})
+
+	_, err := m.Start(context.Background(), importer.Source{Kind: importer.SourceCSV})
+	assert.ErrorIs(t, err, ErrInvalidSource)
+	assert.Empty(t, m.List())
+}
+
+func TestCancel(t *testing.T) {
+	started := make(chan struct{})
+	m := NewManager(Config{Run: func(ctx context.Context, _ importer.Source) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}})
+	source := fileSource(t, snapshot)
+
+	job, err := m.Start(context.Background(), source)
+	require.NoError(t, err)
+	<-started
+
+	// Only one import runs at a time
+	_, err = m.Start(context.Background(), source)
+	assert.ErrorIs(t, err, ErrRunning)
+
+	_, err = m.Cancel(job.ID)
+	require.NoError(t, err)
+	job = waitFor(t, m, job.ID)
+	assert.Equal(t, StatusCanceled, job.Status)
+
+	_, err = m.Cancel(job.ID)
+	assert.ErrorIs(t, err, ErrNotRunning)
+	_, err = m.Cancel("missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestListKeepsHistory(t *testing.T) {
+	m := NewManager(Config{
+		Run:     func(context.Context, importer.Source) error { return errors.New("boom") },
+		History: 2,
+	})
+	source := fileSource(t, snapshot)
+
+	var ids []string
+	for range 3 {
+		job, err := m.Start(context.Background(), source)
+		require.NoError(t, err)
+		waitFor(t, m, job.ID)
+		ids = append(ids, job.ID)
+	}
+
+	jobs := m.List()
+	require.Len(t, jobs, 2)
+	assert.Equal(t, ids[2], jobs[0].ID)
+	assert.Equal(t, ids[1], jobs[1].ID)
+
+	_, err := m.Get(ids[0])
+	assert.ErrorIs(t, err, ErrNotFound)
+}
@@ -0,0 +1,83 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type contextKey struct{}
+
+// Holds logging settings
+type Config struct {
+	Level  string
+	Format string
+}
+
+// Installs the default slog logger, writing JSON by default or text when Format is "text"
+func Setup(w io.Writer, config Config) error {
+	var level slog.Level
+	if config.Level != "" {
+		if err := level.UnmarshalText([]byte(config.Level)); err != nil {
+			return fmt.Errorf("invalid log level: %s", config.Level)
+		}
+	}
+
+	options := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	switch strings.ToLower(config.Format) {
+	case "", "json":
+		handler = slog.NewJSONHandler(w, options)
+	case "text":
+		handler = slog.NewTextHandler(w, options)
+	default:
+		return fmt.Errorf("invalid log format: %s", config.Format)
+	}
+
+	slog.SetDefault(slog.New(NewContextHandler(handler)))
+	return nil
+}
+
+// Returns a context carrying the request ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, contextKey{}, requestID)
+}
+
+// Returns the request ID carried by ctx, if any
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(contextKey{}).(string)
+	return requestID
+}
+
+// Adds the request ID and trace ID from the context to every record
+type ContextHandler struct {
+	slog.Handler
+}
+
+// Wraps a handler so records logged with a context carry its request and trace IDs
+func NewContextHandler(handler slog.Handler) *ContextHandler {
+	return &ContextHandler{Handler: handler}
+}
+
+func (h *ContextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestID := RequestID(ctx); requestID != "" {
+		record.AddAttrs(slog.String("requestId", requestID))
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		record.AddAttrs(slog.String("traceId", spanContext.TraceID().String()))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &ContextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *ContextHandler) WithGroup(name string) slog.Handler {
+	return &ContextHandler{Handler: h.Handler.WithGroup(name)}
+}
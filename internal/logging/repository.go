@@ -0,0 +1,124 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/MarcinZ20/bankAPI/internal/repository"
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Bank store decorator logging failed operations, not found results are not logged
+type LoggedRepository struct {
+	store repository.BankStore
+}
+
+var _ repository.BankStore = (*LoggedRepository)(nil)
+
+// Wraps a bank store with error logging
+func NewLoggedRepository(store repository.BankStore) *LoggedRepository {
+	return &LoggedRepository{store: store}
+}
+
+// Finds a headquarter by SWIFT code
+func (r *LoggedRepository) FindHeadquarter(ctx context.Context, swiftCode string) (*models.Headquarter, error) {
+	hq, err := r.store.FindHeadquarter(ctx, swiftCode)
+	logError(ctx, "FindHeadquarter", err, slog.String("swiftCode", swiftCode))
+	return hq, err
+}
+
+// Finds headquarters by SWIFT codes
+func (r *LoggedRepository) FindHeadquarters(ctx context.Context, swiftCodes []string) ([]models.Headquarter, error) {
+	hqs, err := r.store.FindHeadquarters(ctx, swiftCodes)
+	logError(ctx, "FindHeadquarters", err, slog.Int("swiftCodes", len(swiftCodes)))
+	return hqs, err
+}
+
+// Finds a branch by SWIFT code
+func (r *LoggedRepository) FindBranch(ctx context.Context, swiftCode, parentSwiftCode string) (*models.Branch, error) {
+	branch, err := r.store.FindBranch(ctx, swiftCode, parentSwiftCode)
+	logError(ctx, "FindBranch", err, slog.String("swiftCode", swiftCode))
+	return branch, err
+}
+
+// Finds all banks in a given country
+func (r *LoggedRepository) FindBanksByCountry(ctx context.Context, countryCode string) ([]models.Headquarter, error) {
+	banks, err := r.store.FindBanksByCountry(ctx, countryCode)
+	logError(ctx, "FindBanksByCountry", err, slog.String("countryISO2", countryCode))
+	return banks, err
+}
+
+// Finds all headquarters and branches whose SWIFT code starts with prefix
+func (r *LoggedRepository) FindByPrefix(ctx context.Context, prefix string) ([]models.BankEntity, error) {
+	entities, err := r.store.FindByPrefix(ctx, prefix)
+	logError(ctx, "FindByPrefix", err, slog.String("prefix", prefix))
+	return entities, err
+}
+
+// Finds all headquarters with their branches
+func (r *LoggedRepository) FindAll(ctx context.Context) ([]models.Headquarter, error) {
+	hqs, err := r.store.FindAll(ctx)
+	logError(ctx, "FindAll", err)
+	return hqs, err
+}
+
+// Streams headquarters with their branches
+func (r *LoggedRepository) StreamHeadquarters(ctx context.Context, countryCode string, fn func(hq *models.Headquarter) error) error {
+	err := r.store.StreamHeadquarters(ctx, countryCode, fn)
+	logError(ctx, "StreamHeadquarters", err)
+	return err
+}
+
+// Creates a new headquarter
+func (r *LoggedRepository) CreateHeadquarter(ctx context.Context, hq *models.Headquarter) error {
+	err := r.store.CreateHeadquarter(ctx, hq)
+	logError(ctx, "CreateHeadquarter", err, slog.String("swiftCode", hq.SwiftCode))
+	return err
+}
+
+// Adds a new branch to a headquarter
+func (r *LoggedRepository) AddBranch(ctx context.Context, parentSwiftCode string, branch *models.Branch) error {
+	err := r.store.AddBranch(ctx, parentSwiftCode, branch)
+	logError(ctx, "AddBranch", err, slog.String("swiftCode", branch.SwiftCode))
+	return err
+}
+
+// Changes a headquarter
+func (r *LoggedRepository) UpdateHeadquarter(ctx context.Context, hq *models.Headquarter, check repository.Precondition) (*models.Headquarter, error) {
+	updated, err := r.store.UpdateHeadquarter(ctx, hq, check)
+	logError(ctx, "UpdateHeadquarter", err, slog.String("swiftCode", hq.SwiftCode))
+	return updated, err
+}
+
+// Changes a branch
+func (r *LoggedRepository) UpdateBranch(ctx context.Context, parentSwiftCode string, branch *models.Branch, check repository.Precondition) (*models.Branch, error) {
+	updated, err := r.store.UpdateBranch(ctx, parentSwiftCode, branch, check)
+	logError(ctx, "UpdateBranch", err, slog.String("swiftCode", branch.SwiftCode))
+	return updated, err
+}
+
+// Deletes a headquarter and all its branches
+func (r *LoggedRepository) DeleteHeadquarter(ctx context.Context, swiftCode string, check repository.Precondition) error {
+	err := r.store.DeleteHeadquarter(ctx, swiftCode, check)
+	logError(ctx, "DeleteHeadquarter", err, slog.String("swiftCode", swiftCode))
+	return err
+}
+
+// Removes a branch from its headquarter
+func (r *LoggedRepository) DeleteBranch(ctx context.Context, swiftCode, parentSwiftCode string, check repository.Precondition) error {
+	err := r.store.DeleteBranch(ctx, swiftCode, parentSwiftCode, check)
+	logError(ctx, "DeleteBranch", err, slog.String("swiftCode", swiftCode))
+	return err
+}
+
+func logError(ctx context.Context, method string, err error, attrs ...slog.Attr) {
+	if err == nil || errors.Is(err, mongo.ErrNoDocuments) || errors.Is(err, repository.ErrPreconditionFailed) ||
+		errors.Is(err, repository.ErrImmutableField) {
+		return
+	}
+	attrs = append([]slog.Attr{slog.String("method", method)}, attrs...)
+	attrs = append(attrs, slog.Any("error", err))
+	slog.LogAttrs(ctx, slog.LevelError, "repository operation failed", attrs...)
+}
@@ -0,0 +1,155 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "bankapi"
+
+// Holds every collector exposed on /metrics
+var Registry = prometheus.NewRegistry()
+
+var (
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Number of HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method, route and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	HTTPInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "http_requests_in_flight",
+		Help:      "Number of HTTP requests currently being served.",
+	})
+
+	GRPCRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "grpc_requests_total",
+		Help:      "Number of gRPC calls by method and status code.",
+	}, []string{"method", "code"})
+
+	GRPCDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "grpc_request_duration_seconds",
+		Help:      "gRPC call latency by method and status code, streams are measured until they end.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "code"})
+
+	GRPCInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "grpc_requests_in_flight",
+		Help:      "Number of gRPC calls currently being served.",
+	})
+
+	RepositoryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "repository_operation_duration_seconds",
+		Help:      "Bank repository operation latency by method and outcome.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"method", "outcome"})
+
+	ImportDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "import_duration_seconds",
+		Help:      "Duration of data imports by outcome.",
+		Buckets:   []float64{.5, 1, 2.5, 5, 10, 30, 60, 120, 300},
+	}, []string{"outcome"})
+
+	ImportRows = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "import_rows_total",
+		Help:      "Number of rows processed by imports, by stage (parsed, invalid, stored).",
+	}, []string{"stage"})
+
+	LastImportTimestamp = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "import_last_success_timestamp_seconds",
+		Help:      "Unix time of the last successful import.",
+	})
+
+	ScheduledImports = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "import_scheduled_runs_total",
+		Help:      "Number of scheduled import runs by result (imported, unchanged, locked, failed).",
+	}, []string{"result"})
+
+	DatasetSize = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "dataset_swift_codes",
+		Help:      "Number of SWIFT codes (headquarters and branches) per country in the last imported dataset.",
+	}, []string{"country"})
+
+	WebhookDeliveries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_deliveries_total",
+		Help:      "Number of webhook delivery attempts by result (delivered, retried, failed).",
+	}, []string{"result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPDuration,
+		HTTPInFlight,
+		GRPCRequests,
+		GRPCDuration,
+		GRPCInFlight,
+		RepositoryDuration,
+		ImportDuration,
+		ImportRows,
+		LastImportTimestamp,
+		ScheduledImports,
+		DatasetSize,
+		WebhookDeliveries,
+	)
+}
+
+// Returns an HTTP handler serving the registry in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// Records the outcome of an import started at start
+func ObserveImport(start time.Time, err error) {
+	ImportDuration.WithLabelValues(outcome(err)).Observe(time.Since(start).Seconds())
+}
+
+// Records a successfully stored dataset: the stored row count, the size per country and the import time
+func RecordDataset(data map[string]models.Headquarter) {
+	sizes := make(map[string]int)
+	rows := 0
+	for _, hq := range data {
+		sizes[hq.CountryISO2] += 1 + len(hq.Branches)
+		rows += 1 + len(hq.Branches)
+	}
+
+	DatasetSize.Reset()
+	for country, size := range sizes {
+		DatasetSize.WithLabelValues(country).Set(float64(size))
+	}
+
+	ImportRows.WithLabelValues("stored").Add(float64(rows))
+	LastImportTimestamp.SetToCurrentTime()
+}
+
+func outcome(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "success"
+}
@@ -0,0 +1,141 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/MarcinZ20/bankAPI/internal/repository"
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Bank store decorator recording the latency and outcome of every operation
+type InstrumentedRepository struct {
+	store repository.BankStore
+}
+
+var _ repository.BankStore = (*InstrumentedRepository)(nil)
+
+// Wraps a bank store with operation metrics
+func NewInstrumentedRepository(store repository.BankStore) *InstrumentedRepository {
+	return &InstrumentedRepository{store: store}
+}
+
+// Finds a headquarter by SWIFT code
+func (r *InstrumentedRepository) FindHeadquarter(ctx context.Context, swiftCode string) (*models.Headquarter, error) {
+	start := time.Now()
+	hq, err := r.store.FindHeadquarter(ctx, swiftCode)
+	observe("FindHeadquarter", start, err)
+	return hq, err
+}
+
+// Finds headquarters by SWIFT codes
+func (r *InstrumentedRepository) FindHeadquarters(ctx context.Context, swiftCodes []string) ([]models.Headquarter, error) {
+	start := time.Now()
+	hqs, err := r.store.FindHeadquarters(ctx, swiftCodes)
+	observe("FindHeadquarters", start, err)
+	return hqs, err
+}
+
+// Finds a branch by SWIFT code
+func (r *InstrumentedRepository) FindBranch(ctx context.Context, swiftCode, parentSwiftCode string) (*models.Branch, error) {
+	start := time.Now()
+	branch, err := r.store.FindBranch(ctx, swiftCode, parentSwiftCode)
+	observe("FindBranch", start, err)
+	return branch, err
+}
+
+// Finds all banks in a given country
+func (r *InstrumentedRepository) FindBanksByCountry(ctx context.Context, countryCode string) ([]models.Headquarter, error) {
+	start := time.Now()
+	banks, err := r.store.FindBanksByCountry(ctx, countryCode)
+	observe("FindBanksByCountry", start, err)
+	return banks, err
+}
+
+// Finds all headquarters and branches whose SWIFT code starts with prefix
+func (r *InstrumentedRepository) FindByPrefix(ctx context.Context, prefix string) ([]models.BankEntity, error) {
+	start := time.Now()
+	entities, err := r.store.FindByPrefix(ctx, prefix)
+	observe("FindByPrefix", start, err)
+	return entities, err
+}
+
+// Finds all headquarters with their branches
+func (r *InstrumentedRepository) FindAll(ctx context.Context) ([]models.Headquarter, error) {
+	start := time.Now()
+	hqs, err := r.store.FindAll(ctx)
+	observe("FindAll", start, err)
+	return hqs, err
+}
+
+// Streams headquarters with their branches
+func (r *InstrumentedRepository) StreamHeadquarters(ctx context.Context, countryCode string, fn func(hq *models.Headquarter) error) error {
+	start := time.Now()
+	err := r.store.StreamHeadquarters(ctx, countryCode, fn)
+	observe("StreamHeadquarters", start, err)
+	return err
+}
+
+// Creates a new headquarter
+func (r *InstrumentedRepository) CreateHeadquarter(ctx context.Context, hq *models.Headquarter) error {
+	start := time.Now()
+	err := r.store.CreateHeadquarter(ctx, hq)
+	observe("CreateHeadquarter", start, err)
+	return err
+}
+
+// Adds a new branch to a headquarter
+func (r *InstrumentedRepository) AddBranch(ctx context.Context, parentSwiftCode string, branch *models.Branch) error {
+	start := time.Now()
+	err := r.store.AddBranch(ctx, parentSwiftCode, branch)
+	observe("AddBranch", start, err)
+	return err
+}
+
+// Changes a headquarter
+func (r *InstrumentedRepository) UpdateHeadquarter(ctx context.Context, hq *models.Headquarter, check repository.Precondition) (*models.Headquarter, error) {
+	start := time.Now()
+	updated, err := r.store.UpdateHeadquarter(ctx, hq, check)
+	observe("UpdateHeadquarter", start, err)
+	return updated, err
+}
+
+// Changes a branch
+func (r *InstrumentedRepository) UpdateBranch(ctx context.Context, parentSwiftCode string, branch *models.Branch, check repository.Precondition) (*models.Branch, error) {
+	start := time.Now()
+	updated, err := r.store.UpdateBranch(ctx, parentSwiftCode, branch, check)
+	observe("UpdateBranch", start, err)
+	return updated, err
+}
+
+// Deletes a headquarter and all its branches
+func (r *InstrumentedRepository) DeleteHeadquarter(ctx context.Context, swiftCode string, check repository.Precondition) error {
+	start := time.Now()
+	err := r.store.DeleteHeadquarter(ctx, swiftCode, check)
+	observe("DeleteHeadquarter", start, err)
+	return err
+}
+
+// Removes a branch from its headquarter
+func (r *InstrumentedRepository) DeleteBranch(ctx context.Context, swiftCode, parentSwiftCode string, check repository.Precondition) error {
+	start := time.Now()
+	err := r.store.DeleteBranch(ctx, swiftCode, parentSwiftCode, check)
+	observe("DeleteBranch", start, err)
+	return err
+}
+
+// Not found and failed preconditions are expected outcomes and are reported separately from errors
+func observe(method string, start time.Time, err error) {
+	result := "success"
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		result = "not_found"
+	case errors.Is(err, repository.ErrPreconditionFailed):
+		result = "precondition_failed"
+	case err != nil:
+		result = "error"
+	}
+	RepositoryDuration.WithLabelValues(method, result).Observe(time.Since(start).Seconds())
+}
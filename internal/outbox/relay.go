@@ -0,0 +1,162 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/MarcinZ20/bankAPI/internal/scheduler"
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+)
+
+// Shortest lease on the relay lock, it outlasts a webhook delivery with all its retries
+const minLease = 2 * time.Minute
+
+// Receives outbox entries. The name records which entries a sink received, so it must stay the same.
+type Sink interface {
+	Name() string
+	// Delivers an event, an error leaves it in the outbox to be delivered again. An event can also arrive
+	// again after a crash, so sinks that can tell drop IDs they already have.
+	Deliver(ctx context.Context, event models.ChangeEvent) error
+}
+
+// Outbox entries waiting for sinks, e.g. repository.Outbox
+type Store interface {
+	Pending(ctx context.Context, sink string, limit int64) ([]models.OutboxEntry, error)
+	MarkDelivered(ctx context.Context, seq int64, sink string) error
+	Purge(ctx context.Context, sinks []string) (int64, error)
+}
+
+// Holds relay settings
+type Config struct {
+	Store Store
+	Sinks []Sink
+	// Lets one replica relay at a time
+	Lock scheduler.Lock
+	// How often the outbox is checked for new entries
+	PollInterval time.Duration
+	// Entries read per query
+	BatchSize int64
+}
+
+// Delivers outbox entries to every sink in sequence order, so events of a SWIFT code arrive in the order
+// they were written. An entry is marked per sink once the sink accepted it, and removed when every sink has it.
+// A sink that fails stops at the failed entry and continues from it on the next poll.
+//
+// Delivery is at least once: an entry is marked after its sink accepted it, so a crash in between delivers it again
+// on the next poll. Event IDs are the outbox sequence numbers and never change, receivers de-duplicate on them.
+type Relay struct {
+	config Config
+	owner  string
+}
+
+// Creates a relay
+func New(config Config) (*Relay, error) {
+	owner, err := scheduler.NewOwnerID()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Relay{
+		config: config,
+		owner:  owner,
+	}, nil
+}
+
+// Relays entries every poll interval until ctx is done
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.RunOnce(ctx); err != nil {
+			slog.Error("outbox relay failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			// Another replica may take over right away instead of waiting for the lease to expire
+			releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := r.config.Lock.Release(releaseCtx, r.owner, nil); err != nil {
+				slog.Error("failed to release outbox lock", "error", err)
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Delivers the pending entries to every sink and returns how many deliveries were made.
+// Nothing is delivered while another replica holds the lock.
+func (r *Relay) RunOnce(ctx context.Context) (int, error) {
+	if ok, err := r.hold(ctx); !ok || err != nil {
+		return 0, err
+	}
+
+	// Sinks drain independently, a slow one does not hold back the others
+	counts := make([]int, len(r.config.Sinks))
+	var wg sync.WaitGroup
+	for i, sink := range r.config.Sinks {
+		wg.Go(func() {
+			n, err := r.drain(ctx, sink)
+			counts[i] = n
+			if err != nil {
+				slog.Warn("outbox sink failed, retrying on the next poll", "sink", sink.Name(), "delivered", n, "error", err)
+			}
+		})
+	}
+	wg.Wait()
+
+	delivered := 0
+	names := make([]string, len(r.config.Sinks))
+	for i, sink := range r.config.Sinks {
+		delivered += counts[i]
+		names[i] = sink.Name()
+	}
+
+	if delivered > 0 {
+		if _, err := r.config.Store.Purge(ctx, names); err != nil {
+			return delivered, err
+		}
+		slog.Debug("outbox entries relayed", "deliveries", delivered)
+	}
+	return delivered, nil
+}
+
+// Delivers the entries the sink has not received yet, stopping at the first failure
+func (r *Relay) drain(ctx context.Context, sink Sink) (int, error) {
+	delivered := 0
+	for {
+		entries, err := r.config.Store.Pending(ctx, sink.Name(), r.config.BatchSize)
+		if err != nil {
+			return delivered, err
+		}
+
+		for _, entry := range entries {
+			// The lease is renewed before every delivery, so it cannot expire while the relay is busy
+			if ok, err := r.hold(ctx); !ok || err != nil {
+				return delivered, err
+			}
+			if err := sink.Deliver(ctx, entry.Event); err != nil {
+				return delivered, fmt.Errorf("failed to deliver outbox entry %d: %w", entry.Seq, err)
+			}
+			if err := r.config.Store.MarkDelivered(ctx, entry.Seq, sink.Name()); err != nil {
+				return delivered, err
+			}
+			delivered++
+		}
+
+		if int64(len(entries)) < r.config.BatchSize {
+			return delivered, nil
+		}
+	}
+}
+
+// Takes or renews the relay lock, ok is false while another replica holds it
+func (r *Relay) hold(ctx context.Context) (bool, error) {
+	_, ok, err := r.config.Lock.Acquire(ctx, r.owner, max(minLease, 4*r.config.PollInterval))
+	return ok, err
+}
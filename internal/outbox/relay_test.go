@@ -0,0 +1,170 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/MarcinZ20/bankAPI/internal/scheduler"
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryStore struct {
+	mu      sync.Mutex
+	entries []models.OutboxEntry
+}
+
+func newMemoryStore(swiftCodes ...string) *memoryStore {
+	store := &memoryStore{}
+	for i, code := range swiftCodes {
+		seq := int64(i + 1)
+		store.entries = append(store.entries, models.OutboxEntry{
+			Seq:   seq,
+			Event: models.ChangeEvent{ID: uint64(seq), Operation: models.ChangeCreated, SwiftCode: code},
+		})
+	}
+	return store
+}
+
+func (s *memoryStore) Pending(_ context.Context, sink string, limit int64) ([]models.OutboxEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var pending []models.OutboxEntry
+	for _, entry := range s.entries {
+		if !slices.Contains(entry.Delivered, sink) && int64(len(pending)) < limit {
+			pending = append(pending, entry)
+		}
+	}
+	return pending, nil
+}
+
+func (s *memoryStore) MarkDelivered(_ context.Context, seq int64, sink string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.entries {
+		if s.entries[i].Seq == seq {
+			s.entries[i].Delivered = append(s.entries[i].Delivered, sink)
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) Purge(_ context.Context, sinks []string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	before := len(s.entries)
+	s.entries = slices.DeleteFunc(s.entries, func(entry models.OutboxEntry) bool {
+		for _, sink := range sinks {
+			if !slices.Contains(entry.Delivered, sink) {
+				return false
+			}
+		}
+		return true
+	})
+	return int64(before - len(s.entries)), nil
+}
+
+func (s *memoryStore) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+// Records delivered SWIFT codes, failing once for every code in failOnce
+type recordingSink struct {
+	name      string
+	mu        sync.Mutex
+	received  []string
+	failOnce  map[string]bool
+	processed int
+}
+
+func (s *recordingSink) Name() string {
+	return s.name
+}
+
+func (s *recordingSink) Deliver(_ context.Context, event models.ChangeEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.processed++
+	if s.failOnce[event.SwiftCode] {
+		delete(s.failOnce, event.SwiftCode)
+		return errors.New("sink unavailable")
+	}
+	s.received = append(s.received, event.SwiftCode)
+	return nil
+}
+
+func newTestRelay(t *testing.T, store Store, lock scheduler.Lock, sinks ...Sink) *Relay {
+	t.Helper()
+	relay, err := New(Config{
+		Store:        store,
+		Sinks:        sinks,
+		Lock:         lock,
+		PollInterval: 1,
+		BatchSize:    2,
+	})
+	require.NoError(t, err)
+	return relay
+}
+
+func TestRelayDeliversToEverySinkOnce(t *testing.T) {
+	store := newMemoryStore("AAAAPLPWXXX", "BBBBPLPWXXX", "AAAAPLPWKRA")
+	first := &recordingSink{name: "first"}
+	second := &recordingSink{name: "second"}
+	relay := newTestRelay(t, store, scheduler.NewLocalLock(), first, second)
+
+	delivered, err := relay.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 6, delivered)
+	assert.Equal(t, []string{"AAAAPLPWXXX", "BBBBPLPWXXX", "AAAAPLPWKRA"}, first.received)
+	assert.Equal(t, first.received, second.received)
+	assert.Equal(t, 0, store.len())
+
+	delivered, err = relay.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, delivered)
+	assert.Len(t, first.received, 3)
+}
+
+func TestRelayRetriesFailedEntryInOrder(t *testing.T) {
+	store := newMemoryStore("AAAAPLPWXXX", "BBBBPLPWXXX", "CCCCPLPWXXX")
+	healthy := &recordingSink{name: "healthy"}
+	flaky := &recordingSink{name: "flaky", failOnce: map[string]bool{"BBBBPLPWXXX": true}}
+	relay := newTestRelay(t, store, scheduler.NewLocalLock(), healthy, flaky)
+
+	_, err := relay.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, healthy.received, 3)
+	assert.Equal(t, []string{"AAAAPLPWXXX"}, flaky.received)
+	// Entries the flaky sink still needs stay in the outbox
+	assert.Equal(t, 2, store.len())
+
+	_, err = relay.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"AAAAPLPWXXX", "BBBBPLPWXXX", "CCCCPLPWXXX"}, flaky.received)
+	assert.Len(t, healthy.received, 3)
+	assert.Equal(t, 0, store.len())
+}
+
+func TestRelayWaitsForLock(t *testing.T) {
+	store := newMemoryStore("AAAAPLPWXXX")
+	sink := &recordingSink{name: "sink"}
+	lock := scheduler.NewLocalLock()
+	_, ok, err := lock.Acquire(context.Background(), "other-replica", 0)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	delivered, err := newTestRelay(t, store, lock, sink).RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, delivered)
+	assert.Zero(t, sink.processed)
+}
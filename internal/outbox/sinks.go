@@ -0,0 +1,173 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/MarcinZ20/bankAPI/internal/webhooks"
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+)
+
+// Sink names, also the values of the sinks setting
+const (
+	SinkWebhook = "webhook"
+	SinkFile    = "file"
+	SinkStdout  = "stdout"
+)
+
+// Bytes read from the end of a file sink to find the last event it holds, far more than one event needs
+const fileTail = 64 << 10
+
+// Posts events to the registered webhooks, events a webhook does not accept go to its dead letter log
+type WebhookSink struct {
+	dispatcher *webhooks.Dispatcher
+}
+
+// Creates a sink delivering through dispatcher
+func NewWebhookSink(dispatcher *webhooks.Dispatcher) *WebhookSink {
+	return &WebhookSink{dispatcher: dispatcher}
+}
+
+func (s *WebhookSink) Name() string {
+	return SinkWebhook
+}
+
+func (s *WebhookSink) Deliver(ctx context.Context, event models.ChangeEvent) error {
+	return s.dispatcher.Deliver(ctx, event)
+}
+
+// Writes events as JSON lines to a writer, e.g. os.Stdout for a log collector.
+// An event repeated after a crash is written again, consumers skip IDs they already processed.
+type WriterSink struct {
+	name string
+	mu   sync.Mutex
+	w    io.Writer
+}
+
+// Creates a sink writing to standard output
+func NewStdoutSink() *WriterSink {
+	return &WriterSink{name: SinkStdout, w: os.Stdout}
+}
+
+func (s *WriterSink) Name() string {
+	return s.name
+}
+
+func (s *WriterSink) Deliver(_ context.Context, event models.ChangeEvent) error {
+	line, err := encodeLine(event)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(line)
+	return err
+}
+
+// Appends events as JSON lines to a file, synced after every event. The ID of the last event in the file
+// is read when it is opened, so an event written just before a crash is not written again.
+type FileSink struct {
+	mu     sync.Mutex
+	file   *os.File
+	lastID uint64
+}
+
+// Opens or creates the file, a partly written last line left by a crash is removed
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open outbox file: %w", err)
+	}
+
+	lastID, err := recoverTail(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to read outbox file %s: %w", path, err)
+	}
+
+	return &FileSink{file: file, lastID: lastID}, nil
+}
+
+func (s *FileSink) Name() string {
+	return SinkFile
+}
+
+func (s *FileSink) Deliver(_ context.Context, event models.ChangeEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Entries are delivered in ID order, anything up to the last written ID is already in the file
+	if event.ID <= s.lastID {
+		return nil
+	}
+
+	line, err := encodeLine(event)
+	if err != nil {
+		return err
+	}
+	if _, err := s.file.Write(line); err != nil {
+		return err
+	}
+	if err := s.file.Sync(); err != nil {
+		return err
+	}
+
+	s.lastID = event.ID
+	return nil
+}
+
+// Closes the file
+func (s *FileSink) Close() error {
+	return s.file.Close()
+}
+
+// Cuts off an incomplete last line and returns the ID of the last complete event
+func recoverTail(file *os.File) (uint64, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	size := info.Size()
+	if size == 0 {
+		return 0, nil
+	}
+
+	start := max(size-fileTail, 0)
+	tail := make([]byte, size-start)
+	if _, err := file.ReadAt(tail, start); err != nil {
+		return 0, err
+	}
+
+	end := bytes.LastIndexByte(tail, '\n')
+	if end != len(tail)-1 {
+		if err := file.Truncate(start + int64(end) + 1); err != nil {
+			return 0, err
+		}
+	}
+	if end < 0 {
+		return 0, nil
+	}
+
+	lines := bytes.Split(tail[:end], []byte("\n"))
+	var last struct {
+		ID uint64 `json:"id"`
+	}
+	if err := json.Unmarshal(lines[len(lines)-1], &last); err != nil {
+		return 0, fmt.Errorf("last line is not an event: %w", err)
+	}
+	return last.ID, nil
+}
+
+func encodeLine(event models.ChangeEvent) ([]byte, error) {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	return append(line, '\n'), nil
+}
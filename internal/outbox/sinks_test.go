@@ -0,0 +1,65 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSinkSkipsWrittenEvents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "changes.ndjson")
+	ctx := context.Background()
+
+	sink, err := NewFileSink(path)
+	require.NoError(t, err)
+	require.NoError(t, sink.Deliver(ctx, models.ChangeEvent{ID: 1, SwiftCode: "AAAAPLPWXXX"}))
+	require.NoError(t, sink.Deliver(ctx, models.ChangeEvent{ID: 2, SwiftCode: "BBBBPLPWXXX"}))
+	require.NoError(t, sink.Close())
+
+	// A crash after writing event 2 but before marking it delivered delivers it again after a restart
+	reopened, err := NewFileSink(path)
+	require.NoError(t, err)
+	require.NoError(t, reopened.Deliver(ctx, models.ChangeEvent{ID: 2, SwiftCode: "BBBBPLPWXXX"}))
+	require.NoError(t, reopened.Deliver(ctx, models.ChangeEvent{ID: 3, SwiftCode: "CCCCPLPWXXX"}))
+	require.NoError(t, reopened.Close())
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	require.Len(t, lines, 3)
+	assert.Contains(t, lines[2], `"swiftCode":"CCCCPLPWXXX"`)
+}
+
+func TestFileSinkRemovesPartialLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "changes.ndjson")
+	require.NoError(t, os.WriteFile(path, []byte("{\"id\":7,\"swiftCode\":\"AAAAPLPWXXX\"}\n{\"id\":8,\"swi"), 0o644))
+
+	sink, err := NewFileSink(path)
+	require.NoError(t, err)
+	defer sink.Close()
+	assert.Equal(t, uint64(7), sink.lastID)
+
+	require.NoError(t, sink.Deliver(context.Background(), models.ChangeEvent{ID: 8, SwiftCode: "BBBBPLPWXXX"}))
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[1], `"id":8`)
+}
+
+func TestWriterSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := &WriterSink{name: SinkStdout, w: &buf}
+
+	require.NoError(t, sink.Deliver(context.Background(), models.ChangeEvent{ID: 1, Operation: models.ChangeDeleted, SwiftCode: "AAAAPLPWXXX"}))
+	assert.Equal(t, SinkStdout, sink.Name())
+	assert.True(t, strings.HasSuffix(buf.String(), "}\n"))
+	assert.Contains(t, buf.String(), `"operation":"deleted"`)
+}
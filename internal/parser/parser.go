@@ -12,15 +12,54 @@ import (
 type Parser struct {
 	skipHeaderRow bool
 	minCols       int
+	// Header names of the columns to read, nil reads the export columns by position
+	columns *ColumnMapping
 }
 
+// ColumnMapping holds the header names of the columns read into a Bank, matched case-insensitively
+type ColumnMapping struct {
+	CountryISO2 string
+	SwiftCode   string
+	Name        string
+	Address     string
+	CountryName string
+	// Optional unless named, the town is left empty when the default column is missing
+	TownName string
+}
+
+// Header names of the Google Spreadsheet export, used for the fields a mapping leaves empty
+var DefaultColumns = ColumnMapping{
+	CountryISO2: "COUNTRY ISO2 CODE",
+	SwiftCode:   "SWIFT CODE",
+	Name:        "NAME",
+	Address:     "ADDRESS",
+	CountryName: "COUNTRY NAME",
+	TownName:    "TOWN NAME",
+}
+
+// Positions of the columns read into a Bank, -1 for a missing optional column
+type columnIndexes struct {
+	countryISO2, swiftCode, name, address, countryName, townName int
+}
+
+// Positions of the columns in the Google Spreadsheet export
+var exportColumns = columnIndexes{countryISO2: 0, swiftCode: 1, name: 3, address: 4, townName: 5, countryName: 6}
+
 // Creates a new parser instance
 func NewParser() *Parser {
 	return &Parser{
 		skipHeaderRow: true,
+		minCols:       7,
 	}
 }
 
+// Reads the columns named in the header row instead of the export columns by position
+func (p *Parser) WithColumns(columns ColumnMapping) *Parser {
+	p.columns = &columns
+	p.minCols = 0
+	return p
+}
+
 // ParseBankData parses bank data from CSV string into Bank objects
 func (p *Parser) ParseBankData(response string, data *[]models.Bank) error {
 	if data == nil {
@@ -46,6 +85,13 @@ func (p *Parser) ParseBankData(response string, data *[]models.Bank) error {
 		return fmt.Errorf("invalid CSV format: expected at least 7 columns in header")
 	}
 
+	indexes := exportColumns
+	if p.columns != nil {
+		if indexes, err = p.columns.resolve(rows[0]); err != nil {
+			return err
+		}
+	}
+
 	expectedColumns := len(rows[0])
 
 	for i, row := range rows {
@@ -58,14 +104,65 @@ func (p *Parser) ParseBankData(response string, data *[]models.Bank) error {
 		}
 
 		bank := models.Bank{
-			CountryISO2Code: row[0],
-			SwiftCode:       row[1],
-			Name:            row[3],
-			Address:         row[4],
-			CountryName:     row[6],
+			CountryISO2Code: row[indexes.countryISO2],
+			SwiftCode:       row[indexes.swiftCode],
+			Name:            row[indexes.name],
+			Address:         row[indexes.address],
+			CountryName:     row[indexes.countryName],
+		}
+		if indexes.townName >= 0 {
+			bank.TownName = row[indexes.townName]
 		}
 		*data = append(*data, bank)
 	}
 
 	return nil
 }
+
+// Finds the mapped columns in the header row, empty names fall back to DefaultColumns
+func (m ColumnMapping) resolve(header []string) (columnIndexes, error) {
+	positions := make(map[string]int, len(header))
+	for i, name := range header {
+		key := strings.ToUpper(strings.TrimSpace(name))
+		if _, ok := positions[key]; !ok {
+			positions[key] = i
+		}
+	}
+
+	find := func(name, fallback string) (int, error) {
+		if name == "" {
+			name = fallback
+		}
+		i, ok := positions[strings.ToUpper(strings.TrimSpace(name))]
+		if !ok {
+			return 0, fmt.Errorf("invalid CSV format: column %q not found in header", name)
+		}
+		return i, nil
+	}
+
+	var indexes columnIndexes
+	var err error
+	for _, column := range []struct {
+		index          *int
+		name, fallback string
+	}{
+		{&indexes.countryISO2, m.CountryISO2, DefaultColumns.CountryISO2},
+		{&indexes.swiftCode, m.SwiftCode, DefaultColumns.SwiftCode},
+		{&indexes.name, m.Name, DefaultColumns.Name},
+		{&indexes.address, m.Address, DefaultColumns.Address},
+		{&indexes.countryName, m.CountryName, DefaultColumns.CountryName},
+	} {
+		if *column.index, err = find(column.name, column.fallback); err != nil {
+			return columnIndexes{}, err
+		}
+	}
+
+	if indexes.townName, err = find(m.TownName, DefaultColumns.TownName); err != nil {
+		if m.TownName != "" {
+			return columnIndexes{}, err
+		}
+		indexes.townName = -1
+	}
+
+	return indexes, nil
+}
@@ -32,6 +32,7 @@ FR,BNPAFRPP,HQ,BNP Paribas,16 Boulevard des Italiens,Paris,France`,
 				assert.Equal(t, "DEUTDEFF", banks[0].SwiftCode)
 				assert.Equal(t, "Deutsche Bank", banks[0].Name)
 				assert.Equal(t, "Taunusanlage 12", banks[0].Address)
+				assert.Equal(t, "Frankfurt", banks[0].TownName)
 				assert.Equal(t, "Germany", banks[0].CountryName)
 
 				assert.Equal(t, "FR", banks[1].CountryISO2Code)
@@ -95,3 +96,27 @@ func TestParser_ParseBankData_NilSlice(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "nil slice")
 }
+
+func TestParser_ParseBankData_WithColumns(t *testing.T) {
+	csvData := `Swift,Institution,Street,Country ISO2 Code,Country Name
+DEUTDEFFXXX,DEUTSCHE BANK,TAUNUSANLAGE 12,DE,GERMANY`
+
+	var banks []models.Bank
+	err := NewParser().WithColumns(ColumnMapping{SwiftCode: "swift", Name: "Institution", Address: "STREET"}).ParseBankData(csvData, &banks)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Bank{{
+		CountryISO2Code: "DE",
+		SwiftCode:       "DEUTDEFFXXX",
+		Name:            "DEUTSCHE BANK",
+		Address:         "TAUNUSANLAGE 12",
+		CountryName:     "GERMANY",
+	}}, banks)
+
+	err = NewParser().WithColumns(ColumnMapping{SwiftCode: "BIC"}).ParseBankData(csvData, &banks)
+	assert.ErrorContains(t, err, `column "BIC" not found`)
+
+	// The town is optional unless its column is named
+	err = NewParser().WithColumns(ColumnMapping{SwiftCode: "swift", Name: "Institution", Address: "STREET", TownName: "City"}).
+		ParseBankData(csvData, &banks)
+	assert.ErrorContains(t, err, `column "City" not found`)
+}
@@ -0,0 +1,210 @@
+package parser
+
+import (
+	"encoding/csv"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+)
+
+// Header names of the SWIFTRef BIC directory fields read into a Bank, several names cover the file editions
+var swiftRefColumns = map[string][]string{
+	"bic":               {"BIC", "BIC11", "SWIFT CODE"},
+	"bic8":              {"BIC8"},
+	"branchBIC":         {"BRANCH BIC", "BRANCH CODE"},
+	"name":              {"INSTITUTION NAME", "NAME"},
+	"branchInformation": {"BRANCH INFORMATION"},
+	"street1":           {"STREET ADDRESS 1", "STREET ADDRESS"},
+	"street2":           {"STREET ADDRESS 2"},
+	"street3":           {"STREET ADDRESS 3"},
+	"street4":           {"STREET ADDRESS 4"},
+	"city":              {"CITY", "CITY HEADING"},
+	"zipCode":           {"ZIP CODE", "POSTAL CODE"},
+	"countryName":       {"COUNTRY NAME"},
+	"countryISO2":       {"ISO COUNTRY CODE", "COUNTRY CODE"},
+	"timezone":          {"TIMEZONE", "TIME ZONE"},
+	"institutionType":   {"INSTITUTION TYPE", "SUBTYPE INDICATOR"},
+	"validFrom":         {"VALID FROM", "VALIDITY START DATE", "START DATE"},
+	"validTo":           {"VALID TO", "VALIDITY END DATE", "END DATE"},
+	"modificationFlag":  {"MODIFICATION FLAG"},
+}
+
+// Date layouts used by the directory editions
+var swiftRefDateLayouts = []string{"2006-01-02", "20060102", "02/01/2006"}
+
+// Field name of a fixed-width header, names may contain single spaces and are separated by two or more
+var fixedWidthName = regexp.MustCompile(`\S+(?: \S+)*`)
+
+// SWIFTRefParser reads the SWIFTRef BIC directory, a tab, pipe, semicolon or comma delimited file or a
+// fixed-width file whose header names start at the field offsets. Fields are matched by header name.
+type SWIFTRefParser struct{}
+
+// Creates a parser for the SWIFTRef BIC directory
+func NewSWIFTRefParser() *SWIFTRefParser {
+	return &SWIFTRefParser{}
+}
+
+// ParseBankData parses the directory records into Bank objects, records flagged as deleted are skipped
+func (p *SWIFTRefParser) ParseBankData(response string, data *[]models.Bank) error {
+	if data == nil {
+		return fmt.Errorf("nil slice: data parameter cannot be nil")
+	}
+
+	rows, err := readDirectory(response)
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return fmt.Errorf("empty input: response cannot be empty")
+	}
+
+	columns := make(map[string]int)
+	for i, name := range rows[0] {
+		name = strings.ToUpper(strings.TrimSpace(name))
+		for field, aliases := range swiftRefColumns {
+			if _, ok := columns[field]; !ok && slices.Contains(aliases, name) {
+				columns[field] = i
+			}
+		}
+	}
+	for _, required := range []string{"name", "countryISO2"} {
+		if _, ok := columns[required]; !ok {
+			return fmt.Errorf("invalid SWIFTRef format: column %q not found in header", swiftRefColumns[required][0])
+		}
+	}
+	_, hasBIC := columns["bic"]
+	_, hasBIC8 := columns["bic8"]
+	if !hasBIC && !hasBIC8 {
+		return fmt.Errorf("invalid SWIFTRef format: neither a BIC nor a BIC8 column found in header")
+	}
+
+	for i, row := range rows[1:] {
+		line := i + 2
+		field := func(name string) string {
+			index, ok := columns[name]
+			if !ok || index >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[index])
+		}
+
+		if strings.EqualFold(field("modificationFlag"), "D") {
+			continue
+		}
+
+		swiftCode := field("bic")
+		if swiftCode == "" {
+			branch := field("branchBIC")
+			if branch == "" {
+				branch = "XXX"
+			}
+			swiftCode = field("bic8") + branch
+		}
+
+		validFrom, err := parseDirectoryDate(field("validFrom"))
+		if err != nil {
+			return fmt.Errorf("invalid SWIFTRef record at line %d: %w", line, err)
+		}
+		validTo, err := parseDirectoryDate(field("validTo"))
+		if err != nil {
+			return fmt.Errorf("invalid SWIFTRef record at line %d: %w", line, err)
+		}
+
+		var street []string
+		for _, name := range []string{"street1", "street2", "street3", "street4"} {
+			if value := field(name); value != "" {
+				street = append(street, value)
+			}
+		}
+
+		*data = append(*data, models.Bank{
+			CountryISO2Code:   strings.ToUpper(field("countryISO2")),
+			SwiftCode:         strings.ToUpper(swiftCode),
+			CodeType:          "BIC11",
+			Name:              field("name"),
+			Address:           strings.Join(street, ", "),
+			TownName:          field("city"),
+			CountryName:       field("countryName"),
+			Timezone:          field("timezone"),
+			BranchInformation: field("branchInformation"),
+			ZipCode:           field("zipCode"),
+			InstitutionType:   field("institutionType"),
+			ValidFrom:         validFrom,
+			ValidTo:           validTo,
+		})
+	}
+
+	return nil
+}
+
+// Splits the directory into rows, detecting the delimiter from the header line
+func readDirectory(response string) ([][]string, error) {
+	response = strings.TrimPrefix(response, "\ufeff")
+	header, _, _ := strings.Cut(response, "\n")
+	header = strings.TrimRight(header, "\r")
+	if strings.TrimSpace(header) == "" {
+		return nil, nil
+	}
+
+	for _, delimiter := range []rune{'\t', '|', ';', ','} {
+		if !strings.ContainsRune(header, delimiter) {
+			continue
+		}
+		reader := csv.NewReader(strings.NewReader(response))
+		reader.Comma = delimiter
+		reader.LazyQuotes = true
+		reader.FieldsPerRecord = -1
+		rows, err := reader.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("error while parsing SWIFTRef data: %v", err)
+		}
+		return rows, nil
+	}
+
+	return readFixedWidth(response, header), nil
+}
+
+// Cuts every line at the offsets where the header names start
+func readFixedWidth(response, header string) [][]string {
+	var starts []int
+	for _, match := range fixedWidthName.FindAllStringIndex(header, -1) {
+		starts = append(starts, len([]rune(header[:match[0]])))
+	}
+
+	var rows [][]string
+	for _, line := range strings.Split(response, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		runes := []rune(line)
+		row := make([]string, len(starts))
+		for i, start := range starts {
+			end := len(runes)
+			if i+1 < len(starts) {
+				end = min(starts[i+1], len(runes))
+			}
+			if start < end {
+				row[i] = strings.TrimSpace(string(runes[start:end]))
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func parseDirectoryDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	for _, layout := range swiftRefDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid date %q", value)
+}
@@ -0,0 +1,83 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func date(t *testing.T, value string) *time.Time {
+	t.Helper()
+	d, err := time.Parse("2006-01-02", value)
+	require.NoError(t, err)
+	return &d
+}
+
+func TestSWIFTRefParser_Delimited(t *testing.T) {
+	data := strings.Join([]string{
+		"MODIFICATION FLAG\tBIC8\tBRANCH BIC\tINSTITUTION NAME\tBRANCH INFORMATION\tSTREET ADDRESS 1\tSTREET ADDRESS 2\tCITY\tZIP CODE\tCOUNTRY NAME\tISO COUNTRY CODE\tSUBTYPE INDICATOR\tVALID FROM\tVALID TO",
+		"A\tBREXPLPW\t\tMBANK S.A.\t\tUL. PROSTA 18\t\tWARSZAWA\t00-850\tPOLAND\tPL\tSUPE\t2018-11-19\t",
+		"M\tBREXPLPW\tKRA\tMBANK S.A.\tKRAKOW BRANCH\tRYNEK 1\tPIETRO 2\tKRAKOW\t31-042\tPOLAND\tpl\tBANK\t20200101\t20301231",
+		"D\tBREXPLPW\tGDA\tMBANK S.A.\tGDANSK BRANCH\tDLUGA 5\t\tGDANSK\t80-827\tPOLAND\tPL\tBANK\t\t",
+	}, "\r\n")
+
+	var banks []models.Bank
+	require.NoError(t, NewSWIFTRefParser().ParseBankData(data, &banks))
+
+	// The record flagged as deleted is skipped
+	assert.Equal(t, []models.Bank{
+		{
+			CountryISO2Code: "PL", SwiftCode: "BREXPLPWXXX", CodeType: "BIC11", Name: "MBANK S.A.",
+			Address: "UL. PROSTA 18", TownName: "WARSZAWA", CountryName: "POLAND",
+			ZipCode: "00-850", InstitutionType: "SUPE", ValidFrom: date(t, "2018-11-19"),
+		},
+		{
+			CountryISO2Code: "PL", SwiftCode: "BREXPLPWKRA", CodeType: "BIC11", Name: "MBANK S.A.",
+			Address: "RYNEK 1, PIETRO 2", TownName: "KRAKOW", CountryName: "POLAND",
+			BranchInformation: "KRAKOW BRANCH", ZipCode: "31-042", InstitutionType: "BANK",
+			ValidFrom: date(t, "2020-01-01"), ValidTo: date(t, "2030-12-31"),
+		},
+	}, banks)
+}
+
+func TestSWIFTRefParser_FixedWidth(t *testing.T) {
+	data := "BIC          INSTITUTION NAME    CITY        ISO COUNTRY CODE\n" +
+		"DEUTDEFFXXX  DEUTSCHE BANK AG    FRANKFURT   DE\n" +
+		"DEUTDEFF500  DEUTSCHE BANK AG    MUENCHEN    DE\n"
+
+	var banks []models.Bank
+	require.NoError(t, NewSWIFTRefParser().ParseBankData(data, &banks))
+	require.Len(t, banks, 2)
+
+	assert.Equal(t, "DEUTDEFFXXX", banks[0].SwiftCode)
+	assert.Equal(t, "DEUTSCHE BANK AG", banks[0].Name)
+	assert.Equal(t, "FRANKFURT", banks[0].TownName)
+	assert.Equal(t, "DE", banks[0].CountryISO2Code)
+	assert.Equal(t, "DEUTDEFF500", banks[1].SwiftCode)
+	assert.Equal(t, "MUENCHEN", banks[1].TownName)
+}
+
+func TestSWIFTRefParser_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"empty", "", "empty input"},
+		{"missing name", "BIC|ISO COUNTRY CODE\nDEUTDEFFXXX|DE\n", `column "INSTITUTION NAME" not found`},
+		{"missing BIC", "INSTITUTION NAME|ISO COUNTRY CODE\nDEUTSCHE BANK|DE\n", "neither a BIC nor a BIC8 column"},
+		{"invalid date", "BIC|INSTITUTION NAME|ISO COUNTRY CODE|VALID FROM\nDEUTDEFFXXX|DEUTSCHE BANK|DE|yesterday\n", "line 2: invalid date"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var banks []models.Bank
+			err := NewSWIFTRefParser().ParseBankData(tt.data, &banks)
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Describes a token bucket refilled at Rate tokens per second up to Burst tokens
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Checks if the limit is configured
+func (l Limit) IsEnabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+// Outcome of taking a token from a bucket
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// Persists rate limit counters, implementations must be safe for concurrent use
+type Store interface {
+	// Takes one token from the bucket identified by key
+	TakeToken(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+	// Increments the counter identified by key and returns its new value, the counter is dropped after expiresAt
+	Increment(ctx context.Context, key string, expiresAt, now time.Time) (int64, error)
+}
+
+// Applies separate read and write token buckets and a daily quota per client,
+// and a token bucket per IP address checked before the client is authenticated
+type Limiter struct {
+	store      Store
+	read       Limit
+	write      Limit
+	ip         Limit
+	dailyQuota int64
+	now        func() time.Time
+}
+
+// Creates a new limiter, a zero limit or quota disables the corresponding check
+func NewLimiter(store Store, read, write, ip Limit, dailyQuota int64) *Limiter {
+	return &Limiter{
+		store:      store,
+		read:       read,
+		write:      write,
+		ip:         ip,
+		dailyQuota: dailyQuota,
+		now:        time.Now,
+	}
+}
+
+// Outcome of a limiter check
+type Decision struct {
+	Result
+	QuotaLimit     int64
+	QuotaRemaining int64
+	QuotaExceeded  bool
+}
+
+// Checks whether the client may perform a request.
+// A positive quota overrides the default daily quota for the client.
+func (l *Limiter) Allow(ctx context.Context, client string, write bool, quota int64) (Decision, error) {
+	now := l.now().UTC()
+
+	limit, kind := l.read, "read"
+	if write {
+		limit, kind = l.write, "write"
+	}
+
+	decision := Decision{Result: Result{Allowed: true}}
+	if limit.IsEnabled() {
+		result, err := l.store.TakeToken(ctx, fmt.Sprintf("bucket:%s:%s", kind, client), limit, now)
+		if err != nil {
+			return Decision{}, fmt.Errorf("failed to take token: %w", err)
+		}
+		decision.Result = result
+		if !result.Allowed {
+			return decision, nil
+		}
+	}
+
+	if quota <= 0 {
+		quota = l.dailyQuota
+	}
+	if quota <= 0 {
+		return decision, nil
+	}
+
+	day := now.Truncate(24 * time.Hour)
+	nextDay := day.Add(24 * time.Hour)
+
+	used, err := l.store.Increment(ctx, fmt.Sprintf("quota:%s:%s", client, day.Format(time.DateOnly)), nextDay, now)
+	if err != nil {
+		return Decision{}, fmt.Errorf("failed to count quota: %w", err)
+	}
+
+	decision.QuotaLimit = quota
+	decision.QuotaRemaining = max(quota-used, 0)
+	if used > quota {
+		decision.Allowed = false
+		decision.QuotaExceeded = true
+		decision.RetryAfter = nextDay.Sub(now)
+	}
+
+	return decision, nil
+}
+
+// Checks whether a request from the IP address may be authenticated at all, so clients cycling through
+// invalid credentials are throttled before every attempt costs a credential lookup
+func (l *Limiter) AllowIP(ctx context.Context, ip string) (Decision, error) {
+	if !l.ip.IsEnabled() {
+		return Decision{Result: Result{Allowed: true}}, nil
+	}
+
+	result, err := l.store.TakeToken(ctx, "bucket:ip:"+ip, l.ip, l.now().UTC())
+	if err != nil {
+		return Decision{}, fmt.Errorf("failed to take token: %w", err)
+	}
+
+	return Decision{Result: result}, nil
+}
+
+// Computes the state of a bucket after refilling it for the elapsed time and taking one token
+func takeToken(tokens float64, elapsed time.Duration, limit Limit) (float64, Result) {
+	tokens = math.Min(float64(limit.Burst), tokens+max(elapsed.Seconds(), 0)*limit.Rate)
+
+	allowed := tokens >= 1
+	if allowed {
+		tokens--
+	}
+
+	return tokens, bucketResult(tokens, allowed, limit)
+}
+
+// Describes a bucket holding the given number of tokens after a take
+func bucketResult(tokens float64, allowed bool, limit Limit) Result {
+	result := Result{
+		Allowed:   allowed,
+		Limit:     limit.Burst,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     secondsToDuration((float64(limit.Burst) - tokens) / limit.Rate),
+	}
+
+	if !allowed {
+		result.RetryAfter = secondsToDuration((1 - tokens) / limit.Rate)
+	}
+
+	return result
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(math.Ceil(seconds * float64(time.Second)))
+}
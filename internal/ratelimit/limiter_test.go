@@ -0,0 +1,131 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLimiter(read, write Limit, quota int64, now *time.Time) *Limiter {
+	limiter := NewLimiter(NewMemoryStore(), read, write, Limit{Rate: 1, Burst: 1}, quota)
+	limiter.now = func() time.Time { return *now }
+	return limiter
+}
+
+func TestLimiter_TokenBucket(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := newTestLimiter(Limit{Rate: 1, Burst: 2}, Limit{Rate: 0.5, Burst: 1}, 0, &now)
+	ctx := context.Background()
+
+	for i := range 2 {
+		decision, err := limiter.Allow(ctx, "client", false, 0)
+		require.NoError(t, err)
+		assert.True(t, decision.Allowed, "request %d should be allowed", i)
+		assert.Equal(t, 2, decision.Limit)
+		assert.Equal(t, 1-i, decision.Remaining)
+	}
+
+	decision, err := limiter.Allow(ctx, "client", false, 0)
+	require.NoError(t, err)
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, time.Second, decision.RetryAfter)
+	assert.Equal(t, 2*time.Second, decision.Reset)
+
+	// Reads and writes use separate buckets
+	decision, err = limiter.Allow(ctx, "client", true, 0)
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed)
+
+	// Other clients are not affected
+	decision, err = limiter.Allow(ctx, "other", false, 0)
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed)
+
+	now = now.Add(time.Second)
+	decision, err = limiter.Allow(ctx, "client", false, 0)
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed)
+	assert.Equal(t, 0, decision.Remaining)
+}
+
+func TestLimiter_DailyQuota(t *testing.T) {
+	now := time.Date(2025, 1, 1, 23, 0, 0, 0, time.UTC)
+	limiter := newTestLimiter(Limit{}, Limit{}, 2, &now)
+	ctx := context.Background()
+
+	tests := []struct {
+		name              string
+		client            string
+		quota             int64
+		expectedAllowed   bool
+		expectedRemaining int64
+	}{
+		{name: "First request", client: "client", expectedAllowed: true, expectedRemaining: 1},
+		{name: "Second request", client: "client", expectedAllowed: true, expectedRemaining: 0},
+		{name: "Quota exceeded", client: "client", expectedAllowed: false, expectedRemaining: 0},
+		{name: "Per-key quota overrides default", client: "key", quota: 5, expectedAllowed: true, expectedRemaining: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision, err := limiter.Allow(ctx, tt.client, false, tt.quota)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedAllowed, decision.Allowed)
+			assert.Equal(t, tt.expectedRemaining, decision.QuotaRemaining)
+			if !tt.expectedAllowed {
+				assert.True(t, decision.QuotaExceeded)
+				assert.Equal(t, time.Hour, decision.RetryAfter)
+			}
+		})
+	}
+
+	// Quota resets on the next day
+	now = now.Add(time.Hour)
+	decision, err := limiter.Allow(ctx, "client", false, 0)
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed)
+}
+
+func TestLimiter_AllowIP(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := newTestLimiter(Limit{}, Limit{}, 0, &now)
+	ctx := context.Background()
+
+	decision, err := limiter.AllowIP(ctx, "10.0.0.1")
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed)
+
+	decision, err = limiter.AllowIP(ctx, "10.0.0.1")
+	require.NoError(t, err)
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, time.Second, decision.RetryAfter)
+
+	// Client buckets are separate from the IP bucket
+	decision, err = limiter.Allow(ctx, "ip:10.0.0.1", false, 0)
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed)
+}
+
+func TestMemoryStore_SweepsExpiredCounters(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	expired := now.Add(-time.Second)
+
+	for i := range sweepInterval {
+		_, err := store.Increment(ctx, fmt.Sprintf("quota:%d", i), expired, now)
+		require.NoError(t, err)
+	}
+	assert.Len(t, store.counters, 1)
+
+	// An expired counter starts over
+	_, err := store.Increment(ctx, "quota:0", expired, now)
+	require.NoError(t, err)
+	value, err := store.Increment(ctx, "quota:0", now.Add(time.Hour), now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), value)
+}
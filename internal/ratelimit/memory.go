@@ -0,0 +1,93 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Number of operations between sweeps of idle buckets and expired counters
+const sweepInterval = 10000
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+	fullAt    time.Time
+}
+
+type counter struct {
+	value     int64
+	expiresAt time.Time
+}
+
+// Keeps rate limit counters in process memory, limits are not shared between replicas
+type MemoryStore struct {
+	mu       sync.Mutex
+	buckets  map[string]*bucket
+	counters map[string]*counter
+	ops      int
+}
+
+// Creates a new in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets:  make(map[string]*bucket),
+		counters: make(map[string]*counter),
+	}
+}
+
+// Takes one token from the bucket identified by key
+func (s *MemoryStore) TakeToken(_ context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updatedAt: now}
+		s.buckets[key] = b
+	}
+
+	tokens, result := takeToken(b.tokens, now.Sub(b.updatedAt), limit)
+	b.tokens = tokens
+	b.updatedAt = now
+	b.fullAt = now.Add(result.Reset)
+
+	return result, nil
+}
+
+// Increments the counter identified by key and returns its new value
+func (s *MemoryStore) Increment(_ context.Context, key string, expiresAt, now time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(now)
+
+	c, ok := s.counters[key]
+	if !ok || !now.Before(c.expiresAt) {
+		c = &counter{expiresAt: expiresAt}
+		s.counters[key] = c
+	}
+	c.value++
+
+	return c.value, nil
+}
+
+// Drops full buckets and expired counters, they are equivalent to missing ones
+func (s *MemoryStore) sweep(now time.Time) {
+	s.ops++
+	if s.ops < sweepInterval {
+		return
+	}
+	s.ops = 0
+
+	for key, b := range s.buckets {
+		if !now.Before(b.fullAt) {
+			delete(s.buckets, key)
+		}
+	}
+	for key, c := range s.counters {
+		if !now.Before(c.expiresAt) {
+			delete(s.counters, key)
+		}
+	}
+}
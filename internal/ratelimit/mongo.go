@@ -0,0 +1,104 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Keeps rate limit counters in a MongoDB collection so limits are shared between replicas.
+// Documents carry an expiresAt field meant to be covered by a TTL index.
+type MongoStore struct {
+	collection *mongo.Collection
+}
+
+// Creates a new MongoDB backed store
+func NewMongoStore(collection *mongo.Collection) *MongoStore {
+	return &MongoStore{
+		collection: collection,
+	}
+}
+
+type bucketDocument struct {
+	Tokens  float64 `bson:"tokens"`
+	Allowed bool    `bson:"allowed"`
+}
+
+type counterDocument struct {
+	Value int64 `bson:"value"`
+}
+
+// Takes one token from the bucket identified by key.
+// The refill and take happen in a single update pipeline so concurrent replicas never race.
+func (s *MongoStore) TakeToken(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	burst := float64(limit.Burst)
+	fillTime := secondsToDuration(burst / limit.Rate)
+
+	elapsed := bson.D{{Key: "$max", Value: bson.A{0, bson.D{{Key: "$divide", Value: bson.A{
+		bson.D{{Key: "$subtract", Value: bson.A{now, bson.D{{Key: "$ifNull", Value: bson.A{"$updatedAt", now}}}}}},
+		1000,
+	}}}}}}
+
+	refilled := bson.D{{Key: "$min", Value: bson.A{burst, bson.D{{Key: "$add", Value: bson.A{
+		bson.D{{Key: "$ifNull", Value: bson.A{"$tokens", burst}}},
+		bson.D{{Key: "$multiply", Value: bson.A{elapsed, limit.Rate}}},
+	}}}}}}
+
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.D{
+			{Key: "tokens", Value: refilled},
+			{Key: "updatedAt", Value: now},
+			{Key: "expiresAt", Value: now.Add(fillTime)},
+		}}},
+		{{Key: "$set", Value: bson.D{
+			{Key: "allowed", Value: bson.D{{Key: "$gte", Value: bson.A{"$tokens", 1}}}},
+		}}},
+		{{Key: "$set", Value: bson.D{
+			{Key: "tokens", Value: bson.D{{Key: "$cond", Value: bson.A{
+				"$allowed",
+				bson.D{{Key: "$subtract", Value: bson.A{"$tokens", 1}}},
+				"$tokens",
+			}}}},
+		}}},
+	}
+
+	var doc bucketDocument
+	if err := s.upsert(ctx, key, update, &doc); err != nil {
+		return Result{}, fmt.Errorf("failed to update bucket: %w", err)
+	}
+
+	return bucketResult(doc.Tokens, doc.Allowed, limit), nil
+}
+
+// Increments the counter identified by key and returns its new value
+func (s *MongoStore) Increment(ctx context.Context, key string, expiresAt, _ time.Time) (int64, error) {
+	update := bson.D{
+		{Key: "$inc", Value: bson.D{{Key: "value", Value: 1}}},
+		{Key: "$setOnInsert", Value: bson.D{{Key: "expiresAt", Value: expiresAt}}},
+	}
+
+	var doc counterDocument
+	if err := s.upsert(ctx, key, update, &doc); err != nil {
+		return 0, fmt.Errorf("failed to update counter: %w", err)
+	}
+
+	return doc.Value, nil
+}
+
+// Upserts the document with the given ID and decodes its updated state.
+// Two replicas inserting the same key at once make one of them fail on the unique _id, so it is retried once.
+func (s *MongoStore) upsert(ctx context.Context, key string, update any, result any) error {
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	filter := bson.D{{Key: "_id", Value: key}}
+
+	err := s.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(result)
+	if mongo.IsDuplicateKeyError(err) {
+		err = s.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(result)
+	}
+
+	return err
+}
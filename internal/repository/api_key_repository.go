@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Handles all API key database operations
+type APIKeyRepository struct {
+	collection *mongo.Collection
+}
+
+// Creates a new API key repository
+func NewAPIKeyRepository(collection *mongo.Collection) *APIKeyRepository {
+	return &APIKeyRepository{
+		collection: collection,
+	}
+}
+
+// Stores a new API key
+func (r *APIKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	if _, err := r.collection.InsertOne(ctx, key); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("api key already exists")
+		}
+		return fmt.Errorf("failed to create api key: %w", err)
+	}
+
+	return nil
+}
+
+// Finds an API key by its hash
+func (r *APIKeyRepository) FindByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := r.collection.FindOne(ctx, bson.D{{Key: "hash", Value: hash}}).Decode(&key); err != nil {
+		return nil, fmt.Errorf("failed to find api key: %w", err)
+	}
+
+	return &key, nil
+}
+
+// Finds an API key by its ID
+func (r *APIKeyRepository) FindByID(ctx context.Context, id string) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := r.collection.FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&key); err != nil {
+		return nil, fmt.Errorf("failed to find api key: %w", err)
+	}
+
+	return &key, nil
+}
+
+// Lists all API keys ordered by creation date
+func (r *APIKeyRepository) List(ctx context.Context) ([]models.APIKey, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, bson.D{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	keys := []models.APIKey{}
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, fmt.Errorf("failed to decode api keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// Replaces the hash and prefix of an active API key
+func (r *APIKeyRepository) Rotate(ctx context.Context, id, hash, prefix string, rotatedAt time.Time) error {
+	filter := bson.D{
+		{Key: "_id", Value: id},
+		{Key: "revokedAt", Value: bson.D{{Key: "$exists", Value: false}}},
+	}
+
+	update := bson.D{{
+		Key: "$set",
+		Value: bson.D{
+			{Key: "hash", Value: hash},
+			{Key: "prefix", Value: prefix},
+			{Key: "rotatedAt", Value: rotatedAt},
+		},
+	}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to rotate api key: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// Marks an API key as revoked
+func (r *APIKeyRepository) Revoke(ctx context.Context, id string, revokedAt time.Time) error {
+	filter := bson.D{
+		{Key: "_id", Value: id},
+		{Key: "revokedAt", Value: bson.D{{Key: "$exists", Value: false}}},
+	}
+
+	update := bson.D{{
+		Key:   "$set",
+		Value: bson.D{{Key: "revokedAt", Value: revokedAt}},
+	}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// Records the last time an API key was used
+func (r *APIKeyRepository) Touch(ctx context.Context, id string, usedAt time.Time) error {
+	update := bson.D{{
+		Key:   "$set",
+		Value: bson.D{{Key: "lastUsedAt", Value: usedAt}},
+	}}
+
+	if _, err := r.collection.UpdateByID(ctx, id, update); err != nil {
+		return fmt.Errorf("failed to update api key: %w", err)
+	}
+
+	return nil
+}
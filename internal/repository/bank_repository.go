@@ -2,17 +2,30 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
 
+	"github.com/MarcinZ20/bankAPI/internal/changes"
 	"github.com/MarcinZ20/bankAPI/pkg/models"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// Attempts of a conditional write before it gives up on a headquarter other writes keep changing
+const maxWriteAttempts = 5
+
+// Returned inside a conditional write when the document changed after it was read
+var errWriteConflict = errors.New("document changed during the write")
+
 // Handles all database operations
 type BankRepository struct {
 	collection *mongo.Collection
+	outbox     *Outbox
 }
 
 // Creates a new bank repository
@@ -22,6 +35,20 @@ func NewBankRepository(collection *mongo.Collection) *BankRepository {
 	}
 }
 
+// Stores the change events of every write in the outbox, in the same transaction as the write
+func (r *BankRepository) UseOutbox(outbox *Outbox) {
+	r.outbox = outbox
+}
+
+// Runs a write that reports its change events, in an outbox transaction when the outbox is used
+func (r *BankRepository) write(ctx context.Context, fn func(ctx context.Context) ([]models.ChangeEvent, error)) error {
+	if r.outbox == nil {
+		_, err := fn(ctx)
+		return err
+	}
+	return r.outbox.WithTransaction(ctx, fn)
+}
+
 // Finds a headquarter by SWIFT code
 func (r *BankRepository) FindHeadquarter(ctx context.Context, swiftCode string) (*models.Headquarter, error) {
 	filter := bson.D{
@@ -38,6 +65,29 @@ func (r *BankRepository) FindHeadquarter(ctx context.Context, swiftCode string)
 	return &hq, nil
 }
 
+// Finds the headquarters with the given SWIFT codes in one query, ordered by SWIFT code.
+// Unknown codes are left out of the result instead of failing the lookup.
+func (r *BankRepository) FindHeadquarters(ctx context.Context, swiftCodes []string) ([]models.Headquarter, error) {
+	filter := bson.D{
+		{Key: "swiftCode", Value: bson.D{{Key: "$in", Value: swiftCodes}}},
+		{Key: "isHeadquarter", Value: true},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "swiftCode", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find headquarters: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	foundData := []models.Headquarter{}
+	if err := cursor.All(ctx, &foundData); err != nil {
+		return nil, fmt.Errorf("failed to decode headquarters: %w", err)
+	}
+
+	return foundData, nil
+}
+
 // Finds a branch by SWIFT code
 func (r *BankRepository) FindBranch(ctx context.Context, swiftCode, parentSwiftCode string) (*models.Branch, error) {
 	filter := bson.D{
@@ -88,109 +138,383 @@ func (r *BankRepository) FindBanksByCountry(ctx context.Context, countryCode str
 	return foundData, nil
 }
 
-// Creates a new headquarter
-func (r *BankRepository) CreateHeadquarter(ctx context.Context, hq *models.Headquarter) error {
-	exists := bson.D{
-		{Key: "swiftCode", Value: hq.SwiftCode},
-		{Key: "isHeadquarter", Value: true},
+// Finds all headquarters with their branches, ordered by SWIFT code
+func (r *BankRepository) FindAll(ctx context.Context) ([]models.Headquarter, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "swiftCode", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, bson.D{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find banks: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	foundData := []models.Headquarter{}
+	if err := cursor.All(ctx, &foundData); err != nil {
+		return nil, fmt.Errorf("failed to decode banks: %w", err)
 	}
 
-	var existing models.Headquarter
-	if err := r.collection.FindOne(ctx, exists).Decode(&existing); err == nil {
-		return fmt.Errorf("headquarter already exists")
+	return foundData, nil
+}
+
+// Calls fn for every headquarter with its branches, ordered by SWIFT code and read one document at a time.
+// An empty country code streams all countries, an error from fn stops the stream and is returned.
+func (r *BankRepository) StreamHeadquarters(ctx context.Context, countryCode string, fn func(hq *models.Headquarter) error) error {
+	filter := bson.D{{Key: "isHeadquarter", Value: true}}
+	if countryCode != "" {
+		filter = append(filter, bson.E{Key: "countryISO2", Value: countryCode})
 	}
+	opts := options.Find().SetSort(bson.D{{Key: "swiftCode", Value: 1}})
 
-	_, err := r.collection.InsertOne(ctx, hq)
+	cursor, err := r.collection.Find(ctx, filter, opts)
 	if err != nil {
-		return fmt.Errorf("failed to create headquarter: %w", err)
+		return fmt.Errorf("failed to find banks: %w", err)
 	}
+	defer cursor.Close(ctx)
 
+	for cursor.Next(ctx) {
+		var hq models.Headquarter
+		if err := cursor.Decode(&hq); err != nil {
+			return fmt.Errorf("failed to decode bank: %w", err)
+		}
+		if err := fn(&hq); err != nil {
+			return err
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return fmt.Errorf("failed to read banks: %w", err)
+	}
 	return nil
 }
 
-// Adds a new branch to a headquarter
-func (r *BankRepository) AddBranch(ctx context.Context, parentSwiftCode string, branch *models.Branch) error {
+// Finds all headquarters and branches whose SWIFT code starts with prefix, ordered by SWIFT code
+func (r *BankRepository) FindByPrefix(ctx context.Context, prefix string) ([]models.BankEntity, error) {
+	pattern := bson.D{{Key: "$regex", Value: "^" + regexp.QuoteMeta(prefix)}}
 	filter := bson.D{
-		{Key: "swiftCode", Value: parentSwiftCode},
 		{Key: "isHeadquarter", Value: true},
+		{Key: "$or", Value: bson.A{
+			bson.D{{Key: "swiftCode", Value: pattern}},
+			bson.D{{Key: "branches.swiftCode", Value: pattern}},
+		}},
 	}
 
-	var hq models.Headquarter
-	if err := r.collection.FindOne(ctx, filter).Decode(&hq); err != nil {
-		return fmt.Errorf("failed to find parent headquarter: %w", err)
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find banks: %w", err)
 	}
+	defer cursor.Close(ctx)
 
-	for _, b := range hq.Branches {
-		if b.SwiftCode == branch.SwiftCode {
-			return fmt.Errorf("branch already exists")
-		}
+	var foundData []models.Headquarter
+	if err := cursor.All(ctx, &foundData); err != nil {
+		return nil, fmt.Errorf("failed to decode banks: %w", err)
 	}
 
-	update := bson.D{{
-		Key: "$push",
-		Value: bson.D{{
-			Key:   "branches",
-			Value: branch,
-		}},
-	}}
+	entities := matchPrefix(foundData, prefix)
+	if len(entities) == 0 {
+		return nil, mongo.ErrNoDocuments
+	}
+
+	return entities, nil
+}
+
+// Creates a new headquarter
+func (r *BankRepository) CreateHeadquarter(ctx context.Context, hq *models.Headquarter) error {
+	return r.write(ctx, func(ctx context.Context) ([]models.ChangeEvent, error) {
+		exists := bson.D{
+			{Key: "swiftCode", Value: hq.SwiftCode},
+			{Key: "isHeadquarter", Value: true},
+		}
+
+		var existing models.Headquarter
+		if err := r.collection.FindOne(ctx, exists).Decode(&existing); err == nil {
+			return nil, fmt.Errorf("headquarter %w", ErrAlreadyExists)
+		}
+
+		hq.UpdatedAt = timestamp()
+		for i := range hq.Branches {
+			hq.Branches[i].UpdatedAt = hq.UpdatedAt
+		}
+		_, err := r.collection.InsertOne(ctx, hq)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create headquarter: %w", err)
+		}
+
+		return changes.CreatedHeadquarter(models.ChangeSourceAPI, hq), nil
+	})
+}
+
+// Adds a new branch to a headquarter
+func (r *BankRepository) AddBranch(ctx context.Context, parentSwiftCode string, branch *models.Branch) error {
+	return r.write(ctx, func(ctx context.Context) ([]models.ChangeEvent, error) {
+		filter := bson.D{
+			{Key: "swiftCode", Value: parentSwiftCode},
+			{Key: "isHeadquarter", Value: true},
+		}
 
-	result, err := r.collection.UpdateOne(ctx, filter, update)
+		var hq models.Headquarter
+		if err := r.collection.FindOne(ctx, filter).Decode(&hq); err != nil {
+			return nil, fmt.Errorf("failed to find parent headquarter: %w", err)
+		}
+
+		for _, b := range hq.Branches {
+			if b.SwiftCode == branch.SwiftCode {
+				return nil, fmt.Errorf("branch %w", ErrAlreadyExists)
+			}
+		}
+
+		branch.UpdatedAt = timestamp()
+		update := bson.D{
+			{Key: "$push", Value: bson.D{{Key: "branches", Value: branch}}},
+			{Key: "$set", Value: bson.D{{Key: "updatedAt", Value: branch.UpdatedAt}}},
+			{Key: "$inc", Value: bson.D{{Key: "version", Value: 1}}},
+		}
+
+		result, err := r.collection.UpdateOne(ctx, filter, update)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add branch: %w", err)
+		}
+
+		if result.ModifiedCount == 0 {
+			return nil, fmt.Errorf("failed to modify headquarter")
+		}
+
+		return []models.ChangeEvent{changes.Created(models.ChangeSourceAPI, branch)}, nil
+	})
+}
+
+// Changes the bank name, address and country name of a headquarter and returns it as stored
+func (r *BankRepository) UpdateHeadquarter(ctx context.Context, hq *models.Headquarter, check Precondition) (*models.Headquarter, error) {
+	var updated *models.Headquarter
+	err := r.replace(ctx, hq.SwiftCode, func(stored *models.Headquarter) ([]models.ChangeEvent, error) {
+		if err := check.verify(stored); err != nil {
+			return nil, err
+		}
+
+		before := *stored
+		updated = stored
+		changed, err := applyHeadquarter(stored, hq)
+		if !changed || err != nil {
+			return nil, err
+		}
+		stored.UpdatedAt = timestamp()
+		return []models.ChangeEvent{changes.Updated(models.ChangeSourceAPI, &before, stored)}, nil
+	})
 	if err != nil {
-		return fmt.Errorf("failed to add branch: %w", err)
+		return nil, err
 	}
 
-	if result.ModifiedCount == 0 {
-		return fmt.Errorf("failed to modify headquarter")
+	return updated, nil
+}
+
+// Changes the bank name, address and country name of a branch and returns it as stored
+func (r *BankRepository) UpdateBranch(ctx context.Context, parentSwiftCode string, branch *models.Branch, check Precondition) (*models.Branch, error) {
+	var updated models.Branch
+	err := r.replace(ctx, parentSwiftCode, func(hq *models.Headquarter) ([]models.ChangeEvent, error) {
+		i := slices.IndexFunc(hq.Branches, func(b models.Branch) bool {
+			return b.SwiftCode == branch.SwiftCode
+		})
+		if i < 0 {
+			return nil, mongo.ErrNoDocuments
+		}
+		stored := &hq.Branches[i]
+		if err := check.verify(stored); err != nil {
+			return nil, err
+		}
+
+		before := *stored
+		changed, err := applyBranch(stored, branch)
+		if err != nil {
+			return nil, err
+		}
+		if changed {
+			stored.UpdatedAt = timestamp()
+			hq.UpdatedAt = stored.UpdatedAt
+		}
+		updated = *stored
+		if !changed {
+			return nil, nil
+		}
+		return []models.ChangeEvent{changes.Updated(models.ChangeSourceAPI, &before, stored)}, nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return &updated, nil
 }
 
 // Deletes a headquarter and all its branches
-func (r *BankRepository) DeleteHeadquarter(ctx context.Context, swiftCode string) error {
-	filter := bson.D{
-		{Key: "swiftCode", Value: swiftCode},
-		{Key: "isHeadquarter", Value: true},
+func (r *BankRepository) DeleteHeadquarter(ctx context.Context, swiftCode string, check Precondition) error {
+	if check != nil {
+		return r.deleteChecked(ctx, swiftCode, check)
 	}
 
-	result, err := r.collection.DeleteOne(ctx, filter)
-	if err != nil {
-		return fmt.Errorf("failed to delete headquarter: %w", err)
-	}
+	return r.write(ctx, func(ctx context.Context) ([]models.ChangeEvent, error) {
+		filter := bson.D{
+			{Key: "swiftCode", Value: swiftCode},
+			{Key: "isHeadquarter", Value: true},
+		}
 
-	if result.DeletedCount == 0 {
-		return mongo.ErrNoDocuments
-	}
+		// The deleted document is returned for the change events
+		var hq models.Headquarter
+		if err := r.collection.FindOneAndDelete(ctx, filter).Decode(&hq); err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return nil, mongo.ErrNoDocuments
+			}
+			return nil, fmt.Errorf("failed to delete headquarter: %w", err)
+		}
 
-	return nil
+		return changes.DeletedHeadquarter(models.ChangeSourceAPI, &hq), nil
+	})
 }
 
 // Removes a branch from its headquarter
-func (r *BankRepository) DeleteBranch(ctx context.Context, swiftCode, parentSwiftCode string) error {
-	filter := bson.D{
-		{Key: "swiftCode", Value: parentSwiftCode},
-		{Key: "isHeadquarter", Value: true},
+func (r *BankRepository) DeleteBranch(ctx context.Context, swiftCode, parentSwiftCode string, check Precondition) error {
+	if check != nil {
+		return r.replace(ctx, parentSwiftCode, func(hq *models.Headquarter) ([]models.ChangeEvent, error) {
+			i := slices.IndexFunc(hq.Branches, func(b models.Branch) bool {
+				return b.SwiftCode == swiftCode
+			})
+			if i < 0 {
+				return nil, mongo.ErrNoDocuments
+			}
+			if err := check.verify(&hq.Branches[i]); err != nil {
+				return nil, err
+			}
+
+			event := changes.Deleted(models.ChangeSourceAPI, &hq.Branches[i])
+			hq.Branches = slices.Delete(hq.Branches, i, i+1)
+			hq.UpdatedAt = timestamp()
+			return []models.ChangeEvent{event}, nil
+		})
 	}
 
-	update := bson.D{{
-		Key: "$pull",
-		Value: bson.D{{
-			Key: "branches",
-			Value: bson.D{{
-				Key:   "swiftCode",
-				Value: swiftCode,
-			}},
-		}},
-	}}
+	return r.write(ctx, func(ctx context.Context) ([]models.ChangeEvent, error) {
+		filter := bson.D{
+			{Key: "swiftCode", Value: parentSwiftCode},
+			{Key: "isHeadquarter", Value: true},
+		}
 
-	result, err := r.collection.UpdateOne(ctx, filter, update)
-	if err != nil {
-		return fmt.Errorf("failed to delete branch: %w", err)
+		update := bson.D{
+			{Key: "$pull", Value: bson.D{{Key: "branches", Value: bson.D{{Key: "swiftCode", Value: swiftCode}}}}},
+			{Key: "$set", Value: bson.D{{Key: "updatedAt", Value: timestamp()}}},
+			{Key: "$inc", Value: bson.D{{Key: "version", Value: 1}}},
+		}
+
+		// The headquarter as it was before the update holds the removed branch
+		var hq models.Headquarter
+		opts := options.FindOneAndUpdate().SetReturnDocument(options.Before)
+		if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&hq); err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return nil, mongo.ErrNoDocuments
+			}
+			return nil, fmt.Errorf("failed to delete branch: %w", err)
+		}
+
+		for i := range hq.Branches {
+			if hq.Branches[i].SwiftCode == swiftCode {
+				return []models.ChangeEvent{changes.Deleted(models.ChangeSourceAPI, &hq.Branches[i])}, nil
+			}
+		}
+		return nil, mongo.ErrNoDocuments
+	})
+}
+
+// Deletes a headquarter once the precondition holds for the version that is deleted
+func (r *BankRepository) deleteChecked(ctx context.Context, swiftCode string, check Precondition) error {
+	return retryConflicts(func() error {
+		return r.write(ctx, func(ctx context.Context) ([]models.ChangeEvent, error) {
+			hq, err := r.FindHeadquarter(ctx, swiftCode)
+			if err != nil {
+				return nil, err
+			}
+			if err := check.verify(hq); err != nil {
+				return nil, err
+			}
+
+			result, err := r.collection.DeleteOne(ctx, versionFilter(swiftCode, hq.Version))
+			if err != nil {
+				return nil, fmt.Errorf("failed to delete headquarter: %w", err)
+			}
+			if result.DeletedCount == 0 {
+				return nil, errWriteConflict
+			}
+
+			return changes.DeletedHeadquarter(models.ChangeSourceAPI, hq), nil
+		})
+	})
+}
+
+// Reads a headquarter, lets fn change it and replaces it when no other write changed it in between, otherwise
+// fn is called again with the new state. fn returns the change events of the write, none leave the document as it is.
+func (r *BankRepository) replace(ctx context.Context, swiftCode string, fn func(hq *models.Headquarter) ([]models.ChangeEvent, error)) error {
+	return retryConflicts(func() error {
+		return r.write(ctx, func(ctx context.Context) ([]models.ChangeEvent, error) {
+			hq, err := r.FindHeadquarter(ctx, swiftCode)
+			if err != nil {
+				return nil, err
+			}
+
+			version := hq.Version
+			events, err := fn(hq)
+			if err != nil || len(events) == 0 {
+				return nil, err
+			}
+
+			hq.Version = version + 1
+			result, err := r.collection.ReplaceOne(ctx, versionFilter(swiftCode, version), hq)
+			if err != nil {
+				return nil, fmt.Errorf("failed to update headquarter: %w", err)
+			}
+			if result.MatchedCount == 0 {
+				return nil, errWriteConflict
+			}
+
+			return events, nil
+		})
+	})
+}
+
+// Runs a conditional write again while it conflicts with other writes, up to maxWriteAttempts times
+func retryConflicts(write func() error) error {
+	var err error
+	for range maxWriteAttempts {
+		if err = write(); !errors.Is(err, errWriteConflict) {
+			return err
+		}
+	}
+	return err
+}
+
+// Matches a headquarter document only at the given version, documents written before versioning have none
+func versionFilter(swiftCode string, version int64) bson.D {
+	filter := bson.D{
+		{Key: "swiftCode", Value: swiftCode},
+		{Key: "isHeadquarter", Value: true},
+	}
+	if version == 0 {
+		return append(filter, bson.E{Key: "version", Value: bson.D{{Key: "$exists", Value: false}}})
 	}
+	return append(filter, bson.E{Key: "version", Value: version})
+}
 
-	if result.ModifiedCount == 0 {
-		return mongo.ErrNoDocuments
+// Flattens headquarters and their branches into the entities whose SWIFT code starts with prefix
+func matchPrefix(hqs []models.Headquarter, prefix string) []models.BankEntity {
+	entities := []models.BankEntity{}
+	for i := range hqs {
+		hq := &hqs[i]
+		if strings.HasPrefix(hq.SwiftCode, prefix) {
+			entities = append(entities, hq)
+		}
+		for j := range hq.Branches {
+			if strings.HasPrefix(hq.Branches[j].SwiftCode, prefix) {
+				entities = append(entities, &hq.Branches[j])
+			}
+		}
 	}
 
-	return nil
+	sort.Slice(entities, func(i, j int) bool {
+		return entities[i].GetSwiftCode() < entities[j].GetSwiftCode()
+	})
+
+	return entities
 }
@@ -296,7 +296,7 @@ func TestBankRepository_DeleteHeadquarter(t *testing.T) {
 			collection.Drop(ctx)
 			tt.setup()
 
-			err := repo.DeleteHeadquarter(ctx, tt.swiftCode)
+			err := repo.DeleteHeadquarter(ctx, tt.swiftCode, nil)
 			if tt.wantErr {
 				assert.Error(t, err)
 				return
@@ -429,7 +429,7 @@ func TestBankRepository_DeleteBranch(t *testing.T) {
 			collection.Drop(ctx)
 			tt.setup()
 
-			err := repo.DeleteBranch(ctx, tt.branchSwift, tt.parentSwift)
+			err := repo.DeleteBranch(ctx, tt.branchSwift, tt.parentSwift, nil)
 			if tt.wantErr {
 				assert.Error(t, err)
 				return
@@ -0,0 +1,395 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Serves bank data from process memory, used when running without MongoDB.
+// Every SWIFT code is kept in a sorted index, so prefix lookups are a binary search.
+// Writes are applied to the loaded snapshot only and are lost on the next reload.
+type MemoryRepository struct {
+	mu        sync.RWMutex
+	hqs       map[string]*models.Headquarter
+	codes     []string
+	parents   map[string]string
+	countries map[string][]string
+}
+
+var _ BankStore = (*MemoryRepository)(nil)
+
+// Creates a new empty in-memory repository
+func NewMemoryRepository() *MemoryRepository {
+	r := &MemoryRepository{}
+	r.reset(0)
+	return r
+}
+
+// Replaces the whole dataset with the given headquarters
+func (r *MemoryRepository) Load(hqs map[string]models.Headquarter) {
+	next := &MemoryRepository{}
+	next.reset(len(hqs))
+
+	// Indexes are filled unordered and sorted once, inserting one by one would be quadratic
+	for _, hq := range hqs {
+		next.hqs[hq.SwiftCode] = cloneHeadquarter(&hq)
+		next.parents[hq.SwiftCode] = hq.SwiftCode
+		next.codes = append(next.codes, hq.SwiftCode)
+		for _, branch := range hq.Branches {
+			next.parents[branch.SwiftCode] = hq.SwiftCode
+			next.codes = append(next.codes, branch.SwiftCode)
+		}
+		next.countries[hq.CountryISO2] = append(next.countries[hq.CountryISO2], hq.SwiftCode)
+	}
+
+	slices.Sort(next.codes)
+	next.codes = slices.Compact(next.codes)
+	for _, codes := range next.countries {
+		slices.Sort(codes)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.hqs = next.hqs
+	r.codes = next.codes
+	r.parents = next.parents
+	r.countries = next.countries
+}
+
+// Returns the number of headquarters and branches held in memory
+func (r *MemoryRepository) Count() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.codes)
+}
+
+// Finds a headquarter by SWIFT code
+func (r *MemoryRepository) FindHeadquarter(_ context.Context, swiftCode string) (*models.Headquarter, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	hq, ok := r.hqs[swiftCode]
+	if !ok {
+		return nil, fmt.Errorf("failed to find headquarter: %w", mongo.ErrNoDocuments)
+	}
+
+	return cloneHeadquarter(hq), nil
+}
+
+// Finds the headquarters with the given SWIFT codes, ordered by SWIFT code, unknown codes are left out
+func (r *MemoryRepository) FindHeadquarters(_ context.Context, swiftCodes []string) ([]models.Headquarter, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	foundData := []models.Headquarter{}
+	for _, swiftCode := range slices.Compact(slices.Sorted(slices.Values(swiftCodes))) {
+		if hq, ok := r.hqs[swiftCode]; ok {
+			foundData = append(foundData, *cloneHeadquarter(hq))
+		}
+	}
+
+	return foundData, nil
+}
+
+// Finds a branch by SWIFT code
+func (r *MemoryRepository) FindBranch(_ context.Context, swiftCode, parentSwiftCode string) (*models.Branch, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	hq, ok := r.hqs[parentSwiftCode]
+	if !ok {
+		return nil, fmt.Errorf("failed to find branch: %w", mongo.ErrNoDocuments)
+	}
+
+	for _, branch := range hq.Branches {
+		if branch.SwiftCode == swiftCode {
+			return &branch, nil
+		}
+	}
+
+	return nil, mongo.ErrNoDocuments
+}
+
+// Finds all banks in a given country
+func (r *MemoryRepository) FindBanksByCountry(_ context.Context, countryCode string) ([]models.Headquarter, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	codes := r.countries[countryCode]
+	if len(codes) == 0 {
+		return nil, mongo.ErrNoDocuments
+	}
+
+	foundData := make([]models.Headquarter, 0, len(codes))
+	for _, code := range codes {
+		foundData = append(foundData, *cloneHeadquarter(r.hqs[code]))
+	}
+
+	return foundData, nil
+}
+
+// Finds all headquarters with their branches, ordered by SWIFT code
+func (r *MemoryRepository) FindAll(_ context.Context) ([]models.Headquarter, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	foundData := make([]models.Headquarter, 0, len(r.hqs))
+	for _, hq := range r.hqs {
+		foundData = append(foundData, *cloneHeadquarter(hq))
+	}
+	slices.SortFunc(foundData, func(a, b models.Headquarter) int {
+		return strings.Compare(a.SwiftCode, b.SwiftCode)
+	})
+
+	return foundData, nil
+}
+
+// Calls fn for every headquarter with its branches, ordered by SWIFT code. The headquarters are copied
+// first so fn runs without holding the lock. An empty country code streams all countries.
+func (r *MemoryRepository) StreamHeadquarters(ctx context.Context, countryCode string, fn func(hq *models.Headquarter) error) error {
+	hqs, err := r.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := range hqs {
+		if countryCode != "" && hqs[i].CountryISO2 != countryCode {
+			continue
+		}
+		if err := fn(&hqs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Finds all headquarters and branches whose SWIFT code starts with prefix, ordered by SWIFT code
+func (r *MemoryRepository) FindByPrefix(_ context.Context, prefix string) ([]models.BankEntity, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entities := []models.BankEntity{}
+	for i := sort.SearchStrings(r.codes, prefix); i < len(r.codes) && strings.HasPrefix(r.codes[i], prefix); i++ {
+		code := r.codes[i]
+		hq := r.hqs[r.parents[code]]
+
+		if code == hq.SwiftCode {
+			entities = append(entities, cloneHeadquarter(hq))
+			continue
+		}
+
+		for _, branch := range hq.Branches {
+			if branch.SwiftCode == code {
+				entities = append(entities, &branch)
+				break
+			}
+		}
+	}
+
+	if len(entities) == 0 {
+		return nil, mongo.ErrNoDocuments
+	}
+
+	return entities, nil
+}
+
+// Creates a new headquarter
+func (r *MemoryRepository) CreateHeadquarter(_ context.Context, hq *models.Headquarter) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.parents[hq.SwiftCode]; ok {
+		return fmt.Errorf("headquarter %w", ErrAlreadyExists)
+	}
+
+	hq.UpdatedAt = timestamp()
+	for i := range hq.Branches {
+		hq.Branches[i].UpdatedAt = hq.UpdatedAt
+	}
+	r.insertHeadquarter(*hq)
+	return nil
+}
+
+// Adds a new branch to a headquarter
+func (r *MemoryRepository) AddBranch(_ context.Context, parentSwiftCode string, branch *models.Branch) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	hq, ok := r.hqs[parentSwiftCode]
+	if !ok {
+		return fmt.Errorf("failed to find parent headquarter: %w", mongo.ErrNoDocuments)
+	}
+
+	if _, ok := r.parents[branch.SwiftCode]; ok {
+		return fmt.Errorf("branch %w", ErrAlreadyExists)
+	}
+
+	branch.UpdatedAt = timestamp()
+	hq.Branches = append(hq.Branches, *branch)
+	hq.UpdatedAt = branch.UpdatedAt
+	r.indexCode(branch.SwiftCode, parentSwiftCode)
+
+	return nil
+}
+
+// Changes the bank name, address and country name of a headquarter and returns it as stored
+func (r *MemoryRepository) UpdateHeadquarter(_ context.Context, hq *models.Headquarter, check Precondition) (*models.Headquarter, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.hqs[hq.SwiftCode]
+	if !ok {
+		return nil, fmt.Errorf("failed to find headquarter: %w", mongo.ErrNoDocuments)
+	}
+	if err := check.verify(stored); err != nil {
+		return nil, err
+	}
+
+	changed, err := applyHeadquarter(stored, hq)
+	if err != nil {
+		return nil, err
+	}
+	if changed {
+		stored.UpdatedAt = timestamp()
+	}
+	return cloneHeadquarter(stored), nil
+}
+
+// Changes the bank name, address and country name of a branch and returns it as stored
+func (r *MemoryRepository) UpdateBranch(_ context.Context, parentSwiftCode string, branch *models.Branch, check Precondition) (*models.Branch, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	hq, ok := r.hqs[parentSwiftCode]
+	if !ok {
+		return nil, fmt.Errorf("failed to find branch: %w", mongo.ErrNoDocuments)
+	}
+	i := slices.IndexFunc(hq.Branches, func(b models.Branch) bool {
+		return b.SwiftCode == branch.SwiftCode
+	})
+	if i < 0 {
+		return nil, mongo.ErrNoDocuments
+	}
+	stored := &hq.Branches[i]
+	if err := check.verify(stored); err != nil {
+		return nil, err
+	}
+
+	changed, err := applyBranch(stored, branch)
+	if err != nil {
+		return nil, err
+	}
+	if changed {
+		stored.UpdatedAt = timestamp()
+		hq.UpdatedAt = stored.UpdatedAt
+	}
+	updated := *stored
+	return &updated, nil
+}
+
+// Deletes a headquarter and all its branches
+func (r *MemoryRepository) DeleteHeadquarter(_ context.Context, swiftCode string, check Precondition) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	hq, ok := r.hqs[swiftCode]
+	if !ok {
+		return mongo.ErrNoDocuments
+	}
+	if err := check.verify(hq); err != nil {
+		return err
+	}
+
+	for _, branch := range hq.Branches {
+		r.unindexCode(branch.SwiftCode)
+	}
+	r.unindexCode(swiftCode)
+
+	r.countries[hq.CountryISO2] = slices.DeleteFunc(r.countries[hq.CountryISO2], func(code string) bool {
+		return code == swiftCode
+	})
+	delete(r.hqs, swiftCode)
+
+	return nil
+}
+
+// Removes a branch from its headquarter
+func (r *MemoryRepository) DeleteBranch(_ context.Context, swiftCode, parentSwiftCode string, check Precondition) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	hq, ok := r.hqs[parentSwiftCode]
+	if !ok {
+		return mongo.ErrNoDocuments
+	}
+
+	index := slices.IndexFunc(hq.Branches, func(branch models.Branch) bool {
+		return branch.SwiftCode == swiftCode
+	})
+	if index < 0 {
+		return mongo.ErrNoDocuments
+	}
+	if err := check.verify(&hq.Branches[index]); err != nil {
+		return err
+	}
+
+	hq.Branches = slices.Delete(hq.Branches, index, index+1)
+	hq.UpdatedAt = timestamp()
+	r.unindexCode(swiftCode)
+
+	return nil
+}
+
+func (r *MemoryRepository) reset(size int) {
+	r.hqs = make(map[string]*models.Headquarter, size)
+	r.codes = make([]string, 0, size)
+	r.parents = make(map[string]string, size)
+	r.countries = make(map[string][]string)
+}
+
+// Adds a headquarter and its branches to all indexes
+func (r *MemoryRepository) insertHeadquarter(hq models.Headquarter) {
+	stored := cloneHeadquarter(&hq)
+	r.hqs[hq.SwiftCode] = stored
+
+	r.indexCode(hq.SwiftCode, hq.SwiftCode)
+	for _, branch := range hq.Branches {
+		r.indexCode(branch.SwiftCode, hq.SwiftCode)
+	}
+
+	codes := r.countries[hq.CountryISO2]
+	i, _ := slices.BinarySearch(codes, hq.SwiftCode)
+	r.countries[hq.CountryISO2] = slices.Insert(codes, i, hq.SwiftCode)
+}
+
+// Inserts a SWIFT code into the sorted index
+func (r *MemoryRepository) indexCode(code, parent string) {
+	i, found := slices.BinarySearch(r.codes, code)
+	if !found {
+		r.codes = slices.Insert(r.codes, i, code)
+	}
+	r.parents[code] = parent
+}
+
+// Removes a SWIFT code from the sorted index
+func (r *MemoryRepository) unindexCode(code string) {
+	if i, found := slices.BinarySearch(r.codes, code); found {
+		r.codes = slices.Delete(r.codes, i, i+1)
+	}
+	delete(r.parents, code)
+}
+
+// Copies a headquarter so callers cannot modify stored data
+func cloneHeadquarter(hq *models.Headquarter) *models.Headquarter {
+	clone := *hq
+	clone.Branches = slices.Clone(hq.Branches)
+	return &clone
+}
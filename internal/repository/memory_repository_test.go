@@ -0,0 +1,246 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func newTestMemoryRepository() *MemoryRepository {
+	repo := NewMemoryRepository()
+	repo.Load(map[string]models.Headquarter{
+		"PKOPPLPWXXX": {
+			SwiftCode:     "PKOPPLPWXXX",
+			BankName:      "PKO BANK POLSKI",
+			CountryISO2:   "PL",
+			IsHeadquarter: true,
+			Branches: []models.Branch{
+				{SwiftCode: "PKOPPLPWKRK", BankName: "PKO BANK POLSKI", CountryISO2: "PL"},
+				{SwiftCode: "PKOPPLPWGDA", BankName: "PKO BANK POLSKI", CountryISO2: "PL"},
+			},
+		},
+		"PKOPPLP2XXX": {
+			SwiftCode:     "PKOPPLP2XXX",
+			BankName:      "PKO BANK POLSKI",
+			CountryISO2:   "PL",
+			IsHeadquarter: true,
+		},
+		"DEUTDEFFXXX": {
+			SwiftCode:     "DEUTDEFFXXX",
+			BankName:      "DEUTSCHE BANK",
+			CountryISO2:   "DE",
+			IsHeadquarter: true,
+		},
+	})
+	return repo
+}
+
+func TestMemoryRepository_FindByPrefix(t *testing.T) {
+	repo := newTestMemoryRepository()
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		prefix string
+		want   []string
+	}{
+		{
+			name:   "Institution",
+			prefix: "PKOP",
+			want:   []string{"PKOPPLP2XXX", "PKOPPLPWGDA", "PKOPPLPWKRK", "PKOPPLPWXXX"},
+		},
+		{
+			name:   "Institution and location",
+			prefix: "PKOPPLPW",
+			want:   []string{"PKOPPLPWGDA", "PKOPPLPWKRK", "PKOPPLPWXXX"},
+		},
+		{
+			name:   "Full code",
+			prefix: "DEUTDEFFXXX",
+			want:   []string{"DEUTDEFFXXX"},
+		},
+		{
+			name:   "No match",
+			prefix: "ABCD",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entities, err := repo.FindByPrefix(ctx, tt.prefix)
+			if tt.want == nil {
+				assert.ErrorIs(t, err, mongo.ErrNoDocuments)
+				return
+			}
+			require.NoError(t, err)
+
+			codes := make([]string, len(entities))
+			for i, entity := range entities {
+				codes[i] = entity.GetSwiftCode()
+			}
+			assert.Equal(t, tt.want, codes)
+		})
+	}
+}
+
+func TestMemoryRepository_Lookups(t *testing.T) {
+	repo := newTestMemoryRepository()
+	ctx := context.Background()
+
+	hq, err := repo.FindHeadquarter(ctx, "PKOPPLPWXXX")
+	require.NoError(t, err)
+	assert.Len(t, hq.Branches, 2)
+
+	branch, err := repo.FindBranch(ctx, "PKOPPLPWKRK", "PKOPPLPWXXX")
+	require.NoError(t, err)
+	assert.Equal(t, "PKOPPLPWKRK", branch.SwiftCode)
+
+	banks, err := repo.FindBanksByCountry(ctx, "PL")
+	require.NoError(t, err)
+	assert.Len(t, banks, 2)
+
+	hqs, err := repo.FindHeadquarters(ctx, []string{"PKOPPLPWXXX", "UNKNOWNXXXX", "DEUTDEFFXXX", "PKOPPLPWXXX"})
+	require.NoError(t, err)
+	require.Len(t, hqs, 2)
+	assert.Equal(t, "DEUTDEFFXXX", hqs[0].SwiftCode)
+	assert.Equal(t, "PKOPPLPWXXX", hqs[1].SwiftCode)
+
+	_, err = repo.FindHeadquarter(ctx, "UNKNOWNXXXX")
+	assert.ErrorIs(t, err, mongo.ErrNoDocuments)
+
+	_, err = repo.FindBanksByCountry(ctx, "FR")
+	assert.ErrorIs(t, err, mongo.ErrNoDocuments)
+}
+
+func TestMemoryRepository_Writes(t *testing.T) {
+	repo := newTestMemoryRepository()
+	ctx := context.Background()
+
+	err := repo.CreateHeadquarter(ctx, &models.Headquarter{SwiftCode: "DEUTDEFFXXX", CountryISO2: "DE"})
+	assert.EqualError(t, err, "headquarter already exists")
+
+	require.NoError(t, repo.CreateHeadquarter(ctx, &models.Headquarter{SwiftCode: "BNPAFRPPXXX", CountryISO2: "FR", IsHeadquarter: true}))
+	require.NoError(t, repo.AddBranch(ctx, "BNPAFRPPXXX", &models.Branch{SwiftCode: "BNPAFRPPLYO", CountryISO2: "FR"}))
+
+	err = repo.AddBranch(ctx, "BNPAFRPPXXX", &models.Branch{SwiftCode: "BNPAFRPPLYO", CountryISO2: "FR"})
+	assert.EqualError(t, err, "branch already exists")
+
+	entities, err := repo.FindByPrefix(ctx, "BNPA")
+	require.NoError(t, err)
+	assert.Len(t, entities, 2)
+
+	require.NoError(t, repo.DeleteBranch(ctx, "PKOPPLPWKRK", "PKOPPLPWXXX", nil))
+	_, err = repo.FindBranch(ctx, "PKOPPLPWKRK", "PKOPPLPWXXX")
+	assert.ErrorIs(t, err, mongo.ErrNoDocuments)
+
+	require.NoError(t, repo.DeleteHeadquarter(ctx, "PKOPPLPWXXX", nil))
+	_, err = repo.FindByPrefix(ctx, "PKOPPLPW")
+	assert.ErrorIs(t, err, mongo.ErrNoDocuments)
+
+	banks, err := repo.FindBanksByCountry(ctx, "PL")
+	require.NoError(t, err)
+	assert.Len(t, banks, 1)
+
+	assert.Equal(t, 4, repo.Count())
+}
+
+func TestMemoryRepository_LoadReplacesData(t *testing.T) {
+	repo := newTestMemoryRepository()
+	ctx := context.Background()
+
+	repo.Load(map[string]models.Headquarter{
+		"BNPAFRPPXXX": {SwiftCode: "BNPAFRPPXXX", CountryISO2: "FR", IsHeadquarter: true},
+	})
+
+	_, err := repo.FindHeadquarter(ctx, "PKOPPLPWXXX")
+	assert.ErrorIs(t, err, mongo.ErrNoDocuments)
+
+	_, err = repo.FindHeadquarter(ctx, "BNPAFRPPXXX")
+	require.NoError(t, err)
+	assert.Equal(t, 1, repo.Count())
+}
+
+func TestMemoryRepository_StreamHeadquarters(t *testing.T) {
+	repo := newTestMemoryRepository()
+	ctx := context.Background()
+
+	collect := func(countryCode string) []string {
+		var codes []string
+		require.NoError(t, repo.StreamHeadquarters(ctx, countryCode, func(hq *models.Headquarter) error {
+			codes = append(codes, hq.SwiftCode)
+			return nil
+		}))
+		return codes
+	}
+
+	assert.Equal(t, []string{"DEUTDEFFXXX", "PKOPPLP2XXX", "PKOPPLPWXXX"}, collect(""))
+	assert.Equal(t, []string{"PKOPPLP2XXX", "PKOPPLPWXXX"}, collect("PL"))
+	assert.Empty(t, collect("FR"))
+
+	// An error from the callback stops the stream
+	calls := 0
+	err := repo.StreamHeadquarters(ctx, "", func(*models.Headquarter) error {
+		calls++
+		return assert.AnError
+	})
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Equal(t, 1, calls)
+}
+
+func TestMemoryRepository_Update(t *testing.T) {
+	repo := newTestMemoryRepository()
+	ctx := context.Background()
+
+	branch, err := repo.UpdateBranch(ctx, "PKOPPLPWXXX", &models.Branch{SwiftCode: "PKOPPLPWKRK", BankName: "PKO BP", Address: "RYNEK 1", CountryISO2: "PL"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "RYNEK 1", branch.Address)
+	require.NotNil(t, branch.UpdatedAt)
+
+	hq, err := repo.FindHeadquarter(ctx, "PKOPPLPWXXX")
+	require.NoError(t, err)
+	assert.Equal(t, branch.UpdatedAt, hq.UpdatedAt)
+	assert.Equal(t, "PKO BANK POLSKI", hq.BankName)
+
+	updated, err := repo.UpdateHeadquarter(ctx, &models.Headquarter{SwiftCode: "PKOPPLPWXXX", BankName: "PKO BP", CountryISO2: "PL"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "PKO BP", updated.BankName)
+	assert.Len(t, updated.Branches, 2)
+
+	_, err = repo.UpdateBranch(ctx, "PKOPPLPWXXX", &models.Branch{SwiftCode: "PKOPPLPWWAW"}, nil)
+	assert.ErrorIs(t, err, mongo.ErrNoDocuments)
+
+	_, err = repo.UpdateHeadquarter(ctx, &models.Headquarter{SwiftCode: "DEUTDEFFXXX", BankName: "DB", CountryISO2: "PL"}, nil)
+	assert.ErrorIs(t, err, ErrImmutableField)
+}
+
+func TestMemoryRepository_Precondition(t *testing.T) {
+	repo := newTestMemoryRepository()
+	ctx := context.Background()
+
+	failed := func(models.BankEntity) error { return ErrPreconditionFailed }
+	var checked []string
+	holds := func(current models.BankEntity) error {
+		checked = append(checked, current.GetSwiftCode())
+		return nil
+	}
+
+	_, err := repo.UpdateHeadquarter(ctx, &models.Headquarter{SwiftCode: "DEUTDEFFXXX", BankName: "DB"}, failed)
+	assert.ErrorIs(t, err, ErrPreconditionFailed)
+	assert.ErrorIs(t, repo.DeleteBranch(ctx, "PKOPPLPWKRK", "PKOPPLPWXXX", failed), ErrPreconditionFailed)
+	assert.ErrorIs(t, repo.DeleteHeadquarter(ctx, "DEUTDEFFXXX", failed), ErrPreconditionFailed)
+
+	// Nothing was written while the precondition failed
+	hq, err := repo.FindHeadquarter(ctx, "DEUTDEFFXXX")
+	require.NoError(t, err)
+	assert.Equal(t, "DEUTSCHE BANK", hq.BankName)
+	assert.Nil(t, hq.UpdatedAt)
+	assert.Equal(t, 5, repo.Count())
+
+	require.NoError(t, repo.DeleteBranch(ctx, "PKOPPLPWKRK", "PKOPPLPWXXX", holds))
+	require.NoError(t, repo.DeleteHeadquarter(ctx, "DEUTDEFFXXX", holds))
+	assert.Equal(t, []string{"PKOPPLPWKRK", "DEUTDEFFXXX"}, checked)
+}
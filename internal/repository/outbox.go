@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ID of the document holding the last assigned sequence number. Entries have numeric IDs, and range
+// queries on numbers never match a string, so the counter lives in the outbox collection unnoticed.
+const outboxSequenceID = "sequence"
+
+// Stores change events in MongoDB next to the writes that cause them, for the relay to deliver
+type Outbox struct {
+	collection *mongo.Collection
+	now        func() time.Time
+}
+
+// Creates an outbox stored in collection
+func NewOutbox(collection *mongo.Collection) *Outbox {
+	return &Outbox{
+		collection: collection,
+		now:        time.Now,
+	}
+}
+
+// Runs fn in a transaction and appends the events it returns in the same transaction, so a write
+// and its events are stored together or not at all
+func (o *Outbox) WithTransaction(ctx context.Context, fn func(ctx context.Context) ([]models.ChangeEvent, error)) error {
+	session, err := o.collection.Database().Client().StartSession()
+	if err != nil {
+		return fmt.Errorf("failed to start session: %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(ctx mongo.SessionContext) (any, error) {
+		events, err := fn(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return nil, o.Append(ctx, events...)
+	})
+	return err
+}
+
+// Stores events under consecutive sequence numbers, which also become their IDs. Inside a transaction
+// the counter update serializes concurrent writers, so the sequence follows the commit order.
+func (o *Outbox) Append(ctx context.Context, events ...models.ChangeEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	var counter struct {
+		Seq int64 `bson:"seq"`
+	}
+	update := bson.D{{Key: "$inc", Value: bson.D{{Key: "seq", Value: int64(len(events))}}}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	if err := o.collection.FindOneAndUpdate(ctx, bson.D{{Key: "_id", Value: outboxSequenceID}}, update, opts).Decode(&counter); err != nil {
+		return fmt.Errorf("failed to assign outbox sequence: %w", err)
+	}
+
+	now := o.now().UTC()
+	first := counter.Seq - int64(len(events)) + 1
+	entries := make([]any, len(events))
+	for i, event := range events {
+		seq := first + int64(i)
+		event.ID = uint64(seq)
+		event.Time = now
+		entries[i] = models.OutboxEntry{Seq: seq, Event: event, CreatedAt: now, Delivered: []string{}}
+	}
+
+	if _, err := o.collection.InsertMany(ctx, entries); err != nil {
+		return fmt.Errorf("failed to store outbox entries: %w", err)
+	}
+	return nil
+}
+
+// Lists up to limit entries the sink has not received yet, in sequence order
+func (o *Outbox) Pending(ctx context.Context, sink string, limit int64) ([]models.OutboxEntry, error) {
+	filter := bson.D{
+		{Key: "_id", Value: bson.D{{Key: "$gt", Value: 0}}},
+		{Key: "delivered", Value: bson.D{{Key: "$ne", Value: sink}}},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(limit)
+
+	cursor, err := o.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list outbox entries: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	entries := []models.OutboxEntry{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, fmt.Errorf("failed to decode outbox entries: %w", err)
+	}
+
+	return entries, nil
+}
+
+// Records that the sink received the entry
+func (o *Outbox) MarkDelivered(ctx context.Context, seq int64, sink string) error {
+	update := bson.D{{Key: "$addToSet", Value: bson.D{{Key: "delivered", Value: sink}}}}
+	if _, err := o.collection.UpdateByID(ctx, seq, update); err != nil {
+		return fmt.Errorf("failed to mark outbox entry %d delivered: %w", seq, err)
+	}
+	return nil
+}
+
+// Removes the entries every one of the sinks received and returns how many were removed
+func (o *Outbox) Purge(ctx context.Context, sinks []string) (int64, error) {
+	filter := bson.D{
+		{Key: "_id", Value: bson.D{{Key: "$gt", Value: 0}}},
+		{Key: "delivered", Value: bson.D{{Key: "$all", Value: sinks}}},
+	}
+
+	result, err := o.collection.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge outbox entries: %w", err)
+	}
+	return result.DeletedCount, nil
+}
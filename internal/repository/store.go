@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+)
+
+// Returned, wrapped with the kind of record, when a created headquarter or branch is already stored
+var ErrAlreadyExists = errors.New("already exists")
+
+// Returned by a conditional write whose precondition does not hold for the stored record
+var ErrPreconditionFailed = errors.New("precondition failed")
+
+// Returned, wrapped with the field, when an update would change a field that identifies the record
+var ErrImmutableField = errors.New("cannot be changed")
+
+// Checks the stored headquarter or branch right before a conditional write, an error aborts the write
+// and is returned by it. A nil precondition always holds.
+type Precondition func(current models.BankEntity) error
+
+func (check Precondition) verify(current models.BankEntity) error {
+	if check == nil {
+		return nil
+	}
+	return check(current)
+}
+
+// Defines the bank data operations the service layer depends on
+type BankStore interface {
+	FindHeadquarter(ctx context.Context, swiftCode string) (*models.Headquarter, error)
+	FindHeadquarters(ctx context.Context, swiftCodes []string) ([]models.Headquarter, error)
+	FindBranch(ctx context.Context, swiftCode, parentSwiftCode string) (*models.Branch, error)
+	FindBanksByCountry(ctx context.Context, countryCode string) ([]models.Headquarter, error)
+	FindByPrefix(ctx context.Context, prefix string) ([]models.BankEntity, error)
+	FindAll(ctx context.Context) ([]models.Headquarter, error)
+	StreamHeadquarters(ctx context.Context, countryCode string, fn func(hq *models.Headquarter) error) error
+	CreateHeadquarter(ctx context.Context, hq *models.Headquarter) error
+	AddBranch(ctx context.Context, parentSwiftCode string, branch *models.Branch) error
+	UpdateHeadquarter(ctx context.Context, hq *models.Headquarter, check Precondition) (*models.Headquarter, error)
+	UpdateBranch(ctx context.Context, parentSwiftCode string, branch *models.Branch, check Precondition) (*models.Branch, error)
+	DeleteHeadquarter(ctx context.Context, swiftCode string, check Precondition) error
+	DeleteBranch(ctx context.Context, swiftCode, parentSwiftCode string, check Precondition) error
+}
+
+var _ BankStore = (*BankRepository)(nil)
+
+// Time of a write as MongoDB stores it, in milliseconds, so records read back compare equal
+func timestamp() *time.Time {
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	return &now
+}
+
+// Copies the fields an update replaces onto a stored headquarter and reports whether any changed.
+// The SWIFT and country codes identify the record and must stay as they are.
+func applyHeadquarter(stored, update *models.Headquarter) (bool, error) {
+	if update.CountryISO2 != stored.CountryISO2 {
+		return false, fmt.Errorf("country code %w", ErrImmutableField)
+	}
+	changed := stored.BankName != update.BankName || stored.Address != update.Address || stored.CountryName != update.CountryName
+	stored.BankName, stored.Address, stored.CountryName = update.BankName, update.Address, update.CountryName
+	return changed, nil
+}
+
+// Copies the fields an update replaces onto a stored branch and reports whether any changed
+func applyBranch(stored, update *models.Branch) (bool, error) {
+	if update.CountryISO2 != stored.CountryISO2 {
+		return false, fmt.Errorf("country code %w", ErrImmutableField)
+	}
+	changed := stored.BankName != update.BankName || stored.Address != update.Address || stored.CountryName != update.CountryName
+	stored.BankName, stored.Address, stored.CountryName = update.BankName, update.Address, update.CountryName
+	return changed, nil
+}
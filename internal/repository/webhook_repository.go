@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Handles webhook and dead letter database operations
+type WebhookRepository struct {
+	webhooks    *mongo.Collection
+	deadLetters *mongo.Collection
+}
+
+// Creates a new webhook repository
+func NewWebhookRepository(webhooks, deadLetters *mongo.Collection) *WebhookRepository {
+	return &WebhookRepository{
+		webhooks:    webhooks,
+		deadLetters: deadLetters,
+	}
+}
+
+// Stores a new webhook
+func (r *WebhookRepository) Create(ctx context.Context, webhook *models.Webhook) error {
+	if _, err := r.webhooks.InsertOne(ctx, webhook); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("webhook %w", ErrAlreadyExists)
+		}
+		return fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	return nil
+}
+
+// Lists all webhooks ordered by creation date
+func (r *WebhookRepository) List(ctx context.Context) ([]models.Webhook, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}})
+
+	cursor, err := r.webhooks.Find(ctx, bson.D{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	webhooks := []models.Webhook{}
+	if err := cursor.All(ctx, &webhooks); err != nil {
+		return nil, fmt.Errorf("failed to decode webhooks: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+// Deletes a webhook, its dead letters are kept
+func (r *WebhookRepository) Delete(ctx context.Context, id string) error {
+	result, err := r.webhooks.DeleteOne(ctx, bson.D{{Key: "_id", Value: id}})
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// Records an event a webhook did not accept
+func (r *WebhookRepository) AddDeadLetter(ctx context.Context, letter *models.DeadLetter) error {
+	if _, err := r.deadLetters.InsertOne(ctx, letter); err != nil {
+		return fmt.Errorf("failed to store dead letter: %w", err)
+	}
+
+	return nil
+}
+
+// Lists the most recent dead letters, newest first
+func (r *WebhookRepository) ListDeadLetters(ctx context.Context, limit int64) ([]models.DeadLetter, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "failedAt", Value: -1}}).SetLimit(limit)
+
+	cursor, err := r.deadLetters.Find(ctx, bson.D{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	letters := []models.DeadLetter{}
+	if err := cursor.All(ctx, &letters); err != nil {
+		return nil, fmt.Errorf("failed to decode dead letters: %w", err)
+	}
+
+	return letters, nil
+}
@@ -0,0 +1,130 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Version of the last applied source data, shared by every replica holding the lock
+type State struct {
+	ETag       string    `bson:"etag" json:"etag,omitempty"`
+	Hash       string    `bson:"hash" json:"hash,omitempty"`
+	ImportedAt time.Time `bson:"importedAt" json:"importedAt"`
+}
+
+// Lets one replica import at a time and remembers the last applied version
+type Lock interface {
+	// Takes the lock for ttl, ok is false while another owner holds it
+	Acquire(ctx context.Context, owner string, ttl time.Duration) (state State, ok bool, err error)
+	// Frees the lock, storing state when it is not nil
+	Release(ctx context.Context, owner string, state *State) error
+}
+
+// Lock held in a MongoDB document, a lease that expires if its owner dies
+type MongoLock struct {
+	collection *mongo.Collection
+	name       string
+}
+
+var _ Lock = (*MongoLock)(nil)
+
+type lockDocument struct {
+	ID        string    `bson:"_id"`
+	Owner     string    `bson:"owner"`
+	ExpiresAt time.Time `bson:"expiresAt"`
+	State     State     `bson:",inline"`
+}
+
+// Creates a lock stored as the document with ID name in collection
+func NewMongoLock(collection *mongo.Collection, name string) *MongoLock {
+	return &MongoLock{collection: collection, name: name}
+}
+
+// Takes the lock when it is free, expired or already held by owner
+func (l *MongoLock) Acquire(ctx context.Context, owner string, ttl time.Duration) (State, bool, error) {
+	now := time.Now()
+	filter := bson.M{
+		"_id": l.name,
+		"$or": bson.A{
+			bson.M{"expiresAt": bson.M{"$lte": now}},
+			bson.M{"owner": owner},
+		},
+	}
+	update := bson.M{"$set": bson.M{"owner": owner, "expiresAt": now.Add(ttl)}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	// A held lock does not match the filter, so the upsert collides with its _id
+	var doc lockDocument
+	err := l.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&doc)
+	if mongo.IsDuplicateKeyError(err) {
+		return State{}, false, nil
+	}
+	if err != nil {
+		return State{}, false, fmt.Errorf("failed to acquire lock %s: %w", l.name, err)
+	}
+
+	return doc.State, true, nil
+}
+
+// Frees the lock if owner still holds it
+func (l *MongoLock) Release(ctx context.Context, owner string, state *State) error {
+	set := bson.M{"owner": "", "expiresAt": time.Time{}}
+	if state != nil {
+		set["etag"] = state.ETag
+		set["hash"] = state.Hash
+		set["importedAt"] = state.ImportedAt
+	}
+
+	// Matches nothing when the lease expired and another owner took over
+	if _, err := l.collection.UpdateOne(ctx, bson.M{"_id": l.name, "owner": owner}, bson.M{"$set": set}); err != nil {
+		return fmt.Errorf("failed to release lock %s: %w", l.name, err)
+	}
+	return nil
+}
+
+// In-process lock, used when every replica keeps its own copy of the data
+type LocalLock struct {
+	mu    sync.Mutex
+	owner string
+	state State
+}
+
+var _ Lock = (*LocalLock)(nil)
+
+// Creates a free in-process lock
+func NewLocalLock() *LocalLock {
+	return &LocalLock{}
+}
+
+// Takes the lock when it is free or already held by owner, the lease is not needed within a process
+func (l *LocalLock) Acquire(_ context.Context, owner string, _ time.Duration) (State, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.owner != "" && l.owner != owner {
+		return State{}, false, nil
+	}
+	l.owner = owner
+	return l.state, true, nil
+}
+
+// Frees the lock if owner holds it
+func (l *LocalLock) Release(_ context.Context, owner string, state *State) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.owner != owner {
+		return nil
+	}
+	l.owner = ""
+	if state != nil {
+		l.state = *state
+	}
+	return nil
+}
@@ -0,0 +1,266 @@
+package scheduler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/MarcinZ20/bankAPI/internal/importer"
+	"github.com/MarcinZ20/bankAPI/internal/metrics"
+	"github.com/MarcinZ20/bankAPI/internal/tracing"
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+	"github.com/robfig/cron/v3"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Results of a scheduled run
+const (
+	ResultImported  = "imported"
+	ResultUnchanged = "unchanged"
+	ResultLocked    = "locked"
+	ResultFailed    = "failed"
+)
+
+// How often an import waiting for the lock checks whether another owner released it
+var lockRetryInterval = 5 * time.Second
+
+// Stores a loaded dataset, e.g. importer.ImportIncremental
+type ApplyFunc func(ctx context.Context, data map[string]models.Headquarter) error
+
+// Holds scheduler settings
+type Config struct {
+	// Standard five field cron expression or a descriptor such as @hourly or @every 30m
+	Schedule string
+	Source   importer.Source
+	Lock     Lock
+	Apply    ApplyFunc
+}
+
+// Snapshot of the scheduler state for the admin API
+type Status struct {
+	Schedule   string     `json:"schedule"`
+	Source     string     `json:"source"`
+	Running    bool       `json:"running"`
+	NextRun    *time.Time `json:"nextRun,omitempty"`
+	LastRun    *time.Time `json:"lastRun,omitempty"`
+	LastResult string     `json:"lastResult,omitempty"`
+	LastError  string     `json:"lastError,omitempty"`
+	Duration   string     `json:"duration,omitempty"`
+	// Version of the data applied last by any replica, as seen by the last run
+	Applied *State `json:"applied,omitempty"`
+}
+
+// Re-imports the source on a cron schedule, skipping runs when the source did not change
+type Scheduler struct {
+	config   Config
+	schedule cron.Schedule
+	owner    string
+
+	mu     sync.Mutex
+	status Status
+}
+
+// Creates a scheduler, the schedule is validated here
+func New(config Config) (*Scheduler, error) {
+	schedule, err := cron.ParseStandard(config.Schedule)
+	if err != nil {
+		return nil, fmt.Errorf("invalid import schedule %q: %w", config.Schedule, err)
+	}
+	owner, err := NewOwnerID()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Scheduler{
+		config:   config,
+		schedule: schedule,
+		owner:    owner,
+		status:   Status{Schedule: config.Schedule, Source: config.Source.Kind},
+	}, nil
+}
+
+// Runs the schedule until ctx is done
+func (s *Scheduler) Run(ctx context.Context) {
+	for {
+		next := s.schedule.Next(time.Now())
+		s.update(func(status *Status) { status.NextRun = &next })
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		s.RunOnce(ctx)
+	}
+}
+
+// Imports the source if it changed since the last applied version and returns the result
+func (s *Scheduler) RunOnce(ctx context.Context) string {
+	start := time.Now()
+	s.update(func(status *Status) { status.Running = true })
+
+	ctx, span := tracing.Start(ctx, "scheduler.RunOnce", attribute.String("import.source", s.config.Source.Kind))
+	ctx, cancel := context.WithTimeout(ctx, importer.Timeout)
+	defer cancel()
+
+	result, state, err := s.run(ctx)
+	span.SetAttributes(attribute.String("import.result", result))
+	tracing.End(span, err)
+	if err != nil {
+		slog.ErrorContext(ctx, "scheduled import failed", "error", err)
+	} else {
+		slog.InfoContext(ctx, "scheduled import completed", "result", result)
+	}
+	metrics.ScheduledImports.WithLabelValues(result).Inc()
+
+	s.update(func(status *Status) {
+		status.Running = false
+		status.LastRun = &start
+		status.LastResult = result
+		status.Duration = time.Since(start).String()
+		status.LastError = ""
+		if err != nil {
+			status.LastError = err.Error()
+		}
+		if state != nil {
+			status.Applied = state
+		}
+	})
+
+	return result
+}
+
+// Holds the lock while fetching, comparing and applying, the lease outlives the import timeout
+func (s *Scheduler) run(ctx context.Context) (string, *State, error) {
+	state, ok, err := s.config.Lock.Acquire(ctx, s.owner, importer.Timeout+time.Minute)
+	if err != nil {
+		return ResultFailed, nil, err
+	}
+	if !ok {
+		return ResultLocked, nil, nil
+	}
+
+	// The stored state only changes when new data was applied
+	var applied *State
+	defer func() {
+		if err := s.config.Lock.Release(context.WithoutCancel(ctx), s.owner, applied); err != nil {
+			slog.ErrorContext(ctx, "failed to release import lock", "error", err)
+		}
+	}()
+
+	applied, err = importSource(ctx, s.config.Source, state, false, s.config.Apply)
+	if err != nil {
+		return ResultFailed, &state, err
+	}
+	if applied == nil {
+		return ResultUnchanged, &state, nil
+	}
+	return ResultImported, applied, nil
+}
+
+// Imports source while holding lock, waiting as long as another owner holds it, so imports started outside the
+// schedule never interleave with scheduled ones on any replica. The data is applied even when the source did
+// not change, and recorded as the last applied version.
+func ImportLocked(ctx context.Context, lock Lock, source importer.Source, apply ApplyFunc) (err error) {
+	ctx, span := tracing.Start(ctx, "scheduler.ImportLocked", attribute.String("import.source", source.Kind))
+	defer func() { tracing.End(span, err) }()
+
+	owner, err := NewOwnerID()
+	if err != nil {
+		return err
+	}
+	state, err := waitForLock(ctx, lock, owner)
+	if err != nil {
+		return err
+	}
+
+	var applied *State
+	defer func() {
+		if err := lock.Release(context.WithoutCancel(ctx), owner, applied); err != nil {
+			slog.ErrorContext(ctx, "failed to release import lock", "error", err)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, importer.Timeout)
+	defer cancel()
+
+	applied, err = importSource(ctx, source, state, true, apply)
+	return err
+}
+
+// Takes the lock for an import, retrying until the current owner releases it or its lease expires
+func waitForLock(ctx context.Context, lock Lock, owner string) (State, error) {
+	for logged := false; ; logged = true {
+		state, ok, err := lock.Acquire(ctx, owner, importer.Timeout+time.Minute)
+		if err != nil || ok {
+			return state, err
+		}
+		if !logged {
+			slog.InfoContext(ctx, "waiting for another import to finish")
+		}
+
+		select {
+		case <-ctx.Done():
+			return State{}, ctx.Err()
+		case <-time.After(lockRetryInterval):
+		}
+	}
+}
+
+// Fetches source and applies its data, unless force is set a source still matching state is skipped.
+// Returns the version to record as applied, nil when nothing was applied.
+func importSource(ctx context.Context, source importer.Source, state State, force bool, apply ApplyFunc) (*State, error) {
+	etag := state.ETag
+	if force {
+		etag = ""
+	}
+
+	content, err := source.Fetch(ctx, etag)
+	if err != nil {
+		return nil, err
+	}
+	if !force && (content.NotModified || (content.Hash == state.Hash && state.Hash != "")) {
+		return nil, nil
+	}
+
+	data, err := source.Decode(ctx, content)
+	if err != nil {
+		return nil, err
+	}
+	if err := apply(ctx, *data); err != nil {
+		return nil, err
+	}
+
+	return &State{ETag: content.ETag, Hash: content.Hash, ImportedAt: time.Now()}, nil
+}
+
+// Returns a copy of the current status
+func (s *Scheduler) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
+
+func (s *Scheduler) update(fn func(status *Status)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(&s.status)
+}
+
+// Identifies this process as a lock owner, the random suffix keeps owners on the same host apart
+func NewOwnerID() (string, error) {
+	host, _ := os.Hostname()
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", fmt.Errorf("failed to generate lock owner id: %w", err)
+	}
+	return fmt.Sprintf("%s-%s", host, hex.EncodeToString(suffix)), nil
+}
@@ -0,0 +1,129 @@
+package scheduler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/MarcinZ20/bankAPI/internal/importer"
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const snapshot = `[{"swiftCode":"BREXPLPWXXX","bankName":"MBANK S.A.","address":"PROSTA 18","countryISO2":"PL","countryName":"POLAND","isHeadquarter":true,"branches":[]}]`
+
+func newTestScheduler(t *testing.T, lock Lock) (*Scheduler, string, *int) {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), "snapshot.json")
+	require.NoError(t, os.WriteFile(file, []byte(snapshot), 0o644))
+
+	applied := 0
+	s, err := New(Config{
+		Schedule: "@every 1h",
+		Source:   importer.Source{Kind: importer.SourceFile, File: file},
+		Lock:     lock,
+		Apply: func(_ context.Context, data map[string]models.Headquarter) error {
+			applied++
+			return nil
+		},
+	})
+	require.NoError(t, err)
+
+	return s, file, &applied
+}
+
+func TestRunOnce(t *testing.T) {
+	s, file, applied := newTestScheduler(t, NewLocalLock())
+	ctx := context.Background()
+
+	assert.Equal(t, ResultImported, s.RunOnce(ctx))
+	assert.Equal(t, 1, *applied)
+
+	// Same content is skipped
+	assert.Equal(t, ResultUnchanged, s.RunOnce(ctx))
+	assert.Equal(t, 1, *applied)
+
+	// Changed content is applied again
+	require.NoError(t, os.WriteFile(file, []byte(`[]`), 0o644))
+	assert.Equal(t, ResultImported, s.RunOnce(ctx))
+	assert.Equal(t, 2, *applied)
+
+	status := s.Status()
+	assert.Equal(t, ResultImported, status.LastResult)
+	assert.Empty(t, status.LastError)
+	assert.False(t, status.Running)
+	require.NotNil(t, status.Applied)
+	assert.NotEmpty(t, status.Applied.Hash)
+}
+
+func TestRunOnceFailure(t *testing.T) {
+	s, file, applied := newTestScheduler(t, NewLocalLock())
+	require.NoError(t, os.WriteFile(file, []byte(`not json`), 0o644))
+
+	assert.Equal(t, ResultFailed, s.RunOnce(context.Background()))
+	assert.Equal(t, 0, *applied)
+	assert.NotEmpty(t, s.Status().LastError)
+}
+
+func TestRunOnceLocked(t *testing.T) {
+	lock := NewLocalLock()
+	_, ok, err := lock.Acquire(context.Background(), "other-replica", time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	s, _, applied := newTestScheduler(t, lock)
+
+	assert.Equal(t, ResultLocked, s.RunOnce(context.Background()))
+	assert.Equal(t, 0, *applied)
+}
+
+func TestNewInvalidSchedule(t *testing.T) {
+	_, err := New(Config{Schedule: "every hour"})
+	assert.Error(t, err)
+}
+
+func TestImportLocked(t *testing.T) {
+	lockRetryInterval = 10 * time.Millisecond
+	t.Cleanup(func() { lockRetryInterval = 5 * time.Second })
+
+	s, file, applied := newTestScheduler(t, NewLocalLock())
+	ctx := context.Background()
+	apply := s.config.Apply
+	source := importer.Source{Kind: importer.SourceFile, File: file}
+
+	assert.Equal(t, ResultImported, s.RunOnce(ctx))
+
+	// Unchanged data is applied anyway and recorded, so the next scheduled run skips it
+	require.NoError(t, ImportLocked(ctx, s.config.Lock, source, apply))
+	assert.Equal(t, 2, *applied)
+	assert.Equal(t, ResultUnchanged, s.RunOnce(ctx))
+
+	// An import waits while another replica holds the lock
+	_, ok, err := s.config.Lock.Acquire(ctx, "other-replica", time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	done := make(chan error, 1)
+	go func() { done <- ImportLocked(ctx, s.config.Lock, source, apply) }()
+
+	select {
+	case err := <-done:
+		t.Fatalf("import did not wait for the lock: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	require.NoError(t, s.config.Lock.Release(ctx, "other-replica", nil))
+	require.NoError(t, <-done)
+	assert.Equal(t, 3, *applied)
+
+	// Waiting ends with the context
+	_, _, err = s.config.Lock.Acquire(ctx, "other-replica", time.Minute)
+	require.NoError(t, err)
+	canceled, cancel := context.WithTimeout(ctx, 30*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, ImportLocked(canceled, s.config.Lock, source, apply), context.DeadlineExceeded)
+}
@@ -0,0 +1,240 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MarcinZ20/bankAPI/internal/auth"
+	"github.com/MarcinZ20/bankAPI/internal/repository"
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	apiKeyPrefix     = "bk_"
+	apiKeyBytes      = 32
+	apiKeyPrefixSize = len(apiKeyPrefix) + 8
+
+	// Usage of a key is written at most once per interval, lastUsedAt is only that precise
+	touchInterval = time.Minute
+	touchTimeout  = 5 * time.Second
+)
+
+var (
+	ErrInvalidAPIKey = fmt.Errorf("%w: invalid api key", auth.ErrInvalidCredentials)
+	ErrExpiredAPIKey = fmt.Errorf("%w: api key expired", auth.ErrInvalidCredentials)
+	ErrRevokedAPIKey = fmt.Errorf("%w: api key revoked", auth.ErrInvalidCredentials)
+
+	ErrInvalidAPIKeyRequest = errors.New("invalid api key request")
+)
+
+// Holds the fields a client may set when creating an API key
+type CreateAPIKeyRequest struct {
+	Name       string            `json:"name"`
+	Scopes     []string          `json:"scopes"`
+	Metadata   map[string]string `json:"metadata"`
+	DailyQuota int64             `json:"dailyQuota"`
+	ExpiresAt  *time.Time        `json:"expiresAt"`
+}
+
+// Handles business logic for API key management and authentication
+type APIKeyService struct {
+	repo *repository.APIKeyRepository
+	now  func() time.Time
+
+	touchMu sync.Mutex
+	touched map[string]time.Time
+}
+
+// Creates a new API key service
+func NewAPIKeyService(collection *mongo.Collection) *APIKeyService {
+	return &APIKeyService{
+		repo:    repository.NewAPIKeyRepository(collection),
+		now:     time.Now,
+		touched: make(map[string]time.Time),
+	}
+}
+
+// Checks if the service is initialized
+func (s *APIKeyService) IsInitialized() bool {
+	return s != nil && s.repo != nil
+}
+
+// Creates a new API key and returns it together with its plaintext value.
+// The plaintext value is not stored and cannot be retrieved later.
+func (s *APIKeyService) CreateKey(ctx context.Context, req CreateAPIKeyRequest) (*models.APIKey, string, error) {
+	if err := s.validateRequest(req); err != nil {
+		return nil, "", err
+	}
+
+	rawKey, err := GenerateAPIKey()
+	if err != nil {
+		return nil, "", err
+	}
+
+	key := &models.APIKey{
+		ID:         primitive.NewObjectID().Hex(),
+		Name:       req.Name,
+		Prefix:     rawKey[:apiKeyPrefixSize],
+		Hash:       HashAPIKey(rawKey),
+		Scopes:     req.Scopes,
+		Metadata:   req.Metadata,
+		DailyQuota: req.DailyQuota,
+		CreatedAt:  s.now().UTC(),
+		ExpiresAt:  req.ExpiresAt,
+	}
+
+	if err := s.repo.Create(ctx, key); err != nil {
+		return nil, "", err
+	}
+
+	return key, rawKey, nil
+}
+
+// Makes sure a key with the given plaintext value exists, used to bootstrap the first admin key
+func (s *APIKeyService) EnsureKey(ctx context.Context, name, rawKey string, scopes []string) error {
+	if !strings.HasPrefix(rawKey, apiKeyPrefix) || len(rawKey) < apiKeyPrefixSize {
+		return fmt.Errorf("api key must start with %q and be at least %d characters long", apiKeyPrefix, apiKeyPrefixSize)
+	}
+
+	_, err := s.repo.FindByHash(ctx, HashAPIKey(rawKey))
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return err
+	}
+
+	return s.repo.Create(ctx, &models.APIKey{
+		ID:        primitive.NewObjectID().Hex(),
+		Name:      name,
+		Prefix:    rawKey[:apiKeyPrefixSize],
+		Hash:      HashAPIKey(rawKey),
+		Scopes:    scopes,
+		CreatedAt: s.now().UTC(),
+	})
+}
+
+// Lists all API keys, including revoked and expired ones
+func (s *APIKeyService) ListKeys(ctx context.Context) ([]models.APIKey, error) {
+	return s.repo.List(ctx)
+}
+
+// Replaces the secret of an API key, the old value stops working immediately
+func (s *APIKeyService) RotateKey(ctx context.Context, id string) (*models.APIKey, string, error) {
+	rawKey, err := GenerateAPIKey()
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := s.repo.Rotate(ctx, id, HashAPIKey(rawKey), rawKey[:apiKeyPrefixSize], s.now().UTC()); err != nil {
+		return nil, "", err
+	}
+
+	key, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return key, rawKey, nil
+}
+
+// Revokes an API key
+func (s *APIKeyService) RevokeKey(ctx context.Context, id string) error {
+	return s.repo.Revoke(ctx, id, s.now().UTC())
+}
+
+// Resolves a plaintext API key into an active key
+func (s *APIKeyService) Authenticate(ctx context.Context, rawKey string) (*models.APIKey, error) {
+	if !strings.HasPrefix(rawKey, apiKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	key, err := s.repo.FindByHash(ctx, HashAPIKey(rawKey))
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+
+	now := s.now()
+	if key.IsRevoked() {
+		return nil, ErrRevokedAPIKey
+	}
+	if key.IsExpired(now) {
+		return nil, ErrExpiredAPIKey
+	}
+
+	s.touch(ctx, key.ID, now)
+
+	return key, nil
+}
+
+// Records the use of a key in the background, at most once per touchInterval for each key.
+// Usage tracking is best effort, so a failed write is logged and never fails the request.
+func (s *APIKeyService) touch(ctx context.Context, id string, now time.Time) {
+	s.touchMu.Lock()
+	if last, ok := s.touched[id]; ok && now.Sub(last) < touchInterval {
+		s.touchMu.Unlock()
+		return
+	}
+	s.touched[id] = now
+	s.touchMu.Unlock()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), touchTimeout)
+		defer cancel()
+
+		if err := s.repo.Touch(ctx, id, now.UTC()); err != nil {
+			slog.WarnContext(ctx, "failed to record api key usage", "id", id, "error", err)
+		}
+	}()
+}
+
+// Validates API key creation data
+func (s *APIKeyService) validateRequest(req CreateAPIKeyRequest) error {
+	if strings.TrimSpace(req.Name) == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidAPIKeyRequest)
+	}
+	if len(req.Scopes) == 0 {
+		return fmt.Errorf("%w: at least one scope is required", ErrInvalidAPIKeyRequest)
+	}
+	for _, scope := range req.Scopes {
+		if !models.IsValidScope(scope) {
+			return fmt.Errorf("%w: unknown scope %s", ErrInvalidAPIKeyRequest, scope)
+		}
+	}
+	if req.DailyQuota < 0 {
+		return fmt.Errorf("%w: dailyQuota cannot be negative", ErrInvalidAPIKeyRequest)
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(s.now()) {
+		return fmt.Errorf("%w: expiresAt must be in the future", ErrInvalidAPIKeyRequest)
+	}
+	return nil
+}
+
+// Generates a new random plaintext API key
+func GenerateAPIKey() (string, error) {
+	buf := make([]byte, apiKeyBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+	return apiKeyPrefix + base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// Returns the hex encoded SHA-256 hash of a plaintext API key
+func HashAPIKey(rawKey string) string {
+	sum := sha256.Sum256([]byte(rawKey))
+	return hex.EncodeToString(sum[:])
+}
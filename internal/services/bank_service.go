@@ -2,25 +2,81 @@ package services
 
 import (
 	"context"
-	"fmt"
 	"strings"
 
+	"github.com/MarcinZ20/bankAPI/internal/cache"
+	"github.com/MarcinZ20/bankAPI/internal/changes"
+	"github.com/MarcinZ20/bankAPI/internal/logging"
+	"github.com/MarcinZ20/bankAPI/internal/metrics"
 	"github.com/MarcinZ20/bankAPI/internal/repository"
+	"github.com/MarcinZ20/bankAPI/internal/tracing"
 	"github.com/MarcinZ20/bankAPI/pkg/models"
 	"github.com/MarcinZ20/bankAPI/pkg/utils"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// Reports input the service rejects before it reaches the repository
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
 // Handles business logic for bank operations
 type BankService struct {
-	repo *repository.BankRepository
+	repo  repository.BankStore
+	cache *cache.CachedRepository
+	feed  *changes.Feed
 }
 
 // Creates a new bank service
 func NewBankService(collection *mongo.Collection) *BankService {
 	return &BankService{
-		repo: repository.NewBankRepository(collection),
+		repo: instrument(repository.NewBankRepository(collection)),
+	}
+}
+
+// Creates a new bank service backed by the given store
+func NewBankServiceWithStore(store repository.BankStore) *BankService {
+	return &BankService{
+		repo: instrument(store),
+	}
+}
+
+// Wraps a store with metrics, tracing and error logging
+func instrument(store repository.BankStore) repository.BankStore {
+	return metrics.NewInstrumentedRepository(tracing.NewTracedRepository(logging.NewLoggedRepository(store)))
+}
+
+// Puts a read-through cache in front of the repository
+func (s *BankService) UseCache(config cache.Config) {
+	if s.cache != nil {
+		return
 	}
+	s.cache = cache.NewCachedRepository(s.repo, config)
+	s.repo = s.cache
+}
+
+// Publishes a change event for every headquarter and branch a mutation adds or removes
+func (s *BankService) UseChangeFeed(feed *changes.Feed) {
+	s.feed = feed
+}
+
+// Drops all cached lookups, a no-op when caching is disabled
+func (s *BankService) InvalidateCache() {
+	if s.cache != nil {
+		s.cache.Purge()
+	}
+}
+
+// Returns the cache counters, ok is false when caching is disabled
+func (s *BankService) CacheStats() (stats cache.Stats, ok bool) {
+	if s.cache == nil {
+		return cache.Stats{}, false
+	}
+	return s.cache.Stats(), true
 }
 
 // Checks if the service is initialized
@@ -29,99 +85,265 @@ func (s *BankService) IsInitialized() bool {
 }
 
 // Retrieves a headquarter by SWIFT code
-func (s *BankService) GetHeadquarter(ctx context.Context, swiftCode string) (*models.Headquarter, error) {
+func (s *BankService) GetHeadquarter(ctx context.Context, swiftCode string) (_ *models.Headquarter, err error) {
+	ctx, span := tracing.Start(ctx, "BankService.GetHeadquarter", tracing.SwiftCodeKey.String(swiftCode))
+	defer func() { tracing.End(span, err) }()
+
 	if !utils.IsValidSwiftCodeFormat(swiftCode) {
-		return nil, fmt.Errorf("invalid SWIFT code format")
+		return nil, &ValidationError{Message: "invalid SWIFT code format"}
 	}
 	if !strings.HasSuffix(swiftCode, "XXX") {
-		return nil, fmt.Errorf("SWIFT code must end with XXX for headquarters")
+		return nil, &ValidationError{Message: "SWIFT code must end with XXX for headquarters"}
 	}
 	return s.repo.FindHeadquarter(ctx, swiftCode)
 }
 
+// Retrieves the headquarters with the given SWIFT codes in a single lookup, unknown codes are left out
+func (s *BankService) GetHeadquarters(ctx context.Context, swiftCodes []string) (_ []models.Headquarter, err error) {
+	ctx, span := tracing.Start(ctx, "BankService.GetHeadquarters")
+	defer func() { tracing.End(span, err) }()
+
+	for _, swiftCode := range swiftCodes {
+		if !utils.IsValidSwiftCodeFormat(swiftCode) || !strings.HasSuffix(swiftCode, "XXX") {
+			return nil, &ValidationError{Message: "invalid headquarter SWIFT code: " + swiftCode}
+		}
+	}
+	if len(swiftCodes) == 0 {
+		return []models.Headquarter{}, nil
+	}
+	return s.repo.FindHeadquarters(ctx, swiftCodes)
+}
+
 // Retrieves a branch by SWIFT code
-func (s *BankService) GetBranch(ctx context.Context, swiftCode string) (*models.Branch, error) {
+func (s *BankService) GetBranch(ctx context.Context, swiftCode string) (_ *models.Branch, err error) {
+	ctx, span := tracing.Start(ctx, "BankService.GetBranch", tracing.SwiftCodeKey.String(swiftCode))
+	defer func() { tracing.End(span, err) }()
+
 	if !utils.IsValidSwiftCodeFormat(swiftCode) {
-		return nil, fmt.Errorf("invalid SWIFT code format")
+		return nil, &ValidationError{Message: "invalid SWIFT code format"}
 	}
 	if strings.HasSuffix(swiftCode, "XXX") {
-		return nil, fmt.Errorf("branch SWIFT code cannot end with XXX")
+		return nil, &ValidationError{Message: "branch SWIFT code cannot end with XXX"}
 	}
 	parentHqSwiftCode := swiftCode[0:8] + "XXX"
 	return s.repo.FindBranch(ctx, swiftCode, parentHqSwiftCode)
 }
 
 // Retrieves all banks in a given country
-func (s *BankService) GetBanksByCountryCode(ctx context.Context, countryCode string) ([]models.Headquarter, error) {
+func (s *BankService) GetBanksByCountryCode(ctx context.Context, countryCode string) (_ []models.Headquarter, err error) {
+	ctx, span := tracing.Start(ctx, "BankService.GetBanksByCountryCode", tracing.CountryCodeKey.String(countryCode))
+	defer func() { tracing.End(span, err) }()
+
 	if !utils.IsValidCountryCode(countryCode) {
-		return nil, fmt.Errorf("invalid country code format")
+		return nil, &ValidationError{Message: "invalid country code format"}
 	}
 	return s.repo.FindBanksByCountry(ctx, countryCode)
 }
 
+// Retrieves all headquarters and branches whose SWIFT code starts with prefix
+func (s *BankService) SearchByPrefix(ctx context.Context, prefix string) (_ []models.BankEntity, err error) {
+	ctx, span := tracing.Start(ctx, "BankService.SearchByPrefix", tracing.PrefixKey.String(prefix))
+	defer func() { tracing.End(span, err) }()
+
+	if !utils.IsValidSwiftCodePrefix(prefix) {
+		return nil, &ValidationError{Message: "invalid SWIFT code prefix"}
+	}
+	return s.repo.FindByPrefix(ctx, prefix)
+}
+
+// Retrieves all headquarters with their branches
+func (s *BankService) GetAll(ctx context.Context) (_ []models.Headquarter, err error) {
+	ctx, span := tracing.Start(ctx, "BankService.GetAll")
+	defer func() { tracing.End(span, err) }()
+
+	return s.repo.FindAll(ctx)
+}
+
+// Calls fn for every headquarter with its branches without loading them all, optionally limited to one country
+func (s *BankService) StreamHeadquarters(ctx context.Context, countryCode string, fn func(hq *models.Headquarter) error) (err error) {
+	ctx, span := tracing.Start(ctx, "BankService.StreamHeadquarters")
+	defer func() { tracing.End(span, err) }()
+
+	return s.repo.StreamHeadquarters(ctx, countryCode, fn)
+}
+
 // Creates a new headquarter
-func (s *BankService) AddHeadquarter(ctx context.Context, hq *models.Headquarter) error {
+func (s *BankService) AddHeadquarter(ctx context.Context, hq *models.Headquarter) (err error) {
+	ctx, span := tracing.Start(ctx, "BankService.AddHeadquarter")
+	defer func() { tracing.End(span, err) }()
+
 	if err := s.validateHeadquarter(hq); err != nil {
 		return err
 	}
-	return s.repo.CreateHeadquarter(ctx, hq)
+	if err := s.repo.CreateHeadquarter(ctx, hq); err != nil {
+		return err
+	}
+
+	s.feed.Publish(changes.CreatedHeadquarter(models.ChangeSourceAPI, hq)...)
+	return nil
 }
 
 // Adds a new branch to a headquarter
-func (s *BankService) AddBranch(ctx context.Context, parentSwiftCode string, branch *models.Branch) error {
+func (s *BankService) AddBranch(ctx context.Context, parentSwiftCode string, branch *models.Branch) (err error) {
+	ctx, span := tracing.Start(ctx, "BankService.AddBranch", tracing.SwiftCodeKey.String(parentSwiftCode))
+	defer func() { tracing.End(span, err) }()
+
 	if err := s.validateBranch(branch); err != nil {
 		return err
 	}
 	if !strings.HasSuffix(parentSwiftCode, "XXX") {
-		return fmt.Errorf("parent SWIFT code must end with XXX")
+		return &ValidationError{Message: "parent SWIFT code must end with XXX"}
+	}
+	if err := s.repo.AddBranch(ctx, parentSwiftCode, branch); err != nil {
+		return err
+	}
+
+	s.feed.Publish(changes.Created(models.ChangeSourceAPI, branch))
+	return nil
+}
+
+// Changes the bank name, address and country name of a headquarter, its branches are left as they are.
+// The update only applies when check holds for the stored headquarter, the country code cannot change.
+func (s *BankService) UpdateHeadquarter(ctx context.Context, hq *models.Headquarter, check repository.Precondition) (_ *models.Headquarter, err error) {
+	ctx, span := tracing.Start(ctx, "BankService.UpdateHeadquarter", tracing.SwiftCodeKey.String(hq.SwiftCode))
+	defer func() { tracing.End(span, err) }()
+
+	if err := s.validateHeadquarter(hq); err != nil {
+		return nil, err
+	}
+
+	var before models.Headquarter
+	updated, err := s.repo.UpdateHeadquarter(ctx, hq, func(current models.BankEntity) error {
+		before = *current.(*models.Headquarter)
+		return runCheck(check, current)
+	})
+	if err != nil {
+		return nil, err
 	}
-	return s.repo.AddBranch(ctx, parentSwiftCode, branch)
+
+	s.publishUpdate(&before, updated)
+	return updated, nil
 }
 
-// Deletes a headquarter and all its branches
-func (s *BankService) DeleteHeadquarter(ctx context.Context, swiftCode string) error {
+// Changes the bank name, address and country name of a branch, the update only applies when check holds
+// for the stored branch
+func (s *BankService) UpdateBranch(ctx context.Context, parentSwiftCode string, branch *models.Branch, check repository.Precondition) (_ *models.Branch, err error) {
+	ctx, span := tracing.Start(ctx, "BankService.UpdateBranch", tracing.SwiftCodeKey.String(branch.SwiftCode))
+	defer func() { tracing.End(span, err) }()
+
+	if err := s.validateBranch(branch); err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(parentSwiftCode, "XXX") {
+		return nil, &ValidationError{Message: "parent SWIFT code must end with XXX"}
+	}
+
+	var before models.Branch
+	updated, err := s.repo.UpdateBranch(ctx, parentSwiftCode, branch, func(current models.BankEntity) error {
+		before = *current.(*models.Branch)
+		return runCheck(check, current)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publishUpdate(&before, updated)
+	return updated, nil
+}
+
+// Deletes a headquarter and all its branches, when check holds for the stored headquarter
+func (s *BankService) DeleteHeadquarter(ctx context.Context, swiftCode string, check repository.Precondition) (err error) {
+	ctx, span := tracing.Start(ctx, "BankService.DeleteHeadquarter", tracing.SwiftCodeKey.String(swiftCode))
+	defer func() { tracing.End(span, err) }()
+
 	if !utils.IsValidSwiftCodeFormat(swiftCode) {
-		return fmt.Errorf("invalid SWIFT code format")
+		return &ValidationError{Message: "invalid SWIFT code format"}
 	}
 	if !strings.HasSuffix(swiftCode, "XXX") {
-		return fmt.Errorf("SWIFT code must end with XXX for headquarters")
+		return &ValidationError{Message: "SWIFT code must end with XXX for headquarters"}
+	}
+	if s.feed == nil {
+		return s.repo.DeleteHeadquarter(ctx, swiftCode, check)
+	}
+
+	// The deleted state goes into the events, it is copied when the repository checks it
+	var hq models.Headquarter
+	err = s.repo.DeleteHeadquarter(ctx, swiftCode, func(current models.BankEntity) error {
+		hq = *current.(*models.Headquarter)
+		return runCheck(check, current)
+	})
+	if err != nil {
+		return err
 	}
-	return s.repo.DeleteHeadquarter(ctx, swiftCode)
+
+	s.feed.Publish(changes.DeletedHeadquarter(models.ChangeSourceAPI, &hq)...)
+	return nil
 }
 
-// Removes a branch from its headquarter
-func (s *BankService) DeleteBranch(ctx context.Context, swiftCode, parentSwiftCode string) error {
+// Removes a branch from its headquarter, when check holds for the stored branch
+func (s *BankService) DeleteBranch(ctx context.Context, swiftCode, parentSwiftCode string, check repository.Precondition) (err error) {
+	ctx, span := tracing.Start(ctx, "BankService.DeleteBranch", tracing.SwiftCodeKey.String(swiftCode))
+	defer func() { tracing.End(span, err) }()
+
 	if !utils.IsValidSwiftCodeFormat(swiftCode) || !utils.IsValidSwiftCodeFormat(parentSwiftCode) {
-		return fmt.Errorf("invalid SWIFT code format")
+		return &ValidationError{Message: "invalid SWIFT code format"}
 	}
 	if strings.HasSuffix(swiftCode, "XXX") {
-		return fmt.Errorf("branch SWIFT code cannot end with XXX")
+		return &ValidationError{Message: "branch SWIFT code cannot end with XXX"}
 	}
 	if !strings.HasSuffix(parentSwiftCode, "XXX") {
-		return fmt.Errorf("parent SWIFT code must end with XXX")
+		return &ValidationError{Message: "parent SWIFT code must end with XXX"}
+	}
+	if s.feed == nil {
+		return s.repo.DeleteBranch(ctx, swiftCode, parentSwiftCode, check)
+	}
+
+	var branch models.Branch
+	err = s.repo.DeleteBranch(ctx, swiftCode, parentSwiftCode, func(current models.BankEntity) error {
+		branch = *current.(*models.Branch)
+		return runCheck(check, current)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.feed.Publish(changes.Deleted(models.ChangeSourceAPI, &branch))
+	return nil
+}
+
+// Publishes the change of an update that modified any field
+func (s *BankService) publishUpdate(before, after models.BankEntity) {
+	if *models.NewBankSnapshot(before) != *models.NewBankSnapshot(after) {
+		s.feed.Publish(changes.Updated(models.ChangeSourceAPI, before, after))
+	}
+}
+
+func runCheck(check repository.Precondition, current models.BankEntity) error {
+	if check == nil {
+		return nil
 	}
-	return s.repo.DeleteBranch(ctx, swiftCode, parentSwiftCode)
+	return check(current)
 }
 
 // Validates headquarter data
 func (s *BankService) validateHeadquarter(hq *models.Headquarter) error {
 	if hq == nil {
-		return fmt.Errorf("headquarter cannot be nil")
+		return &ValidationError{Message: "headquarter cannot be nil"}
 	}
 	if !utils.IsValidSwiftCodeFormat(hq.SwiftCode) {
-		return fmt.Errorf("invalid SWIFT code format")
+		return &ValidationError{Message: "invalid SWIFT code format"}
 	}
 	if !strings.HasSuffix(hq.SwiftCode, "XXX") {
-		return fmt.Errorf("headquarter SWIFT code must end with XXX")
+		return &ValidationError{Message: "headquarter SWIFT code must end with XXX"}
 	}
 	if hq.BankName == "" {
-		return fmt.Errorf("bank name is required")
+		return &ValidationError{Message: "bank name is required"}
 	}
 	if !utils.IsValidCountryCode(hq.CountryISO2) {
-		return fmt.Errorf("invalid country code format")
+		return &ValidationError{Message: "invalid country code format"}
 	}
 	if !hq.IsHeadquarter {
-		return fmt.Errorf("isHeadquarter must be true")
+		return &ValidationError{Message: "isHeadquarter must be true"}
 	}
 	return nil
 }
@@ -129,22 +351,22 @@ func (s *BankService) validateHeadquarter(hq *models.Headquarter) error {
 // Validates branch data
 func (s *BankService) validateBranch(branch *models.Branch) error {
 	if branch == nil {
-		return fmt.Errorf("branch cannot be nil")
+		return &ValidationError{Message: "branch cannot be nil"}
 	}
 	if !utils.IsValidSwiftCodeFormat(branch.SwiftCode) {
-		return fmt.Errorf("invalid SWIFT code format")
+		return &ValidationError{Message: "invalid SWIFT code format"}
 	}
 	if strings.HasSuffix(branch.SwiftCode, "XXX") {
-		return fmt.Errorf("branch SWIFT code cannot end with XXX")
+		return &ValidationError{Message: "branch SWIFT code cannot end with XXX"}
 	}
 	if branch.BankName == "" {
-		return fmt.Errorf("bank name is required")
+		return &ValidationError{Message: "bank name is required"}
 	}
 	if !utils.IsValidCountryCode(branch.CountryISO2) {
-		return fmt.Errorf("invalid country code format")
+		return &ValidationError{Message: "invalid country code format"}
 	}
 	if branch.IsHeadquarter {
-		return fmt.Errorf("isHeadquarter must be false")
+		return &ValidationError{Message: "isHeadquarter must be false"}
 	}
 	return nil
 }
@@ -2,11 +2,14 @@ package services
 
 import (
 	"github.com/MarcinZ20/bankAPI/internal/database"
+	"github.com/MarcinZ20/bankAPI/internal/repository"
 )
 
 // Handles all services in the application
 type ServiceManager struct {
-	BankService *BankService
+	BankService    *BankService
+	APIKeyService  *APIKeyService
+	WebhookService *WebhookService
 }
 
 var instance *ServiceManager
@@ -21,6 +24,34 @@ func NewServiceManager(db *database.Config) *ServiceManager {
 		BankService: NewBankService(db.Collection),
 	}
 
+	if db.Outbox != nil {
+		store := repository.NewBankRepository(db.Collection)
+		store.UseOutbox(repository.NewOutbox(db.Outbox))
+		instance.BankService = NewBankServiceWithStore(store)
+	}
+
+	if db.APIKeys != nil {
+		instance.APIKeyService = NewAPIKeyService(db.APIKeys)
+	}
+
+	if db.Webhooks != nil && db.DeadLetters != nil {
+		instance.WebhookService = NewWebhookService(db.Webhooks, db.DeadLetters)
+	}
+
+	return instance
+}
+
+// Creates a new service manager serving bank data from the given store.
+// Services that need MongoDB, such as API key and webhook management, are left unset.
+func NewServiceManagerWithStore(store repository.BankStore) *ServiceManager {
+	if instance != nil {
+		return instance
+	}
+
+	instance = &ServiceManager{
+		BankService: NewBankServiceWithStore(store),
+	}
+
 	return instance
 }
 
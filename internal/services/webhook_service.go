@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/MarcinZ20/bankAPI/internal/repository"
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	webhookSecretPrefix = "whsec_"
+	webhookSecretBytes  = 32
+)
+
+var ErrInvalidWebhookRequest = errors.New("invalid webhook request")
+
+// Holds the fields a client may set when registering a webhook
+type CreateWebhookRequest struct {
+	URL        string   `json:"url"`
+	Operations []string `json:"operations"`
+}
+
+// Handles business logic for webhook registrations and their failed deliveries
+type WebhookService struct {
+	repo *repository.WebhookRepository
+	now  func() time.Time
+}
+
+// Creates a new webhook service
+func NewWebhookService(webhooks, deadLetters *mongo.Collection) *WebhookService {
+	return &WebhookService{
+		repo: repository.NewWebhookRepository(webhooks, deadLetters),
+		now:  time.Now,
+	}
+}
+
+// Checks if the service is initialized
+func (s *WebhookService) IsInitialized() bool {
+	return s != nil && s.repo != nil
+}
+
+// Registers a webhook with a new signing secret.
+// The secret is returned with the webhook only here, it is not part of any response later.
+func (s *WebhookService) CreateWebhook(ctx context.Context, req CreateWebhookRequest) (*models.Webhook, error) {
+	if err := validateWebhookRequest(req); err != nil {
+		return nil, err
+	}
+
+	secret, err := GenerateWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	webhook := &models.Webhook{
+		ID:         primitive.NewObjectID().Hex(),
+		URL:        req.URL,
+		Secret:     secret,
+		Operations: req.Operations,
+		CreatedAt:  s.now().UTC(),
+	}
+
+	if err := s.repo.Create(ctx, webhook); err != nil {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+// Lists all webhooks
+func (s *WebhookService) ListWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	return s.repo.List(ctx)
+}
+
+// Deletes a webhook, events are no longer delivered to it
+func (s *WebhookService) DeleteWebhook(ctx context.Context, id string) error {
+	return s.repo.Delete(ctx, id)
+}
+
+// Records an event a webhook did not accept after all delivery attempts
+func (s *WebhookService) RecordDeadLetter(ctx context.Context, letter *models.DeadLetter) error {
+	if letter.ID == "" {
+		letter.ID = primitive.NewObjectID().Hex()
+	}
+	return s.repo.AddDeadLetter(ctx, letter)
+}
+
+// Lists the most recent failed deliveries, newest first
+func (s *WebhookService) DeadLetters(ctx context.Context, limit int64) ([]models.DeadLetter, error) {
+	return s.repo.ListDeadLetters(ctx, limit)
+}
+
+// Validates webhook registration data
+func validateWebhookRequest(req CreateWebhookRequest) error {
+	target, err := url.Parse(req.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https URL", ErrInvalidWebhookRequest)
+	}
+	for _, operation := range req.Operations {
+		if !models.IsValidChangeOperation(operation) {
+			return fmt.Errorf("%w: unknown operation %s", ErrInvalidWebhookRequest, operation)
+		}
+	}
+	return nil
+}
+
+// Generates a new random webhook signing secret
+func GenerateWebhookSecret() (string, error) {
+	buf := make([]byte, webhookSecretBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return webhookSecretPrefix + base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,326 @@
+package spreadsheet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Google Sheets export endpoint, the spreadsheet ID and export path are appended
+const DefaultBaseURL = "https://docs.google.com/spreadsheets/d"
+
+// Google Sheets API endpoint, used for private spreadsheets
+const DefaultSheetsEndpoint = "https://sheets.googleapis.com"
+
+// Media types accepted as a CSV export
+var csvMediaTypes = map[string]bool{
+	"text/csv":        true,
+	"text/plain":      true,
+	"application/csv": true,
+}
+
+// Spreadsheet IDs are URL and file name safe
+var spreadsheetIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Holds spreadsheet download settings
+type Config struct {
+	BaseURL string
+	// Client used for downloads, a new one is created when nil
+	HTTPClient *http.Client
+	// Limits each download attempt
+	Timeout time.Duration
+	// Attempts after the first one on network errors, 5xx and 429 responses
+	MaxRetries int
+	// Backoff before the first retry, doubled on every further one and randomized
+	RetryDelay    time.Duration
+	MaxRetryDelay time.Duration
+	// Largest accepted export in bytes
+	MaxBodySize int64
+	// Directory keeping the last good download of each spreadsheet, empty disables the fallback
+	CacheDir string
+	// Sheets API base URL, replaced by a local server in tests
+	SheetsEndpoint string
+	// Service account key file authorizing Sheets API requests
+	CredentialsFile string
+}
+
+// Returns the settings used when nothing is configured
+func DefaultConfig() Config {
+	return Config{
+		BaseURL:        DefaultBaseURL,
+		SheetsEndpoint: DefaultSheetsEndpoint,
+		Timeout:        10 * time.Second,
+		MaxRetries:     3,
+		RetryDelay:     500 * time.Millisecond,
+		MaxRetryDelay:  10 * time.Second,
+		MaxBodySize:    32 << 20,
+	}
+}
+
+// Downloads spreadsheet CSV exports and reads private spreadsheets through the Sheets API
+type Client struct {
+	config Config
+
+	// Loaded on the first Sheets API request
+	tokensOnce sync.Once
+	tokens     *tokenSource
+	tokensErr  error
+}
+
+// Creates a client, an unset base URL, timeout, delay or size falls back to DefaultConfig
+func NewClient(config Config) *Client {
+	defaults := DefaultConfig()
+	if config.BaseURL == "" {
+		config.BaseURL = defaults.BaseURL
+	}
+	if config.SheetsEndpoint == "" {
+		config.SheetsEndpoint = defaults.SheetsEndpoint
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{}
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaults.Timeout
+	}
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = defaults.RetryDelay
+	}
+	if config.MaxRetryDelay <= 0 {
+		config.MaxRetryDelay = defaults.MaxRetryDelay
+	}
+	if config.MaxBodySize <= 0 {
+		config.MaxBodySize = defaults.MaxBodySize
+	}
+	return &Client{config: config}
+}
+
+// Failed download attempt
+type attemptError struct {
+	err error
+	// Set for network errors and responses worth retrying
+	retryable bool
+	// Delay requested by the server through Retry-After
+	retryAfter time.Duration
+}
+
+func (e *attemptError) Error() string { return e.err.Error() }
+func (e *attemptError) Unwrap() error { return e.err }
+
+// Downloads the spreadsheet unless it still matches etag, retrying transient failures.
+// The last good download is returned when the spreadsheet stays unreachable.
+func (c *Client) Fetch(ctx context.Context, spreadsheetID, etag string) (*Response, error) {
+	if !spreadsheetIDPattern.MatchString(spreadsheetID) {
+		return nil, fmt.Errorf("invalid spreadsheet ID: %q", spreadsheetID)
+	}
+
+	return c.retry(ctx, spreadsheetID, spreadsheetID, etag, func(ctx context.Context) (*Response, error) {
+		return c.fetchOnce(ctx, spreadsheetID, etag)
+	})
+}
+
+// Runs attempt until it succeeds or fails for good, falling back to the download cached under cacheKey.
+// A fresh download is cached only when the caller keeps it.
+func (c *Client) retry(ctx context.Context, spreadsheetID, cacheKey, etag string, attempt func(ctx context.Context) (*Response, error)) (*Response, error) {
+	var err error
+	for retries := 0; ; retries++ {
+		var response *Response
+		response, err = attempt(ctx)
+		if err == nil {
+			if !response.NotModified {
+				response.keep = func(ctx context.Context) { c.store(ctx, cacheKey, response) }
+			}
+			return response, nil
+		}
+
+		var failed *attemptError
+		if !errors.As(err, &failed) || !failed.retryable || retries >= c.config.MaxRetries {
+			break
+		}
+
+		delay := c.backoff(retries, failed.retryAfter)
+		slog.WarnContext(ctx, "spreadsheet download failed, retrying",
+			"spreadsheetId", spreadsheetID, "attempt", retries+1, "delay", delay, "error", err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("%w (retry aborted: %w)", err, ctx.Err())
+		case <-timer.C:
+		}
+	}
+
+	// Only an unreachable source falls back, a missing or private spreadsheet is a configuration error
+	var failed *attemptError
+	if errors.As(err, &failed) && failed.retryable && ctx.Err() == nil {
+		if cached, ok := c.load(ctx, cacheKey, etag); ok {
+			slog.WarnContext(ctx, "spreadsheet unreachable, using the last good download",
+				"spreadsheetId", spreadsheetID, "error", err)
+			return cached, nil
+		}
+	}
+	return nil, err
+}
+
+func (c *Client) fetchOnce(ctx context.Context, spreadsheetID, etag string) (*Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.config.Timeout)
+	defer cancel()
+
+	url := fmt.Sprintf("%s/%s/export?format=csv", c.config.BaseURL, spreadsheetID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	response, err := c.config.HTTPClient.Do(req)
+	if err != nil {
+		return nil, &attemptError{err: fmt.Errorf("error while fetching data from google spreadsheet: %w", err), retryable: true}
+	}
+	defer response.Body.Close()
+
+	switch status := response.StatusCode; {
+	case status == http.StatusOK:
+	case status == http.StatusNotModified:
+		return &Response{ETag: etag, NotModified: true}, nil
+	case status == http.StatusTooManyRequests || status >= http.StatusInternalServerError:
+		return nil, &attemptError{
+			err:        fmt.Errorf("unexpected status while fetching data from google spreadsheet: %s", response.Status),
+			retryable:  true,
+			retryAfter: parseRetryAfter(response.Header.Get("Retry-After")),
+		}
+	case status == http.StatusNotFound:
+		return nil, fmt.Errorf("google spreadsheet %s not found", spreadsheetID)
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return nil, fmt.Errorf("access to google spreadsheet %s denied, it must be shared publicly: %s", spreadsheetID, response.Status)
+	default:
+		return nil, fmt.Errorf("unexpected status while fetching data from google spreadsheet: %s", response.Status)
+	}
+
+	// A spreadsheet that is not shared redirects to an HTML sign in page with status 200
+	mediaType, _, _ := mime.ParseMediaType(response.Header.Get("Content-Type"))
+	if !csvMediaTypes[mediaType] {
+		return nil, fmt.Errorf("google spreadsheet %s returned %q instead of CSV, check that it is shared publicly",
+			spreadsheetID, response.Header.Get("Content-Type"))
+	}
+
+	if response.ContentLength > c.config.MaxBodySize {
+		return nil, fmt.Errorf("google spreadsheet export is %d bytes, the limit is %d", response.ContentLength, c.config.MaxBodySize)
+	}
+	body, err := io.ReadAll(io.LimitReader(response.Body, c.config.MaxBodySize+1))
+	if err != nil {
+		return nil, &attemptError{err: fmt.Errorf("error reading response body: %w", err), retryable: true}
+	}
+	if int64(len(body)) > c.config.MaxBodySize {
+		return nil, fmt.Errorf("google spreadsheet export exceeds the limit of %d bytes", c.config.MaxBodySize)
+	}
+
+	return &Response{Body: string(body), ETag: response.Header.Get("ETag")}, nil
+}
+
+// Returns the delay before retry number attempt+1, exponential with jitter and capped
+func (c *Client) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return min(retryAfter, c.config.MaxRetryDelay)
+	}
+
+	delay := c.config.RetryDelay << attempt
+	if delay <= 0 || delay > c.config.MaxRetryDelay {
+		delay = c.config.MaxRetryDelay
+	}
+
+	// Half fixed, half random so replicas retrying together spread out
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+// Reads a Retry-After header in seconds or as an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date)
+	}
+	return 0
+}
+
+func (c *Client) cachePaths(cacheKey string) (body, etag string) {
+	base := filepath.Join(c.config.CacheDir, cacheKey)
+	return base + ".csv", base + ".etag"
+}
+
+// Keeps a good download on disk, failures are logged and otherwise ignored
+func (c *Client) store(ctx context.Context, cacheKey string, response *Response) {
+	if c.config.CacheDir == "" {
+		return
+	}
+
+	bodyPath, etagPath := c.cachePaths(cacheKey)
+	err := os.MkdirAll(c.config.CacheDir, 0o755)
+	if err == nil {
+		err = writeFileAtomic(bodyPath, []byte(response.Body))
+	}
+	if err == nil {
+		err = writeFileAtomic(etagPath, []byte(response.ETag))
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "failed to cache spreadsheet download", "cacheKey", cacheKey, "error", err)
+	}
+}
+
+// Returns the cached download, as not modified when it still matches etag
+func (c *Client) load(ctx context.Context, cacheKey, etag string) (*Response, bool) {
+	if c.config.CacheDir == "" {
+		return nil, false
+	}
+
+	bodyPath, etagPath := c.cachePaths(cacheKey)
+	body, err := os.ReadFile(bodyPath)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			slog.WarnContext(ctx, "failed to read cached spreadsheet download", "cacheKey", cacheKey, "error", err)
+		}
+		return nil, false
+	}
+	cachedETag, _ := os.ReadFile(etagPath)
+
+	if etag != "" && etag == string(cachedETag) {
+		return &Response{ETag: etag, NotModified: true, Cached: true}, true
+	}
+	return &Response{Body: string(body), ETag: string(cachedETag), Cached: true}, true
+}
+
+// Writes through a temporary file so readers never see a partial download
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,167 @@
+package spreadsheet
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const csvExport = "CountryISO2,SwiftCode,Type,Name,Address,City,Country\nDE,DEUTDEFFXXX,HQ,Deutsche Bank,Frankfurt,Frankfurt,Germany"
+
+// Serves responses in order, repeating the last one
+func newServer(t *testing.T, handlers ...http.HandlerFunc) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1)) - 1
+		handlers[min(n, len(handlers)-1)](w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func respond(status int, contentType, body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if contentType != "" {
+			w.Header().Set("Content-Type", contentType)
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}
+}
+
+func testClient(server *httptest.Server, config Config) *Client {
+	config.BaseURL = server.URL
+	config.RetryDelay = time.Millisecond
+	config.MaxRetryDelay = 5 * time.Millisecond
+	return NewClient(config)
+}
+
+func TestFetchRetriesTransientFailures(t *testing.T) {
+	server, calls := newServer(t,
+		respond(http.StatusServiceUnavailable, "text/html", "unavailable"),
+		respond(http.StatusTooManyRequests, "text/html", "slow down"),
+		respond(http.StatusOK, "text/csv; charset=utf-8", csvExport),
+	)
+	client := testClient(server, Config{MaxRetries: 3})
+
+	response, err := client.Fetch(context.Background(), "sheet1", "")
+	require.NoError(t, err)
+	assert.Equal(t, csvExport, response.Body)
+	assert.Equal(t, `"v1"`, response.ETag)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestFetchGivesUpAfterMaxRetries(t *testing.T) {
+	server, calls := newServer(t, respond(http.StatusBadGateway, "text/html", "bad gateway"))
+	client := testClient(server, Config{MaxRetries: 2})
+
+	_, err := client.Fetch(context.Background(), "sheet1", "")
+	assert.ErrorContains(t, err, "502")
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestFetchRejectsWithoutRetry(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		message string
+	}{
+		{"Not found", respond(http.StatusNotFound, "text/html", "missing"), "not found"},
+		{"Private", respond(http.StatusForbidden, "text/html", "denied"), "denied"},
+		{"Sign in page", respond(http.StatusOK, "text/html; charset=utf-8", "<html>Sign in</html>"), "instead of CSV"},
+		{"Too large", respond(http.StatusOK, "text/csv", strings.Repeat("x", 100)), "limit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, calls := newServer(t, tt.handler)
+			client := testClient(server, Config{MaxRetries: 3, MaxBodySize: 50})
+
+			_, err := client.Fetch(context.Background(), "sheet1", "")
+			assert.ErrorContains(t, err, tt.message)
+			assert.Equal(t, int32(1), calls.Load())
+		})
+	}
+}
+
+func TestFetchNotModified(t *testing.T) {
+	server, _ := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, `"v1"`, r.Header.Get("If-None-Match"))
+		w.WriteHeader(http.StatusNotModified)
+	})
+	client := testClient(server, Config{})
+
+	response, err := client.Fetch(context.Background(), "sheet1", `"v1"`)
+	require.NoError(t, err)
+	assert.True(t, response.NotModified)
+}
+
+func TestFetchFallsBackToLastGoodDownload(t *testing.T) {
+	server, _ := newServer(t,
+		respond(http.StatusOK, "text/csv", csvExport),
+		respond(http.StatusInternalServerError, "text/html", "down"),
+	)
+	client := testClient(server, Config{MaxRetries: 1, CacheDir: t.TempDir()})
+
+	response, err := client.Fetch(context.Background(), "sheet1", "")
+	require.NoError(t, err)
+	response.Keep(context.Background())
+
+	response, err = client.Fetch(context.Background(), "sheet1", "")
+	require.NoError(t, err)
+	assert.True(t, response.Cached)
+	assert.Equal(t, csvExport, response.Body)
+
+	// The cached copy still matches the ETag the caller already has
+	response, err = client.Fetch(context.Background(), "sheet1", `"v1"`)
+	require.NoError(t, err)
+	assert.True(t, response.NotModified)
+
+	// Nothing is cached for another spreadsheet
+	_, err = client.Fetch(context.Background(), "sheet2", "")
+	assert.Error(t, err)
+}
+
+func TestFetchCachesOnlyKeptDownloads(t *testing.T) {
+	server, _ := newServer(t,
+		respond(http.StatusOK, "text/csv", "not a valid export"),
+		respond(http.StatusInternalServerError, "text/html", "down"),
+	)
+	client := testClient(server, Config{MaxRetries: 1, CacheDir: t.TempDir()})
+
+	_, err := client.Fetch(context.Background(), "sheet1", "")
+	require.NoError(t, err)
+
+	// The first download was never kept, so there is nothing to fall back to
+	_, err = client.Fetch(context.Background(), "sheet1", "")
+	assert.Error(t, err)
+}
+
+func TestFetchInvalidSpreadsheetID(t *testing.T) {
+	client := NewClient(Config{})
+
+	_, err := client.Fetch(context.Background(), "../etc/passwd", "")
+	assert.ErrorContains(t, err, "invalid spreadsheet ID")
+}
+
+func TestBackoff(t *testing.T) {
+	client := NewClient(Config{RetryDelay: 100 * time.Millisecond, MaxRetryDelay: time.Second})
+
+	for attempt, want := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second} {
+		delay := client.backoff(attempt, 0)
+		assert.GreaterOrEqual(t, delay, want/2)
+		assert.LessOrEqual(t, delay, want)
+	}
+
+	assert.Equal(t, time.Second, client.backoff(0, time.Minute))
+	assert.Equal(t, 2*time.Second, parseRetryAfter("2"))
+}
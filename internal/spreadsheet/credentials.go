@@ -0,0 +1,154 @@
+package spreadsheet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// OAuth scope allowing read only access to spreadsheets
+const sheetsReadOnlyScope = "https://www.googleapis.com/auth/spreadsheets.readonly"
+
+// Access tokens are renewed this long before they expire
+const tokenExpiryMargin = time.Minute
+
+// Fields of a Google service account key file used for the JWT bearer grant
+type serviceAccount struct {
+	Type         string `json:"type"`
+	ClientEmail  string `json:"client_email"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	TokenURI     string `json:"token_uri"`
+}
+
+// Reads a service account key file
+func loadServiceAccount(path string) (*serviceAccount, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account credentials: %w", err)
+	}
+
+	var account serviceAccount
+	if err := json.Unmarshal(data, &account); err != nil {
+		return nil, fmt.Errorf("failed to parse service account credentials: %w", err)
+	}
+	if account.Type != "service_account" {
+		return nil, fmt.Errorf("credentials are of type %q, expected service_account", account.Type)
+	}
+	if account.ClientEmail == "" || account.PrivateKey == "" || account.TokenURI == "" {
+		return nil, fmt.Errorf("service account credentials must contain client_email, private_key and token_uri")
+	}
+
+	return &account, nil
+}
+
+// Exchanges signed service account assertions for access tokens and caches them until they expire
+type tokenSource struct {
+	account    *serviceAccount
+	httpClient *http.Client
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+	TokenType   string `json:"token_type"`
+}
+
+// Returns a valid access token, requesting a new one when the cached one is about to expire
+func (s *tokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && time.Now().Add(tokenExpiryMargin).Before(s.expiresAt) {
+		return s.token, nil
+	}
+
+	token, expiresAt, err := s.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	s.token, s.expiresAt = token, expiresAt
+	return token, nil
+}
+
+// Drops the cached token, e.g. after the API rejected it
+func (s *tokenSource) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = ""
+}
+
+func (s *tokenSource) fetch(ctx context.Context) (string, time.Time, error) {
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(s.account.PrivateKey))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("invalid service account private key: %w", err)
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":   s.account.ClientEmail,
+		"scope": sheetsReadOnlyScope,
+		"aud":   s.account.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}
+	assertion := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	if s.account.PrivateKeyID != "" {
+		assertion.Header["kid"] = s.account.PrivateKeyID
+	}
+	signed, err := assertion.SignedString(key)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to sign service account assertion: %w", err)
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {signed},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.account.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("error creating token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	response, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", time.Time{}, &attemptError{err: fmt.Errorf("error requesting access token: %w", err), retryable: true}
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, 1<<20))
+	if err != nil {
+		return "", time.Time{}, &attemptError{err: fmt.Errorf("error reading token response: %w", err), retryable: true}
+	}
+	if response.StatusCode != http.StatusOK {
+		err := fmt.Errorf("token request for %s failed: %s: %s", s.account.ClientEmail, response.Status, strings.TrimSpace(string(body)))
+		if response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= http.StatusInternalServerError {
+			return "", time.Time{}, &attemptError{err: err, retryable: true}
+		}
+		return "", time.Time{}, err
+	}
+
+	var token tokenResponse
+	if err := json.Unmarshal(body, &token); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to parse token response: %w", err)
+	}
+	if token.AccessToken == "" {
+		return "", time.Time{}, fmt.Errorf("token response contains no access token")
+	}
+
+	return token.AccessToken, now.Add(time.Duration(token.ExpiresIn) * time.Second), nil
+}
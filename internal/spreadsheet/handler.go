@@ -2,37 +2,55 @@ package spreadsheet
 
 import (
 	"context"
-	"fmt"
-	"io"
-	"net/http"
-	"time"
 
 	"github.com/MarcinZ20/bankAPI/pkg/models"
 )
 
-// Retrieves data from a Google Spreadsheet
-func FetchData(spreadsheet *models.GoogleSpreadsheet) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	url := fmt.Sprintf("https://docs.google.com/spreadsheets/d/%s/export?format=csv", spreadsheet.SpreadsheetId)
+// Downloaded spreadsheet export
+type Response struct {
+	Body string
+	// Version of the export reported by the server, empty when it sends none
+	ETag string
+	// Set when the export still matches the ETag passed to Fetch, Body is empty then
+	NotModified bool
+	// Set when the spreadsheet was unreachable and the last good download was returned instead
+	Cached bool
+
+	// Stores a fresh download as the fallback, nil when there is nothing to store
+	keep func(ctx context.Context)
+}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return "", fmt.Errorf("error creating request: %w", err)
+// Keeps the download as the last good one for later outages.
+// Callers invoke it once the body parsed and validated, so a broken export never replaces a good copy.
+func (r *Response) Keep(ctx context.Context) {
+	if r.keep != nil {
+		r.keep(ctx)
 	}
+}
 
-	client := &http.Client{}
-	response, err := client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("error while fetching data from google spreadsheet: %w", err)
-	}
-	defer response.Body.Close()
+// Client used by FetchData and Fetch
+var defaultClient = NewClient(DefaultConfig())
 
-	body, err := io.ReadAll(response.Body)
+// Replaces the client used by FetchData and Fetch, meant to be called once at startup
+func SetDefaultClient(client *Client) {
+	defaultClient = client
+}
+
+// Retrieves data from a Google Spreadsheet, the request is limited by ctx
+func FetchData(ctx context.Context, spreadsheet *models.GoogleSpreadsheet) (string, error) {
+	response, err := Fetch(ctx, spreadsheet, "")
 	if err != nil {
-		return "", fmt.Errorf("error reading response body: %w", err)
+		return "", err
 	}
+	return response.Body, nil
+}
+
+// Retrieves data from a Google Spreadsheet unless it still matches etag, an empty etag always downloads it
+func Fetch(ctx context.Context, spreadsheet *models.GoogleSpreadsheet, etag string) (*Response, error) {
+	return defaultClient.Fetch(ctx, spreadsheet.SpreadsheetId, etag)
+}
 
-	return string(body), nil
+// Retrieves a range of a private Google Spreadsheet through the Sheets API as CSV
+func FetchSheet(ctx context.Context, spreadsheet *models.GoogleSpreadsheet, sheetRange string) (*Response, error) {
+	return defaultClient.FetchSheet(ctx, spreadsheet.SpreadsheetId, sheetRange)
 }
@@ -1,9 +1,11 @@
 package spreadsheet
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/MarcinZ20/bankAPI/pkg/models"
 	"github.com/stretchr/testify/assert"
@@ -56,10 +58,23 @@ func TestFetchData(t *testing.T) {
 				// Verify that the URL contains spreadsheet ID
 				assert.Contains(t, r.URL.String(), tt.spreadsheet.SpreadsheetId)
 
+				w.Header().Set("Content-Type", "text/csv")
 				w.WriteHeader(tt.mockStatus)
 				w.Write([]byte(tt.mockResponse))
 			}))
 			defer server.Close()
+
+			previous := defaultClient
+			SetDefaultClient(NewClient(Config{BaseURL: server.URL, RetryDelay: time.Millisecond}))
+			defer SetDefaultClient(previous)
+
+			data, err := FetchData(context.Background(), tt.spreadsheet)
+			if tt.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.mockResponse, data)
 		})
 	}
 }
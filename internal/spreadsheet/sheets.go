@@ -0,0 +1,227 @@
+package spreadsheet
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+)
+
+// Cell range without a tab, e.g. A1:G or B2, which the API reads from the first tab
+var cellRangePattern = regexp.MustCompile(`^([A-Za-z]{1,3}[0-9]*:[A-Za-z]{1,3}[0-9]*|[A-Za-z]{1,3}[0-9]+)$`)
+
+// Values of a range as returned by spreadsheets.values.get
+type valueRange struct {
+	Range  string     `json:"range"`
+	Values [][]string `json:"values"`
+}
+
+// Reads a range of a private spreadsheet through the Sheets API and returns it as CSV in the export format.
+// The range is a tab name, a named range or A1 notation such as Banks!A1:G, empty reads the whole first tab.
+func (c *Client) FetchSheet(ctx context.Context, spreadsheetID, sheetRange string) (*Response, error) {
+	if !spreadsheetIDPattern.MatchString(spreadsheetID) {
+		return nil, fmt.Errorf("invalid spreadsheet ID: %q", spreadsheetID)
+	}
+
+	tokens, err := c.tokenSource()
+	if err != nil {
+		return nil, err
+	}
+
+	// Every range of a spreadsheet gets its own fallback copy
+	sum := sha256.Sum256([]byte(sheetRange))
+	cacheKey := spreadsheetID + "-" + hex.EncodeToString(sum[:8])
+
+	return c.retry(ctx, spreadsheetID, cacheKey, "", func(ctx context.Context) (*Response, error) {
+		return c.fetchSheetOnce(ctx, tokens, spreadsheetID, sheetRange)
+	})
+}
+
+// Loads the service account credentials once
+func (c *Client) tokenSource() (*tokenSource, error) {
+	c.tokensOnce.Do(func() {
+		if c.config.CredentialsFile == "" {
+			c.tokensErr = fmt.Errorf("the Sheets API requires service account credentials")
+			return
+		}
+		account, err := loadServiceAccount(c.config.CredentialsFile)
+		if err != nil {
+			c.tokensErr = err
+			return
+		}
+		c.tokens = &tokenSource{account: account, httpClient: c.config.HTTPClient}
+	})
+	return c.tokens, c.tokensErr
+}
+
+func (c *Client) fetchSheetOnce(ctx context.Context, tokens *tokenSource, spreadsheetID, sheetRange string) (*Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.config.Timeout)
+	defer cancel()
+
+	var spreadsheet models.GoogleSpreadsheet
+	fields := url.Values{"fields": {"spreadsheetId,properties.title,sheets.properties,namedRanges.name"}}
+	if err := c.getJSON(ctx, tokens, spreadsheetID, "/v4/spreadsheets/"+spreadsheetID+"?"+fields.Encode(), &spreadsheet); err != nil {
+		return nil, err
+	}
+
+	sheetRange, err := resolveRange(&spreadsheet, sheetRange)
+	if err != nil {
+		return nil, err
+	}
+
+	var values valueRange
+	path := "/v4/spreadsheets/" + spreadsheetID + "/values/" + url.PathEscape(sheetRange) + "?majorDimension=ROWS"
+	if err := c.getJSON(ctx, tokens, spreadsheetID, path, &values); err != nil {
+		return nil, err
+	}
+
+	body, err := toCSV(values.Values)
+	if err != nil {
+		return nil, err
+	}
+	return &Response{Body: body}, nil
+}
+
+// Sends an authorized GET request to the Sheets API and decodes the JSON response into target
+func (c *Client) getJSON(ctx context.Context, tokens *tokenSource, spreadsheetID, path string, target any) error {
+	token, err := tokens.Token(ctx)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(c.config.SheetsEndpoint, "/")+path, nil)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	response, err := c.config.HTTPClient.Do(req)
+	if err != nil {
+		return &attemptError{err: fmt.Errorf("error while calling the Sheets API: %w", err), retryable: true}
+	}
+	defer response.Body.Close()
+
+	switch status := response.StatusCode; {
+	case status == http.StatusOK:
+	case status == http.StatusUnauthorized:
+		// The token may have been revoked, the retry requests a new one
+		tokens.Invalidate()
+		return &attemptError{err: fmt.Errorf("the Sheets API rejected the access token: %s", response.Status), retryable: true}
+	case status == http.StatusTooManyRequests || status >= http.StatusInternalServerError:
+		return &attemptError{
+			err:        fmt.Errorf("unexpected status from the Sheets API: %s", response.Status),
+			retryable:  true,
+			retryAfter: parseRetryAfter(response.Header.Get("Retry-After")),
+		}
+	case status == http.StatusNotFound:
+		return fmt.Errorf("google spreadsheet %s not found", spreadsheetID)
+	case status == http.StatusForbidden:
+		return fmt.Errorf("access to google spreadsheet %s denied, share it with %s", spreadsheetID, tokens.account.ClientEmail)
+	default:
+		return fmt.Errorf("unexpected status from the Sheets API: %s: %s", response.Status, apiErrorMessage(response.Body))
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(response.Header.Get("Content-Type"))
+	if mediaType != "application/json" {
+		return fmt.Errorf("the Sheets API returned %q instead of JSON", response.Header.Get("Content-Type"))
+	}
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, c.config.MaxBodySize+1))
+	if err != nil {
+		return &attemptError{err: fmt.Errorf("error reading response body: %w", err), retryable: true}
+	}
+	if int64(len(body)) > c.config.MaxBodySize {
+		return fmt.Errorf("the Sheets API response exceeds the limit of %d bytes", c.config.MaxBodySize)
+	}
+
+	if err := json.Unmarshal(body, target); err != nil {
+		return fmt.Errorf("failed to parse Sheets API response: %w", err)
+	}
+	return nil
+}
+
+// Extracts the message of a Sheets API error response
+func apiErrorMessage(body io.Reader) string {
+	var apiError struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(body, 64<<10))
+	if err := json.Unmarshal(data, &apiError); err != nil || apiError.Error.Message == "" {
+		return strings.TrimSpace(string(data))
+	}
+	return apiError.Error.Message
+}
+
+// Checks that the range refers to an existing tab or named range, an empty range becomes the first tab
+func resolveRange(spreadsheet *models.GoogleSpreadsheet, sheetRange string) (string, error) {
+	titles := make([]string, 0, len(spreadsheet.Sheets))
+	for _, sheet := range spreadsheet.Sheets {
+		titles = append(titles, sheet.Properties.Title)
+	}
+
+	if sheetRange == "" {
+		if len(titles) == 0 {
+			return "", errors.New("google spreadsheet has no tabs")
+		}
+		return "'" + strings.ReplaceAll(titles[0], "'", "''") + "'", nil
+	}
+
+	// Quoted tab names may contain an exclamation mark themselves
+	if i := strings.LastIndex(sheetRange, "!"); i >= 0 {
+		tab := strings.ReplaceAll(strings.TrimSuffix(strings.TrimPrefix(sheetRange[:i], "'"), "'"), "''", "'")
+		if !slices.Contains(titles, tab) {
+			return "", fmt.Errorf("tab %q not found, the spreadsheet has %s", tab, strings.Join(titles, ", "))
+		}
+		return sheetRange, nil
+	}
+
+	if slices.Contains(titles, sheetRange) || cellRangePattern.MatchString(sheetRange) {
+		return sheetRange, nil
+	}
+	for _, named := range spreadsheet.NamedRanges {
+		if named.Name == sheetRange {
+			return sheetRange, nil
+		}
+	}
+	return "", fmt.Errorf("tab or named range %q not found", sheetRange)
+}
+
+// Writes rows as CSV, padding the rows the API shortened by omitting trailing empty cells
+func toCSV(rows [][]string) (string, error) {
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row))
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	for _, row := range rows {
+		if len(row) < width {
+			row = append(row, make([]string, width-len(row))...)
+		}
+		if err := writer.Write(row); err != nil {
+			return "", fmt.Errorf("failed to convert sheet values to CSV: %w", err)
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return "", fmt.Errorf("failed to convert sheet values to CSV: %w", err)
+	}
+
+	return buf.String(), nil
+}
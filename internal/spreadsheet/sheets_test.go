@@ -0,0 +1,210 @@
+package spreadsheet
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const serviceAccountEmail = "importer@bankapi.iam.gserviceaccount.com"
+
+// Fake Sheets API with a token endpoint, serving a spreadsheet with the tabs Banks and Archive
+type fakeSheets struct {
+	t      *testing.T
+	key    *rsa.PrivateKey
+	server *httptest.Server
+
+	tokens      atomic.Int32
+	rejectToken atomic.Bool
+	ranges      []string
+}
+
+func newFakeSheets(t *testing.T) *fakeSheets {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	f := &fakeSheets{t: t, key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /token", f.token)
+	mux.HandleFunc("GET /v4/spreadsheets/{id}", f.metadata)
+	mux.HandleFunc("GET /v4/spreadsheets/{id}/values/{range}", f.values)
+	f.server = httptest.NewServer(mux)
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func (f *fakeSheets) token(w http.ResponseWriter, r *http.Request) {
+	assert.Equal(f.t, "urn:ietf:params:oauth:grant-type:jwt-bearer", r.FormValue("grant_type"))
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(r.FormValue("assertion"), claims, func(*jwt.Token) (any, error) {
+		return &f.key.PublicKey, nil
+	})
+	require.NoError(f.t, err)
+	assert.Equal(f.t, serviceAccountEmail, claims["iss"])
+	assert.Equal(f.t, sheetsReadOnlyScope, claims["scope"])
+
+	n := f.tokens.Add(1)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tokenResponse{AccessToken: "token-" + string(rune('0'+n)), ExpiresIn: 3600, TokenType: "Bearer"})
+}
+
+func (f *fakeSheets) authorized(w http.ResponseWriter, r *http.Request) bool {
+	if f.rejectToken.CompareAndSwap(true, false) || !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer token-") {
+		w.WriteHeader(http.StatusUnauthorized)
+		return false
+	}
+	if r.PathValue("id") != "private" {
+		w.WriteHeader(http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+func (f *fakeSheets) metadata(w http.ResponseWriter, r *http.Request) {
+	if !f.authorized(w, r) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	json.NewEncoder(w).Encode(models.GoogleSpreadsheet{
+		SpreadsheetId: "private",
+		Sheets: []models.Sheet{
+			{Properties: models.SheetProperties{Title: "Banks"}},
+			{Properties: models.SheetProperties{Title: "Archive", Index: 1}},
+		},
+		NamedRanges: []models.NamedRange{{Name: "Registry"}},
+	})
+}
+
+func (f *fakeSheets) values(w http.ResponseWriter, r *http.Request) {
+	if !f.authorized(w, r) {
+		return
+	}
+	f.ranges = append(f.ranges, r.PathValue("range"))
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	json.NewEncoder(w).Encode(valueRange{Values: [][]string{
+		{"COUNTRY ISO2 CODE", "SWIFT CODE", "CODE TYPE", "NAME", "ADDRESS", "TOWN NAME", "COUNTRY NAME"},
+		{"DE", "DEUTDEFFXXX", "BIC11", "DEUTSCHE BANK", "TAUNUSANLAGE 12, FRANKFURT", "FRANKFURT", "GERMANY"},
+		{"PL", "BREXPLPWXXX", "BIC11", "MBANK S.A."},
+	}})
+}
+
+func (f *fakeSheets) client(t *testing.T) *Client {
+	t.Helper()
+	der := x509.MarshalPKCS1PrivateKey(f.key)
+	credentials, err := json.Marshal(serviceAccount{
+		Type:         "service_account",
+		ClientEmail:  serviceAccountEmail,
+		PrivateKeyID: "key1",
+		PrivateKey:   string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: der})),
+		TokenURI:     f.server.URL + "/token",
+	})
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	require.NoError(t, os.WriteFile(path, credentials, 0o600))
+
+	return NewClient(Config{
+		SheetsEndpoint:  f.server.URL,
+		CredentialsFile: path,
+		MaxRetries:      1,
+		RetryDelay:      time.Millisecond,
+	})
+}
+
+func TestFetchSheet(t *testing.T) {
+	fake := newFakeSheets(t)
+	client := fake.client(t)
+
+	response, err := client.FetchSheet(context.Background(), "private", "")
+	require.NoError(t, err)
+
+	// Short rows are padded to the header width
+	assert.Equal(t, "COUNTRY ISO2 CODE,SWIFT CODE,CODE TYPE,NAME,ADDRESS,TOWN NAME,COUNTRY NAME\n"+
+		"DE,DEUTDEFFXXX,BIC11,DEUTSCHE BANK,\"TAUNUSANLAGE 12, FRANKFURT\",FRANKFURT,GERMANY\n"+
+		"PL,BREXPLPWXXX,BIC11,MBANK S.A.,,,\n", response.Body)
+
+	// An empty range reads the first tab, the token is reused
+	_, err = client.FetchSheet(context.Background(), "private", "Archive!A1:G")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"'Banks'", "Archive!A1:G"}, fake.ranges)
+	assert.Equal(t, int32(1), fake.tokens.Load())
+}
+
+func TestFetchSheetRenewsRejectedToken(t *testing.T) {
+	fake := newFakeSheets(t)
+	client := fake.client(t)
+
+	fake.rejectToken.Store(true)
+	_, err := client.FetchSheet(context.Background(), "private", "Banks")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), fake.tokens.Load())
+}
+
+func TestFetchSheetErrors(t *testing.T) {
+	fake := newFakeSheets(t)
+	client := fake.client(t)
+
+	_, err := client.FetchSheet(context.Background(), "other", "")
+	assert.ErrorContains(t, err, "share it with "+serviceAccountEmail)
+
+	_, err = client.FetchSheet(context.Background(), "private", "Missing!A1:G")
+	assert.ErrorContains(t, err, `tab "Missing" not found`)
+
+	_, err = NewClient(Config{}).FetchSheet(context.Background(), "private", "")
+	assert.ErrorContains(t, err, "requires service account credentials")
+}
+
+func TestResolveRange(t *testing.T) {
+	spreadsheet := &models.GoogleSpreadsheet{
+		Sheets: []models.Sheet{
+			{Properties: models.SheetProperties{Title: "Bank's list"}},
+			{Properties: models.SheetProperties{Title: "Archive"}},
+		},
+		NamedRanges: []models.NamedRange{{Name: "Registry"}},
+	}
+
+	tests := []struct {
+		sheetRange string
+		want       string
+		wantErr    bool
+	}{
+		{"", "'Bank''s list'", false},
+		{"Archive", "Archive", false},
+		{"Archive!A1:G", "Archive!A1:G", false},
+		{"'Bank''s list'!A:G", "'Bank''s list'!A:G", false},
+		{"Registry", "Registry", false},
+		{"A1:G", "A1:G", false},
+		{"Missing!A1:G", "", true},
+		{"Unknown", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sheetRange, func(t *testing.T) {
+			got, err := resolveRange(spreadsheet, tt.sheetRange)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/MarcinZ20/bankAPI/internal/repository"
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Span attributes describing the bank data involved in an operation
+var (
+	SwiftCodeKey   = attribute.Key("bank.swift_code")
+	CountryCodeKey = attribute.Key("bank.country_iso2")
+	PrefixKey      = attribute.Key("bank.swift_prefix")
+	SwiftCountKey  = attribute.Key("bank.swift_code_count")
+)
+
+// Bank store decorator wrapping every operation in a span
+type TracedRepository struct {
+	store repository.BankStore
+}
+
+var _ repository.BankStore = (*TracedRepository)(nil)
+
+// Wraps a bank store with tracing
+func NewTracedRepository(store repository.BankStore) *TracedRepository {
+	return &TracedRepository{store: store}
+}
+
+// Finds a headquarter by SWIFT code
+func (r *TracedRepository) FindHeadquarter(ctx context.Context, swiftCode string) (*models.Headquarter, error) {
+	ctx, span := startRepository(ctx, "FindHeadquarter", SwiftCodeKey.String(swiftCode))
+	hq, err := r.store.FindHeadquarter(ctx, swiftCode)
+	End(span, err)
+	return hq, err
+}
+
+// Finds headquarters by SWIFT codes
+func (r *TracedRepository) FindHeadquarters(ctx context.Context, swiftCodes []string) ([]models.Headquarter, error) {
+	ctx, span := startRepository(ctx, "FindHeadquarters", SwiftCountKey.Int(len(swiftCodes)))
+	hqs, err := r.store.FindHeadquarters(ctx, swiftCodes)
+	End(span, err)
+	return hqs, err
+}
+
+// Finds a branch by SWIFT code
+func (r *TracedRepository) FindBranch(ctx context.Context, swiftCode, parentSwiftCode string) (*models.Branch, error) {
+	ctx, span := startRepository(ctx, "FindBranch", SwiftCodeKey.String(swiftCode))
+	branch, err := r.store.FindBranch(ctx, swiftCode, parentSwiftCode)
+	End(span, err)
+	return branch, err
+}
+
+// Finds all banks in a given country
+func (r *TracedRepository) FindBanksByCountry(ctx context.Context, countryCode string) ([]models.Headquarter, error) {
+	ctx, span := startRepository(ctx, "FindBanksByCountry", CountryCodeKey.String(countryCode))
+	banks, err := r.store.FindBanksByCountry(ctx, countryCode)
+	End(span, err)
+	return banks, err
+}
+
+// Finds all headquarters and branches whose SWIFT code starts with prefix
+func (r *TracedRepository) FindByPrefix(ctx context.Context, prefix string) ([]models.BankEntity, error) {
+	ctx, span := startRepository(ctx, "FindByPrefix", PrefixKey.String(prefix))
+	entities, err := r.store.FindByPrefix(ctx, prefix)
+	End(span, err)
+	return entities, err
+}
+
+// Finds all headquarters with their branches
+func (r *TracedRepository) FindAll(ctx context.Context) ([]models.Headquarter, error) {
+	ctx, span := startRepository(ctx, "FindAll")
+	hqs, err := r.store.FindAll(ctx)
+	End(span, err)
+	return hqs, err
+}
+
+// Streams headquarters with their branches, the span covers the whole stream
+func (r *TracedRepository) StreamHeadquarters(ctx context.Context, countryCode string, fn func(hq *models.Headquarter) error) error {
+	ctx, span := startRepository(ctx, "StreamHeadquarters", CountryCodeKey.String(countryCode))
+	err := r.store.StreamHeadquarters(ctx, countryCode, fn)
+	End(span, err)
+	return err
+}
+
+// Creates a new headquarter
+func (r *TracedRepository) CreateHeadquarter(ctx context.Context, hq *models.Headquarter) error {
+	ctx, span := startRepository(ctx, "CreateHeadquarter", SwiftCodeKey.String(hq.SwiftCode))
+	err := r.store.CreateHeadquarter(ctx, hq)
+	End(span, err)
+	return err
+}
+
+// Adds a new branch to a headquarter
+func (r *TracedRepository) AddBranch(ctx context.Context, parentSwiftCode string, branch *models.Branch) error {
+	ctx, span := startRepository(ctx, "AddBranch", SwiftCodeKey.String(branch.SwiftCode))
+	err := r.store.AddBranch(ctx, parentSwiftCode, branch)
+	End(span, err)
+	return err
+}
+
+// Changes a headquarter
+func (r *TracedRepository) UpdateHeadquarter(ctx context.Context, hq *models.Headquarter, check repository.Precondition) (*models.Headquarter, error) {
+	ctx, span := startRepository(ctx, "UpdateHeadquarter", SwiftCodeKey.String(hq.SwiftCode))
+	updated, err := r.store.UpdateHeadquarter(ctx, hq, check)
+	End(span, err)
+	return updated, err
+}
+
+// Changes a branch
+func (r *TracedRepository) UpdateBranch(ctx context.Context, parentSwiftCode string, branch *models.Branch, check repository.Precondition) (*models.Branch, error) {
+	ctx, span := startRepository(ctx, "UpdateBranch", SwiftCodeKey.String(branch.SwiftCode))
+	updated, err := r.store.UpdateBranch(ctx, parentSwiftCode, branch, check)
+	End(span, err)
+	return updated, err
+}
+
+// Deletes a headquarter and all its branches
+func (r *TracedRepository) DeleteHeadquarter(ctx context.Context, swiftCode string, check repository.Precondition) error {
+	ctx, span := startRepository(ctx, "DeleteHeadquarter", SwiftCodeKey.String(swiftCode))
+	err := r.store.DeleteHeadquarter(ctx, swiftCode, check)
+	End(span, err)
+	return err
+}
+
+// Removes a branch from its headquarter
+func (r *TracedRepository) DeleteBranch(ctx context.Context, swiftCode, parentSwiftCode string, check repository.Precondition) error {
+	ctx, span := startRepository(ctx, "DeleteBranch", SwiftCodeKey.String(swiftCode))
+	err := r.store.DeleteBranch(ctx, swiftCode, parentSwiftCode, check)
+	End(span, err)
+	return err
+}
+
+func startRepository(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, "BankRepository."+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+}
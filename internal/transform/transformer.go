@@ -16,6 +16,8 @@ func (t *ModelTransformer) CleanRequestModel(branch *models.Branch) {
 	branch.BankName = strings.ToUpper(branch.BankName)
 	branch.CountryName = strings.ToUpper(branch.CountryName)
 	branch.Address = strings.Trim(branch.Address, " ")
+	// Provenance is set by imports only
+	branch.Provenance = nil
 }
 
 // Transforms Bank entity into Headquarter object
@@ -27,6 +29,14 @@ func (t *ModelTransformer) ToHeadquarter(bank models.Bank) models.Headquarter {
 		CountryName:   bank.CountryName,
 		IsHeadquarter: bank.IsHeadquarter(),
 		SwiftCode:     bank.SwiftCode,
+		Provenance:    bank.Provenance,
+
+		TownName:          bank.TownName,
+		ZipCode:           bank.ZipCode,
+		BranchInformation: bank.BranchInformation,
+		InstitutionType:   bank.InstitutionType,
+		ValidFrom:         bank.ValidFrom,
+		ValidTo:           bank.ValidTo,
 	}
 }
 
@@ -39,6 +49,14 @@ func (t *ModelTransformer) ToBranch(bank models.Bank) models.Branch {
 		CountryName:   bank.CountryName,
 		IsHeadquarter: bank.IsHeadquarter(),
 		SwiftCode:     bank.SwiftCode,
+		Provenance:    bank.Provenance,
+
+		TownName:          bank.TownName,
+		ZipCode:           bank.ZipCode,
+		BranchInformation: bank.BranchInformation,
+		InstitutionType:   bank.InstitutionType,
+		ValidFrom:         bank.ValidFrom,
+		ValidTo:           bank.ValidTo,
 	}
 }
 
@@ -0,0 +1,8 @@
+package version
+
+// Build information, set at build time with
+// -ldflags "-X github.com/MarcinZ20/bankAPI/internal/version.Version=v1.2.3 -X github.com/MarcinZ20/bankAPI/internal/version.Commit=abc123"
+var (
+	Version = "dev"
+	Commit  = "unknown"
+)
@@ -0,0 +1,355 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/MarcinZ20/bankAPI/internal/metrics"
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+)
+
+// Headers sent with every delivery
+const (
+	// Timestamp and HMAC-SHA256 of "<timestamp>.<body>" keyed with the webhook secret: t=<unix>,v1=<hex>
+	SignatureHeader = "X-Webhook-Signature"
+	// ID of the change event, the same on every attempt so receivers can drop duplicates
+	EventIDHeader = "X-Webhook-Event-Id"
+	// Operation of the change event
+	EventHeader = "X-Webhook-Event"
+)
+
+// Upper bound of the delay between two attempts
+const maxBackoff = 5 * time.Minute
+
+// Results of a delivery attempt
+const (
+	resultDelivered = "delivered"
+	resultRetried   = "retried"
+	resultFailed    = "failed"
+)
+
+// Source of webhook registrations and log of failed deliveries, e.g. services.WebhookService
+type Store interface {
+	ListWebhooks(ctx context.Context) ([]models.Webhook, error)
+	RecordDeadLetter(ctx context.Context, letter *models.DeadLetter) error
+}
+
+// Holds webhook delivery settings
+type Config struct {
+	Store Store
+	// Limits a single attempt
+	Timeout time.Duration
+	// Attempts before an event goes to the dead letter log
+	MaxAttempts int
+	// Delay before the second attempt, doubled for every further one
+	RetryBackoff time.Duration
+	// Events waiting per webhook, later ones go to the dead letter log
+	QueueSize int
+	// How often registrations are reloaded from the store
+	RefreshInterval time.Duration
+	// Client used for deliveries, a client with Timeout when nil
+	Client *http.Client
+}
+
+// Posts change events to the registered webhooks. Every webhook has its own queue and worker,
+// so it receives events in order and a slow receiver does not hold back the others.
+type Dispatcher struct {
+	config Config
+	client *http.Client
+	now    func() time.Time
+
+	mu      sync.Mutex
+	workers map[string]*worker
+	// Webhooks that already received an event Deliver failed on, a retry skips them
+	delivered map[uint64]map[string]bool
+	wg        sync.WaitGroup
+}
+
+type worker struct {
+	webhook models.Webhook
+	queue   chan models.ChangeEvent
+	cancel  context.CancelFunc
+}
+
+// Creates a dispatcher, Refresh loads the webhooks
+func New(config Config) *Dispatcher {
+	client := config.Client
+	if client == nil {
+		client = &http.Client{Timeout: config.Timeout}
+	}
+
+	return &Dispatcher{
+		config:    config,
+		client:    client,
+		now:       time.Now,
+		workers:   make(map[string]*worker),
+		delivered: make(map[uint64]map[string]bool),
+	}
+}
+
+// Queues an event for every webhook accepting its operation, it never blocks and can be
+// registered as a change feed listener
+func (d *Dispatcher) Enqueue(event models.ChangeEvent) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, w := range d.workers {
+		if !w.webhook.Accepts(event.Operation) {
+			continue
+		}
+		select {
+		case w.queue <- event:
+		default:
+			metrics.WebhookDeliveries.WithLabelValues(resultFailed).Inc()
+			go d.deadLetter(w.webhook, event, 0, "delivery queue is full")
+		}
+	}
+}
+
+// Delivers an event to every webhook accepting its operation and waits for the outcome. Events a webhook does
+// not accept are skipped and failed deliveries go to the dead letter log. It only fails when ctx is done first
+// or a dead letter could not be recorded, the event should then be delivered again and goes only to the webhooks
+// that did not receive it yet. Those are kept in memory, so after a restart every webhook may receive it again.
+func (d *Dispatcher) Deliver(ctx context.Context, event models.ChangeEvent) error {
+	d.mu.Lock()
+	done := d.delivered[event.ID]
+	var targets []models.Webhook
+	for id, w := range d.workers {
+		if w.webhook.Accepts(event.Operation) && !done[id] {
+			targets = append(targets, w.webhook)
+		}
+	}
+	d.mu.Unlock()
+
+	errs := make([]error, len(targets))
+	var wg sync.WaitGroup
+	for i, webhook := range targets {
+		wg.Go(func() {
+			attempts, err := d.attempt(ctx, webhook, event)
+			switch {
+			case err == nil:
+			case ctx.Err() != nil:
+				errs[i] = ctx.Err()
+			default:
+				errs[i] = d.deadLetter(webhook, event, attempts, err.Error())
+			}
+		})
+	}
+	wg.Wait()
+
+	err := errors.Join(errs...)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err == nil {
+		delete(d.delivered, event.ID)
+		return nil
+	}
+	if done == nil {
+		done = make(map[string]bool, len(targets))
+		d.delivered[event.ID] = done
+	}
+	for i, webhook := range targets {
+		if errs[i] == nil {
+			done[webhook.ID] = true
+		}
+	}
+	return err
+}
+
+// Reloads the webhooks, workers of new webhooks run until ctx is done
+func (d *Dispatcher) Refresh(ctx context.Context) error {
+	webhooks, err := d.config.Store.ListWebhooks(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load webhooks: %w", err)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	current := make(map[string]bool, len(webhooks))
+	for _, webhook := range webhooks {
+		current[webhook.ID] = true
+		if _, ok := d.workers[webhook.ID]; ok {
+			continue
+		}
+
+		workerCtx, cancel := context.WithCancel(ctx)
+		w := &worker{
+			webhook: webhook,
+			queue:   make(chan models.ChangeEvent, max(d.config.QueueSize, 1)),
+			cancel:  cancel,
+		}
+		d.workers[webhook.ID] = w
+		d.wg.Add(1)
+		go d.work(workerCtx, w)
+	}
+
+	// Events still queued for a deleted webhook are dropped
+	for id, w := range d.workers {
+		if !current[id] {
+			w.cancel()
+			delete(d.workers, id)
+		}
+	}
+
+	return nil
+}
+
+// Reloads the webhooks every refresh interval until ctx is done, then waits for the workers
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.config.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			d.wg.Wait()
+			return
+		case <-ticker.C:
+			if err := d.Refresh(ctx); err != nil {
+				slog.Error("webhook refresh failed", "error", err)
+			}
+		}
+	}
+}
+
+func (d *Dispatcher) work(ctx context.Context, w *worker) {
+	defer d.wg.Done()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-w.queue:
+			// A failed event goes to the dead letter log, the store logs when that fails too
+			if attempts, err := d.attempt(ctx, w.webhook, event); err != nil {
+				d.deadLetter(w.webhook, event, attempts, err.Error())
+			}
+		}
+	}
+}
+
+// Posts the event until the webhook accepts it, and returns the attempts made and the error of the last one
+// when it gives up
+func (d *Dispatcher) attempt(ctx context.Context, webhook models.Webhook, event models.ChangeEvent) (int, error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return 0, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		retry, err := d.post(ctx, webhook, event, body)
+		if err == nil {
+			metrics.WebhookDeliveries.WithLabelValues(resultDelivered).Inc()
+			return attempt, nil
+		}
+		if !retry || attempt >= d.config.MaxAttempts || !sleep(ctx, d.backoff(attempt)) {
+			metrics.WebhookDeliveries.WithLabelValues(resultFailed).Inc()
+			return attempt, err
+		}
+
+		metrics.WebhookDeliveries.WithLabelValues(resultRetried).Inc()
+		slog.Warn("webhook delivery failed, retrying", "webhookId", webhook.ID, "eventId", event.ID, "attempt", attempt, "error", err)
+	}
+}
+
+// Makes one attempt and reports whether a failed one is worth retrying
+func (d *Dispatcher) post(ctx context.Context, webhook models.Webhook, event models.ChangeEvent, body []byte) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.config.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventIDHeader, strconv.FormatUint(event.ID, 10))
+	req.Header.Set(EventHeader, event.Operation)
+	req.Header.Set(SignatureHeader, Sign(webhook.Secret, d.now(), body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	// Reading the rest of a short body lets the connection be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	return isRetryable(resp.StatusCode), fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+}
+
+// Records an event that was not delivered, the store gets its own deadline since ctx may be done
+func (d *Dispatcher) deadLetter(webhook models.Webhook, event models.ChangeEvent, attempts int, reason string) error {
+	slog.Error("webhook delivery failed", "webhookId", webhook.ID, "eventId", event.ID, "attempts", attempts, "error", reason)
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.config.Timeout)
+	defer cancel()
+
+	letter := &models.DeadLetter{
+		WebhookID: webhook.ID,
+		URL:       webhook.URL,
+		Event:     event,
+		Attempts:  attempts,
+		Error:     reason,
+		FailedAt:  d.now().UTC(),
+	}
+	if err := d.config.Store.RecordDeadLetter(ctx, letter); err != nil {
+		slog.Error("failed to record dead letter", "webhookId", webhook.ID, "eventId", event.ID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// Delay after the given attempt, doubled every time up to maxBackoff
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	delay := d.config.RetryBackoff
+	for i := 1; i < attempt && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxBackoff)
+}
+
+// Signs a delivery body, receivers recompute the HMAC over "<t>.<body>" with their secret and compare
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "t=" + t + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Server errors, timeouts and rate limiting may pass, other client errors will not
+func isRetryable(status int) bool {
+	return status >= 500 || status == http.StatusRequestTimeout || status == http.StatusTooManyRequests
+}
+
+// Waits for d, reports false when ctx is done first
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
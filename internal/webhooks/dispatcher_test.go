@@ -0,0 +1,255 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MarcinZ20/bankAPI/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStore struct {
+	mu            sync.Mutex
+	webhooks      []models.Webhook
+	deadLetters   []models.DeadLetter
+	deadLetterErr error
+}
+
+func (s *fakeStore) ListWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.webhooks, nil
+}
+
+func (s *fakeStore) RecordDeadLetter(ctx context.Context, letter *models.DeadLetter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.deadLetterErr != nil {
+		return s.deadLetterErr
+	}
+	s.deadLetters = append(s.deadLetters, *letter)
+	return nil
+}
+
+func (s *fakeStore) letters() []models.DeadLetter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]models.DeadLetter(nil), s.deadLetters...)
+}
+
+func newTestDispatcher(t *testing.T, store *fakeStore) *Dispatcher {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	d := New(Config{
+		Store:           store,
+		Timeout:         time.Second,
+		MaxAttempts:     3,
+		RetryBackoff:    time.Millisecond,
+		QueueSize:       10,
+		RefreshInterval: time.Hour,
+	})
+	require.NoError(t, d.Refresh(ctx))
+	return d
+}
+
+func testEvent() models.ChangeEvent {
+	return models.ChangeEvent{ID: 42, Operation: models.ChangeCreated, Source: models.ChangeSourceAPI, SwiftCode: "DEUTDEFFXXX"}
+}
+
+func TestDeliverySigned(t *testing.T) {
+	received := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- r
+		bodies <- body
+	}))
+	defer server.Close()
+
+	store := &fakeStore{webhooks: []models.Webhook{{ID: "hook", URL: server.URL, Secret: "whsec_test"}}}
+	d := newTestDispatcher(t, store)
+	now := time.Unix(1700000000, 0)
+	d.now = func() time.Time { return now }
+
+	d.Enqueue(testEvent())
+
+	select {
+	case r := <-received:
+		body := <-bodies
+		assert.Equal(t, "42", r.Header.Get(EventIDHeader))
+		assert.Equal(t, models.ChangeCreated, r.Header.Get(EventHeader))
+		assert.Equal(t, Sign("whsec_test", now, body), r.Header.Get(SignatureHeader))
+		assert.Contains(t, string(body), `"swiftCode":"DEUTDEFFXXX"`)
+	case <-time.After(2 * time.Second):
+		t.Fatal("webhook was not called")
+	}
+}
+
+func TestDeliveryRetriedUntilAccepted(t *testing.T) {
+	var calls atomic.Int32
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		close(done)
+	}))
+	defer server.Close()
+
+	store := &fakeStore{webhooks: []models.Webhook{{ID: "hook", URL: server.URL, Secret: "whsec_test"}}}
+	d := newTestDispatcher(t, store)
+
+	d.Enqueue(testEvent())
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("webhook was not retried")
+	}
+	assert.Equal(t, int32(3), calls.Load())
+	assert.Empty(t, store.letters())
+}
+
+func TestFailedDeliveryGoesToDeadLetters(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	store := &fakeStore{webhooks: []models.Webhook{{ID: "hook", URL: server.URL, Secret: "whsec_test"}}}
+	d := newTestDispatcher(t, store)
+
+	d.Enqueue(testEvent())
+
+	require.Eventually(t, func() bool { return len(store.letters()) == 1 }, 2*time.Second, 5*time.Millisecond)
+	letter := store.letters()[0]
+	assert.Equal(t, "hook", letter.WebhookID)
+	assert.Equal(t, uint64(42), letter.Event.ID)
+	assert.Equal(t, 3, letter.Attempts)
+	assert.Contains(t, letter.Error, "500")
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestClientErrorIsNotRetried(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer server.Close()
+
+	store := &fakeStore{webhooks: []models.Webhook{{ID: "hook", URL: server.URL, Secret: "whsec_test"}}}
+	d := newTestDispatcher(t, store)
+
+	d.Enqueue(testEvent())
+
+	require.Eventually(t, func() bool { return len(store.letters()) == 1 }, 2*time.Second, 5*time.Millisecond)
+	assert.Equal(t, 1, store.letters()[0].Attempts)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestOperationFilter(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	defer server.Close()
+
+	store := &fakeStore{webhooks: []models.Webhook{
+		{ID: "hook", URL: server.URL, Secret: "whsec_test", Operations: []string{models.ChangeDeleted}},
+	}}
+	d := newTestDispatcher(t, store)
+
+	d.Enqueue(testEvent())
+	deleted := testEvent()
+	deleted.Operation = models.ChangeDeleted
+	d.Enqueue(deleted)
+
+	require.Eventually(t, func() bool { return calls.Load() == 1 }, 2*time.Second, 5*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestBackoff(t *testing.T) {
+	d := New(Config{RetryBackoff: time.Second})
+
+	assert.Equal(t, time.Second, d.backoff(1))
+	assert.Equal(t, 4*time.Second, d.backoff(3))
+	assert.Equal(t, maxBackoff, d.backoff(20))
+}
+
+func TestDeliverWaitsForOutcome(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	store := &fakeStore{webhooks: []models.Webhook{{ID: "hook", URL: server.URL, Secret: "whsec_test"}}}
+	d := newTestDispatcher(t, store)
+
+	// A rejected event is settled once it is in the dead letter log
+	require.NoError(t, d.Deliver(context.Background(), testEvent()))
+	assert.Equal(t, int32(2), calls.Load())
+	require.Len(t, store.letters(), 1)
+	assert.Equal(t, 2, store.letters()[0].Attempts)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, d.Deliver(ctx, testEvent()), context.Canceled)
+	assert.Len(t, store.letters(), 1)
+}
+
+func TestDeliverRetriesOnlyFailedWebhooks(t *testing.T) {
+	var accepted, rejected atomic.Int32
+	accepting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accepted.Add(1)
+	}))
+	defer accepting.Close()
+	rejecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rejected.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer rejecting.Close()
+
+	store := &fakeStore{
+		webhooks: []models.Webhook{
+			{ID: "accepting", URL: accepting.URL, Secret: "whsec_test"},
+			{ID: "rejecting", URL: rejecting.URL, Secret: "whsec_test"},
+		},
+		deadLetterErr: errors.New("store unavailable"),
+	}
+	d := newTestDispatcher(t, store)
+
+	// The dead letter is lost, so the event fails and is delivered again, but only to the rejecting webhook
+	require.Error(t, d.Deliver(context.Background(), testEvent()))
+	require.Error(t, d.Deliver(context.Background(), testEvent()))
+	assert.Equal(t, int32(1), accepted.Load())
+	assert.Equal(t, int32(2), rejected.Load())
+
+	store.mu.Lock()
+	store.deadLetterErr = nil
+	store.mu.Unlock()
+
+	require.NoError(t, d.Deliver(context.Background(), testEvent()))
+	assert.Equal(t, int32(1), accepted.Load())
+	assert.Len(t, store.letters(), 1)
+	assert.Empty(t, d.delivered)
+}
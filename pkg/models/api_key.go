@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+)
+
+// Permission scopes that can be granted to an API key
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+	ScopeAdmin = "admin"
+)
+
+// APIKey represents a client credential stored in the database.
+// Only the SHA-256 hash of the key is persisted, never the key itself.
+type APIKey struct {
+	ID         string            `bson:"_id" json:"id"`
+	Name       string            `bson:"name" json:"name"`
+	Prefix     string            `bson:"prefix" json:"prefix"`
+	Hash       string            `bson:"hash" json:"-"`
+	Scopes     []string          `bson:"scopes" json:"scopes"`
+	Metadata   map[string]string `bson:"metadata,omitempty" json:"metadata,omitempty"`
+	DailyQuota int64             `bson:"dailyQuota,omitempty" json:"dailyQuota,omitempty"`
+	CreatedAt  time.Time         `bson:"createdAt" json:"createdAt"`
+	ExpiresAt  *time.Time        `bson:"expiresAt,omitempty" json:"expiresAt,omitempty"`
+	RotatedAt  *time.Time        `bson:"rotatedAt,omitempty" json:"rotatedAt,omitempty"`
+	RevokedAt  *time.Time        `bson:"revokedAt,omitempty" json:"revokedAt,omitempty"`
+	LastUsedAt *time.Time        `bson:"lastUsedAt,omitempty" json:"lastUsedAt,omitempty"`
+}
+
+// Checks if the scope is a known permission scope
+func IsValidScope(scope string) bool {
+	return scope == ScopeRead || scope == ScopeWrite || scope == ScopeAdmin
+}
+
+// Checks if the key has passed its expiry date
+func (k *APIKey) IsExpired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
+
+// Checks if the key has been revoked
+func (k *APIKey) IsRevoked() bool {
+	return k.RevokedAt != nil
+}
@@ -1,6 +1,9 @@
 package models
 
-import "strings"
+import (
+	"strings"
+	"time"
+)
 
 // This represents an object parsed from google spreadsheet
 type Bank struct {
@@ -12,6 +15,14 @@ type Bank struct {
 	TownName        string `json:"townName"`
 	CountryName     string `json:"countryName"`
 	Timezone        string `json:"timezone"`
+	// Directory details, only filled by the SWIFTRef import format
+	BranchInformation string     `json:"branchInformation,omitempty"`
+	ZipCode           string     `json:"zipCode,omitempty"`
+	InstitutionType   string     `json:"institutionType,omitempty"`
+	ValidFrom         *time.Time `json:"validFrom,omitempty"`
+	ValidTo           *time.Time `json:"validTo,omitempty"`
+	// Where the record was imported from
+	Provenance *Provenance `json:"provenance,omitempty"`
 }
 
 func (b *Bank) IsHeadquarter() bool {
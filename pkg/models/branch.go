@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 // Branch represents a branch data structure to be processed by the parser
 type Branch struct {
 	Address       string `bson:"address" json:"address"`
@@ -8,6 +10,17 @@ type Branch struct {
 	CountryName   string `bson:"countryName" json:"countryName"`
 	IsHeadquarter bool   `bson:"isHeadquarter" json:"isHeadquarter"`
 	SwiftCode     string `bson:"swiftCode" json:"swiftCode"`
+	// Directory details, only set when the import source provides them
+	TownName          string     `bson:"townName,omitempty" json:"townName,omitempty"`
+	ZipCode           string     `bson:"zipCode,omitempty" json:"zipCode,omitempty"`
+	BranchInformation string     `bson:"branchInformation,omitempty" json:"branchInformation,omitempty"`
+	InstitutionType   string     `bson:"institutionType,omitempty" json:"institutionType,omitempty"`
+	ValidFrom         *time.Time `bson:"validFrom,omitempty" json:"validFrom,omitempty"`
+	ValidTo           *time.Time `bson:"validTo,omitempty" json:"validTo,omitempty"`
+	// Where the record was imported from, nil for records added through the API
+	Provenance *Provenance `bson:"provenance,omitempty" json:"provenance,omitempty"`
+	// Last change to the branch
+	UpdatedAt *time.Time `bson:"updatedAt,omitempty" json:"updatedAt,omitempty"`
 }
 
 func (b *Branch) GetAddress() string {
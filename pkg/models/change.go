@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// Operations recorded by a change event
+const (
+	ChangeCreated = "created"
+	ChangeUpdated = "updated"
+	ChangeDeleted = "deleted"
+)
+
+// Origins of a change event
+const (
+	ChangeSourceAPI    = "api"
+	ChangeSourceImport = "import"
+)
+
+// ChangeEvent records that a single headquarter or branch was added, modified or removed.
+// Before is nil for created entries and After for deleted ones.
+type ChangeEvent struct {
+	ID        uint64        `bson:"id" json:"id"`
+	Time      time.Time     `bson:"time" json:"time"`
+	Operation string        `bson:"operation" json:"operation"`
+	Source    string        `bson:"source" json:"source"`
+	SwiftCode string        `bson:"swiftCode" json:"swiftCode"`
+	Before    *BankSnapshot `bson:"before,omitempty" json:"before,omitempty"`
+	After     *BankSnapshot `bson:"after,omitempty" json:"after,omitempty"`
+}
+
+// State of a headquarter or branch in a change event, branches of a headquarter get their own events
+type BankSnapshot struct {
+	SwiftCode     string `bson:"swiftCode" json:"swiftCode"`
+	BankName      string `bson:"bankName" json:"bankName"`
+	Address       string `bson:"address" json:"address"`
+	CountryISO2   string `bson:"countryISO2" json:"countryISO2"`
+	CountryName   string `bson:"countryName" json:"countryName"`
+	IsHeadquarter bool   `bson:"isHeadquarter" json:"isHeadquarter"`
+}
+
+// Captures the fields of a headquarter or branch
+func NewBankSnapshot(entity BankEntity) *BankSnapshot {
+	return &BankSnapshot{
+		SwiftCode:     entity.GetSwiftCode(),
+		BankName:      entity.GetBankName(),
+		Address:       entity.GetAddress(),
+		CountryISO2:   entity.GetCountryISO2(),
+		CountryName:   entity.GetCountryName(),
+		IsHeadquarter: entity.IsHq(),
+	}
+}
+
+// OutboxEntry is a change event stored in the same transaction as the write that caused it, until every
+// relay sink has received it. The sequence number is the event ID.
+type OutboxEntry struct {
+	Seq       int64       `bson:"_id" json:"seq"`
+	Event     ChangeEvent `bson:"event" json:"event"`
+	CreatedAt time.Time   `bson:"createdAt" json:"createdAt"`
+	// Names of the sinks that received the event
+	Delivered []string `bson:"delivered" json:"delivered"`
+}
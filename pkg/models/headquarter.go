@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 // BankData represents a bank data structure to be processed by the parser
 type Headquarter struct {
 	Address       string   `bson:"address" json:"address"`
@@ -9,6 +11,19 @@ type Headquarter struct {
 	IsHeadquarter bool     `bson:"isHeadquarter" json:"isHeadquarter"`
 	SwiftCode     string   `bson:"swiftCode" json:"swiftCode"`
 	Branches      []Branch `bson:"branches" json:"branches"`
+	// Directory details, only set when the import source provides them
+	TownName          string     `bson:"townName,omitempty" json:"townName,omitempty"`
+	ZipCode           string     `bson:"zipCode,omitempty" json:"zipCode,omitempty"`
+	BranchInformation string     `bson:"branchInformation,omitempty" json:"branchInformation,omitempty"`
+	InstitutionType   string     `bson:"institutionType,omitempty" json:"institutionType,omitempty"`
+	ValidFrom         *time.Time `bson:"validFrom,omitempty" json:"validFrom,omitempty"`
+	ValidTo           *time.Time `bson:"validTo,omitempty" json:"validTo,omitempty"`
+	// Where the record was imported from, nil for records added through the API
+	Provenance *Provenance `bson:"provenance,omitempty" json:"provenance,omitempty"`
+	// Last write to the document, changes to its branches included
+	UpdatedAt *time.Time `bson:"updatedAt,omitempty" json:"updatedAt,omitempty"`
+	// Incremented on every write to the document, conditional writes only apply to the version they read
+	Version int64 `bson:"version,omitempty" json:"-"`
 }
 
 func (h *Headquarter) GetAddress() string {
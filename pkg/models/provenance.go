@@ -0,0 +1,12 @@
+package models
+
+// Provenance records which import source a record came from
+type Provenance struct {
+	// Name of the source, set in the configuration or derived from its location
+	Source        string `bson:"source" json:"source"`
+	SpreadsheetID string `bson:"spreadsheetId,omitempty" json:"spreadsheetId,omitempty"`
+	Range         string `bson:"range,omitempty" json:"range,omitempty"`
+	File          string `bson:"file,omitempty" json:"file,omitempty"`
+	// Row of the record in the source, the header being row 1
+	Row int `bson:"row" json:"row"`
+}
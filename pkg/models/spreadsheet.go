@@ -3,12 +3,35 @@ package models
 // GoogleSpreadsheet represents a spreadsheet data structure according to Google Sheets API:
 // https://developers.google.com/sheets/api/reference/rest/v4/spreadsheets#SpreadsheetProperties
 type GoogleSpreadsheet struct {
-	SpreadsheetId       string `json:"spreadsheetId"`
-	Properties          string `json:"properties"`
-	Sheets              string `json:"sheets"`
-	NamedRanges         string `json:"namedRanges"`
-	SpreadsheetUrl      string `json:"spreadsheetUrl"`
-	DeveloperMetadata   string `json:"developerMetadata"`
-	DataSource          string `json:"dataSource"`
-	DataSourceSchedules string `json:"dataSourceSchedules"`
+	SpreadsheetId       string                `json:"spreadsheetId"`
+	Properties          SpreadsheetProperties `json:"properties"`
+	Sheets              []Sheet               `json:"sheets"`
+	NamedRanges         []NamedRange          `json:"namedRanges"`
+	SpreadsheetUrl      string                `json:"spreadsheetUrl"`
+	DeveloperMetadata   string                `json:"developerMetadata"`
+	DataSource          string                `json:"dataSource"`
+	DataSourceSchedules string                `json:"dataSourceSchedules"`
+}
+
+// SpreadsheetProperties holds the spreadsheet wide settings used by the importer
+type SpreadsheetProperties struct {
+	Title string `json:"title"`
+}
+
+// Sheet is a single tab of a spreadsheet
+type Sheet struct {
+	Properties SheetProperties `json:"properties"`
+}
+
+// SheetProperties identifies a tab
+type SheetProperties struct {
+	SheetId int    `json:"sheetId"`
+	Title   string `json:"title"`
+	Index   int    `json:"index"`
+}
+
+// NamedRange is a range of cells referred to by name
+type NamedRange struct {
+	NamedRangeId string `json:"namedRangeId"`
+	Name         string `json:"name"`
 }
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// Webhook is an endpoint change events are posted to, signed with its secret
+type Webhook struct {
+	ID     string `bson:"_id" json:"id"`
+	URL    string `bson:"url" json:"url"`
+	Secret string `bson:"secret" json:"-"`
+	// Operations delivered to the webhook, all of them when empty
+	Operations []string  `bson:"operations,omitempty" json:"operations,omitempty"`
+	CreatedAt  time.Time `bson:"createdAt" json:"createdAt"`
+}
+
+// Checks if events with the operation are delivered to the webhook
+func (w *Webhook) Accepts(operation string) bool {
+	return len(w.Operations) == 0 || slices.Contains(w.Operations, operation)
+}
+
+// DeadLetter records a change event a webhook did not accept after all delivery attempts
+type DeadLetter struct {
+	ID        string      `bson:"_id" json:"id"`
+	WebhookID string      `bson:"webhookId" json:"webhookId"`
+	URL       string      `bson:"url" json:"url"`
+	Event     ChangeEvent `bson:"event" json:"event"`
+	Attempts  int         `bson:"attempts" json:"attempts"`
+	Error     string      `bson:"error" json:"error"`
+	FailedAt  time.Time   `bson:"failedAt" json:"failedAt"`
+}
+
+// Checks if the operation is one a change event can record
+func IsValidChangeOperation(operation string) bool {
+	return operation == ChangeCreated || operation == ChangeUpdated || operation == ChangeDeleted
+}
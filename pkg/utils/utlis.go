@@ -30,3 +30,11 @@ func IsValidCountryCode(code string) bool {
 	match, _ := regexp.MatchString(countryISO2Regex, code)
 	return match
 }
+
+// Accepts the leading part of a SWIFT code: at least the 4 letter institution code,
+// optionally followed by the country and more characters, at most a full 11 character code
+func IsValidSwiftCodePrefix(prefix string) bool {
+	prefixRegex := `^[A-Z]{4}([A-Z]{1,2}|[A-Z]{2}[A-Z0-9]{1,5})?$`
+	match, _ := regexp.MatchString(prefixRegex, prefix)
+	return match
+}